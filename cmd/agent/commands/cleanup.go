@@ -1,29 +1,58 @@
 package commands
 
 import (
+	"errors"
 	"syscall"
 
+	"github.com/grafana/xk6-disruptor/pkg/agent/network"
+	"github.com/grafana/xk6-disruptor/pkg/agent/stressors"
+	"github.com/grafana/xk6-disruptor/pkg/iptables"
 	"github.com/grafana/xk6-disruptor/pkg/runtime"
+	"github.com/grafana/xk6-disruptor/pkg/tc"
 	"github.com/spf13/cobra"
 )
 
+// ballastPaths are the directories where the disk stressor can leave ballast files: the default path of the disk
+// command, and the path where the disruptor mounts the volumes of the target in the agent's container
+//
+//nolint:gochecknoglobals
+var ballastPaths = []string{"/tmp", "/xk6-disruptor/volumes"}
+
 // BuiltCleanupCmd returns a cobra command with the specification of the kill command
 func BuiltCleanupCmd(env runtime.Environment) *cobra.Command {
+	var paths []string
+
 	cmd := &cobra.Command{
 		Use:   "cleanup",
 		Short: "stops any ongoing fault injection and cleans resources",
+		Long: "Stops any ongoing fault injection, which removes the resources it created." +
+			" If no injection is running, removes the resources left behind by an injection that was killed:" +
+			" iptables rules, traffic control queueing disciplines and disk ballast files.",
 		RunE: func(cmd *cobra.Command, args []string) error { //nolint:revive
 			runningProcess := env.Lock().Owner()
-			// no instance is currently running
-			if runningProcess == -1 {
-				return nil
+			if runningProcess != -1 {
+				err := syscall.Kill(runningProcess, syscall.SIGTERM)
+				// the running instance removes its resources when it terminates
+				if !errors.Is(err, syscall.ESRCH) {
+					return err
+				}
 			}
 
-			return syscall.Kill(runningProcess, syscall.SIGTERM)
+			// no instance is currently running, but a previous one could have been killed before removing its
+			// resources
+			errs := []error{
+				iptables.New(env.Executor()).RemoveAll(),
+				network.RemoveLeftoverQdiscs(tc.New(env.Executor())),
+			}
+			for _, path := range paths {
+				errs = append(errs, stressors.RemoveBallast(path))
+			}
 
-			// TODO: cleanup resources (e.g iptables)
+			return errors.Join(errs...)
 		},
 	}
+	cmd.Flags().StringArrayVar(&paths, "ballast-path", ballastPaths, "directory where ballast files are searched for"+
+		" and removed, with its subdirectories. Can be repeated")
 
 	return cmd
 }
@@ -0,0 +1,92 @@
+package commands
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/grafana/xk6-disruptor/pkg/agent"
+	"github.com/grafana/xk6-disruptor/pkg/agent/stressors"
+	"github.com/grafana/xk6-disruptor/pkg/runtime"
+	"github.com/spf13/cobra"
+)
+
+// BuildDiskCmd returns a cobra command with the specification of the disk command
+func BuildDiskCmd(env runtime.Environment, config *agent.Config) *cobra.Command {
+	var duration time.Duration
+	var path string
+	var workers int
+	var blockSize string
+	var fileSize string
+	var fill int
+	var sizeLimit string
+
+	cmd := &cobra.Command{
+		Use:   "disk",
+		Short: "disk stressor",
+		Long: "Stress the disk of a path. By default, generates read and write I/O load. " +
+			"If a fill percentage is specified, fills the filesystem with ballast files up to that usage instead.",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			var disruptor agent.Disruptor
+			if fill > 0 {
+				limit := uint64(0)
+				if sizeLimit != "" {
+					var err error
+					limit, err = stressors.ParseBytes(sizeLimit)
+					if err != nil {
+						return fmt.Errorf("invalid size limit: %w", err)
+					}
+				}
+
+				s, err := stressors.NewDiskFillStressor(stressors.DiskFillDisruption{
+					Path:      path,
+					Usage:     fill,
+					SizeLimit: limit,
+				})
+				if err != nil {
+					return err
+				}
+				disruptor = s
+			} else {
+				disruption := stressors.DiskIODisruption{
+					Path:    path,
+					Workers: workers,
+				}
+
+				var err error
+				disruption.BlockSize, err = stressors.ParseBytes(blockSize)
+				if err != nil {
+					return fmt.Errorf("invalid block size: %w", err)
+				}
+
+				disruption.FileSize, err = stressors.ParseBytes(fileSize)
+				if err != nil {
+					return fmt.Errorf("invalid file size: %w", err)
+				}
+
+				s, err := stressors.NewDiskIOStressor(disruption)
+				if err != nil {
+					return err
+				}
+				disruptor = s
+			}
+
+			agent, err := agent.Start(env, config)
+			if err != nil {
+				return fmt.Errorf("initializing agent: %w", err)
+			}
+			defer agent.Stop()
+
+			return agent.ApplyDisruption(cmd.Context(), disruptor, duration)
+		},
+	}
+	cmd.Flags().DurationVarP(&duration, "duration", "d", 0, "duration of the disruptions")
+	cmd.Flags().StringVarP(&path, "path", "p", "/tmp", "directory to stress")
+	cmd.Flags().IntVarP(&workers, "workers", "w", 1, "number of workers generating I/O load")
+	cmd.Flags().StringVar(&blockSize, "block-size", "1Mi", "size of each read and write")
+	cmd.Flags().StringVar(&fileSize, "file-size", "64Mi", "size of the file written and read by each worker")
+	cmd.Flags().IntVar(&fill, "fill", 0, "fill the filesystem up to this usage percentage instead of generating I/O load")
+	cmd.Flags().StringVar(&sizeLimit, "size-limit", "", "size of the volume when filling it, if it is limited by the"+
+		" size of its files instead of by the size of its filesystem")
+
+	return cmd
+}
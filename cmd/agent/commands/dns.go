@@ -0,0 +1,139 @@
+package commands
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/grafana/xk6-disruptor/pkg/agent"
+	"github.com/grafana/xk6-disruptor/pkg/agent/protocol"
+	"github.com/grafana/xk6-disruptor/pkg/agent/protocol/dns"
+	"github.com/grafana/xk6-disruptor/pkg/iptables"
+	"github.com/grafana/xk6-disruptor/pkg/runtime"
+
+	"github.com/spf13/cobra"
+)
+
+// resolvConf is the resolver configuration shared by the containers of a pod
+const resolvConf = "/etc/resolv.conf"
+
+// BuildDNSCmd returns a cobra command with the specification of the dns command
+//
+//nolint:funlen
+func BuildDNSCmd(env runtime.Environment, config *agent.Config) *cobra.Command {
+	disruption := dns.Disruption{}
+	var duration time.Duration
+	var port uint
+	var upstream string
+	var rules []string
+
+	cmd := &cobra.Command{
+		Use:   "dns",
+		Short: "dns disruptor",
+		Long: "Disrupts the DNS queries sent by the target by introducing delays, errors and spoofed addresses." +
+			" Requires NET_ADMIN capabilities for setting iptable rules that redirect the queries sent to" +
+			" any DNS server to the proxy.",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			var err error
+			disruption.Rules, err = dns.ParseRules(rules)
+			if err != nil {
+				return err
+			}
+
+			if upstream == "" {
+				upstream, err = nameserver(resolvConf)
+				if err != nil {
+					return err
+				}
+			}
+
+			agent, err := agent.Start(env, config)
+			if err != nil {
+				return fmt.Errorf("initializing agent: %w", err)
+			}
+
+			defer agent.Stop()
+
+			listenAddress := net.JoinHostPort("", fmt.Sprint(port))
+			listener, err := net.Listen("tcp", listenAddress)
+			if err != nil {
+				return fmt.Errorf("setting up listener at %q: %w", listenAddress, err)
+			}
+
+			conn, err := net.ListenPacket("udp", listenAddress)
+			if err != nil {
+				_ = listener.Close()
+				return fmt.Errorf("setting up packet listener at %q: %w", listenAddress, err)
+			}
+
+			proxy, err := dns.NewEgressProxy(listener, conn, upstream, disruption)
+			if err != nil {
+				return err
+			}
+
+			// Redirect the queries to the proxy
+			tr := &protocol.DNSRedirectionSpec{
+				RedirectPort: port,
+			}
+
+			redirector, err := protocol.NewDNSTrafficRedirector(tr, iptables.New(env.Executor()))
+			if err != nil {
+				return err
+			}
+
+			disruptor, err := protocol.NewDisruptor(
+				env.Executor(),
+				proxy,
+				redirector,
+			)
+			if err != nil {
+				return err
+			}
+
+			return agent.ApplyDisruption(cmd.Context(), disruptor, duration)
+		},
+	}
+	cmd.Flags().DurationVarP(&duration, "duration", "d", 0, "duration of the disruptions")
+	cmd.Flags().DurationVarP(&disruption.AverageDelay, "average-delay", "a", 0, "average query delay")
+	cmd.Flags().DurationVarP(&disruption.DelayVariation, "delay-variation", "v", 0, "variation in query delay")
+	cmd.Flags().Float32VarP(&disruption.ErrorRate, "rate", "r", 0, "error rate")
+	cmd.Flags().StringVar(&disruption.Error, "error", "", "error returned to the queries selected by the error rate"+
+		" (NXDOMAIN or SERVFAIL)")
+	cmd.Flags().StringArrayVar(&disruption.Addresses, "address", []string{}, "address returned to the A and AAAA"+
+		" queries instead of the upstream's answers. Can be repeated")
+	cmd.Flags().UintVarP(&port, "port", "p", 8000, "port the proxy will listen to")
+	cmd.Flags().StringArrayVar(&rules, "rule", []string{}, "rule for selecting queries and their disruption,"+
+		" in JSON format. Can be repeated")
+	cmd.Flags().StringVar(&upstream, "upstream", "", "DNS server (host:port) the queries are forwarded to."+
+		" Defaults to the first nameserver in "+resolvConf)
+
+	return cmd
+}
+
+// nameserver returns the address of the first nameserver in a resolver configuration file
+func nameserver(path string) (string, error) {
+	file, err := os.Open(path) //nolint:gosec // path is a constant
+	if err != nil {
+		return "", fmt.Errorf("reading resolver configuration: %w", err)
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) >= 2 && fields[0] == "nameserver" {
+			return net.JoinHostPort(fields[1], fmt.Sprint(protocol.DNSPort)), nil
+		}
+	}
+
+	if err = scanner.Err(); err != nil {
+		return "", fmt.Errorf("reading resolver configuration: %w", err)
+	}
+
+	return "", fmt.Errorf("no nameserver found in %s", path)
+}
@@ -0,0 +1,45 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+
+	"github.com/grafana/xk6-disruptor/pkg/agent/network"
+	"github.com/grafana/xk6-disruptor/pkg/agent/protocol"
+	"github.com/grafana/xk6-disruptor/pkg/iptables"
+	"github.com/grafana/xk6-disruptor/pkg/runtime"
+)
+
+// buildEgressRedirector returns a redirector for the outbound traffic sent to an upstream given as host:port.
+// The host is resolved when the redirector is built.
+func buildEgressRedirector(
+	ctx context.Context,
+	env runtime.Environment,
+	upstream string,
+	proxyPort uint,
+) (protocol.TrafficRedirector, error) {
+	host, portStr, err := net.SplitHostPort(upstream)
+	if err != nil {
+		return nil, fmt.Errorf("invalid egress upstream %q: %w", upstream, err)
+	}
+
+	port, err := strconv.ParseUint(portStr, 10, 16)
+	if err != nil || port == 0 {
+		return nil, fmt.Errorf("invalid egress upstream port %q", portStr)
+	}
+
+	destinations, err := network.ResolveDestinations(ctx, network.DefaultResolver(), []string{host})
+	if err != nil {
+		return nil, err
+	}
+
+	tr := &protocol.EgressRedirectionSpec{
+		Destinations:    destinations, // Redirect traffic sent to the upstream...
+		DestinationPort: uint(port),
+		RedirectPort:    proxyPort, // to the proxy port.
+	}
+
+	return protocol.NewEgressTrafficRedirector(tr, iptables.New(env.Executor()))
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/grafana/xk6-disruptor/pkg/agent"
 	"github.com/grafana/xk6-disruptor/pkg/agent/protocol"
 	"github.com/grafana/xk6-disruptor/pkg/agent/protocol/grpc"
+	"github.com/grafana/xk6-disruptor/pkg/agent/schedule"
 	"github.com/grafana/xk6-disruptor/pkg/iptables"
 	"github.com/grafana/xk6-disruptor/pkg/runtime"
 
@@ -23,6 +24,9 @@ func BuildGrpcCmd(env runtime.Environment, config *agent.Config) *cobra.Command
 	var port uint
 	var upstreamHost string
 	var targetPort uint
+	var egress string
+	var rules []string
+	var faultSchedule string
 	transparent := true
 
 	cmd := &cobra.Command{
@@ -30,18 +34,34 @@ func BuildGrpcCmd(env runtime.Environment, config *agent.Config) *cobra.Command
 		Short: "grpc disruptor",
 		Long: "Disrupts http request by introducing delays and errors." +
 			" When running as a transparent proxy requires NET_ADMIM capabilities for setting" +
-			" iptable rules.",
+			" iptable rules. If an egress upstream is specified, the requests the target sends to it are" +
+			" disrupted instead.",
 		RunE: func(cmd *cobra.Command, _ []string) error {
-			if targetPort == 0 {
+			if targetPort == 0 && egress == "" {
 				return fmt.Errorf("target port for fault injection is required")
 			}
 
-			if transparent && (upstreamHost == "localhost" || upstreamHost == "127.0.0.1") {
+			if targetPort != 0 && egress != "" {
+				return fmt.Errorf("target port and egress upstream cannot be both specified")
+			}
+
+			if transparent && egress == "" && (upstreamHost == "localhost" || upstreamHost == "127.0.0.1") {
 				// When running in transparent mode, the Redirector will also redirect traffic directed to 127.0.0.1 to
 				// the proxy. Using 127.0.0.1 as the proxy upstream would cause a redirection loop.
 				return fmt.Errorf("upstream host cannot be localhost when running in transparent mode")
 			}
 
+			var err error
+			disruption.Rules, err = grpc.ParseRules(rules)
+			if err != nil {
+				return err
+			}
+
+			disruption.Schedule, err = schedule.Parse(faultSchedule)
+			if err != nil {
+				return err
+			}
+
 			agent, err := agent.Start(env, config)
 			if err != nil {
 				return fmt.Errorf("initializing agent: %w", err)
@@ -51,20 +71,31 @@ func BuildGrpcCmd(env runtime.Environment, config *agent.Config) *cobra.Command
 
 			listenAddress := net.JoinHostPort("", fmt.Sprint(port))
 			upstreamAddress := net.JoinHostPort(upstreamHost, fmt.Sprint(targetPort))
+			newProxy := grpc.NewProxy
+			if egress != "" {
+				upstreamAddress = egress
+				newProxy = grpc.NewEgressProxy
+			}
 
 			listener, err := net.Listen("tcp", listenAddress)
 			if err != nil {
 				return fmt.Errorf("setting up listener at %q: %w", listenAddress, err)
 			}
 
-			proxy, err := grpc.NewProxy(listener, upstreamAddress, disruption)
+			proxy, err := newProxy(listener, upstreamAddress, disruption)
 			if err != nil {
 				return err
 			}
 
 			// Redirect traffic to the proxy
 			var redirector protocol.TrafficRedirector
-			if transparent {
+			switch {
+			case egress != "":
+				redirector, err = buildEgressRedirector(cmd.Context(), env, egress, port)
+				if err != nil {
+					return err
+				}
+			case transparent:
 				tr := &protocol.TrafficRedirectionSpec{
 					DestinationPort: targetPort, // Redirect traffic from the application (target) port...
 					RedirectPort:    port,       // to the proxy port.
@@ -74,7 +105,7 @@ func BuildGrpcCmd(env runtime.Environment, config *agent.Config) *cobra.Command
 				if err != nil {
 					return err
 				}
-			} else {
+			default:
 				redirector = protocol.NoopTrafficRedirector()
 			}
 
@@ -96,13 +127,25 @@ func BuildGrpcCmd(env runtime.Environment, config *agent.Config) *cobra.Command
 	cmd.Flags().Uint32VarP(&disruption.StatusCode, "status", "s", 0, "status code")
 	cmd.Flags().Float32VarP(&disruption.ErrorRate, "rate", "r", 0, "error rate")
 	cmd.Flags().StringVarP(&disruption.StatusMessage, "message", "m", "", "error message for injected faults")
+	cmd.Flags().DurationVar(&disruption.MessageDelay, "message-delay", 0, "delay added to each stream message")
+	cmd.Flags().Float32Var(&disruption.MessageDropRate, "message-drop-rate", 0, "fraction of stream messages dropped")
+	cmd.Flags().UintVar(&disruption.AbortAfterMessages, "abort-after-messages", 0,
+		"abort streams with the status code after sending this number of messages")
+	cmd.Flags().DurationVar(&disruption.AbortAfter, "abort-after", 0,
+		"abort streams with the status code at a random time up to this duration")
 	cmd.Flags().UintVarP(&port, "port", "p", 8000, "port the proxy will listen to")
 	cmd.Flags().UintVarP(&targetPort, "target", "t", 0, "port the proxy will redirect request to")
 	cmd.Flags().StringSliceVarP(&disruption.Excluded, "exclude", "x", []string{}, "comma-separated list of grpc services"+
 		" to be excluded from disruption")
+	cmd.Flags().StringArrayVar(&rules, "rule", []string{}, "rule for selecting requests and their disruption,"+
+		" in JSON format. Can be repeated")
+	cmd.Flags().StringVar(&faultSchedule, "schedule", "", "schedule that varies the delays and error rates during the"+
+		" disruption, in JSON format")
 	cmd.Flags().BoolVar(&transparent, "transparent", true, "run as transparent proxy")
 	cmd.Flags().StringVar(&upstreamHost, "upstream-host", "localhost",
 		"upstream host to redirect traffic to")
+	cmd.Flags().StringVar(&egress, "egress", "", "disrupt the outbound requests sent to this upstream (host:port)"+
+		" instead of the requests sent to the target port")
 
 	return cmd
 }
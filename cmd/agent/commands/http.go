@@ -1,13 +1,16 @@
 package commands
 
 import (
+	"crypto/tls"
 	"fmt"
+	"io"
 	"net"
 	"time"
 
 	"github.com/grafana/xk6-disruptor/pkg/agent"
 	"github.com/grafana/xk6-disruptor/pkg/agent/protocol"
 	"github.com/grafana/xk6-disruptor/pkg/agent/protocol/http"
+	"github.com/grafana/xk6-disruptor/pkg/agent/schedule"
 	"github.com/grafana/xk6-disruptor/pkg/iptables"
 	"github.com/grafana/xk6-disruptor/pkg/runtime"
 	"github.com/spf13/cobra"
@@ -22,25 +25,49 @@ func BuildHTTPCmd(env runtime.Environment, config *agent.Config) *cobra.Command
 	var port uint
 	var upstreamHost string
 	var targetPort uint
+	var egress string
+	var rules []string
+	var faultSchedule string
+	var tlsEnabled bool
+	var tlsGenerate bool
+	var tlsHosts []string
 	transparent := true
 
 	cmd := &cobra.Command{
 		Use:   "http",
 		Short: "http disruptor",
-		Long: "Disrupts http request by introducing delays and errors." +
+		Long: "Disrupts http request by introducing delays and errors, and modifying the responses." +
 			" When running as a transparent proxy requires NET_ADMIM capabilities for setting" +
-			" iptable rules.",
+			" iptable rules. If an egress upstream is specified, the requests the target sends to it are" +
+			" disrupted instead. In TLS mode, the proxy terminates TLS with the certificate and key read from the" +
+			" standard input in PEM format, or with a certificate generated from the CA read from the standard input," +
+			" and re-encrypts the requests to the upstream.",
 		RunE: func(cmd *cobra.Command, _ []string) error {
-			if targetPort == 0 {
+			if targetPort == 0 && egress == "" {
 				return fmt.Errorf("target port for fault injection is required")
 			}
 
-			if transparent && (upstreamHost == "localhost" || upstreamHost == "127.0.0.1") {
+			if targetPort != 0 && egress != "" {
+				return fmt.Errorf("target port and egress upstream cannot be both specified")
+			}
+
+			if transparent && egress == "" && (upstreamHost == "localhost" || upstreamHost == "127.0.0.1") {
 				// When running in transparent mode, the Redirector will also redirect traffic directed to 127.0.0.1 to
 				// the proxy. Using 127.0.0.1 as the proxy upstream would cause a redirection loop.
 				return fmt.Errorf("upstream host cannot be localhost when running in transparent mode")
 			}
 
+			var err error
+			disruption.Rules, err = http.ParseRules(rules)
+			if err != nil {
+				return err
+			}
+
+			disruption.Schedule, err = schedule.Parse(faultSchedule)
+			if err != nil {
+				return err
+			}
+
 			agent, err := agent.Start(env, config)
 			if err != nil {
 				return fmt.Errorf("initializing agent: %w", err)
@@ -48,22 +75,47 @@ func BuildHTTPCmd(env runtime.Environment, config *agent.Config) *cobra.Command
 
 			defer agent.Stop()
 
+			scheme := "http://"
+			var tlsConfig *tls.Config
+			if tlsEnabled {
+				scheme = "https://"
+				tlsConfig, err = buildTLSConfig(cmd.InOrStdin(), tlsGenerate, tlsHosts)
+				if err != nil {
+					return err
+				}
+			}
+
 			listenAddress := net.JoinHostPort("", fmt.Sprint(port))
-			upstreamAddress := "http://" + net.JoinHostPort(upstreamHost, fmt.Sprint(targetPort))
+			upstreamAddress := scheme + net.JoinHostPort(upstreamHost, fmt.Sprint(targetPort))
+			newProxy := http.NewProxy
+			if egress != "" {
+				upstreamAddress = scheme + egress
+				newProxy = http.NewEgressProxy
+			}
 
 			listener, err := net.Listen("tcp", listenAddress)
 			if err != nil {
 				return fmt.Errorf("setting up listener at %q: %w", listenAddress, err)
 			}
 
-			proxy, err := http.NewProxy(listener, upstreamAddress, disruption)
+			if tlsConfig != nil {
+				listener = tls.NewListener(listener, tlsConfig)
+			}
+
+			proxy, err := newProxy(listener, upstreamAddress, disruption)
 			if err != nil {
 				return err
 			}
 
 			// Redirect traffic to the proxy
 			var redirector protocol.TrafficRedirector
-			if transparent {
+			switch {
+			case egress != "":
+				redirector, err = buildEgressRedirector(cmd.Context(), env, egress, port)
+				if err != nil {
+					return err
+				}
+			case transparent:
 				tr := &protocol.TrafficRedirectionSpec{
 					DestinationPort: targetPort, // Redirect traffic from the application (target) port...
 					RedirectPort:    port,       // to the proxy port.
@@ -73,7 +125,7 @@ func BuildHTTPCmd(env runtime.Environment, config *agent.Config) *cobra.Command
 				if err != nil {
 					return err
 				}
-			} else {
+			default:
 				redirector = protocol.NoopTrafficRedirector()
 			}
 
@@ -98,11 +150,76 @@ func BuildHTTPCmd(env runtime.Environment, config *agent.Config) *cobra.Command
 	cmd.Flags().StringVarP(&disruption.ErrorBody, "body", "b", "", "body for injected faults")
 	cmd.Flags().StringSliceVarP(&disruption.Excluded, "exclude", "x", []string{}, "comma-separated list of path(s)"+
 		" to be excluded from disruption")
+	cmd.Flags().StringArrayVar(&rules, "rule", []string{}, "rule for selecting requests and their disruption,"+
+		" in JSON format. Can be repeated")
+	cmd.Flags().StringVar(&faultSchedule, "schedule", "", "schedule that varies the delays and error rates during the"+
+		" disruption, in JSON format")
+	cmd.Flags().StringToStringVar(&disruption.SetHeaders, "set-header", map[string]string{},
+		"header to add to the responses, as name=value. Can be repeated")
+	cmd.Flags().StringSliceVar(&disruption.RemoveHeaders, "remove-header", []string{},
+		"comma-separated list of headers to be removed from the responses")
+	cmd.Flags().IntVar(&disruption.ResponseStatus, "response-status", 0,
+		"status code that replaces the status of the responses, keeping their body")
+	cmd.Flags().Float32Var(&disruption.TruncateBody, "truncate-body", 0,
+		"fraction of the body of the responses removed from its end")
+	cmd.Flags().Float32Var(&disruption.CorruptBody, "corrupt-body", 0,
+		"fraction of the bytes of the body of the responses replaced by random bytes")
+	cmd.Flags().BoolVar(&disruption.MalformedJSON, "malformed-json", false,
+		"replace the body of the responses with a malformed JSON payload")
+	cmd.Flags().UintVar(&disruption.BodyRate, "body-rate", 0,
+		"maximum rate, in bytes per second, at which the body of the responses is sent")
+	cmd.Flags().DurationVar(&disruption.FirstByteDelay, "first-byte-delay", 0,
+		"delay between sending the headers and the first byte of the body of the responses")
+	cmd.Flags().UintVar(&disruption.StallAfter, "stall-after", 0,
+		"number of bytes of the body of the responses sent before the body stalls")
+	cmd.Flags().DurationVar(&disruption.StallDuration, "stall-duration", 0,
+		"time the body of the responses stalls")
+	cmd.Flags().DurationVar(&disruption.FrameDelay, "frame-delay", 0,
+		"delay introduced to each WebSocket frame and Server-Sent Event")
+	cmd.Flags().UintVar(&disruption.DropAfterFrames, "drop-after-frames", 0,
+		"number of WebSocket frames or Server-Sent Events sent to the client after which the connection is dropped")
+	cmd.Flags().DurationVar(&disruption.DropAfter, "drop-after", 0,
+		"maximum time after which WebSocket connections and Server-Sent Events streams are dropped")
+	cmd.Flags().Uint16Var(&disruption.CloseCode, "close-code", 0,
+		"close code sent to the client when a WebSocket connection is dropped")
 	cmd.Flags().BoolVar(&transparent, "transparent", true, "run as transparent proxy")
+	cmd.Flags().BoolVar(&tlsEnabled, "tls", false, "terminate TLS with the certificate and key read from the"+
+		" standard input and re-encrypt the requests to the upstream")
+	cmd.Flags().BoolVar(&tlsGenerate, "tls-generate", false, "generate the certificate from the CA certificate and"+
+		" key read from the standard input")
+	cmd.Flags().StringSliceVar(&tlsHosts, "tls-host", []string{}, "comma-separated list of hosts (names or IPs)"+
+		" of the generated certificate")
 	cmd.Flags().StringVar(&upstreamHost, "upstream-host", "localhost",
 		"upstream host to redirect traffic to")
+	cmd.Flags().StringVar(&egress, "egress", "", "disrupt the outbound requests sent to this upstream (host:port)"+
+		" instead of the requests sent to the target port")
 	cmd.Flags().UintVarP(&port, "port", "p", 8000, "port the proxy will listen to")
 	cmd.Flags().UintVarP(&targetPort, "target", "t", 0, "port the proxy will redirect request to")
 
 	return cmd
 }
+
+// buildTLSConfig returns the configuration for terminating TLS with the certificate read from the reader in PEM
+// format. If generate is true, the reader contains the CA used for generating a certificate for the hosts.
+func buildTLSConfig(reader io.Reader, generate bool, hosts []string) (*tls.Config, error) {
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("reading TLS credentials: %w", err)
+	}
+
+	var cert tls.Certificate
+	if generate {
+		cert, err = http.GenerateCertificate(data, hosts)
+	} else {
+		cert, err = http.LoadCertificate(data)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+		NextProtos:   []string{"h2", "http/1.1"},
+	}, nil
+}
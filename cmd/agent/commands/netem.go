@@ -0,0 +1,76 @@
+package commands
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/grafana/xk6-disruptor/pkg/agent"
+	"github.com/grafana/xk6-disruptor/pkg/agent/network"
+	"github.com/grafana/xk6-disruptor/pkg/agent/schedule"
+	"github.com/grafana/xk6-disruptor/pkg/runtime"
+	"github.com/grafana/xk6-disruptor/pkg/tc"
+	"github.com/spf13/cobra"
+)
+
+// BuildNetemCmd builds the command for emulating network conditions on the traffic sent from a given port.
+func BuildNetemCmd(env runtime.Environment, config *agent.Config) *cobra.Command {
+	var duration time.Duration
+	disruptor := network.NetemDisruptor{}
+	var egress []string
+	var faultSchedule string
+
+	cmd := &cobra.Command{
+		Use:   "netem",
+		Short: "network emulation (experimental)",
+		Long: "Emulates network conditions such as latency, packet loss or limited bandwidth using tc netem. " +
+			"If no port or protocol is specified, all traffic sent from the interface is disrupted. " +
+			"If egress destinations are specified, only the traffic sent to them is disrupted. " +
+			"Requires either to be run as root, or the NET_ADMIN capability.",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if err := disruptor.Netem.Validate(); err != nil {
+				return err
+			}
+
+			var err error
+			disruptor.Schedule, err = schedule.Parse(faultSchedule)
+			if err != nil {
+				return err
+			}
+
+			destinations, err := network.ResolveDestinations(cmd.Context(), network.DefaultResolver(), egress)
+			if err != nil {
+				return err
+			}
+			disruptor.Filter.Destinations = destinations
+
+			agent, err := agent.Start(env, config)
+			if err != nil {
+				return fmt.Errorf("initializing agent: %w", err)
+			}
+
+			defer agent.Stop()
+
+			disruptor.TC = tc.New(env.Executor())
+
+			return agent.ApplyDisruption(cmd.Context(), disruptor, duration)
+		},
+	}
+	cmd.Flags().DurationVarP(&duration, "duration", "d", 0, "duration of the disruptions")
+	cmd.Flags().StringVarP(&disruptor.Interface, "interface", "i", network.DefaultInterface, "interface to disrupt")
+	cmd.Flags().UintVarP(&disruptor.Filter.Port, "port", "p", 0,
+		"source port of the traffic to be disrupted, or destination port of the egress traffic")
+	cmd.Flags().StringVarP(&disruptor.Filter.Protocol, "protocol", "P", "", "protocol of the traffic to be disrupted")
+	cmd.Flags().StringSliceVar(&egress, "egress", nil,
+		"destinations of the outbound traffic to be disrupted (IP address, CIDR or hostname)")
+	cmd.Flags().DurationVar(&disruptor.Netem.Delay, "delay", 0, "delay added to each packet")
+	cmd.Flags().DurationVar(&disruptor.Netem.Jitter, "jitter", 0, "variation of the delay")
+	cmd.Flags().Float64Var(&disruptor.Netem.LossRate, "loss", 0, "fraction of packets dropped (0.0-1.0)")
+	cmd.Flags().StringVar(&disruptor.Netem.Bandwidth, "bandwidth", "", "bandwidth limit (e.g. 1mbit)")
+	cmd.Flags().Float64Var(&disruptor.Netem.ReorderRate, "reorder", 0, "fraction of packets reordered (0.0-1.0)")
+	cmd.Flags().Float64Var(&disruptor.Netem.DuplicateRate, "duplicate", 0, "fraction of packets duplicated (0.0-1.0)")
+	cmd.Flags().Float64Var(&disruptor.Netem.CorruptRate, "corrupt", 0, "fraction of packets corrupted (0.0-1.0)")
+	cmd.Flags().StringVar(&faultSchedule, "schedule", "", "schedule that varies the delays and rates during the"+
+		" disruption, in JSON format")
+
+	return cmd
+}
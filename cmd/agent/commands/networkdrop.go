@@ -6,6 +6,7 @@ import (
 
 	"github.com/grafana/xk6-disruptor/pkg/agent"
 	"github.com/grafana/xk6-disruptor/pkg/agent/network"
+	"github.com/grafana/xk6-disruptor/pkg/agent/schedule"
 	"github.com/grafana/xk6-disruptor/pkg/iptables"
 	"github.com/grafana/xk6-disruptor/pkg/runtime"
 	"github.com/spf13/cobra"
@@ -15,13 +16,27 @@ import (
 func BuildNetworkDropCmd(env runtime.Environment, config *agent.Config) *cobra.Command {
 	var duration time.Duration
 	filter := network.Filter{}
+	var egress []string
+	var faultSchedule string
 
 	cmd := &cobra.Command{
 		Use:   "network-drop",
 		Short: "network connection drop (experimental)",
-		Long: "Drops Network Traffic. If no port or protocol is specified, all INPUT traffic will be dropped." +
+		Long: "Drops Network Traffic. If no port or protocol is specified, all INPUT traffic will be dropped. " +
+			"If egress destinations are specified, the OUTPUT traffic sent to them is dropped instead. " +
 			"Requires either to be run as root, or the NET_ADMIN capability.",
 		RunE: func(cmd *cobra.Command, _ []string) error {
+			dropSchedule, err := schedule.Parse(faultSchedule)
+			if err != nil {
+				return err
+			}
+
+			destinations, err := network.ResolveDestinations(cmd.Context(), network.DefaultResolver(), egress)
+			if err != nil {
+				return err
+			}
+			filter.Destinations = destinations
+
 			agent, err := agent.Start(env, config)
 			if err != nil {
 				return fmt.Errorf("initializing agent: %w", err)
@@ -32,6 +47,7 @@ func BuildNetworkDropCmd(env runtime.Environment, config *agent.Config) *cobra.C
 			disruptor := network.Disruptor{
 				Iptables: iptables.New(env.Executor()),
 				Filter:   filter,
+				Schedule: dropSchedule,
 			}
 
 			return agent.ApplyDisruption(cmd.Context(), disruptor, duration)
@@ -40,6 +56,10 @@ func BuildNetworkDropCmd(env runtime.Environment, config *agent.Config) *cobra.C
 	cmd.Flags().DurationVarP(&duration, "duration", "d", 0, "duration of the disruptions")
 	cmd.Flags().UintVarP(&filter.Port, "port", "p", 0, "target port of the connections to be disrupted")
 	cmd.Flags().StringVarP(&filter.Protocol, "protocol", "P", "", "target protocol of the connections to be disrupted")
+	cmd.Flags().StringSliceVar(&egress, "egress", nil,
+		"destinations of the outbound traffic to be disrupted (IP address, CIDR or hostname)")
+	cmd.Flags().StringVar(&faultSchedule, "schedule", "", "schedule that varies the fraction of packets dropped"+
+		" during the disruption, in JSON format")
 
 	return cmd
 }
@@ -0,0 +1,137 @@
+package commands
+
+import (
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/grafana/xk6-disruptor/pkg/agent"
+	"github.com/grafana/xk6-disruptor/pkg/agent/protocol"
+	"github.com/grafana/xk6-disruptor/pkg/agent/protocol/postgres"
+	"github.com/grafana/xk6-disruptor/pkg/iptables"
+	"github.com/grafana/xk6-disruptor/pkg/runtime"
+
+	"github.com/spf13/cobra"
+)
+
+// BuildPostgresCmd returns a cobra command with the specification of the postgres command
+//
+//nolint:funlen
+func BuildPostgresCmd(env runtime.Environment, config *agent.Config) *cobra.Command {
+	disruption := postgres.Disruption{}
+	var duration time.Duration
+	var port uint
+	var upstreamHost string
+	var targetPort uint
+	var egress string
+	var rules []string
+	transparent := true
+
+	cmd := &cobra.Command{
+		Use:   "postgres",
+		Short: "postgres disruptor",
+		Long: "Disrupts the queries sent to a PostgreSQL server by introducing delays, errors and" +
+			" terminating the connections." +
+			" When running as a transparent proxy requires NET_ADMIN capabilities for setting" +
+			" iptable rules. If an egress upstream is specified, the queries the target sends to it are" +
+			" disrupted instead.",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if targetPort == 0 && egress == "" {
+				return fmt.Errorf("target port for fault injection is required")
+			}
+
+			if targetPort != 0 && egress != "" {
+				return fmt.Errorf("target port and egress upstream cannot be both specified")
+			}
+
+			if transparent && egress == "" && (upstreamHost == "localhost" || upstreamHost == "127.0.0.1") {
+				// When running in transparent mode, the Redirector will also redirect traffic directed to 127.0.0.1 to
+				// the proxy. Using 127.0.0.1 as the proxy upstream would cause a redirection loop.
+				return fmt.Errorf("upstream host cannot be localhost when running in transparent mode")
+			}
+
+			var err error
+			disruption.Rules, err = postgres.ParseRules(rules)
+			if err != nil {
+				return err
+			}
+
+			agent, err := agent.Start(env, config)
+			if err != nil {
+				return fmt.Errorf("initializing agent: %w", err)
+			}
+
+			defer agent.Stop()
+
+			listenAddress := net.JoinHostPort("", fmt.Sprint(port))
+			upstreamAddress := net.JoinHostPort(upstreamHost, fmt.Sprint(targetPort))
+			newProxy := postgres.NewProxy
+			if egress != "" {
+				upstreamAddress = egress
+				newProxy = postgres.NewEgressProxy
+			}
+
+			listener, err := net.Listen("tcp", listenAddress)
+			if err != nil {
+				return fmt.Errorf("setting up listener at %q: %w", listenAddress, err)
+			}
+
+			proxy, err := newProxy(listener, upstreamAddress, disruption)
+			if err != nil {
+				return err
+			}
+
+			// Redirect traffic to the proxy
+			var redirector protocol.TrafficRedirector
+			switch {
+			case egress != "":
+				redirector, err = buildEgressRedirector(cmd.Context(), env, egress, port)
+				if err != nil {
+					return err
+				}
+			case transparent:
+				tr := &protocol.TrafficRedirectionSpec{
+					DestinationPort: targetPort, // Redirect traffic from the application (target) port...
+					RedirectPort:    port,       // to the proxy port.
+				}
+
+				redirector, err = protocol.NewTrafficRedirector(tr, iptables.New(env.Executor()))
+				if err != nil {
+					return err
+				}
+			default:
+				redirector = protocol.NoopTrafficRedirector()
+			}
+
+			disruptor, err := protocol.NewDisruptor(
+				env.Executor(),
+				proxy,
+				redirector,
+			)
+			if err != nil {
+				return err
+			}
+
+			return agent.ApplyDisruption(cmd.Context(), disruptor, duration)
+		},
+	}
+	cmd.Flags().DurationVarP(&duration, "duration", "d", 0, "duration of the disruptions")
+	cmd.Flags().DurationVarP(&disruption.AverageDelay, "average-delay", "a", 0, "average query delay")
+	cmd.Flags().DurationVarP(&disruption.DelayVariation, "delay-variation", "v", 0, "variation in query delay")
+	cmd.Flags().Float32VarP(&disruption.ErrorRate, "rate", "r", 0, "error rate")
+	cmd.Flags().StringVar(&disruption.ErrorCode, "error-code", "", "SQLSTATE code of the injected errors (e.g. 40001)")
+	cmd.Flags().StringVar(&disruption.ErrorMessage, "error-message", "", "message of the injected errors")
+	cmd.Flags().Float32Var(&disruption.TerminateRate, "terminate-rate", 0, "fraction of queries that terminate"+
+		" the connection to the server")
+	cmd.Flags().UintVarP(&port, "port", "p", 8000, "port the proxy will listen to")
+	cmd.Flags().UintVarP(&targetPort, "target", "t", 0, "port the proxy will redirect queries to")
+	cmd.Flags().StringArrayVar(&rules, "rule", []string{}, "rule for selecting queries and their disruption,"+
+		" in JSON format. Can be repeated")
+	cmd.Flags().BoolVar(&transparent, "transparent", true, "run as transparent proxy")
+	cmd.Flags().StringVar(&upstreamHost, "upstream-host", "localhost",
+		"upstream host to redirect traffic to")
+	cmd.Flags().StringVar(&egress, "egress", "", "disrupt the outbound queries sent to this upstream (host:port)"+
+		" instead of the queries sent to the target port")
+
+	return cmd
+}
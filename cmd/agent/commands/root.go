@@ -26,10 +26,17 @@ func NewRootCommand(env runtime.Environment) *RootCommand {
 	rootCmd := buildRootCmd(config)
 	rootCmd.AddCommand(BuildHTTPCmd(env, config))
 	rootCmd.AddCommand(BuildGrpcCmd(env, config))
+	rootCmd.AddCommand(BuildTCPCmd(env, config))
+	rootCmd.AddCommand(BuildRedisCmd(env, config))
+	rootCmd.AddCommand(BuildPostgresCmd(env, config))
+	rootCmd.AddCommand(BuildKafkaCmd(env, config))
+	rootCmd.AddCommand(BuildDNSCmd(env, config))
 	rootCmd.AddCommand(BuildTCPDropCmd(env, config))
 	rootCmd.AddCommand(BuildStressCmd(env, config))
+	rootCmd.AddCommand(BuildDiskCmd(env, config))
 	rootCmd.AddCommand(BuiltCleanupCmd(env))
 	rootCmd.AddCommand(BuildNetworkDropCmd(env, config))
+	rootCmd.AddCommand(BuildNetemCmd(env, config))
 
 	return &RootCommand{
 		cmd: rootCmd,
@@ -15,12 +15,20 @@ func BuildStressCmd(env runtime.Environment, config *agent.Config) *cobra.Comman
 	var duration time.Duration
 	var disruption stressors.ResourceDisruption
 	var opts stressors.ResourceStressOptions
+	var memory string
 
 	cmd := &cobra.Command{
 		Use:   "stress",
 		Short: "resource stressor",
-		Long:  "Stress CPU resource",
+		Long:  "Stress CPU and Memory resources",
 		RunE: func(cmd *cobra.Command, args []string) error { //nolint:revive
+			memoryDisruption, err := stressors.ParseMemory(memory)
+			if err != nil {
+				return err
+			}
+			disruption.Bytes = memoryDisruption.Bytes
+			disruption.Percentage = memoryDisruption.Percentage
+
 			agent, err := agent.Start(env, config)
 			if err != nil {
 				return fmt.Errorf("initializing agent: %w", err)
@@ -32,13 +40,19 @@ func BuildStressCmd(env runtime.Environment, config *agent.Config) *cobra.Comman
 				return err
 			}
 
-			return s.Apply(cmd.Context(), duration)
+			return agent.ApplyDisruption(cmd.Context(), s, duration)
 		},
 	}
 	cmd.Flags().DurationVarP(&duration, "duration", "d", 0, "duration of the disruptions")
-	cmd.Flags().DurationVarP(&opts.Slice, "slice", "s", 100, "CPU stress cycle in milliseconds (default 100ms)")
-	cmd.Flags().IntVarP(&disruption.Load, "load", "l", 100, "CPU load percentage (default 100%)")
-	cmd.Flags().IntVarP(&disruption.CPUs, "cpus", "c", 1, "number of CPUs to stress (default 1)")
+	cmd.Flags().DurationVarP(&opts.Slice, "slice", "s", stressors.DefaultSlice, "CPU stress cycle")
+	cmd.Flags().IntVarP(&disruption.Load, "load", "l", 100, "CPU load percentage")
+	cmd.Flags().IntVarP(&disruption.CPUs, "cpus", "c", 1, "number of CPUs to stress")
+	cmd.Flags().StringVarP(&memory, "memory", "m", "",
+		"memory to allocate as a quantity (e.g. 256Mi) or as a percentage of the memory limit of the target container"+
+			" (e.g. 50%)")
+	cmd.Flags().DurationVar(&disruption.RampUp, "memory-ramp", 0, "time for reaching the memory to allocate")
+	cmd.Flags().StringVar(&disruption.Container, "target-container", "",
+		"ID of the container the memory is accounted to")
 
 	return cmd
 }
@@ -0,0 +1,129 @@
+package commands
+
+import (
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/grafana/xk6-disruptor/pkg/agent"
+	"github.com/grafana/xk6-disruptor/pkg/agent/protocol"
+	"github.com/grafana/xk6-disruptor/pkg/agent/protocol/tcp"
+	"github.com/grafana/xk6-disruptor/pkg/iptables"
+	"github.com/grafana/xk6-disruptor/pkg/runtime"
+
+	"github.com/spf13/cobra"
+)
+
+// BuildTCPCmd returns a cobra command with the specification of the tcp command
+//
+//nolint:funlen
+func BuildTCPCmd(env runtime.Environment, config *agent.Config) *cobra.Command {
+	disruption := tcp.Disruption{}
+	var duration time.Duration
+	var port uint
+	var upstreamHost string
+	var targetPort uint
+	var egress string
+	transparent := true
+
+	cmd := &cobra.Command{
+		Use:   "tcp",
+		Short: "tcp disruptor",
+		Long: "Disrupts the TCP connections of any protocol by delaying, throttling, holding or resetting them." +
+			" When running as a transparent proxy requires NET_ADMIN capabilities for setting" +
+			" iptable rules. If an egress upstream is specified, the connections the target opens to it are" +
+			" disrupted instead.",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if targetPort == 0 && egress == "" {
+				return fmt.Errorf("target port for fault injection is required")
+			}
+
+			if targetPort != 0 && egress != "" {
+				return fmt.Errorf("target port and egress upstream cannot be both specified")
+			}
+
+			if transparent && egress == "" && (upstreamHost == "localhost" || upstreamHost == "127.0.0.1") {
+				// When running in transparent mode, the Redirector will also redirect traffic directed to 127.0.0.1 to
+				// the proxy. Using 127.0.0.1 as the proxy upstream would cause a redirection loop.
+				return fmt.Errorf("upstream host cannot be localhost when running in transparent mode")
+			}
+
+			agent, err := agent.Start(env, config)
+			if err != nil {
+				return fmt.Errorf("initializing agent: %w", err)
+			}
+
+			defer agent.Stop()
+
+			listenAddress := net.JoinHostPort("", fmt.Sprint(port))
+			upstreamAddress := net.JoinHostPort(upstreamHost, fmt.Sprint(targetPort))
+			newProxy := tcp.NewProxy
+			if egress != "" {
+				upstreamAddress = egress
+				newProxy = tcp.NewEgressProxy
+			}
+
+			listener, err := net.Listen("tcp", listenAddress)
+			if err != nil {
+				return fmt.Errorf("setting up listener at %q: %w", listenAddress, err)
+			}
+
+			proxy, err := newProxy(listener, upstreamAddress, disruption)
+			if err != nil {
+				return err
+			}
+
+			// Redirect traffic to the proxy
+			var redirector protocol.TrafficRedirector
+			switch {
+			case egress != "":
+				redirector, err = buildEgressRedirector(cmd.Context(), env, egress, port)
+				if err != nil {
+					return err
+				}
+			case transparent:
+				tr := &protocol.TrafficRedirectionSpec{
+					DestinationPort: targetPort, // Redirect traffic from the application (target) port...
+					RedirectPort:    port,       // to the proxy port.
+				}
+
+				redirector, err = protocol.NewTrafficRedirector(tr, iptables.New(env.Executor()))
+				if err != nil {
+					return err
+				}
+			default:
+				redirector = protocol.NoopTrafficRedirector()
+			}
+
+			disruptor, err := protocol.NewDisruptor(
+				env.Executor(),
+				proxy,
+				redirector,
+			)
+			if err != nil {
+				return err
+			}
+
+			return agent.ApplyDisruption(cmd.Context(), disruptor, duration)
+		},
+	}
+	cmd.Flags().DurationVarP(&duration, "duration", "d", 0, "duration of the disruptions")
+	cmd.Flags().DurationVar(&disruption.ConnectDelay, "connect-delay", 0,
+		"delay before connecting to the upstream when a connection is accepted")
+	cmd.Flags().DurationVar(&disruption.ChunkDelay, "chunk-delay", 0, "delay added to each chunk of data forwarded")
+	cmd.Flags().UintVar(&disruption.Bandwidth, "bandwidth", 0,
+		"maximum rate, in bytes per second, at which data is forwarded in each direction")
+	cmd.Flags().Float32Var(&disruption.HalfOpenRate, "half-open-rate", 0,
+		"fraction of connections that are accepted but never forwarded")
+	cmd.Flags().UintVar(&disruption.ResetAfterBytes, "reset-after-bytes", 0,
+		"reset connections after forwarding this number of bytes")
+	cmd.Flags().UintVarP(&port, "port", "p", 8000, "port the proxy will listen to")
+	cmd.Flags().UintVarP(&targetPort, "target", "t", 0, "port the proxy will redirect connections to")
+	cmd.Flags().BoolVar(&transparent, "transparent", true, "run as transparent proxy")
+	cmd.Flags().StringVar(&upstreamHost, "upstream-host", "localhost",
+		"upstream host to redirect traffic to")
+	cmd.Flags().StringVar(&egress, "egress", "", "disrupt the outbound connections opened to this upstream"+
+		" (host:port) instead of the connections to the target port")
+
+	return cmd
+}
@@ -27,6 +27,8 @@ type ModuleInstance struct {
 	vu modules.VU
 	// instance of a Kubernetes helper
 	k8s kubernetes.Kubernetes
+	// teardown stops the faults started by the disruptors of the VU
+	teardown *api.Teardown
 }
 
 // Ensure the interfaces are implemented correctly.
@@ -42,10 +44,44 @@ func (*RootModule) NewModuleInstance(vu modules.VU) modules.Instance {
 		common.Throw(vu.Runtime(), fmt.Errorf("error creating Kubernetes helper: %w", err))
 	}
 
-	return &ModuleInstance{
-		vu:  vu,
-		k8s: k8s,
+	m := &ModuleInstance{
+		vu:       vu,
+		k8s:      k8s,
+		teardown: &api.Teardown{},
 	}
+
+	m.teardownOnTestEnd()
+
+	return m
+}
+
+// types of the k6 events. k6 does not export them, so they are defined with the values of the event.Type enum of its
+// internal event package in k6 v1.3.0. Test_TeardownOnTestEnd checks they match the events emitted by k6.
+const (
+	testEndEvent = 3
+	exitEvent    = 6
+)
+
+// teardownOnTestEnd runs the teardown when the test ends, or when k6 is about to exit if the test is aborted.
+// k6 waits for the teardown to return before exiting.
+func (m *ModuleInstance) teardownOnTestEnd() {
+	events := m.vu.Events().Global
+	if events == nil {
+		return
+	}
+
+	id, ch := events.Subscribe(testEndEvent, exitEvent)
+	go func() {
+		for e := range ch {
+			m.teardown.Run()
+			e.Done()
+
+			if e.Type == exitEvent {
+				events.Unsubscribe(id)
+				return
+			}
+		}
+	}()
 }
 
 // Exports implements the modules.Instance interface and returns the exports
@@ -55,6 +91,7 @@ func (m *ModuleInstance) Exports() modules.Exports {
 		Named: map[string]interface{}{
 			"PodDisruptor":     m.newPodDisruptor,
 			"ServiceDisruptor": m.newServiceDisruptor,
+			"NodeDisruptor":    m.newNodeDisruptor,
 		},
 	}
 }
@@ -64,7 +101,7 @@ func (m *ModuleInstance) newPodDisruptor(c sobek.ConstructorCall) *sobek.Object
 	rt := m.vu.Runtime()
 	ctx := m.vu.Context()
 
-	disruptor, err := api.NewPodDisruptor(ctx, rt, c, m.k8s)
+	disruptor, err := api.NewPodDisruptor(ctx, rt, c, m.k8s, m.teardown)
 	if err != nil {
 		common.Throw(rt, fmt.Errorf("error creating PodDisruptor: %w", err))
 	}
@@ -76,10 +113,23 @@ func (m *ModuleInstance) newServiceDisruptor(c sobek.ConstructorCall) *sobek.Obj
 	rt := m.vu.Runtime()
 	ctx := m.vu.Context()
 
-	disruptor, err := api.NewServiceDisruptor(ctx, rt, c, m.k8s)
+	disruptor, err := api.NewServiceDisruptor(ctx, rt, c, m.k8s, m.teardown)
 	if err != nil {
 		common.Throw(rt, fmt.Errorf("error creating ServiceDisruptor: %w", err))
 	}
 
 	return disruptor
 }
+
+// creates an instance of a NodeDisruptor
+func (m *ModuleInstance) newNodeDisruptor(c sobek.ConstructorCall) *sobek.Object {
+	rt := m.vu.Runtime()
+	ctx := m.vu.Context()
+
+	disruptor, err := api.NewNodeDisruptor(ctx, rt, c, m.k8s, m.teardown)
+	if err != nil {
+		common.Throw(rt, fmt.Errorf("error creating NodeDisruptor: %w", err))
+	}
+
+	return disruptor
+}
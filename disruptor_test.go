@@ -2,20 +2,28 @@ package disruptor
 
 import (
 	"context"
+	"fmt"
 	"io/ioutil"
+	"math"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/grafana/sobek"
+	"github.com/grafana/xk6-disruptor/pkg/api"
 	"github.com/grafana/xk6-disruptor/pkg/kubernetes"
 	"github.com/grafana/xk6-disruptor/pkg/testutils/kubernetes/builders"
 	"github.com/sirupsen/logrus"
 
+	"go.k6.io/k6/cmd/state"
 	"go.k6.io/k6/js/common"
 	"go.k6.io/k6/js/modules"
 	"go.k6.io/k6/js/modulestest"
 	"go.k6.io/k6/lib"
 	"go.k6.io/k6/metrics"
 
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/client-go/kubernetes/fake"
 )
 
@@ -45,14 +53,19 @@ func testVU() modules.VU {
 // instantiates a module with a fake kubernetes and a test VU
 func setTestModule(k8s *kubernetes.FakeKubernetes, vu modules.VU) error {
 	m := ModuleInstance{
-		k8s: k8s,
-		vu:  vu,
+		k8s:      k8s,
+		vu:       vu,
+		teardown: &api.Teardown{},
 	}
 	err := vu.Runtime().Set("PodDisruptor", m.Exports().Named["PodDisruptor"])
 	if err != nil {
 		return err
 	}
 	err = vu.Runtime().Set("ServiceDisruptor", m.Exports().Named["ServiceDisruptor"])
+	if err != nil {
+		return err
+	}
+	err = vu.Runtime().Set("NodeDisruptor", m.Exports().Named["NodeDisruptor"])
 
 	return err
 }
@@ -136,3 +149,153 @@ func Test_ServiceDisruptor(t *testing.T) {
 		t.Errorf("failed %v", err)
 	}
 }
+
+const listNodeTargetsScript = `
+const selector = {
+   select: {
+     labels: {
+	pool: "test"
+     }
+   }
+}
+const disruptor = new NodeDisruptor(selector)
+const targets = disruptor.targets()
+if (targets.length != 1) {
+   throw new Error("expected list to have one target")
+}
+`
+
+func Test_NodeDisruptor(t *testing.T) {
+	t.Parallel()
+
+	node := builders.NewNodeBuilder("node-with-pool-label").
+		WithLabel("pool", "test").
+		Build()
+	other := builders.NewNodeBuilder("other-node").
+		Build()
+	client := fake.NewSimpleClientset(&node, &other)
+	k8s, _ := kubernetes.NewFakeKubernetes(client)
+	vu := testVU()
+	err := setTestModule(k8s, vu)
+	if err != nil {
+		t.Errorf("test setup failed: %v", err)
+	}
+
+	_, err = vu.Runtime().RunString(listNodeTargetsScript)
+	if err != nil {
+		t.Errorf("failed %v", err)
+	}
+}
+
+// emitEvent emits the k6 event with the given name of its type and waits until the subscribers process it.
+// The types of the events are internal to k6, so the event is built using reflection from the signature of Emit.
+func emitEvent(t *testing.T, events any, name string) {
+	t.Helper()
+
+	emit := reflect.ValueOf(events).MethodByName("Emit")
+	event := reflect.New(emit.Type().In(0).Elem())
+	eventType := event.Elem().FieldByName("Type")
+
+	found := false
+	for value := range uint64(math.MaxUint8) {
+		eventType.SetUint(value)
+		if fmt.Sprint(eventType.Interface()) == name {
+			found = true
+			break
+		}
+	}
+	if !found {
+		t.Fatalf("k6 event %q not found", name)
+	}
+
+	wait, ok := emit.Call([]reflect.Value{event})[0].Interface().(func(context.Context) error)
+	if !ok {
+		t.Fatalf("unexpected signature of Emit: %s", emit.Type())
+	}
+
+	ctx, cancel := context.WithTimeout(t.Context(), 5*time.Second)
+	defer cancel()
+
+	if err := wait(ctx); err != nil {
+		t.Fatalf("waiting for event %q: %v", name, err)
+	}
+}
+
+const createPodDisruptorScript = `
+const selector = {
+   namespace: "default",
+   select: {
+     labels: {
+	app: "test"
+     }
+   }
+}
+const disruptor = new PodDisruptor(selector, { injectTimeout: "-1s" })
+`
+
+func Test_TeardownOnTestEnd(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		title string
+		event string
+	}{
+		{
+			title: "test end",
+			event: "TestEnd",
+		},
+		{
+			title: "exit",
+			event: "Exit",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			t.Parallel()
+
+			pod := builders.NewPodBuilder("pod-with-app-label").
+				WithDefaultNamespace().
+				WithLabel("app", "test").
+				Build()
+			pod.Spec.EphemeralContainers = []corev1.EphemeralContainer{
+				{EphemeralContainerCommon: corev1.EphemeralContainerCommon{Name: "xk6-agent"}},
+			}
+			client := fake.NewSimpleClientset(&pod)
+			k8s, _ := kubernetes.NewFakeKubernetes(client)
+
+			// the events are emitted by the event system of k6
+			events := state.NewGlobalState(t.Context()).Events
+			vu := testVU().(*modulestest.VU) //nolint:forcetypeassert
+			vu.EventsField = common.Events{Global: events}
+
+			m := &ModuleInstance{
+				k8s:      k8s,
+				vu:       vu,
+				teardown: &api.Teardown{},
+			}
+			m.teardownOnTestEnd()
+
+			err := vu.Runtime().Set("PodDisruptor", m.Exports().Named["PodDisruptor"])
+			if err != nil {
+				t.Fatalf("test setup failed: %v", err)
+			}
+
+			_, err = vu.Runtime().RunString(createPodDisruptorScript)
+			if err != nil {
+				t.Fatalf("failed %v", err)
+			}
+
+			emitEvent(t, events, tc.event)
+
+			// the teardown cleans up the targets of the disruptor
+			for _, cmd := range k8s.GetFakeProcessExecutor().GetHistory() {
+				if cmd.Pod == pod.Name && strings.Join(cmd.Command, " ") == "xk6-disruptor-agent cleanup" {
+					return
+				}
+			}
+
+			t.Fatalf("expected teardown to clean up pod %s", pod.Name)
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package network
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/netip"
+)
+
+// Resolver resolves hostnames into IP addresses. It is implemented by net.Resolver.
+type Resolver interface {
+	LookupNetIP(ctx context.Context, network string, host string) ([]netip.Addr, error)
+}
+
+// ResolveDestinations converts a list of destinations given as IP addresses, CIDRs or hostnames into a list of CIDRs.
+// Hostnames are resolved using the given resolver. Only IPv4 destinations are supported.
+func ResolveDestinations(ctx context.Context, resolver Resolver, destinations []string) ([]string, error) {
+	cidrs := []string{}
+	for _, destination := range destinations {
+		if prefix, err := netip.ParsePrefix(destination); err == nil {
+			if !prefix.Addr().Is4() {
+				return nil, fmt.Errorf("destination %q: only IPv4 is supported", destination)
+			}
+			cidrs = append(cidrs, prefix.Masked().String())
+			continue
+		}
+
+		if addr, err := netip.ParseAddr(destination); err == nil {
+			if !addr.Is4() {
+				return nil, fmt.Errorf("destination %q: only IPv4 is supported", destination)
+			}
+			cidrs = append(cidrs, netip.PrefixFrom(addr, 32).String())
+			continue
+		}
+
+		addrs, err := resolver.LookupNetIP(ctx, "ip4", destination)
+		if err != nil {
+			return nil, fmt.Errorf("resolving destination %q: %w", destination, err)
+		}
+
+		if len(addrs) == 0 {
+			return nil, fmt.Errorf("resolving destination %q: no IPv4 addresses", destination)
+		}
+
+		for _, addr := range addrs {
+			cidrs = append(cidrs, netip.PrefixFrom(addr.Unmap(), 32).String())
+		}
+	}
+
+	return cidrs, nil
+}
+
+// DefaultResolver returns the resolver of the system
+func DefaultResolver() Resolver {
+	return net.DefaultResolver
+}
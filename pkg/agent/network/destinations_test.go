@@ -0,0 +1,92 @@
+package network
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// fakeResolver resolves hostnames from a map
+type fakeResolver map[string][]netip.Addr
+
+func (r fakeResolver) LookupNetIP(_ context.Context, _ string, host string) ([]netip.Addr, error) {
+	addrs, found := r[host]
+	if !found {
+		return nil, fmt.Errorf("host %q not found", host)
+	}
+
+	return addrs, nil
+}
+
+func Test_ResolveDestinations(t *testing.T) {
+	t.Parallel()
+
+	resolver := fakeResolver{
+		"db.example.com": {netip.MustParseAddr("192.0.2.10"), netip.MustParseAddr("192.0.2.11")},
+		"empty.example":  {},
+	}
+
+	testCases := []struct {
+		name         string
+		destinations []string
+		expectError  bool
+		expected     []string
+	}{
+		{
+			name:         "IP address",
+			destinations: []string{"10.0.0.1"},
+			expected:     []string{"10.0.0.1/32"},
+		},
+		{
+			name:         "CIDR",
+			destinations: []string{"10.0.0.1/24"},
+			expected:     []string{"10.0.0.0/24"},
+		},
+		{
+			name:         "hostname",
+			destinations: []string{"db.example.com", "10.0.0.1"},
+			expected:     []string{"192.0.2.10/32", "192.0.2.11/32", "10.0.0.1/32"},
+		},
+		{
+			name:         "unknown hostname",
+			destinations: []string{"unknown.example.com"},
+			expectError:  true,
+		},
+		{
+			name:         "hostname without addresses",
+			destinations: []string{"empty.example"},
+			expectError:  true,
+		},
+		{
+			name:         "IPv6",
+			destinations: []string{"2001:db8::1"},
+			expectError:  true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			actual, err := ResolveDestinations(context.Background(), resolver, tc.destinations)
+			if tc.expectError && err == nil {
+				t.Fatalf("should had failed")
+			}
+
+			if !tc.expectError && err != nil {
+				t.Fatalf("failed: %v", err)
+			}
+
+			if tc.expectError {
+				return
+			}
+
+			if diff := cmp.Diff(tc.expected, actual); diff != "" {
+				t.Fatalf("Resolved destinations do not match expected:\n%s", diff)
+			}
+		})
+	}
+}
@@ -5,22 +5,36 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
+	"strconv"
 	"time"
 
+	"github.com/grafana/xk6-disruptor/pkg/agent/schedule"
 	"github.com/grafana/xk6-disruptor/pkg/iptables"
 )
 
 // Disruptor applies network disruptions by dropping packets using iptables DROP rules.
 // A filter decides which packets (PORT, PROTOCOL) are considered for dropping.
+// If a Schedule is given, the packets are dropped with a probability equal to its level, which is updated
+// periodically.
 type Disruptor struct {
 	Iptables iptables.Iptables
 	Filter   Filter
+	Schedule schedule.Schedule
 }
 
 // Filter decides which packets (PORT, PROTOCOL) are considered for dropping.
+// If Destinations are specified, the outbound packets sent to them are considered instead of the inbound packets.
 type Filter struct {
 	Protocol string
 	Port     uint
+	// Destinations are the CIDRs of the outbound traffic to be disrupted
+	Destinations []string
+}
+
+// IsEgress returns if the filter selects outbound traffic
+func (f Filter) IsEgress() bool {
+	return len(f.Destinations) > 0
 }
 
 // ErrDurationTooShort is returned when the supplied duration is smaller than 1s.
@@ -31,34 +45,89 @@ func (d Disruptor) Apply(ctx context.Context, duration time.Duration) error {
 	if duration < time.Second {
 		return ErrDurationTooShort
 	}
-	ruleset := iptables.NewRuleSet(d.Iptables)
+
+	if err := d.Schedule.Validate(); err != nil {
+		return err
+	}
+
+	state := &dropState{disruptor: d, ruleset: iptables.NewRuleSet(d.Iptables)}
+
 	//nolint:errcheck // Errors while removing rules are not actionable.
-	defer ruleset.Remove()
+	defer func() { state.ruleset.Remove() }()
 
-	for _, r := range d.rules() {
-		err := ruleset.Add(r)
-		if err != nil {
-			return err
-		}
+	start := time.Now()
+	if err := state.update(d.Schedule.Level(0)); err != nil {
+		return err
 	}
 
 	ctx, cancel := context.WithTimeout(ctx, duration)
 	defer cancel()
 
-	// Wait for request duration or context cancellation to restore state
-	select {
-	case <-time.After(duration):
+	// a constant schedule never updates the disruption
+	var updates <-chan time.Time
+	if !d.Schedule.IsConstant() {
+		ticker := time.NewTicker(schedule.UpdateInterval)
+		defer ticker.Stop()
+		updates = ticker.C
+	}
+
+	// Update the disruption until request duration or context cancellation to restore state
+	for {
+		select {
+		case <-updates:
+			if err := state.update(d.Schedule.Level(time.Since(start))); err != nil {
+				return err
+			}
+		case <-ctx.Done():
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return nil
+			}
+
+			return ctx.Err()
+		}
+	}
+}
+
+// dropState keeps track of the rules added by a Disruptor while the level of its schedule changes
+type dropState struct {
+	disruptor Disruptor
+	ruleset   *iptables.RuleSet
+	rules     []iptables.Rule
+}
+
+// update atomically replaces the rules with the rules for the level, so the traffic is not left undisrupted in
+// between.
+func (s *dropState) update(level float64) error {
+	rules := s.disruptor.rules(level)
+	if slices.Equal(rules, s.rules) {
 		return nil
-	case <-ctx.Done():
-		return ctx.Err()
 	}
+
+	if err := s.ruleset.Replace(rules...); err != nil {
+		return err
+	}
+
+	s.rules = rules
+
+	return nil
 }
 
-func (d Disruptor) rules() []iptables.Rule {
+// rules returns the rules that drop the packets selected by the filter with a probability given by the level.
+// No rules are returned for a level of zero.
+func (d Disruptor) rules(level float64) []iptables.Rule {
+	if level <= 0 {
+		return nil
+	}
+
 	var args string
 
 	args = "-j DROP"
 
+	if level < 1 {
+		probability := strconv.FormatFloat(level, 'f', 2, 64)
+		args = fmt.Sprintf("-m statistic --mode random --probability %s %s", probability, args)
+	}
+
 	if d.Filter.Port != 0 {
 		args = fmt.Sprintf("--dport %d %s", d.Filter.Port, args)
 	}
@@ -67,10 +136,22 @@ func (d Disruptor) rules() []iptables.Rule {
 		args = fmt.Sprintf("-p %s %s", d.Filter.Protocol, args)
 	}
 
-	return []iptables.Rule{
-		{
-			// This rule drops all INPUT packets that match the filter criteria
-			Table: "filter", Chain: "INPUT", Args: args,
-		},
+	if !d.Filter.IsEgress() {
+		return []iptables.Rule{
+			{
+				// This rule drops all INPUT packets that match the filter criteria
+				Table: "filter", Chain: "INPUT", Args: args,
+			},
+		}
 	}
+
+	rules := []iptables.Rule{}
+	for _, destination := range d.Filter.Destinations {
+		rules = append(rules, iptables.Rule{
+			// This rule drops all OUTPUT packets sent to the destination that match the filter criteria
+			Table: "filter", Chain: "OUTPUT", Args: fmt.Sprintf("-d %s %s", destination, args),
+		})
+	}
+
+	return rules
 }
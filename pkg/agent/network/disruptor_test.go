@@ -1,10 +1,12 @@
 package network
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/grafana/xk6-disruptor/pkg/iptables"
+	"github.com/grafana/xk6-disruptor/pkg/runtime"
 )
 
 // Test_rules checks that the queue returns the correct rules for a given config and disruption.
@@ -14,6 +16,7 @@ func Test_DisruptorRules(t *testing.T) {
 	testCases := []struct {
 		name     string
 		filter   Filter
+		level    float64
 		expected []iptables.Rule
 	}{
 		{
@@ -22,6 +25,7 @@ func Test_DisruptorRules(t *testing.T) {
 				Port:     6666,
 				Protocol: "tcp",
 			},
+			level: 1.0,
 			expected: []iptables.Rule{
 				{
 					Table: "filter", Chain: "INPUT",
@@ -34,6 +38,7 @@ func Test_DisruptorRules(t *testing.T) {
 			filter: Filter{
 				Protocol: "tcp",
 			},
+			level: 1.0,
 			expected: []iptables.Rule{
 				{
 					Table: "filter", Chain: "INPUT",
@@ -46,6 +51,7 @@ func Test_DisruptorRules(t *testing.T) {
 			filter: Filter{
 				Port: 8080,
 			},
+			level: 1.0,
 			expected: []iptables.Rule{
 				{
 					Table: "filter", Chain: "INPUT",
@@ -53,9 +59,29 @@ func Test_DisruptorRules(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "egress to destinations",
+			filter: Filter{
+				Port:         5432,
+				Protocol:     "tcp",
+				Destinations: []string{"10.0.0.0/24", "192.0.2.10/32"},
+			},
+			level: 1.0,
+			expected: []iptables.Rule{
+				{
+					Table: "filter", Chain: "OUTPUT",
+					Args: "-d 10.0.0.0/24 -p tcp --dport 5432 -j DROP",
+				},
+				{
+					Table: "filter", Chain: "OUTPUT",
+					Args: "-d 192.0.2.10/32 -p tcp --dport 5432 -j DROP",
+				},
+			},
+		},
 		{
 			name:   "neither protocol nor port specified",
 			filter: Filter{},
+			level:  1.0,
 			expected: []iptables.Rule{
 				{
 					Table: "filter", Chain: "INPUT",
@@ -63,6 +89,28 @@ func Test_DisruptorRules(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "partial level",
+			filter: Filter{
+				Port:     6666,
+				Protocol: "tcp",
+			},
+			level: 0.25,
+			expected: []iptables.Rule{
+				{
+					Table: "filter", Chain: "INPUT",
+					Args: "-p tcp --dport 6666 -m statistic --mode random --probability 0.25 -j DROP",
+				},
+			},
+		},
+		{
+			name: "zero level",
+			filter: Filter{
+				Port: 6666,
+			},
+			level:    0.0,
+			expected: nil,
+		},
 	}
 
 	for _, tc := range testCases {
@@ -72,10 +120,50 @@ func Test_DisruptorRules(t *testing.T) {
 				Filter: tc.filter,
 			}
 
-			actual := d.rules()
+			actual := d.rules(tc.level)
 			if diff := cmp.Diff(actual, tc.expected); diff != "" {
 				t.Fatalf("Generated rules do not match expected:\n%s", diff)
 			}
 		})
 	}
 }
+
+func Test_DisruptorScheduleUpdates(t *testing.T) {
+	t.Parallel()
+
+	executor := runtime.NewFakeExecutor(nil, nil)
+	d := Disruptor{
+		Iptables: iptables.New(executor),
+		Filter:   Filter{Port: 8080},
+	}
+
+	state := &dropState{disruptor: d, ruleset: iptables.NewRuleSet(d.Iptables)}
+	for _, level := range []float64{0.5, 0.5, 1.0, 0.0} {
+		if err := state.update(level); err != nil {
+			t.Fatalf("failed: %v", err)
+		}
+	}
+
+	// each update atomically replaces the rules in the chains of the agent
+	expected := [][]string{
+		{"--dport 8080 -m statistic --mode random --probability 0.50 -j DROP"},
+		{"--dport 8080 -j DROP"},
+		{},
+	}
+
+	actual := [][]string{}
+	for _, input := range executor.InputHistory() {
+		rules := []string{}
+		for _, line := range strings.Split(input, "\n") {
+			fields := strings.SplitN(line, " ", 3)
+			if len(fields) == 3 && fields[0] == "-A" && strings.HasPrefix(fields[1], iptables.ChainPrefix) {
+				rules = append(rules, fields[2])
+			}
+		}
+		actual = append(actual, rules)
+	}
+
+	if diff := cmp.Diff(expected, actual); diff != "" {
+		t.Fatalf("Rules do not match expected:\n%s", diff)
+	}
+}
@@ -0,0 +1,395 @@
+package network
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/xk6-disruptor/pkg/agent/schedule"
+	"github.com/grafana/xk6-disruptor/pkg/tc"
+)
+
+// DefaultInterface is the network interface disrupted by default
+const DefaultInterface = "eth0"
+
+const (
+	// rootHandle is the handle of the root qdisc added by the NetemDisruptor
+	rootHandle = "1:"
+	// netemBand is the class of the prio qdisc the filtered traffic is sent to. The prio qdisc has 4 bands and the
+	// default priomap only uses the first 3, so only traffic selected by the filter reaches this band.
+	netemBand = "1:4"
+	// netemHandle is the handle of the netem qdisc attached to the netemBand
+	netemHandle = "40:"
+	// defaultPriomap is the default priomap of the prio qdisc
+	defaultPriomap = "1 2 2 2 1 2 0 0 1 1 1 1 1 1 1 1"
+)
+
+// bandwidthRegexp matches the bandwidth units accepted by tc
+var bandwidthRegexp = regexp.MustCompile(`^[0-9]+(\.[0-9]+)?([kmgt]?(bit|bps))$`)
+
+// ipProtocols maps the protocols to their number in the IP header
+var ipProtocols = map[string]int{ //nolint:gochecknoglobals
+	"icmp": 1,
+	"tcp":  6,
+	"udp":  17,
+}
+
+// Netem defines the network emulation parameters applied to the disrupted traffic.
+// Rates are expressed as a fraction in the range [0.0, 1.0].
+type Netem struct {
+	// Delay added to each packet
+	Delay time.Duration
+	// Jitter is the variation of the delay
+	Jitter time.Duration
+	// LossRate is the fraction of packets dropped
+	LossRate float64
+	// Bandwidth limits the rate of the traffic (e.g "1mbit")
+	Bandwidth string
+	// ReorderRate is the fraction of packets sent immediately, while the rest are delayed. Requires a delay.
+	ReorderRate float64
+	// DuplicateRate is the fraction of packets duplicated
+	DuplicateRate float64
+	// CorruptRate is the fraction of packets with a random bit error
+	CorruptRate float64
+}
+
+// NetemDisruptor applies network disruptions using the netem queueing discipline. Only the traffic sent from the port
+// and protocol that matches the Filter is disrupted. If the Filter has Destinations, only the traffic sent to them
+// (and to the port, if specified) is disrupted.
+// If a Schedule is given, the delays and rates of the Netem are multiplied by its level, which is updated periodically.
+type NetemDisruptor struct {
+	TC        tc.TC
+	Interface string
+	Filter    Filter
+	Netem     Netem
+	Schedule  schedule.Schedule
+}
+
+// validateRate checks a rate is in the range [0.0, 1.0]
+func validateRate(name string, rate float64) error {
+	if rate < 0.0 || rate > 1.0 {
+		return fmt.Errorf("%s must be in the range [0.0, 1.0]", name)
+	}
+
+	return nil
+}
+
+// Validate checks the netem parameters are valid
+func (n Netem) Validate() error {
+	if n.Delay < 0 || n.Jitter < 0 {
+		return fmt.Errorf("delay and jitter must be positive")
+	}
+
+	if n.Jitter > 0 && n.Delay == 0 {
+		return fmt.Errorf("jitter requires a delay")
+	}
+
+	if n.ReorderRate > 0 && n.Delay == 0 {
+		return fmt.Errorf("reorder requires a delay")
+	}
+
+	if n.Bandwidth != "" && !bandwidthRegexp.MatchString(n.Bandwidth) {
+		return fmt.Errorf("invalid bandwidth %q", n.Bandwidth)
+	}
+
+	return errors.Join(
+		validateRate("loss rate", n.LossRate),
+		validateRate("reorder rate", n.ReorderRate),
+		validateRate("duplicate rate", n.DuplicateRate),
+		validateRate("corrupt rate", n.CorruptRate),
+	)
+}
+
+// IsEmpty returns if the Netem does not define any disruption
+func (n Netem) IsEmpty() bool {
+	return n == Netem{}
+}
+
+// percentage formats a rate as a percentage
+func percentage(rate float64) string {
+	return strconv.FormatFloat(rate*100, 'f', -1, 64) + "%"
+}
+
+// scaled returns the netem parameters with the delays and rates multiplied by a level. The bandwidth is not scaled.
+func (n Netem) scaled(level float64) Netem {
+	scaled := n
+	scaled.Delay = schedule.Duration(n.Delay, level)
+	scaled.Jitter = schedule.Duration(n.Jitter, level)
+	scaled.LossRate = schedule.Rate(n.LossRate, level)
+	scaled.ReorderRate = schedule.Rate(n.ReorderRate, level)
+	scaled.DuplicateRate = schedule.Rate(n.DuplicateRate, level)
+	scaled.CorruptRate = schedule.Rate(n.CorruptRate, level)
+
+	return scaled
+}
+
+// args returns the arguments for the netem qdisc
+func (n Netem) args() string {
+	args := []string{}
+
+	if n.Delay > 0 {
+		args = append(args, "delay", fmt.Sprintf("%dus", n.Delay.Microseconds()))
+		if n.Jitter > 0 {
+			args = append(args, fmt.Sprintf("%dus", n.Jitter.Microseconds()))
+		}
+	}
+
+	if n.LossRate > 0 {
+		args = append(args, "loss", percentage(n.LossRate))
+	}
+
+	if n.ReorderRate > 0 {
+		args = append(args, "reorder", percentage(n.ReorderRate))
+	}
+
+	if n.DuplicateRate > 0 {
+		args = append(args, "duplicate", percentage(n.DuplicateRate))
+	}
+
+	if n.CorruptRate > 0 {
+		args = append(args, "corrupt", percentage(n.CorruptRate))
+	}
+
+	if n.Bandwidth != "" {
+		args = append(args, "rate", n.Bandwidth)
+	}
+
+	return strings.Join(args, " ")
+}
+
+// Apply applies the netem disruption for the given duration. The queueing disciplines are removed when done.
+func (d NetemDisruptor) Apply(ctx context.Context, duration time.Duration) error {
+	if duration < time.Second {
+		return ErrDurationTooShort
+	}
+
+	if err := d.Netem.Validate(); err != nil {
+		return err
+	}
+
+	if d.Netem.IsEmpty() {
+		return fmt.Errorf("at least one network disruption must be specified")
+	}
+
+	if err := d.Schedule.Validate(); err != nil {
+		return err
+	}
+
+	state := &netemState{disruptor: d}
+
+	//nolint:errcheck // Errors while removing qdiscs are not actionable.
+	defer state.remove()
+
+	start := time.Now()
+	if err := state.update(d.Schedule.Level(0)); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, duration)
+	defer cancel()
+
+	// a constant schedule never updates the disruption
+	var updates <-chan time.Time
+	if !d.Schedule.IsConstant() {
+		ticker := time.NewTicker(schedule.UpdateInterval)
+		defer ticker.Stop()
+		updates = ticker.C
+	}
+
+	// Update the disruption until request duration or context cancellation to restore state
+	for {
+		select {
+		case <-updates:
+			if err := state.update(d.Schedule.Level(time.Since(start))); err != nil {
+				return err
+			}
+		case <-ctx.Done():
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return nil
+			}
+
+			return ctx.Err()
+		}
+	}
+}
+
+// netemState keeps track of the qdiscs added by a NetemDisruptor while the level of its schedule changes
+type netemState struct {
+	disruptor NetemDisruptor
+	// root is the root qdisc, or nil if the qdiscs are not added
+	root *tc.Qdisc
+	// netem is the netem qdisc as last added or changed
+	netem tc.Qdisc
+}
+
+// update applies the disruption with the netem parameters scaled by the level. The qdiscs are removed while the level
+// is zero and added again when it increases. Otherwise, the netem qdisc is changed in place.
+func (s *netemState) update(level float64) error {
+	if level <= 0 {
+		return s.remove()
+	}
+
+	qdiscs, filters, err := s.disruptor.qdiscs(s.disruptor.Netem.scaled(level))
+	if err != nil {
+		return err
+	}
+
+	// the netem qdisc is always the last one
+	netem := qdiscs[len(qdiscs)-1]
+
+	if s.root == nil {
+		root, err := s.disruptor.add(qdiscs, filters)
+		if err != nil {
+			return err
+		}
+
+		s.root, s.netem = &root, netem
+
+		return nil
+	}
+
+	if netem == s.netem {
+		return nil
+	}
+
+	if err = s.disruptor.TC.ChangeQdisc(netem); err != nil {
+		return err
+	}
+	s.netem = netem
+
+	return nil
+}
+
+// remove removes the qdiscs, if added
+func (s *netemState) remove() error {
+	if s.root == nil {
+		return nil
+	}
+
+	root := *s.root
+	s.root = nil
+
+	return s.disruptor.TC.RemoveQdisc(root)
+}
+
+// RemoveLeftoverQdiscs removes the root qdiscs added by a NetemDisruptor to any device, identified by their handle,
+// with all their child qdiscs and filters. It is intended for removing the qdiscs left behind by a process that could
+// not remove them, for example because it was killed.
+func RemoveLeftoverQdiscs(t tc.TC) error {
+	qdiscs, err := t.RootQdiscs()
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, q := range qdiscs {
+		if q.Handle != rootHandle {
+			continue
+		}
+
+		if err := t.RemoveQdisc(q); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// add adds the qdiscs and filters for the disruption and returns the root qdisc. If any of them fails, the root qdisc
+// is removed. A root qdisc left behind by a previous execution that was killed is replaced.
+func (d NetemDisruptor) add(qdiscs []tc.Qdisc, filters []tc.Filter) (tc.Qdisc, error) {
+	root := qdiscs[0]
+	if err := d.TC.AddQdisc(root); err != nil {
+		// removing the root qdisc fails if the device has no root qdisc with its handle
+		if d.TC.RemoveQdisc(root) != nil {
+			return tc.Qdisc{}, err
+		}
+
+		if err = d.TC.AddQdisc(root); err != nil {
+			return tc.Qdisc{}, err
+		}
+	}
+
+	for _, q := range qdiscs[1:] {
+		if err := d.TC.AddQdisc(q); err != nil {
+			_ = d.TC.RemoveQdisc(root)
+			return tc.Qdisc{}, err
+		}
+	}
+
+	for _, f := range filters {
+		if err := d.TC.AddFilter(f); err != nil {
+			_ = d.TC.RemoveQdisc(root)
+			return tc.Qdisc{}, err
+		}
+	}
+
+	return root, nil
+}
+
+// qdiscs returns the qdiscs and filters that implement the disruption with the given netem parameters. The first
+// qdisc is always the root.
+func (d NetemDisruptor) qdiscs(netem Netem) ([]tc.Qdisc, []tc.Filter, error) {
+	device := d.Interface
+	if device == "" {
+		device = DefaultInterface
+	}
+
+	// without filter, all the traffic is disrupted
+	if d.Filter.Port == 0 && d.Filter.Protocol == "" && !d.Filter.IsEgress() {
+		return []tc.Qdisc{
+			{Device: device, Parent: "root", Handle: rootHandle, Kind: "netem", Args: netem.args()},
+		}, nil, nil
+	}
+
+	matches := []string{}
+	if d.Filter.Protocol != "" {
+		protocol, found := ipProtocols[strings.ToLower(d.Filter.Protocol)]
+		if !found {
+			return nil, nil, fmt.Errorf("unsupported protocol %q", d.Filter.Protocol)
+		}
+		matches = append(matches, fmt.Sprintf("match ip protocol %d 0xff", protocol))
+	}
+
+	// for inbound traffic, the port is the source of the responses. For outbound traffic, the destination.
+	if d.Filter.Port != 0 {
+		direction := "sport"
+		if d.Filter.IsEgress() {
+			direction = "dport"
+		}
+		matches = append(matches, fmt.Sprintf("match ip %s %d 0xffff", direction, d.Filter.Port))
+	}
+
+	qdiscs := []tc.Qdisc{
+		{Device: device, Parent: "root", Handle: rootHandle, Kind: "prio", Args: "bands 4 priomap " + defaultPriomap},
+		{Device: device, Parent: netemBand, Handle: netemHandle, Kind: "netem", Args: netem.args()},
+	}
+
+	// one filter for each destination, or a single filter if there are no destinations
+	destinations := []string{""}
+	if d.Filter.IsEgress() {
+		destinations = d.Filter.Destinations
+	}
+
+	filters := []tc.Filter{}
+	for _, destination := range destinations {
+		filterMatches := matches
+		if destination != "" {
+			filterMatches = append([]string{"match ip dst " + destination}, matches...)
+		}
+
+		filters = append(filters, tc.Filter{
+			Device:   device,
+			Parent:   rootHandle,
+			Protocol: "ip",
+			Priority: 1,
+			Args:     fmt.Sprintf("u32 %s flowid %s", strings.Join(filterMatches, " "), netemBand),
+		})
+	}
+
+	return qdiscs, filters, nil
+}
@@ -0,0 +1,354 @@
+package network
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/grafana/xk6-disruptor/pkg/runtime"
+	"github.com/grafana/xk6-disruptor/pkg/tc"
+)
+
+func Test_NetemValidate(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name        string
+		netem       Netem
+		expectError bool
+	}{
+		{
+			name:  "valid delay and jitter",
+			netem: Netem{Delay: 100 * time.Millisecond, Jitter: 10 * time.Millisecond},
+		},
+		{
+			name:  "valid rates",
+			netem: Netem{LossRate: 0.1, DuplicateRate: 0.01, CorruptRate: 1.0},
+		},
+		{
+			name:  "valid bandwidth",
+			netem: Netem{Bandwidth: "1.5mbit"},
+		},
+		{
+			name:        "jitter without delay",
+			netem:       Netem{Jitter: 10 * time.Millisecond},
+			expectError: true,
+		},
+		{
+			name:        "reorder without delay",
+			netem:       Netem{ReorderRate: 0.25},
+			expectError: true,
+		},
+		{
+			name:        "rate above 1",
+			netem:       Netem{LossRate: 10},
+			expectError: true,
+		},
+		{
+			name:        "negative rate",
+			netem:       Netem{CorruptRate: -0.1},
+			expectError: true,
+		},
+		{
+			name:        "invalid bandwidth",
+			netem:       Netem{Bandwidth: "fast"},
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := tc.netem.Validate()
+			if tc.expectError && err == nil {
+				t.Fatalf("should had failed")
+			}
+
+			if !tc.expectError && err != nil {
+				t.Fatalf("failed: %v", err)
+			}
+		})
+	}
+}
+
+func Test_NetemDisruptorCommands(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name        string
+		iface       string
+		filter      Filter
+		netem       Netem
+		expectError bool
+		expected    []string
+	}{
+		{
+			name:  "all traffic",
+			netem: Netem{Delay: 100 * time.Millisecond, Jitter: 10 * time.Millisecond},
+			expected: []string{
+				"tc qdisc add dev eth0 root handle 1: netem delay 100000us 10000us",
+				"tc qdisc del dev eth0 root handle 1:",
+			},
+		},
+		{
+			name:  "all netem parameters",
+			iface: "ens5",
+			netem: Netem{
+				Delay:         50 * time.Millisecond,
+				LossRate:      0.1,
+				ReorderRate:   0.25,
+				DuplicateRate: 0.01,
+				CorruptRate:   0.001,
+				Bandwidth:     "1mbit",
+			},
+			expected: []string{
+				"tc qdisc add dev ens5 root handle 1: netem delay 50000us loss 10% reorder 25% duplicate 1%" +
+					" corrupt 0.1% rate 1mbit",
+				"tc qdisc del dev ens5 root handle 1:",
+			},
+		},
+		{
+			name:   "filter by port and protocol",
+			filter: Filter{Port: 80, Protocol: "tcp"},
+			netem:  Netem{LossRate: 0.5},
+			expected: []string{
+				"tc qdisc add dev eth0 root handle 1: prio bands 4 priomap 1 2 2 2 1 2 0 0 1 1 1 1 1 1 1 1",
+				"tc qdisc add dev eth0 parent 1:4 handle 40: netem loss 50%",
+				"tc filter add dev eth0 parent 1: protocol ip prio 1 u32 match ip protocol 6 0xff" +
+					" match ip sport 80 0xffff flowid 1:4",
+				"tc qdisc del dev eth0 root handle 1:",
+			},
+		},
+		{
+			name:   "filter by port",
+			filter: Filter{Port: 8080},
+			netem:  Netem{Bandwidth: "100kbit"},
+			expected: []string{
+				"tc qdisc add dev eth0 root handle 1: prio bands 4 priomap 1 2 2 2 1 2 0 0 1 1 1 1 1 1 1 1",
+				"tc qdisc add dev eth0 parent 1:4 handle 40: netem rate 100kbit",
+				"tc filter add dev eth0 parent 1: protocol ip prio 1 u32 match ip sport 8080 0xffff flowid 1:4",
+				"tc qdisc del dev eth0 root handle 1:",
+			},
+		},
+		{
+			name:   "egress to destinations",
+			filter: Filter{Port: 6379, Destinations: []string{"10.0.0.0/24", "192.0.2.10/32"}},
+			netem:  Netem{Delay: 200 * time.Millisecond},
+			expected: []string{
+				"tc qdisc add dev eth0 root handle 1: prio bands 4 priomap 1 2 2 2 1 2 0 0 1 1 1 1 1 1 1 1",
+				"tc qdisc add dev eth0 parent 1:4 handle 40: netem delay 200000us",
+				"tc filter add dev eth0 parent 1: protocol ip prio 1 u32 match ip dst 10.0.0.0/24" +
+					" match ip dport 6379 0xffff flowid 1:4",
+				"tc filter add dev eth0 parent 1: protocol ip prio 1 u32 match ip dst 192.0.2.10/32" +
+					" match ip dport 6379 0xffff flowid 1:4",
+				"tc qdisc del dev eth0 root handle 1:",
+			},
+		},
+		{
+			name:        "unsupported protocol",
+			filter:      Filter{Protocol: "sctp"},
+			netem:       Netem{LossRate: 0.5},
+			expectError: true,
+		},
+		{
+			name:        "no disruption",
+			netem:       Netem{},
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			executor := runtime.NewFakeExecutor(nil, nil)
+			d := NetemDisruptor{
+				TC:        newTC(executor),
+				Interface: tc.iface,
+				Filter:    tc.filter,
+				Netem:     tc.netem,
+			}
+
+			err := d.Apply(context.Background(), time.Second)
+			if tc.expectError && err == nil {
+				t.Fatalf("should had failed")
+			}
+
+			if !tc.expectError && err != nil {
+				t.Fatalf("failed: %v", err)
+			}
+
+			if tc.expectError {
+				return
+			}
+
+			if diff := cmp.Diff(tc.expected, executor.CmdHistory()); diff != "" {
+				t.Fatalf("Ran commands do not match expected:\n%s", diff)
+			}
+		})
+	}
+}
+
+func Test_NetemDisruptorRemovesRootOnError(t *testing.T) {
+	t.Parallel()
+
+	history := []string{}
+	executor := runtime.NewCallbackExecutor(func(cmd string, args ...string) ([]byte, error) {
+		history = append(history, cmd+" "+strings.Join(args, " "))
+		if args[0] == "filter" {
+			return nil, errors.New("filter failed")
+		}
+		return nil, nil
+	})
+
+	d := NetemDisruptor{
+		TC:     newTC(executor),
+		Filter: Filter{Port: 80},
+		Netem:  Netem{LossRate: 0.5},
+	}
+
+	err := d.Apply(context.Background(), time.Second)
+	if err == nil {
+		t.Fatalf("should had failed")
+	}
+
+	expected := "tc qdisc del dev eth0 root handle 1:"
+	if len(history) == 0 || history[len(history)-1] != expected {
+		t.Fatalf("expected root qdisc to be removed, commands: %v", history)
+	}
+}
+
+func Test_NetemDisruptorReplacesLeftoverRoot(t *testing.T) {
+	t.Parallel()
+
+	// the first root qdisc cannot be added because one is left behind by a previous execution
+	leftover := true
+	executor := runtime.NewCallbackExecutor(func(_ string, args ...string) ([]byte, error) {
+		switch strings.Join(args[:3], " ") {
+		case "qdisc add dev":
+			if leftover {
+				return []byte("Error: Exclusivity flag on, cannot modify."), errors.New("exit status 2")
+			}
+		case "qdisc del dev":
+			leftover = false
+		}
+		return nil, nil
+	})
+
+	d := NetemDisruptor{
+		TC:    newTC(executor),
+		Netem: Netem{LossRate: 0.5},
+	}
+
+	err := d.Apply(context.Background(), time.Second)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []string{
+		"tc qdisc add dev eth0 root handle 1: netem loss 50%",
+		"tc qdisc del dev eth0 root handle 1:",
+		"tc qdisc add dev eth0 root handle 1: netem loss 50%",
+		"tc qdisc del dev eth0 root handle 1:",
+	}
+	if diff := cmp.Diff(expected, executor.CmdHistory()); diff != "" {
+		t.Fatalf("Ran commands do not match expected:\n%s", diff)
+	}
+}
+
+func Test_NetemDisruptorCancel(t *testing.T) {
+	t.Parallel()
+
+	executor := runtime.NewFakeExecutor(nil, nil)
+	d := NetemDisruptor{
+		TC:    newTC(executor),
+		Netem: Netem{LossRate: 0.5},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		cancel()
+	}()
+
+	err := d.Apply(ctx, time.Minute)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context canceled, got %v", err)
+	}
+
+	expected := []string{
+		"tc qdisc add dev eth0 root handle 1: netem loss 50%",
+		"tc qdisc del dev eth0 root handle 1:",
+	}
+	if diff := cmp.Diff(expected, executor.CmdHistory()); diff != "" {
+		t.Fatalf("Ran commands do not match expected:\n%s", diff)
+	}
+}
+
+func Test_NetemDisruptorScheduleUpdates(t *testing.T) {
+	t.Parallel()
+
+	executor := runtime.NewFakeExecutor(nil, nil)
+	d := NetemDisruptor{
+		TC:     newTC(executor),
+		Filter: Filter{Port: 80},
+		Netem:  Netem{Delay: 100 * time.Millisecond, LossRate: 0.2, Bandwidth: "1mbit"},
+	}
+
+	state := &netemState{disruptor: d}
+	for _, level := range []float64{0.0, 0.5, 0.5, 1.0, 0.0, 2.0} {
+		if err := state.update(level); err != nil {
+			t.Fatalf("failed: %v", err)
+		}
+	}
+
+	expected := []string{
+		"tc qdisc add dev eth0 root handle 1: prio bands 4 priomap 1 2 2 2 1 2 0 0 1 1 1 1 1 1 1 1",
+		"tc qdisc add dev eth0 parent 1:4 handle 40: netem delay 50000us loss 10% rate 1mbit",
+		"tc filter add dev eth0 parent 1: protocol ip prio 1 u32 match ip sport 80 0xffff flowid 1:4",
+		"tc qdisc change dev eth0 parent 1:4 handle 40: netem delay 100000us loss 20% rate 1mbit",
+		"tc qdisc del dev eth0 root handle 1:",
+		"tc qdisc add dev eth0 root handle 1: prio bands 4 priomap 1 2 2 2 1 2 0 0 1 1 1 1 1 1 1 1",
+		"tc qdisc add dev eth0 parent 1:4 handle 40: netem delay 200000us loss 40% rate 1mbit",
+		"tc filter add dev eth0 parent 1: protocol ip prio 1 u32 match ip sport 80 0xffff flowid 1:4",
+	}
+	if diff := cmp.Diff(expected, executor.CmdHistory()); diff != "" {
+		t.Fatalf("Ran commands do not match expected:\n%s", diff)
+	}
+}
+
+func newTC(executor runtime.Executor) tc.TC {
+	return tc.New(executor)
+}
+
+func Test_RemoveLeftoverQdiscs(t *testing.T) {
+	t.Parallel()
+
+	executor := runtime.NewCallbackExecutor(func(_ string, args ...string) ([]byte, error) {
+		if args[1] == "show" {
+			return []byte("qdisc noqueue 0: dev lo root refcnt 2\n" +
+				"qdisc prio 1: dev eth0 root refcnt 2 bands 4 priomap 1 2 2 2 1 2 0 0 1 1 1 1 1 1 1 1\n" +
+				"qdisc netem 40: dev eth0 parent 1:4 limit 1000 delay 100ms\n" +
+				"qdisc netem 1: dev eth1 root refcnt 2 limit 1000 loss 10%\n"), nil
+		}
+		return nil, nil
+	})
+
+	err := RemoveLeftoverQdiscs(newTC(executor))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []string{
+		"tc qdisc show",
+		"tc qdisc del dev eth0 root handle 1:",
+		"tc qdisc del dev eth1 root handle 1:",
+	}
+	if diff := cmp.Diff(expected, executor.CmdHistory()); diff != "" {
+		t.Fatalf("Ran commands do not match expected:\n%s", diff)
+	}
+}
@@ -0,0 +1,117 @@
+package dns
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net/netip"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// spoofedTTL is the TTL, in seconds, of the spoofed answers. It is short so the clients do not keep using the
+// spoofed addresses long after the disruption ends.
+const spoofedTTL = 5
+
+// query is a DNS query sent by a client
+type query struct {
+	header   dnsmessage.Header
+	question dnsmessage.Question
+	// decoded is true if the query was decoded and has a question. Queries that are not decoded are forwarded
+	// without disruption.
+	decoded bool
+	// raw message of the query
+	raw []byte
+}
+
+// parseQuery decodes the header and first question of a query
+func parseQuery(raw []byte) query {
+	q := query{raw: raw}
+
+	var parser dnsmessage.Parser
+	header, err := parser.Start(raw)
+	if err != nil || header.Response {
+		return q
+	}
+
+	question, err := parser.Question()
+	if err != nil {
+		return q
+	}
+
+	q.header = header
+	q.question = question
+	q.decoded = true
+
+	return q
+}
+
+// response builds the response to a query with the given code and, if the query is for A or AAAA records, the
+// addresses of the corresponding family as answers
+func response(q query, code dnsmessage.RCode, addresses []netip.Addr) ([]byte, error) {
+	builder := dnsmessage.NewBuilder(nil, dnsmessage.Header{
+		ID:                 q.header.ID,
+		Response:           true,
+		OpCode:             q.header.OpCode,
+		RecursionDesired:   q.header.RecursionDesired,
+		RecursionAvailable: true,
+		RCode:              code,
+	})
+	builder.EnableCompression()
+
+	if err := builder.StartQuestions(); err != nil {
+		return nil, err
+	}
+
+	if err := builder.Question(q.question); err != nil {
+		return nil, err
+	}
+
+	if err := builder.StartAnswers(); err != nil {
+		return nil, err
+	}
+
+	header := dnsmessage.ResourceHeader{Name: q.question.Name, Class: q.question.Class, TTL: spoofedTTL}
+	for _, addr := range addresses {
+		var err error
+		switch {
+		case q.question.Type == dnsmessage.TypeA && addr.Is4():
+			err = builder.AResource(header, dnsmessage.AResource{A: addr.As4()})
+		case q.question.Type == dnsmessage.TypeAAAA && addr.Is6():
+			err = builder.AAAAResource(header, dnsmessage.AAAAResource{AAAA: addr.As16()})
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return builder.Finish()
+}
+
+// readMessage reads a message sent over TCP, which is prefixed by its length
+func readMessage(reader io.Reader) ([]byte, error) {
+	var length uint16
+	if err := binary.Read(reader, binary.BigEndian, &length); err != nil {
+		return nil, err
+	}
+
+	message := make([]byte, length)
+	if _, err := io.ReadFull(reader, message); err != nil {
+		return nil, err
+	}
+
+	return message, nil
+}
+
+// writeMessage writes a message over TCP, prefixed by its length
+func writeMessage(writer io.Writer, message []byte) error {
+	if len(message) > 0xffff {
+		return fmt.Errorf("message of %d bytes is too long", len(message))
+	}
+
+	framed := make([]byte, 2, 2+len(message))
+	binary.BigEndian.PutUint16(framed, uint16(len(message))) //nolint:gosec // length is checked
+	_, err := writer.Write(append(framed, message...))
+
+	return err
+}
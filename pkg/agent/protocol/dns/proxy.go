@@ -0,0 +1,398 @@
+// Package dns implements a proxy that applies disruptions to the DNS queries sent by the target.
+// The proxy receives the queries over UDP and TCP and forwards them to an upstream DNS server. Queries are delayed,
+// and can also be answered with an error, such as NXDOMAIN, or with spoofed addresses instead of being forwarded.
+package dns
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"net"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/grafana/xk6-disruptor/pkg/agent/protocol"
+	"github.com/grafana/xk6-disruptor/pkg/agent/protocol/tcp"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+const (
+	// maxMessageSize is the maximum size of a message sent over UDP
+	maxMessageSize = 65535
+	// queryTimeout is the maximum time for receiving the upstream's response to a query sent over UDP
+	queryTimeout = 5 * time.Second
+)
+
+// Disruption specifies disruptions in the DNS queries
+type Disruption struct {
+	// Average delay introduced to queries
+	AverageDelay time.Duration
+	// Variation in the delay (with respect of the average delay)
+	DelayVariation time.Duration
+	// Fraction (in the range 0.0 to 1.0) of queries that will return an error
+	ErrorRate float32
+	// Error returned to the queries selected to return an error: "NXDOMAIN" or "SERVFAIL"
+	Error string
+	// Addresses returned in the answers to the A and AAAA queries instead of the upstream's answers
+	Addresses []string
+	// Rules define the disruption of the queries that match them. The first rule that matches a query is applied.
+	// The queries that do not match any rule are disrupted with the settings above.
+	Rules []Rule
+}
+
+// NewProxy return a new Proxy for the queries received in the listener (TCP) and conn (UDP), which are forwarded to
+// the upstream
+func NewProxy(
+	listener net.Listener,
+	conn net.PacketConn,
+	upstreamAddress string,
+	d Disruption,
+) (protocol.Proxy, error) {
+	return newProxy(listener, conn, upstreamAddress, d, &net.Dialer{})
+}
+
+// NewEgressProxy returns a new Proxy for the queries sent by the target to an upstream. The queries forwarded to
+// the upstream are marked so they are not redirected back to the proxy.
+func NewEgressProxy(
+	listener net.Listener,
+	conn net.PacketConn,
+	upstreamAddress string,
+	d Disruption,
+) (protocol.Proxy, error) {
+	return newProxy(listener, conn, upstreamAddress, d, protocol.MarkedDialer())
+}
+
+func newProxy(
+	listener net.Listener,
+	conn net.PacketConn,
+	upstreamAddress string,
+	d Disruption,
+	dialer *net.Dialer,
+) (protocol.Proxy, error) {
+	defaultRule := Rule{
+		AverageDelay:   d.AverageDelay,
+		DelayVariation: d.DelayVariation,
+		ErrorRate:      d.ErrorRate,
+		Error:          d.Error,
+		Addresses:      d.Addresses,
+	}
+	if err := defaultRule.compile(); err != nil {
+		return nil, err
+	}
+
+	// compile a copy of the rules, so the caller's rules are not modified
+	rules := slices.Clone(d.Rules)
+	for i := range rules {
+		if err := rules[i].compile(); err != nil {
+			return nil, fmt.Errorf("invalid rule %d: %w", i, err)
+		}
+	}
+
+	metrics := protocol.NewMetricMap(supportedMetrics()...)
+	h := &handler{
+		rules:       rules,
+		defaultRule: defaultRule,
+		metrics:     metrics,
+	}
+
+	tcpProxy, err := tcp.NewConnectionProxy(listener, upstreamAddress, dialer, h, metrics)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &proxy{
+		conn:            conn,
+		upstreamAddress: upstreamAddress,
+		dialer:          dialer,
+		handler:         h,
+		tcp:             tcpProxy,
+		metrics:         metrics,
+		ctx:             ctx,
+		cancel:          cancel,
+	}, nil
+}
+
+// proxy forwards the queries sent over UDP and uses a tcp proxy for the queries sent over TCP
+type proxy struct {
+	conn            net.PacketConn
+	upstreamAddress string
+	dialer          *net.Dialer
+	handler         *handler
+	tcp             protocol.Proxy
+	metrics         *protocol.MetricMap
+	ctx             context.Context
+	cancel          func()
+	// queries sent over UDP that are being handled
+	mutex   sync.Mutex
+	queries sync.WaitGroup
+}
+
+// Start starts the execution of the proxy
+func (p *proxy) Start() error {
+	tcpErr := make(chan error, 1)
+	go func() {
+		err := p.tcp.Start()
+		if err != nil {
+			// stop receiving queries over UDP
+			p.close()
+		}
+		tcpErr <- err
+	}()
+
+	err := p.serveUDP()
+	if err != nil {
+		_ = p.tcp.Force()
+	}
+
+	if tcpError := <-tcpErr; tcpError != nil {
+		return tcpError
+	}
+
+	return err
+}
+
+// Stop stops the execution of the proxy, waiting for the queries being handled
+func (p *proxy) Stop() error {
+	p.close()
+	err := p.tcp.Stop()
+	p.queries.Wait()
+
+	return err
+}
+
+// Metrics returns runtime metrics for the proxy
+func (p *proxy) Metrics() map[string]uint {
+	return p.metrics.Map()
+}
+
+// Force stops the proxy without waiting for the queries being handled
+func (p *proxy) Force() error {
+	p.close()
+
+	return p.tcp.Force()
+}
+
+// close stops receiving queries over UDP
+func (p *proxy) close() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.cancel()
+	_ = p.conn.Close()
+}
+
+// track adds a query to the queries being handled. Returns false if the proxy is stopped and the query must not
+// be handled.
+func (p *proxy) track() bool {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.ctx.Err() != nil {
+		return false
+	}
+
+	p.queries.Add(1)
+
+	return true
+}
+
+// serveUDP handles the queries received over UDP until the proxy is stopped
+func (p *proxy) serveUDP() error {
+	for {
+		buffer := make([]byte, maxMessageSize)
+		n, client, err := p.conn.ReadFrom(buffer)
+		if err != nil {
+			if p.ctx.Err() != nil {
+				return nil
+			}
+
+			return fmt.Errorf("proxy terminated with error: %w", err)
+		}
+
+		if !p.track() {
+			return nil
+		}
+
+		go func() {
+			defer p.queries.Done()
+
+			p.handleUDP(buffer[:n], client)
+		}()
+	}
+}
+
+// handleUDP answers a query received over UDP
+func (p *proxy) handleUDP(raw []byte, client net.Addr) {
+	response, err := p.handler.disrupt(p.ctx, parseQuery(raw))
+	if err != nil {
+		return
+	}
+
+	if response == nil {
+		if response, err = p.forwardUDP(raw); err != nil {
+			return
+		}
+	}
+
+	_, _ = p.conn.WriteTo(response, client)
+}
+
+// forwardUDP sends a query to the upstream over UDP and returns its response
+func (p *proxy) forwardUDP(raw []byte) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(p.ctx, queryTimeout)
+	defer cancel()
+
+	upstream, err := p.dialer.DialContext(ctx, "udp", p.upstreamAddress)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = upstream.Close()
+	}()
+
+	deadline, _ := ctx.Deadline()
+	_ = upstream.SetDeadline(deadline)
+
+	if _, err = upstream.Write(raw); err != nil {
+		return nil, err
+	}
+
+	buffer := make([]byte, maxMessageSize)
+	n, err := upstream.Read(buffer)
+	if err != nil {
+		return nil, err
+	}
+
+	return buffer[:n], nil
+}
+
+// handler applies the disruption to the queries, and forwards the queries sent over TCP
+type handler struct {
+	rules []Rule
+	// defaultRule applies to the queries that do not match any rule
+	defaultRule Rule
+	metrics     *protocol.MetricMap
+}
+
+// rule returns the rule that applies to a question
+func (h *handler) rule(question dnsmessage.Question) Rule {
+	for _, rule := range h.rules {
+		if rule.Match.matches(question) {
+			return rule
+		}
+	}
+
+	return h.defaultRule
+}
+
+// Handle answers the queries sent by the client over a TCP connection. The connection to the upstream is opened
+// when the first query is forwarded.
+func (h *handler) Handle(ctx context.Context, client net.Conn, dial tcp.DialFunc) {
+	var upstream net.Conn
+	for {
+		raw, err := readMessage(client)
+		if err != nil {
+			return
+		}
+
+		response, err := h.disrupt(ctx, parseQuery(raw))
+		if err != nil {
+			return
+		}
+
+		if response == nil {
+			if upstream == nil {
+				if upstream, err = dial(); err != nil {
+					return
+				}
+			}
+
+			if err = writeMessage(upstream, raw); err != nil {
+				return
+			}
+
+			if response, err = readMessage(upstream); err != nil {
+				return
+			}
+		}
+
+		if err = writeMessage(client, response); err != nil {
+			return
+		}
+	}
+}
+
+// disrupt applies the disruption to a query. Returns the response to the query, if the query must not be
+// forwarded. Returns an error if the context is done while the query is delayed.
+func (h *handler) disrupt(ctx context.Context, q query) ([]byte, error) {
+	h.metrics.Inc(protocol.MetricRequests)
+
+	// queries that cannot be decoded are forwarded without disruption
+	if !q.decoded {
+		return nil, nil
+	}
+
+	rule := h.rule(q.question)
+
+	delay := rule.AverageDelay
+	if rule.DelayVariation > 0 {
+		variation := int64(rule.DelayVariation)
+		delay += time.Duration(variation - 2*rand.Int63n(variation))
+	}
+
+	isError := rule.ErrorRate > 0 && rand.Float32() <= rule.ErrorRate
+	// only the queries for addresses are spoofed
+	isSpoofed := !isError && rule.spoofs() &&
+		(q.question.Type == dnsmessage.TypeA || q.question.Type == dnsmessage.TypeAAAA)
+	if isError || isSpoofed || delay > 0 {
+		h.metrics.Inc(protocol.MetricRequestsDisrupted)
+	}
+
+	if err := sleep(ctx, delay); err != nil {
+		return nil, err
+	}
+
+	var (
+		answer []byte
+		err    error
+	)
+	switch {
+	case isError:
+		answer, err = response(q, rule.errorCode, nil)
+	case isSpoofed:
+		answer, err = response(q, dnsmessage.RCodeSuccess, rule.addresses)
+	default:
+		return nil, nil
+	}
+
+	// queries whose response cannot be built are forwarded
+	if err != nil {
+		return nil, nil //nolint:nilerr
+	}
+
+	return answer, nil
+}
+
+// sleep waits for the given duration. Returns an error if the context is done before.
+func sleep(ctx context.Context, duration time.Duration) error {
+	timer := time.NewTimer(duration)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// supportedMetrics returns the metrics that the dns proxy supports and thus should be pre-initialized to zero
+func supportedMetrics() []string {
+	return []string{
+		protocol.MetricRequests,
+		protocol.MetricRequestsDisrupted,
+	}
+}
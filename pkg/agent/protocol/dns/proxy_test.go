@@ -0,0 +1,445 @@
+package dns
+
+import (
+	"context"
+	"net"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/grafana/xk6-disruptor/pkg/agent/protocol"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// upstreamAddress is the address the DNS server used in the tests answers to all A queries
+const upstreamAddress = "192.0.2.1"
+
+// buildQuery returns a query for the records of a type in a domain
+func buildQuery(t *testing.T, id uint16, domain string, recordType dnsmessage.Type) []byte {
+	t.Helper()
+
+	builder := dnsmessage.NewBuilder(nil, dnsmessage.Header{ID: id, RecursionDesired: true})
+	if err := builder.StartQuestions(); err != nil {
+		t.Fatalf("building query: %v", err)
+	}
+
+	question := dnsmessage.Question{
+		Name:  dnsmessage.MustNewName(domain),
+		Type:  recordType,
+		Class: dnsmessage.ClassINET,
+	}
+	if err := builder.Question(question); err != nil {
+		t.Fatalf("building query: %v", err)
+	}
+
+	raw, err := builder.Finish()
+	if err != nil {
+		t.Fatalf("building query: %v", err)
+	}
+
+	return raw
+}
+
+// answer is the part of a response checked by the tests
+type answer struct {
+	ID        uint16
+	Code      dnsmessage.RCode
+	Addresses []string
+}
+
+func parseAnswer(t *testing.T, raw []byte) answer {
+	t.Helper()
+
+	msg := dnsmessage.Message{}
+	if err := msg.Unpack(raw); err != nil {
+		t.Fatalf("decoding response: %v", err)
+	}
+
+	a := answer{ID: msg.ID, Code: msg.RCode}
+	for _, resource := range msg.Answers {
+		switch body := resource.Body.(type) {
+		case *dnsmessage.AResource:
+			a.Addresses = append(a.Addresses, netip.AddrFrom4(body.A).String())
+		case *dnsmessage.AAAAResource:
+			a.Addresses = append(a.Addresses, netip.AddrFrom16(body.AAAA).String())
+		}
+	}
+
+	return a
+}
+
+// serveDNS answers the A queries with the upstreamAddress, and other queries with no answers
+func serveDNS(raw []byte) []byte {
+	q := parseQuery(raw)
+	addresses := []netip.Addr{}
+	if q.question.Type == dnsmessage.TypeA {
+		addresses = append(addresses, netip.MustParseAddr(upstreamAddress))
+	}
+
+	response, err := response(q, dnsmessage.RCodeSuccess, addresses)
+	if err != nil {
+		return nil
+	}
+
+	return response
+}
+
+// dnsServer starts a DNS server that listens over UDP and TCP in the same port and returns its address
+func dnsServer(t *testing.T) string {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("creating listener: %v", err)
+	}
+	t.Cleanup(func() { _ = listener.Close() })
+
+	conn, err := net.ListenPacket("udp", listener.Addr().String())
+	if err != nil {
+		t.Fatalf("creating packet listener: %v", err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+
+	go func() {
+		buffer := make([]byte, maxMessageSize)
+		for {
+			n, client, err := conn.ReadFrom(buffer)
+			if err != nil {
+				return
+			}
+			_, _ = conn.WriteTo(serveDNS(buffer[:n]), client)
+		}
+	}()
+
+	go func() {
+		for {
+			client, err := listener.Accept()
+			if err != nil {
+				return
+			}
+
+			go func() {
+				defer func() {
+					_ = client.Close()
+				}()
+
+				for {
+					raw, err := readMessage(client)
+					if err != nil {
+						return
+					}
+					if err = writeMessage(client, serveDNS(raw)); err != nil {
+						return
+					}
+				}
+			}()
+		}
+	}()
+
+	return listener.Addr().String()
+}
+
+// startProxy starts a proxy for the upstream and returns the address it listens to over UDP and TCP
+func startProxy(t *testing.T, upstream string, disruption Disruption) (protocol.Proxy, string) {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("creating listener: %v", err)
+	}
+
+	conn, err := net.ListenPacket("udp", listener.Addr().String())
+	if err != nil {
+		t.Fatalf("creating packet listener: %v", err)
+	}
+
+	proxy, err := NewProxy(listener, conn, upstream, disruption)
+	if err != nil {
+		t.Fatalf("creating proxy: %v", err)
+	}
+
+	go func() {
+		_ = proxy.Start()
+	}()
+	t.Cleanup(func() { _ = proxy.Force() })
+
+	return proxy, listener.Addr().String()
+}
+
+func Test_Validations(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		title       string
+		disruption  Disruption
+		upstream    string
+		expectError bool
+	}{
+		{
+			title:       "valid defaults",
+			disruption:  Disruption{},
+			upstream:    ":53",
+			expectError: false,
+		},
+		{
+			title:       "valid error and addresses",
+			disruption:  Disruption{ErrorRate: 0.1, Error: "servfail", Addresses: []string{"10.0.0.1", "fd00::1"}},
+			upstream:    ":53",
+			expectError: false,
+		},
+		{
+			title:       "invalid upstream address",
+			disruption:  Disruption{},
+			upstream:    "",
+			expectError: true,
+		},
+		{
+			title:       "error rate without error",
+			disruption:  Disruption{ErrorRate: 0.1},
+			upstream:    ":53",
+			expectError: true,
+		},
+		{
+			title:       "unknown error",
+			disruption:  Disruption{ErrorRate: 0.1, Error: "REFUSED"},
+			upstream:    ":53",
+			expectError: true,
+		},
+		{
+			title:       "invalid address",
+			disruption:  Disruption{Addresses: []string{"10.0.0"}},
+			upstream:    ":53",
+			expectError: true,
+		},
+		{
+			title: "invalid domain",
+			disruption: Disruption{
+				Rules: []Rule{{Match: Match{Domains: []string{"[example.com"}}, AverageDelay: time.Second}},
+			},
+			upstream:    ":53",
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			t.Parallel()
+
+			listener, err := net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatalf("creating listener: %v", err)
+			}
+			defer func() {
+				_ = listener.Close()
+			}()
+
+			conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatalf("creating packet listener: %v", err)
+			}
+			defer func() {
+				_ = conn.Close()
+			}()
+
+			_, err = NewProxy(listener, conn, tc.upstream, tc.disruption)
+			if !tc.expectError && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if tc.expectError && err == nil {
+				t.Fatalf("expected error but none returned")
+			}
+		})
+	}
+}
+
+//nolint:funlen // the test cases are long
+func Test_ProxyDisruption(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		title      string
+		disruption Disruption
+		domain     string
+		recordType dnsmessage.Type
+		expected   answer
+		// minimum time for receiving the answer
+		minElapsed      time.Duration
+		expectedMetrics map[string]uint
+	}{
+		{
+			title:      "no disruption",
+			disruption: Disruption{},
+			domain:     "example.com.",
+			recordType: dnsmessage.TypeA,
+			expected:   answer{Code: dnsmessage.RCodeSuccess, Addresses: []string{upstreamAddress}},
+			expectedMetrics: map[string]uint{
+				protocol.MetricRequests:          1,
+				protocol.MetricRequestsDisrupted: 0,
+			},
+		},
+		{
+			title:      "error in all queries",
+			disruption: Disruption{ErrorRate: 1.0, Error: "SERVFAIL"},
+			domain:     "example.com.",
+			recordType: dnsmessage.TypeA,
+			expected:   answer{Code: dnsmessage.RCodeServerFailure},
+			expectedMetrics: map[string]uint{
+				protocol.MetricRequests:          1,
+				protocol.MetricRequestsDisrupted: 1,
+			},
+		},
+		{
+			title: "error in matching domain",
+			disruption: Disruption{
+				Rules: []Rule{{Match: Match{Domains: []string{"*.Example.com"}}, ErrorRate: 1.0, Error: "NXDOMAIN"}},
+			},
+			domain:     "api.example.com.",
+			recordType: dnsmessage.TypeA,
+			expected:   answer{Code: dnsmessage.RCodeNameError},
+			expectedMetrics: map[string]uint{
+				protocol.MetricRequests:          1,
+				protocol.MetricRequestsDisrupted: 1,
+			},
+		},
+		{
+			title: "domain does not match",
+			disruption: Disruption{
+				Rules: []Rule{{Match: Match{Domains: []string{"*.example.com"}}, ErrorRate: 1.0, Error: "NXDOMAIN"}},
+			},
+			domain:     "example.org.",
+			recordType: dnsmessage.TypeA,
+			expected:   answer{Code: dnsmessage.RCodeSuccess, Addresses: []string{upstreamAddress}},
+			expectedMetrics: map[string]uint{
+				protocol.MetricRequests:          1,
+				protocol.MetricRequestsDisrupted: 0,
+			},
+		},
+		{
+			title:      "spoofed IPv4 address",
+			disruption: Disruption{Addresses: []string{"10.0.0.1", "fd00::1"}},
+			domain:     "example.com.",
+			recordType: dnsmessage.TypeA,
+			expected:   answer{Code: dnsmessage.RCodeSuccess, Addresses: []string{"10.0.0.1"}},
+			expectedMetrics: map[string]uint{
+				protocol.MetricRequests:          1,
+				protocol.MetricRequestsDisrupted: 1,
+			},
+		},
+		{
+			title:      "spoofed IPv6 address",
+			disruption: Disruption{Addresses: []string{"10.0.0.1", "fd00::1"}},
+			domain:     "example.com.",
+			recordType: dnsmessage.TypeAAAA,
+			expected:   answer{Code: dnsmessage.RCodeSuccess, Addresses: []string{"fd00::1"}},
+			expectedMetrics: map[string]uint{
+				protocol.MetricRequests:          1,
+				protocol.MetricRequestsDisrupted: 1,
+			},
+		},
+		{
+			title:      "other record types are not spoofed",
+			disruption: Disruption{Addresses: []string{"10.0.0.1"}},
+			domain:     "example.com.",
+			recordType: dnsmessage.TypeTXT,
+			expected:   answer{Code: dnsmessage.RCodeSuccess},
+			expectedMetrics: map[string]uint{
+				protocol.MetricRequests:          1,
+				protocol.MetricRequestsDisrupted: 0,
+			},
+		},
+		{
+			title: "delay in matching type",
+			disruption: Disruption{
+				Rules: []Rule{{Match: Match{Types: []string{"a"}}, AverageDelay: 100 * time.Millisecond}},
+			},
+			domain:     "example.com.",
+			recordType: dnsmessage.TypeA,
+			expected:   answer{Code: dnsmessage.RCodeSuccess, Addresses: []string{upstreamAddress}},
+			minElapsed: 100 * time.Millisecond,
+			expectedMetrics: map[string]uint{
+				protocol.MetricRequests:          1,
+				protocol.MetricRequestsDisrupted: 1,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		for _, network := range []string{"udp", "tcp"} {
+			t.Run(tc.title+" over "+network, func(t *testing.T) {
+				t.Parallel()
+
+				proxy, address := startProxy(t, dnsServer(t), tc.disruption)
+
+				conn, err := net.Dial(network, address)
+				if err != nil {
+					t.Fatalf("connecting to proxy: %v", err)
+				}
+				defer func() {
+					_ = conn.Close()
+				}()
+				_ = conn.SetDeadline(time.Now().Add(2 * time.Second))
+
+				query := buildQuery(t, 42, tc.domain, tc.recordType)
+
+				start := time.Now()
+				var raw []byte
+				if network == "udp" {
+					if _, err = conn.Write(query); err != nil {
+						t.Fatalf("sending query: %v", err)
+					}
+					buffer := make([]byte, maxMessageSize)
+					var n int
+					if n, err = conn.Read(buffer); err != nil {
+						t.Fatalf("receiving answer: %v", err)
+					}
+					raw = buffer[:n]
+				} else {
+					if err = writeMessage(conn, query); err != nil {
+						t.Fatalf("sending query: %v", err)
+					}
+					if raw, err = readMessage(conn); err != nil {
+						t.Fatalf("receiving answer: %v", err)
+					}
+				}
+				elapsed := time.Since(start)
+
+				tc.expected.ID = 42
+				if diff := cmp.Diff(tc.expected, parseAnswer(t, raw)); diff != "" {
+					t.Fatalf("expected answer does not match returned:\n%s", diff)
+				}
+
+				if elapsed < tc.minElapsed {
+					t.Fatalf("expected answer in at least %v but received in %v", tc.minElapsed, elapsed)
+				}
+
+				if diff := cmp.Diff(tc.expectedMetrics, proxy.Metrics()); diff != "" {
+					t.Fatalf("expected metrics do not match returned:\n%s", diff)
+				}
+			})
+		}
+	}
+}
+
+func Test_DisruptCanceled(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+
+	h := &handler{
+		defaultRule: Rule{AverageDelay: time.Hour},
+		metrics:     protocol.NewMetricMap(supportedMetrics()...),
+	}
+
+	start := time.Now()
+	_, err := h.disrupt(ctx, parseQuery(buildQuery(t, 1, "example.com.", dnsmessage.TypeA)))
+	if err == nil {
+		t.Fatalf("expected query not to be answered once the context is done")
+	}
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("expected delay to end when the context is done, but took %v", elapsed)
+	}
+}
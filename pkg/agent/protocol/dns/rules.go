@@ -0,0 +1,184 @@
+package dns
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/netip"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// recordTypes maps the names of the most common record types to their values
+var recordTypes = map[string]dnsmessage.Type{ //nolint:gochecknoglobals
+	"A":     dnsmessage.TypeA,
+	"NS":    dnsmessage.TypeNS,
+	"CNAME": dnsmessage.TypeCNAME,
+	"SOA":   dnsmessage.TypeSOA,
+	"PTR":   dnsmessage.TypePTR,
+	"MX":    dnsmessage.TypeMX,
+	"TXT":   dnsmessage.TypeTXT,
+	"AAAA":  dnsmessage.TypeAAAA,
+	"SRV":   dnsmessage.TypeSRV,
+	"ANY":   dnsmessage.TypeALL,
+}
+
+// errorCodes maps the names of the errors that can be returned to queries to their response codes
+var errorCodes = map[string]dnsmessage.RCode{ //nolint:gochecknoglobals
+	"NXDOMAIN": dnsmessage.RCodeNameError,
+	"SERVFAIL": dnsmessage.RCodeServerFailure,
+}
+
+// parseRecordType returns a record type given by its name, such as "AAAA", or its numeric value
+func parseRecordType(value string) (dnsmessage.Type, error) {
+	if recordType, found := recordTypes[strings.ToUpper(value)]; found {
+		return recordType, nil
+	}
+
+	recordType, err := strconv.ParseUint(value, 10, 16)
+	if err != nil || recordType == 0 {
+		return 0, fmt.Errorf("unknown record type %q", value)
+	}
+
+	return dnsmessage.Type(recordType), nil
+}
+
+// normalizeName returns a domain name in lower case and without the trailing dot
+func normalizeName(name string) string {
+	return strings.TrimSuffix(strings.ToLower(name), ".")
+}
+
+// Match selects queries by their domain and record type. A query matches if it satisfies all the criteria specified.
+// An empty Match selects all queries.
+type Match struct {
+	// Domains selects the queries for any of these domains. Domains are patterns that can use "*" as a wildcard
+	// (e.g. "*.example.com"). Domains are not case-sensitive
+	Domains []string `json:"domains,omitempty"`
+	// Types selects the queries for any of these record types, given by their name (e.g. "A", "SRV") or their
+	// numeric value. Names are not case-sensitive
+	Types []string `json:"types,omitempty"`
+
+	domains []string
+	types   []dnsmessage.Type
+}
+
+// Rule defines the disruption applied to the queries that match a Match
+type Rule struct {
+	Match Match `json:"match"`
+	// Average delay introduced to queries
+	AverageDelay time.Duration `json:"averageDelay,omitempty"`
+	// Variation in the delay (with respect of the average delay)
+	DelayVariation time.Duration `json:"delayVariation,omitempty"`
+	// Fraction (in the range 0.0 to 1.0) of queries that will return an error
+	ErrorRate float32 `json:"errorRate,omitempty"`
+	// Error returned to the queries selected to return an error: "NXDOMAIN" or "SERVFAIL"
+	Error string `json:"error,omitempty"`
+	// Addresses returned in the answers to the A and AAAA queries instead of the upstream's answers. IPv4
+	// addresses are returned to A queries and IPv6 addresses to AAAA queries.
+	Addresses []string `json:"addresses,omitempty"`
+
+	errorCode dnsmessage.RCode
+	addresses []netip.Addr
+}
+
+// ParseRules parses a list of rules in JSON format
+func ParseRules(values []string) ([]Rule, error) {
+	rules := []Rule{}
+	for _, value := range values {
+		decoder := json.NewDecoder(bytes.NewBufferString(value))
+		decoder.DisallowUnknownFields()
+
+		rule := Rule{}
+		if err := decoder.Decode(&rule); err != nil {
+			return nil, fmt.Errorf("parsing rule %q: %w", value, err)
+		}
+
+		rules = append(rules, rule)
+	}
+
+	return rules, nil
+}
+
+// compile validates the rule and compiles its error, addresses and match
+func (r *Rule) compile() error {
+	if r.DelayVariation > r.AverageDelay {
+		return fmt.Errorf("variation must be less that average delay")
+	}
+
+	if r.ErrorRate < 0.0 || r.ErrorRate > 1.0 {
+		return fmt.Errorf("error rate must be in the range [0.0, 1.0]")
+	}
+
+	if r.ErrorRate > 0.0 && r.Error == "" {
+		return fmt.Errorf("error is required when the error rate is set")
+	}
+
+	r.errorCode = dnsmessage.RCodeSuccess
+	if r.Error != "" {
+		code, found := errorCodes[strings.ToUpper(r.Error)]
+		if !found {
+			return fmt.Errorf("unknown error %q", r.Error)
+		}
+		r.errorCode = code
+	}
+
+	r.addresses = nil
+	for _, address := range r.Addresses {
+		addr, err := netip.ParseAddr(address)
+		if err != nil {
+			return fmt.Errorf("invalid address %q", address)
+		}
+		r.addresses = append(r.addresses, addr.Unmap())
+	}
+
+	r.Match.domains = nil
+	for _, domain := range r.Match.Domains {
+		pattern := normalizeName(domain)
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid domain %q: %w", domain, err)
+		}
+		r.Match.domains = append(r.Match.domains, pattern)
+	}
+
+	r.Match.types = nil
+	for _, name := range r.Match.Types {
+		recordType, err := parseRecordType(name)
+		if err != nil {
+			return err
+		}
+		r.Match.types = append(r.Match.types, recordType)
+	}
+
+	return nil
+}
+
+// spoofs returns if the rule answers the A and AAAA queries with its addresses
+func (r Rule) spoofs() bool {
+	return len(r.addresses) > 0
+}
+
+// matches returns if the question satisfies all the criteria of the Match
+func (m Match) matches(question dnsmessage.Question) bool {
+	if len(m.types) > 0 && !slices.Contains(m.types, question.Type) {
+		return false
+	}
+
+	if len(m.domains) == 0 {
+		return true
+	}
+
+	name := normalizeName(question.Name.String())
+	for _, pattern := range m.domains {
+		// patterns are validated when the rule is compiled
+		if matched, _ := path.Match(pattern, name); matched {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,145 @@
+package dns
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+func Test_ParseRules(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		title       string
+		values      []string
+		expected    []Rule
+		expectError bool
+	}{
+		{
+			title: "valid rules",
+			values: []string{
+				`{"match":{"domains":["*.example.com"],"types":["A"]},"errorRate":1,"error":"NXDOMAIN"}`,
+				`{"match":{"domains":["db.local"]},"averageDelay":100000000,"addresses":["10.0.0.1"]}`,
+			},
+			expected: []Rule{
+				{
+					Match:     Match{Domains: []string{"*.example.com"}, Types: []string{"A"}},
+					ErrorRate: 1,
+					Error:     "NXDOMAIN",
+				},
+				{
+					Match:        Match{Domains: []string{"db.local"}},
+					AverageDelay: 100 * time.Millisecond,
+					Addresses:    []string{"10.0.0.1"},
+				},
+			},
+		},
+		{
+			title:       "unknown field",
+			values:      []string{`{"match":{"domain":"example.com"}}`},
+			expectError: true,
+		},
+		{
+			title:       "malformed rule",
+			values:      []string{`{"match":`},
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			t.Parallel()
+
+			rules, err := ParseRules(tc.values)
+			if !tc.expectError && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if tc.expectError && err == nil {
+				t.Fatalf("expected error but none returned")
+			}
+
+			if tc.expectError {
+				return
+			}
+
+			if diff := cmp.Diff(tc.expected, rules, cmpopts.IgnoreUnexported(Rule{}, Match{})); diff != "" {
+				t.Fatalf("expected rules do not match returned:\n%s", diff)
+			}
+		})
+	}
+}
+
+func Test_MatchQuestion(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		title      string
+		match      Match
+		domain     string
+		recordType dnsmessage.Type
+		expected   bool
+	}{
+		{
+			title:      "empty match",
+			match:      Match{},
+			domain:     "example.com.",
+			recordType: dnsmessage.TypeA,
+			expected:   true,
+		},
+		{
+			title:      "exact domain",
+			match:      Match{Domains: []string{"example.com."}},
+			domain:     "EXAMPLE.com.",
+			recordType: dnsmessage.TypeA,
+			expected:   true,
+		},
+		{
+			title:      "wildcard domain",
+			match:      Match{Domains: []string{"*.svc.cluster.local"}},
+			domain:     "db.default.svc.cluster.local.",
+			recordType: dnsmessage.TypeA,
+			expected:   true,
+		},
+		{
+			title:      "wildcard does not match parent domain",
+			match:      Match{Domains: []string{"*.example.com"}},
+			domain:     "example.com.",
+			recordType: dnsmessage.TypeA,
+			expected:   false,
+		},
+		{
+			title:      "numeric type",
+			match:      Match{Types: []string{"33"}},
+			domain:     "example.com.",
+			recordType: dnsmessage.TypeSRV,
+			expected:   true,
+		},
+		{
+			title:      "type does not match",
+			match:      Match{Domains: []string{"example.com"}, Types: []string{"AAAA"}},
+			domain:     "example.com.",
+			recordType: dnsmessage.TypeA,
+			expected:   false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			t.Parallel()
+
+			rule := Rule{Match: tc.match}
+			if err := rule.compile(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			question := dnsmessage.Question{Name: dnsmessage.MustNewName(tc.domain), Type: tc.recordType}
+			if matched := rule.Match.matches(question); matched != tc.expected {
+				t.Fatalf("expected match to be %t but got %t", tc.expected, matched)
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package protocol
+
+import (
+	"fmt"
+
+	"github.com/grafana/xk6-disruptor/pkg/iptables"
+)
+
+// DNSPort is the port DNS queries are sent to
+const DNSPort = 53
+
+// DNSRedirectionSpec specifies the redirection of the DNS queries sent by the target
+type DNSRedirectionSpec struct {
+	// RedirectPort is the port where the queries should be redirected to, over both UDP and TCP.
+	// Typically, this would be where a DNS proxy is listening.
+	RedirectPort uint
+}
+
+// DNSRedirector is an implementation of TrafficRedirector that redirects the outbound DNS queries using iptables
+// rules.
+type DNSRedirector struct {
+	*DNSRedirectionSpec
+	ruleset *iptables.RuleSet
+}
+
+// NewDNSTrafficRedirector creates instances of an iptables DNS traffic redirector
+func NewDNSTrafficRedirector(
+	tr *DNSRedirectionSpec,
+	ipt iptables.Iptables,
+) (*DNSRedirector, error) {
+	if tr.RedirectPort == 0 {
+		return nil, fmt.Errorf("RedirectPort must be specified")
+	}
+
+	if tr.RedirectPort == DNSPort {
+		return nil, fmt.Errorf("RedirectPort cannot be the DNS port (%d)", DNSPort)
+	}
+
+	return &DNSRedirector{
+		DNSRedirectionSpec: tr,
+		ruleset:            iptables.NewRuleSet(ipt),
+	}, nil
+}
+
+// rules returns the iptables rules that cause the DNS queries to be forwarded according to the spec:
+// - Redirect the queries sent over UDP and new TCP connections to any DNS server through the proxy, excluding the
+// queries of the proxy itself.
+// - Reset existing, non-redirected TCP connections to any DNS server, except those of the proxy itself.
+// The queries of the proxy are identified by the ProxyMark.
+func (tr *DNSRedirector) rules() []iptables.Rule {
+	notProxy := fmt.Sprintf("-m mark ! --mark %#x", ProxyMark)
+
+	rules := []iptables.Rule{}
+	for _, proto := range []string{"udp", "tcp"} {
+		rules = append(rules, iptables.Rule{
+			Table: "nat",
+			Chain: "OUTPUT", // For locally originated traffic
+			Args:  fmt.Sprintf("-p %s --dport %d %s -j REDIRECT --to-port %d", proto, DNSPort, notProxy, tr.RedirectPort),
+		})
+	}
+
+	return append(rules, iptables.Rule{
+		Table: "filter",
+		Chain: "OUTPUT",
+		Args: fmt.Sprintf("-p tcp --dport %d %s ", DNSPort, notProxy) +
+			"-m state --state ESTABLISHED -j REJECT --reject-with tcp-reset",
+	})
+}
+
+// Start applies the TrafficRedirect. Either all the rules are added or none of them is.
+func (tr *DNSRedirector) Start() error {
+	if err := tr.ruleset.Add(tr.rules()...); err != nil {
+		return fmt.Errorf("adding rules: %w", err)
+	}
+
+	return nil
+}
+
+// Stop stops the TrafficRedirect by removing all the rules it deployed at once.
+func (tr *DNSRedirector) Stop() error {
+	return tr.ruleset.Remove()
+}
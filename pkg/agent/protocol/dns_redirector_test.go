@@ -0,0 +1,86 @@
+package protocol
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/grafana/xk6-disruptor/pkg/iptables"
+	"github.com/grafana/xk6-disruptor/pkg/runtime"
+)
+
+func Test_DNSRedirectorCommands(t *testing.T) {
+	t.Parallel()
+
+	//nolint:lll
+	expected := []string{
+		"-t filter -p tcp --dport 53 -m mark ! --mark 0x6b36 -m state --state ESTABLISHED -j REJECT --reject-with tcp-reset",
+		"-t nat -p udp --dport 53 -m mark ! --mark 0x6b36 -j REDIRECT --to-port 5353",
+		"-t nat -p tcp --dport 53 -m mark ! --mark 0x6b36 -j REDIRECT --to-port 5353",
+	}
+
+	executor := runtime.NewFakeExecutor(nil, nil)
+	redirector, err := NewDNSTrafficRedirector(&DNSRedirectionSpec{RedirectPort: 5353}, iptables.New(executor))
+	if err != nil {
+		t.Fatalf("failed creating traffic redirector with error %v", err)
+	}
+
+	if err = redirector.Start(); err != nil {
+		t.Fatalf("failed starting redirector: %v", err)
+	}
+
+	if diff := cmp.Diff(expected, restoredRules(executor.InputHistory())); diff != "" {
+		t.Fatalf("Added rules differ from expected:\n%s", diff)
+	}
+
+	if err = redirector.Stop(); err != nil {
+		t.Fatalf("failed stopping redirector: %v", err)
+	}
+
+	// rules are added and removed at once
+	expectedCmds := []string{"iptables-restore --noflush", "iptables-restore --noflush"}
+	if diff := cmp.Diff(expectedCmds, executor.CmdHistory()); diff != "" {
+		t.Fatalf("Actual commands differ from expected:\n%s", diff)
+	}
+}
+
+func Test_validateDNSTrafficRedirect(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		title       string
+		redirect    DNSRedirectionSpec
+		expectError bool
+	}{
+		{
+			title:       "Valid redirect",
+			redirect:    DNSRedirectionSpec{RedirectPort: 5353},
+			expectError: false,
+		},
+		{
+			title:       "Port not specified",
+			redirect:    DNSRedirectionSpec{},
+			expectError: true,
+		},
+		{
+			title:       "Redirect to DNS port",
+			redirect:    DNSRedirectionSpec{RedirectPort: DNSPort},
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			t.Parallel()
+
+			executor := runtime.NewFakeExecutor(nil, nil)
+			_, err := NewDNSTrafficRedirector(&tc.redirect, iptables.New(executor))
+			if tc.expectError && err == nil {
+				t.Errorf("error expected but none returned")
+			}
+
+			if !tc.expectError && err != nil {
+				t.Errorf("failed with error %v", err)
+			}
+		})
+	}
+}
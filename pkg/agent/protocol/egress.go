@@ -0,0 +1,98 @@
+package protocol
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/grafana/xk6-disruptor/pkg/iptables"
+)
+
+// ProxyMark is the netfilter mark set on the connections the proxy opens to the upstream when running in egress mode.
+// The EgressRedirector does not redirect the traffic with this mark, preventing a redirection loop.
+const ProxyMark = 0x6b36
+
+// EgressRedirectionSpec specifies the redirection of the outbound traffic sent to a destination
+type EgressRedirectionSpec struct {
+	// Destinations are the CIDRs of the upstream the traffic is sent to
+	Destinations []string
+	// DestinationPort is the port of the upstream the traffic is sent to
+	DestinationPort uint
+	// RedirectPort is the port where the traffic should be redirected to.
+	// Typically, this would be where a transparent proxy is listening.
+	RedirectPort uint
+}
+
+// EgressRedirector is an implementation of TrafficRedirector that redirects the outbound traffic using iptables rules.
+type EgressRedirector struct {
+	*EgressRedirectionSpec
+	ruleset *iptables.RuleSet
+}
+
+// NewEgressTrafficRedirector creates instances of an iptables egress traffic redirector
+func NewEgressTrafficRedirector(
+	tr *EgressRedirectionSpec,
+	ipt iptables.Iptables,
+) (*EgressRedirector, error) {
+	if len(tr.Destinations) == 0 {
+		return nil, fmt.Errorf("at least one destination must be specified")
+	}
+
+	if tr.DestinationPort == 0 || tr.RedirectPort == 0 {
+		return nil, fmt.Errorf("DestinationPort and RedirectPort must be specified")
+	}
+
+	return &EgressRedirector{
+		EgressRedirectionSpec: tr,
+		ruleset:               iptables.NewRuleSet(ipt),
+	}, nil
+}
+
+// rules returns the iptables rules that cause the outbound traffic to be forwarded according to the spec.
+// For each destination, two rules are returned:
+// - Redirect new connections to the destination through the proxy, excluding the connections of the proxy itself.
+// - Reset existing, non-redirected connections to the destination, except those of the proxy itself.
+// The connections of the proxy are identified by the ProxyMark. Redirected connections are not reset because their
+// destination is rewritten to the loopback address by the time they traverse the filter table.
+func (tr *EgressRedirector) rules() []iptables.Rule {
+	notProxy := fmt.Sprintf("-m mark ! --mark %#x", ProxyMark)
+
+	rules := []iptables.Rule{}
+	for _, destination := range tr.Destinations {
+		match := fmt.Sprintf("-d %s -p tcp --dport %d %s", destination, tr.DestinationPort, notProxy)
+		rules = append(rules,
+			iptables.Rule{
+				Table: "nat",
+				Chain: "OUTPUT", // For locally originated traffic
+				Args:  fmt.Sprintf("%s -j REDIRECT --to-port %d", match, tr.RedirectPort),
+			},
+			iptables.Rule{
+				Table: "filter",
+				Chain: "OUTPUT",
+				Args:  match + " -m state --state ESTABLISHED -j REJECT --reject-with tcp-reset",
+			},
+		)
+	}
+
+	return rules
+}
+
+// Start applies the TrafficRedirect. Either all the rules are added or none of them is.
+func (tr *EgressRedirector) Start() error {
+	if err := tr.ruleset.Add(tr.rules()...); err != nil {
+		return fmt.Errorf("adding rules: %w", err)
+	}
+
+	return nil
+}
+
+// Stop stops the TrafficRedirect by removing all the rules it deployed at once.
+func (tr *EgressRedirector) Stop() error {
+	return tr.ruleset.Remove()
+}
+
+// MarkedDialer returns a dialer that sets the ProxyMark on the connections it opens
+func MarkedDialer() *net.Dialer {
+	return &net.Dialer{
+		Control: markConn,
+	}
+}
@@ -0,0 +1,102 @@
+package protocol
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/grafana/xk6-disruptor/pkg/iptables"
+	"github.com/grafana/xk6-disruptor/pkg/runtime"
+)
+
+func Test_EgressRedirectorCommands(t *testing.T) {
+	t.Parallel()
+
+	redirect := EgressRedirectionSpec{
+		Destinations:    []string{"10.0.0.1/32", "10.0.0.2/32"},
+		DestinationPort: 80,
+		RedirectPort:    8080,
+	}
+
+	//nolint:lll
+	expected := []string{
+		"-t filter -d 10.0.0.1/32 -p tcp --dport 80 -m mark ! --mark 0x6b36 -m state --state ESTABLISHED -j REJECT --reject-with tcp-reset",
+		"-t filter -d 10.0.0.2/32 -p tcp --dport 80 -m mark ! --mark 0x6b36 -m state --state ESTABLISHED -j REJECT --reject-with tcp-reset",
+		"-t nat -d 10.0.0.1/32 -p tcp --dport 80 -m mark ! --mark 0x6b36 -j REDIRECT --to-port 8080",
+		"-t nat -d 10.0.0.2/32 -p tcp --dport 80 -m mark ! --mark 0x6b36 -j REDIRECT --to-port 8080",
+	}
+
+	executor := runtime.NewFakeExecutor(nil, nil)
+	redirector, err := NewEgressTrafficRedirector(&redirect, iptables.New(executor))
+	if err != nil {
+		t.Fatalf("failed creating traffic redirector with error %v", err)
+	}
+
+	if err = redirector.Start(); err != nil {
+		t.Fatalf("failed starting redirector: %v", err)
+	}
+
+	if diff := cmp.Diff(expected, restoredRules(executor.InputHistory())); diff != "" {
+		t.Fatalf("Added rules differ from expected:\n%s", diff)
+	}
+
+	if err = redirector.Stop(); err != nil {
+		t.Fatalf("failed stopping redirector: %v", err)
+	}
+
+	// rules are added and removed at once
+	expectedCmds := []string{"iptables-restore --noflush", "iptables-restore --noflush"}
+	if diff := cmp.Diff(expectedCmds, executor.CmdHistory()); diff != "" {
+		t.Fatalf("Actual commands differ from expected:\n%s", diff)
+	}
+}
+
+func Test_validateEgressTrafficRedirect(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		title       string
+		redirect    EgressRedirectionSpec
+		expectError bool
+	}{
+		{
+			title: "Valid redirect",
+			redirect: EgressRedirectionSpec{
+				Destinations:    []string{"10.0.0.1/32"},
+				DestinationPort: 80,
+				RedirectPort:    8080,
+			},
+			expectError: false,
+		},
+		{
+			title: "No destinations",
+			redirect: EgressRedirectionSpec{
+				DestinationPort: 80,
+				RedirectPort:    8080,
+			},
+			expectError: true,
+		},
+		{
+			title: "Ports not specified",
+			redirect: EgressRedirectionSpec{
+				Destinations: []string{"10.0.0.1/32"},
+			},
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			t.Parallel()
+
+			executor := runtime.NewFakeExecutor(nil, nil)
+			_, err := NewEgressTrafficRedirector(&tc.redirect, iptables.New(executor))
+			if tc.expectError && err == nil {
+				t.Errorf("error expected but none returned")
+			}
+
+			if !tc.expectError && err != nil {
+				t.Errorf("failed with error %v", err)
+			}
+		})
+	}
+}
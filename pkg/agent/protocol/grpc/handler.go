@@ -30,6 +30,7 @@ func NewHandler(disruption Disruption, forwardConn *grpc.ClientConn, metrics *pr
 		disruption:  disruption,
 		forwardConn: forwardConn,
 		metrics:     metrics,
+		start:       time.Now(),
 	}
 
 	// return the handler function
@@ -40,6 +41,8 @@ type handler struct {
 	disruption  Disruption
 	forwardConn *grpc.ClientConn
 	metrics     *protocol.MetricMap
+	// start of the disruption, for evaluating its schedule
+	start time.Time
 }
 
 // contains verifies if a list of strings contains the given string
@@ -66,30 +69,40 @@ func (h *handler) streamHandler(_ interface{}, serverStream grpc.ServerStream) e
 	serviceName := strings.Split(fullMethodName, "/")[1]
 	if contains(h.disruption.Excluded, serviceName) {
 		h.metrics.Inc(protocol.MetricRequestsExcluded)
-		return h.transparentForward(serverStream)
+		return h.transparentForward(serverStream, Rule{})
 	}
 
-	if rand.Float32() < h.disruption.ErrorRate {
+	md, _ := metadata.FromIncomingContext(serverStream.Context())
+	rule := h.disruption.rule(fullMethodName, md)
+	if !h.disruption.Schedule.IsConstant() {
+		rule = rule.scaled(h.disruption.Schedule.Level(time.Since(h.start)))
+	}
+
+	if rand.Float32() < rule.ErrorRate {
 		h.metrics.Inc(protocol.MetricRequestsDisrupted)
-		return h.injectError(serverStream)
+		return h.injectError(serverStream, rule)
 	}
 
-	// add delay
-	if h.disruption.AverageDelay > 0 {
+	if rule.AverageDelay > 0 || !rule.StreamDisruption.IsEmpty() {
 		h.metrics.Inc(protocol.MetricRequestsDisrupted)
+	}
 
-		delay := int64(h.disruption.AverageDelay)
-		if h.disruption.DelayVariation > 0 {
-			variation := int64(h.disruption.DelayVariation)
+	// add delay
+	if rule.AverageDelay > 0 {
+		delay := int64(rule.AverageDelay)
+		if rule.DelayVariation > 0 {
+			variation := int64(rule.DelayVariation)
 			delay = delay + variation - 2*rand.Int63n(variation)
 		}
 		time.Sleep(time.Duration(delay))
 	}
 
-	return h.transparentForward(serverStream)
+	return h.transparentForward(serverStream, rule)
 }
 
-func (h *handler) transparentForward(serverStream grpc.ServerStream) error {
+// transparentForward forwards the messages of the stream between the client and the upstream, applying the rule's
+// stream disruption to each message
+func (h *handler) transparentForward(serverStream grpc.ServerStream, rule Rule) error {
 	// TODO: Add a `forwarded` header to metadata, https://en.wikipedia.org/wiki/X-Forwarded-For.
 	ctx := serverStream.Context()
 	md, _ := metadata.FromIncomingContext(ctx)
@@ -114,11 +127,16 @@ func (h *handler) transparentForward(serverStream grpc.ServerStream) error {
 	// Explicitly *do not close* s2cErrChan and c2sErrChan, otherwise the select below will not terminate.
 	// Channels do not have to be closed, it is just a control flow mechanism, see
 	// https://groups.google.com/forum/#!msg/golang-nuts/pZwdYRGxCIk/qpbHxRRPJdUJ
-	s2cErrChan := h.forwardServerToClient(serverStream, clientStream)
-	c2sErrChan := h.forwardClientToServer(clientStream, serverStream)
+	s2cErrChan := h.forwardServerToClient(serverStream, clientStream, rule.StreamDisruption)
+	c2sErrChan := h.forwardClientToServer(clientStream, serverStream, rule.StreamDisruption)
+	abortTimer := rule.abortTimer()
 	// We don't know which side is going to stop sending first, so we need a select between the two.
 	for range 2 {
 		select {
+		case <-abortTimer:
+			clientCancel()
+			h.metrics.Inc(MetricStreamsAborted)
+			return status.Error(codes.Code(rule.StatusCode), rule.StatusMessage)
 		case s2cErr := <-s2cErrChan:
 			if errors.Is(s2cErr, io.EOF) {
 				// this is the happy case where the sender has encountered io.EOF, and won't be sending anymore./
@@ -135,6 +153,11 @@ func (h *handler) transparentForward(serverStream grpc.ServerStream) error {
 			// This happens when the clientStream has nothing else to offer (io.EOF), returned a gRPC error. In those two
 			// cases we may have received Trailers as part of the call. In case of other errors (stream closed) the trailers
 			// will be nil.
+			if errors.Is(c2sErr, errStreamAborted) {
+				clientCancel()
+				h.metrics.Inc(MetricStreamsAborted)
+				return status.Error(codes.Code(rule.StatusCode), rule.StatusMessage)
+			}
 			serverStream.SetTrailer(clientStream.Trailer())
 			// c2sErr will contain RPC error from client code. If not io.EOF return the RPC error as server stream error.
 			if !errors.Is(c2sErr, io.EOF) {
@@ -146,10 +169,15 @@ func (h *handler) transparentForward(serverStream grpc.ServerStream) error {
 	return status.Errorf(codes.Internal, "gRPC proxy should never reach this stage.")
 }
 
-func (h *handler) forwardClientToServer(src grpc.ClientStream, dst grpc.ServerStream) chan error {
+func (h *handler) forwardClientToServer(
+	src grpc.ClientStream,
+	dst grpc.ServerStream,
+	disruption StreamDisruption,
+) chan error {
 	ret := make(chan error, 1)
 	go func() {
 		f := &emptypb.Empty{}
+		sent := uint(0)
 		for i := 0; ; i++ {
 			if err := src.RecvMsg(f); err != nil {
 				ret <- err // this can be io.EOF which is happy case
@@ -169,16 +197,28 @@ func (h *handler) forwardClientToServer(src grpc.ClientStream, dst grpc.ServerSt
 					break
 				}
 			}
+			if !disruption.disruptMessage(src.Context(), h.metrics) {
+				continue
+			}
 			if err := dst.SendMsg(f); err != nil {
 				ret <- err
 				break
 			}
+			sent++
+			if disruption.AbortAfterMessages > 0 && sent >= disruption.AbortAfterMessages {
+				ret <- errStreamAborted
+				break
+			}
 		}
 	}()
 	return ret
 }
 
-func (h *handler) forwardServerToClient(src grpc.ServerStream, dst grpc.ClientStream) chan error {
+func (h *handler) forwardServerToClient(
+	src grpc.ServerStream,
+	dst grpc.ClientStream,
+	disruption StreamDisruption,
+) chan error {
 	ret := make(chan error, 1)
 	go func() {
 		f := &emptypb.Empty{}
@@ -187,6 +227,9 @@ func (h *handler) forwardServerToClient(src grpc.ServerStream, dst grpc.ClientSt
 				ret <- err // this can be io.EOF which is happy case
 				break
 			}
+			if !disruption.disruptMessage(src.Context(), h.metrics) {
+				continue
+			}
 			if err := dst.SendMsg(f); err != nil {
 				ret <- err
 				break
@@ -196,13 +239,13 @@ func (h *handler) forwardServerToClient(src grpc.ServerStream, dst grpc.ClientSt
 	return ret
 }
 
-func (h *handler) injectError(serverStream grpc.ServerStream) error {
+func (h *handler) injectError(serverStream grpc.ServerStream, rule Rule) error {
 	err := h.drainServerStream(serverStream)
 	if err != nil {
 		return fmt.Errorf("error receiving request from client %w", err)
 	}
 
-	return status.Error(codes.Code(h.disruption.StatusCode), h.disruption.StatusMessage)
+	return status.Error(codes.Code(rule.StatusCode), rule.StatusMessage)
 }
 
 // read all messages from client
@@ -9,8 +9,10 @@ import (
 	"time"
 
 	"github.com/grafana/xk6-disruptor/pkg/agent/protocol"
+	"github.com/grafana/xk6-disruptor/pkg/agent/schedule"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
 )
 
 // Disruption specifies disruptions in grpc requests
@@ -27,6 +29,39 @@ type Disruption struct {
 	StatusMessage string
 	// List of grpc services to be excluded from disruptions
 	Excluded []string
+	// Disruptions applied to the individual messages of the streams
+	StreamDisruption
+	// Rules define the disruption of the requests that match them. The first rule that matches a request is applied.
+	// The requests that do not match any rule are disrupted with the settings above, unless a rule excludes them.
+	Rules []Rule
+	// Schedule varies the delays and error rates of the disruption and its rules during the injection
+	Schedule schedule.Schedule
+}
+
+// rule returns the rule that applies to a request to the method with the given metadata. A request excluded by a rule
+// that does not match any other rule is not disrupted.
+func (d Disruption) rule(fullMethodName string, md metadata.MD) Rule {
+	excluded := false
+	for _, rule := range d.Rules {
+		if rule.Match.matches(fullMethodName, md) {
+			return rule
+		}
+
+		excluded = excluded || rule.Match.excludes(fullMethodName, md)
+	}
+
+	if excluded {
+		return Rule{}
+	}
+
+	return Rule{
+		AverageDelay:     d.AverageDelay,
+		DelayVariation:   d.DelayVariation,
+		ErrorRate:        d.ErrorRate,
+		StatusCode:       d.StatusCode,
+		StatusMessage:    d.StatusMessage,
+		StreamDisruption: d.StreamDisruption,
+	}
 }
 
 // Proxy defines the parameters used by the proxy for processing grpc requests and its execution state
@@ -39,38 +74,60 @@ type proxy struct {
 
 // NewProxy return a new Proxy
 func NewProxy(listener net.Listener, upstreamAddress string, d Disruption) (protocol.Proxy, error) {
+	return newProxy(listener, upstreamAddress, d)
+}
+
+// NewEgressProxy returns a new Proxy for the grpc requests sent by the target to an upstream. The connections to the
+// upstream are marked so they are not redirected back to the proxy.
+func NewEgressProxy(listener net.Listener, upstreamAddress string, d Disruption) (protocol.Proxy, error) {
+	dialer := protocol.MarkedDialer()
+	dial := func(ctx context.Context, address string) (net.Conn, error) {
+		return dialer.DialContext(ctx, "tcp", address)
+	}
+
+	return newProxy(listener, upstreamAddress, d, grpc.WithContextDialer(dial))
+}
+
+func newProxy(
+	listener net.Listener,
+	upstreamAddress string,
+	d Disruption,
+	dialOptions ...grpc.DialOption,
+) (protocol.Proxy, error) {
 	if upstreamAddress == "" {
 		return nil, fmt.Errorf("proxy's forwarding address must be provided")
 	}
 
-	if d.DelayVariation > d.AverageDelay {
-		return nil, fmt.Errorf("variation must be less that average delay")
+	if err := validateFault(d.AverageDelay, d.DelayVariation, d.ErrorRate, d.StatusCode); err != nil {
+		return nil, err
+	}
+
+	if err := d.StreamDisruption.validate(d.StatusCode); err != nil {
+		return nil, err
 	}
 
-	if d.ErrorRate < 0.0 || d.ErrorRate > 1.0 {
-		return nil, fmt.Errorf("error rate must be in the range [0.0, 1.0]")
+	if err := d.Schedule.Validate(); err != nil {
+		return nil, err
 	}
 
-	if d.ErrorRate > 0.0 && d.StatusCode == 0 {
-		return nil, fmt.Errorf("status code cannot be 0 (OK)")
+	for i, rule := range d.Rules {
+		if err := rule.validate(); err != nil {
+			return nil, fmt.Errorf("invalid rule %d: %w", i, err)
+		}
 	}
 
 	ctx, cancel := context.WithCancel(context.Background())
 	conn, err := grpc.DialContext(
 		ctx,
 		upstreamAddress,
-		grpc.WithInsecure(),
+		append([]grpc.DialOption{grpc.WithInsecure()}, dialOptions...)...,
 	)
 	if err != nil {
 		cancel()
 		return nil, fmt.Errorf("error dialing %s: %w", upstreamAddress, err)
 	}
 
-	metrics := protocol.NewMetricMap(
-		protocol.MetricRequests,
-		protocol.MetricRequestsExcluded,
-		protocol.MetricRequestsDisrupted,
-	)
+	metrics := protocol.NewMetricMap(supportedMetrics()...)
 
 	handler := NewHandler(d, conn, metrics)
 
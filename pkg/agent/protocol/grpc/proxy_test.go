@@ -95,6 +95,26 @@ func Test_Validations(t *testing.T) {
 			upstream:    ":8080",
 			expectError: true,
 		},
+		{
+			title: "invalid rule method pattern",
+			disruption: Disruption{
+				Rules: []Rule{
+					{Match: Match{Methods: []string{"/pkg.Service/[*"}}},
+				},
+			},
+			upstream:    ":8080",
+			expectError: true,
+		},
+		{
+			title: "invalid rule status code",
+			disruption: Disruption{
+				Rules: []Rule{
+					{Match: Match{Methods: []string{"/pkg.Service/*"}}, ErrorRate: 1.0},
+				},
+			},
+			upstream:    ":8080",
+			expectError: true,
+		},
 		{
 			title: "negative error rate",
 			disruption: Disruption{
@@ -140,6 +160,7 @@ func Test_ProxyHandler(t *testing.T) {
 	type TestCase struct {
 		title        string
 		disruption   Disruption
+		metadata     metadata.MD
 		request      *ping.PingRequest
 		response     *ping.PingResponse
 		expectStatus codes.Code
@@ -181,6 +202,65 @@ func Test_ProxyHandler(t *testing.T) {
 			response:     nil,
 			expectStatus: codes.Internal,
 		},
+		{
+			title: "rule for method",
+			disruption: Disruption{
+				ErrorRate:  1.0,
+				StatusCode: uint32(codes.Internal),
+				Rules: []Rule{
+					{
+						Match:      Match{Methods: []string{"/disruptor.testproto.PingService/*"}},
+						ErrorRate:  1.0,
+						StatusCode: uint32(codes.Unavailable),
+					},
+				},
+			},
+			request: &ping.PingRequest{
+				Error:   0,
+				Message: "ping",
+			},
+			response:     nil,
+			expectStatus: codes.Unavailable,
+		},
+		{
+			title: "rule for metadata excludes requests",
+			disruption: Disruption{
+				ErrorRate:  1.0,
+				StatusCode: uint32(codes.Internal),
+				Rules: []Rule{
+					{
+						Match: Match{Metadata: map[string]string{"x-skip-faults": ""}},
+					},
+				},
+			},
+			metadata: metadata.Pairs("x-skip-faults", "true"),
+			request: &ping.PingRequest{
+				Error:   0,
+				Message: "ping",
+			},
+			response: &ping.PingResponse{
+				Message: "ping",
+			},
+			expectStatus: codes.OK,
+		},
+		{
+			title: "rule for metadata does not match",
+			disruption: Disruption{
+				ErrorRate:  1.0,
+				StatusCode: uint32(codes.Internal),
+				Rules: []Rule{
+					{
+						Match: Match{Metadata: map[string]string{"x-skip-faults": ""}},
+					},
+				},
+			},
+			request: &ping.PingRequest{
+				Error:   0,
+				Message: "ping",
+			},
+			response:     nil,
+			expectStatus: codes.Internal,
+		},
 		{
 			title: "delay injection",
 			disruption: Disruption{
@@ -256,7 +336,7 @@ func Test_ProxyHandler(t *testing.T) {
 
 			var headers metadata.MD
 			response, err := client.Ping(
-				t.Context(),
+				metadata.NewOutgoingContext(t.Context(), tc.metadata),
 				tc.request,
 				grpc.Header(&headers),
 				grpc.WaitForReady(true),
@@ -312,6 +392,10 @@ func Test_ProxyMetrics(t *testing.T) {
 				protocol.MetricRequests:          0,
 				protocol.MetricRequestsDisrupted: 0,
 				protocol.MetricRequestsExcluded:  0,
+				MetricMessages:                   0,
+				MetricMessagesDelayed:            0,
+				MetricMessagesDropped:            0,
+				MetricStreamsAborted:             0,
 			},
 		},
 		{
@@ -327,6 +411,10 @@ func Test_ProxyMetrics(t *testing.T) {
 				protocol.MetricRequests:          1,
 				protocol.MetricRequestsDisrupted: 0,
 				protocol.MetricRequestsExcluded:  0,
+				MetricMessages:                   2,
+				MetricMessagesDelayed:            0,
+				MetricMessagesDropped:            0,
+				MetricStreamsAborted:             0,
 			},
 		},
 		{
@@ -342,6 +430,27 @@ func Test_ProxyMetrics(t *testing.T) {
 				protocol.MetricRequests:          1,
 				protocol.MetricRequestsDisrupted: 1,
 				protocol.MetricRequestsExcluded:  0,
+				MetricMessages:                   0,
+				MetricMessagesDelayed:            0,
+				MetricMessagesDropped:            0,
+				MetricStreamsAborted:             0,
+			},
+		},
+		{
+			title: "message delay",
+			disruption: Disruption{
+				StreamDisruption: StreamDisruption{
+					MessageDelay: 10 * time.Millisecond,
+				},
+			},
+			expectedMetrics: map[string]uint{
+				protocol.MetricRequests:          1,
+				protocol.MetricRequestsDisrupted: 1,
+				protocol.MetricRequestsExcluded:  0,
+				MetricMessages:                   2,
+				MetricMessagesDelayed:            2,
+				MetricMessagesDropped:            0,
+				MetricStreamsAborted:             0,
 			},
 		},
 	}
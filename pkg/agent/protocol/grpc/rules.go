@@ -0,0 +1,178 @@
+package grpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/grafana/xk6-disruptor/pkg/agent/schedule"
+	"google.golang.org/grpc/metadata"
+)
+
+// Match selects requests by their method and metadata. A request matches if it satisfies all the criteria specified.
+// An empty Match selects all requests.
+//
+// Methods are given as patterns for the full method name, with the form "/package.Service/Method". Patterns follow
+// the syntax of path.Match, so "/package.Service/*" matches all the methods of a service and "/*/Method" matches the
+// method in any service.
+type Match struct {
+	// Methods selects the requests to any of these methods
+	Methods []string `json:"methods,omitempty"`
+	// ExcludeMethods excludes the requests to any of these methods. An excluded request can match a later rule, but it
+	// is not disrupted with the default disruption if none does.
+	ExcludeMethods []string `json:"excludeMethods,omitempty"`
+	// Metadata selects the requests that have all these metadata keys. If the value of a key is empty, the key must
+	// be present with any value. Otherwise, any of its values must be equal to the given value.
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// Rule defines the disruption applied to the requests that match a Match
+type Rule struct {
+	Match Match `json:"match"`
+	// Average delay introduced to requests
+	AverageDelay time.Duration `json:"averageDelay,omitempty"`
+	// Variation in the delay (with respect of the average delay)
+	DelayVariation time.Duration `json:"delayVariation,omitempty"`
+	// Fraction (in the range 0.0 to 1.0) of requests that will return an error
+	ErrorRate float32 `json:"errorRate,omitempty"`
+	// Status code to be returned by requests selected to return an error
+	StatusCode uint32 `json:"statusCode,omitempty"`
+	// Status message to be returned in requests selected to return an error
+	StatusMessage string `json:"statusMessage,omitempty"`
+	StreamDisruption
+}
+
+// ParseRules parses a list of rules in JSON format
+func ParseRules(values []string) ([]Rule, error) {
+	rules := []Rule{}
+	for _, value := range values {
+		decoder := json.NewDecoder(bytes.NewBufferString(value))
+		decoder.DisallowUnknownFields()
+
+		rule := Rule{}
+		if err := decoder.Decode(&rule); err != nil {
+			return nil, fmt.Errorf("parsing rule %q: %w", value, err)
+		}
+
+		rules = append(rules, rule)
+	}
+
+	return rules, nil
+}
+
+// validate checks the rule's disruption and method patterns are valid
+func (r Rule) validate() error {
+	if err := validateFault(r.AverageDelay, r.DelayVariation, r.ErrorRate, r.StatusCode); err != nil {
+		return err
+	}
+
+	if err := r.StreamDisruption.validate(r.StatusCode); err != nil {
+		return err
+	}
+
+	for _, pattern := range slices.Concat(r.Match.Methods, r.Match.ExcludeMethods) {
+		if _, err := path.Match(methodPattern(pattern), ""); err != nil {
+			return fmt.Errorf("invalid method pattern %q: %w", pattern, err)
+		}
+	}
+
+	return nil
+}
+
+// scaled returns the rule with its delays and error rate multiplied by the level of a schedule. With a level of zero,
+// the rule does not disrupt the requests.
+func (r Rule) scaled(level float64) Rule {
+	if level == 0 {
+		return Rule{}
+	}
+
+	r.AverageDelay = schedule.Duration(r.AverageDelay, level)
+	r.DelayVariation = schedule.Duration(r.DelayVariation, level)
+	r.ErrorRate = float32(schedule.Rate(float64(r.ErrorRate), level))
+
+	return r
+}
+
+// validateFault checks the delay and error settings of a fault
+func validateFault(averageDelay, delayVariation time.Duration, errorRate float32, statusCode uint32) error {
+	if delayVariation > averageDelay {
+		return fmt.Errorf("variation must be less that average delay")
+	}
+
+	if errorRate < 0.0 || errorRate > 1.0 {
+		return fmt.Errorf("error rate must be in the range [0.0, 1.0]")
+	}
+
+	if errorRate > 0.0 && statusCode == 0 {
+		return fmt.Errorf("status code cannot be 0 (OK)")
+	}
+
+	return nil
+}
+
+// methodPattern returns the pattern with the leading '/' of full method names, which is optional in the rules
+func methodPattern(pattern string) string {
+	if strings.HasPrefix(pattern, "/") {
+		return pattern
+	}
+
+	return "/" + pattern
+}
+
+// matchesMethod returns if the full method name matches any of the patterns
+func matchesMethod(patterns []string, fullMethodName string) bool {
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(methodPattern(pattern), fullMethodName); matched {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matches returns if a request to the method with the given metadata satisfies all the criteria of the Match
+func (m Match) matches(fullMethodName string, md metadata.MD) bool {
+	if len(m.Methods) > 0 && !matchesMethod(m.Methods, fullMethodName) {
+		return false
+	}
+
+	if matchesMethod(m.ExcludeMethods, fullMethodName) {
+		return false
+	}
+
+	for key, value := range m.Metadata {
+		if !matchesValues(md.Get(key), value) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// excludes returns if a request to the method with the given metadata satisfies all the criteria of the Match, except
+// that its method is excluded
+func (m Match) excludes(fullMethodName string, md metadata.MD) bool {
+	if !matchesMethod(m.ExcludeMethods, fullMethodName) {
+		return false
+	}
+
+	return Match{Methods: m.Methods, Metadata: m.Metadata}.matches(fullMethodName, md)
+}
+
+// matchesValues returns if any of the values is equal to the expected value. An empty expected value matches any
+// value, but at least one value must exist.
+func matchesValues(values []string, expected string) bool {
+	if len(values) == 0 {
+		return false
+	}
+
+	if expected == "" {
+		return true
+	}
+
+	return contains(values, expected)
+}
@@ -0,0 +1,315 @@
+package grpc
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/grpc/metadata"
+)
+
+func Test_Match(t *testing.T) {
+	t.Parallel()
+
+	const method = "/disruptor.testproto.PingService/Ping"
+
+	testCases := []struct {
+		title    string
+		match    Match
+		method   string
+		md       metadata.MD
+		expected bool
+	}{
+		{
+			title:    "empty match",
+			match:    Match{},
+			method:   method,
+			expected: true,
+		},
+		{
+			title:    "exact method",
+			match:    Match{Methods: []string{method}},
+			method:   method,
+			expected: true,
+		},
+		{
+			title:    "method without leading slash",
+			match:    Match{Methods: []string{"disruptor.testproto.PingService/Ping"}},
+			method:   method,
+			expected: true,
+		},
+		{
+			title:    "service wildcard",
+			match:    Match{Methods: []string{"/disruptor.testproto.PingService/*"}},
+			method:   method,
+			expected: true,
+		},
+		{
+			title:    "method in any service",
+			match:    Match{Methods: []string{"/*/Ping"}},
+			method:   method,
+			expected: true,
+		},
+		{
+			title:    "method does not match",
+			match:    Match{Methods: []string{"/disruptor.testproto.PingService/Pong"}},
+			method:   method,
+			expected: false,
+		},
+		{
+			title: "excluded method",
+			match: Match{
+				Methods:        []string{"/disruptor.testproto.PingService/*"},
+				ExcludeMethods: []string{"/disruptor.testproto.PingService/Ping"},
+			},
+			method:   method,
+			expected: false,
+		},
+		{
+			title:    "metadata presence",
+			match:    Match{Metadata: map[string]string{"x-canary": ""}},
+			method:   method,
+			md:       metadata.Pairs("x-canary", "yes"),
+			expected: true,
+		},
+		{
+			title:    "metadata value",
+			match:    Match{Metadata: map[string]string{"X-Tenant": "acme"}},
+			method:   method,
+			md:       metadata.Pairs("x-tenant", "other", "x-tenant", "acme"),
+			expected: true,
+		},
+		{
+			title:    "metadata missing",
+			match:    Match{Metadata: map[string]string{"x-canary": ""}},
+			method:   method,
+			md:       metadata.MD{},
+			expected: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			t.Parallel()
+
+			if actual := tc.match.matches(tc.method, tc.md); actual != tc.expected {
+				t.Fatalf("expected %t got %t", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func Test_DisruptionRule(t *testing.T) {
+	t.Parallel()
+
+	const (
+		ping = "/disruptor.testproto.PingService/Ping"
+		pong = "/disruptor.testproto.PingService/Pong"
+	)
+
+	testCases := []struct {
+		title    string
+		rules    []Rule
+		method   string
+		md       metadata.MD
+		expected Rule
+	}{
+		{
+			title:    "no rules",
+			method:   ping,
+			expected: Rule{ErrorRate: 0.5, StatusCode: 14},
+		},
+		{
+			title: "matching rule",
+			rules: []Rule{
+				{Match: Match{Methods: []string{ping}}, ErrorRate: 1.0, StatusCode: 13},
+			},
+			method:   ping,
+			expected: Rule{Match: Match{Methods: []string{ping}}, ErrorRate: 1.0, StatusCode: 13},
+		},
+		{
+			title: "no matching rule",
+			rules: []Rule{
+				{Match: Match{Methods: []string{pong}}, ErrorRate: 1.0, StatusCode: 13},
+			},
+			method:   ping,
+			expected: Rule{ErrorRate: 0.5, StatusCode: 14},
+		},
+		{
+			title: "excluded method is not disrupted",
+			rules: []Rule{
+				{Match: Match{ExcludeMethods: []string{ping}}, ErrorRate: 1.0, StatusCode: 13},
+			},
+			method:   ping,
+			expected: Rule{},
+		},
+		{
+			title: "excluded method matches later rule",
+			rules: []Rule{
+				{Match: Match{ExcludeMethods: []string{ping}}, ErrorRate: 1.0, StatusCode: 13},
+				{Match: Match{Methods: []string{ping}}, AverageDelay: time.Second},
+			},
+			method:   ping,
+			expected: Rule{Match: Match{Methods: []string{ping}}, AverageDelay: time.Second},
+		},
+		{
+			title: "excluded method of a rule with other criteria",
+			rules: []Rule{
+				{
+					Match:      Match{ExcludeMethods: []string{ping}, Metadata: map[string]string{"x-canary": ""}},
+					ErrorRate:  1.0,
+					StatusCode: 13,
+				},
+			},
+			method:   ping,
+			md:       metadata.MD{},
+			expected: Rule{ErrorRate: 0.5, StatusCode: 14},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			t.Parallel()
+
+			d := Disruption{
+				ErrorRate:  0.5,
+				StatusCode: 14,
+				Rules:      tc.rules,
+			}
+
+			rule := d.rule(tc.method, tc.md)
+			if diff := cmp.Diff(tc.expected, rule); diff != "" {
+				t.Fatalf("rule does not match expected:\n%s", diff)
+			}
+		})
+	}
+}
+
+func Test_ParseRules(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		title       string
+		values      []string
+		expected    []Rule
+		expectError bool
+	}{
+		{
+			title:    "no rules",
+			values:   []string{},
+			expected: []Rule{},
+		},
+		{
+			title: "rules",
+			values: []string{
+				`{"match":{"methods":["/pkg.Service/*"],"excludeMethods":["/pkg.Service/Health"]},` +
+					`"errorRate":1,"statusCode":14}`,
+				`{"match":{"metadata":{"x-canary":""}},"averageDelay":100000000}`,
+			},
+			expected: []Rule{
+				{
+					Match: Match{
+						Methods:        []string{"/pkg.Service/*"},
+						ExcludeMethods: []string{"/pkg.Service/Health"},
+					},
+					ErrorRate:  1,
+					StatusCode: 14,
+				},
+				{
+					Match:        Match{Metadata: map[string]string{"x-canary": ""}},
+					AverageDelay: 100 * time.Millisecond,
+				},
+			},
+		},
+		{
+			title:       "unknown field",
+			values:      []string{`{"match":{"service":"pkg.Service"}}`},
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			t.Parallel()
+
+			actual, err := ParseRules(tc.values)
+			if tc.expectError && err == nil {
+				t.Fatalf("should had failed")
+			}
+
+			if !tc.expectError && err != nil {
+				t.Fatalf("failed: %v", err)
+			}
+
+			if tc.expectError {
+				return
+			}
+
+			if diff := cmp.Diff(tc.expected, actual); diff != "" {
+				t.Fatalf("Parsed rules do not match expected:\n%s", diff)
+			}
+		})
+	}
+}
+
+func Test_RuleScaled(t *testing.T) {
+	t.Parallel()
+
+	rule := Rule{
+		AverageDelay:   100 * time.Millisecond,
+		DelayVariation: 20 * time.Millisecond,
+		ErrorRate:      0.4,
+		StatusCode:     14,
+	}
+
+	testCases := []struct {
+		title             string
+		level             float64
+		expectedDelay     time.Duration
+		expectedVariation time.Duration
+		expectedRate      float32
+	}{
+		{
+			title:             "full level",
+			level:             1.0,
+			expectedDelay:     100 * time.Millisecond,
+			expectedVariation: 20 * time.Millisecond,
+			expectedRate:      0.4,
+		},
+		{
+			title:             "half level",
+			level:             0.5,
+			expectedDelay:     50 * time.Millisecond,
+			expectedVariation: 10 * time.Millisecond,
+			expectedRate:      0.2,
+		},
+		{
+			title:             "rate is limited",
+			level:             3.0,
+			expectedDelay:     300 * time.Millisecond,
+			expectedVariation: 60 * time.Millisecond,
+			expectedRate:      1.0,
+		},
+		{
+			title: "zero level",
+			level: 0.0,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			t.Parallel()
+
+			scaled := rule.scaled(tc.level)
+			if scaled.AverageDelay != tc.expectedDelay || scaled.DelayVariation != tc.expectedVariation {
+				t.Fatalf("expected delay %v±%v but got %v±%v",
+					tc.expectedDelay, tc.expectedVariation, scaled.AverageDelay, scaled.DelayVariation)
+			}
+
+			if math.Abs(float64(scaled.ErrorRate-tc.expectedRate)) > 1e-6 {
+				t.Fatalf("expected error rate %f but got %f", tc.expectedRate, scaled.ErrorRate)
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/grafana/xk6-disruptor/pkg/agent/protocol"
+)
+
+const (
+	// MetricMessages is the total number of messages received by the proxy in the streams, in either direction.
+	MetricMessages = "messages_total"
+	// MetricMessagesDelayed is the total number of messages delayed by the proxy.
+	MetricMessagesDelayed = "messages_delayed"
+	// MetricMessagesDropped is the total number of messages dropped by the proxy.
+	MetricMessagesDropped = "messages_dropped"
+	// MetricStreamsAborted is the total number of streams aborted by the proxy.
+	MetricStreamsAborted = "streams_aborted"
+)
+
+// errStreamAborted is returned when forwarding the messages of a stream that must be aborted
+var errStreamAborted = errors.New("stream aborted")
+
+// StreamDisruption specifies disruptions applied to the individual messages of a stream. As unary requests are
+// streams with a single message in each direction, they are also affected.
+// Streams are aborted with the status code and message of the disruption.
+type StreamDisruption struct {
+	// Delay introduced to each message, in either direction
+	MessageDelay time.Duration `json:"messageDelay,omitempty"`
+	// Fraction (in the range 0.0 to 1.0) of messages that are dropped, in either direction
+	MessageDropRate float32 `json:"messageDropRate,omitempty"`
+	// Number of messages sent to the client after which the stream is aborted
+	AbortAfterMessages uint `json:"abortAfterMessages,omitempty"`
+	// Maximum time after which the stream is aborted. The stream is aborted at a random time up to this duration.
+	AbortAfter time.Duration `json:"abortAfter,omitempty"`
+}
+
+// IsEmpty returns if the StreamDisruption does not define any disruption
+func (s StreamDisruption) IsEmpty() bool {
+	return s == StreamDisruption{}
+}
+
+// aborts returns if the StreamDisruption aborts streams
+func (s StreamDisruption) aborts() bool {
+	return s.AbortAfterMessages > 0 || s.AbortAfter > 0
+}
+
+// validate checks the StreamDisruption is valid. The status code is required for aborting streams.
+func (s StreamDisruption) validate(statusCode uint32) error {
+	if s.MessageDelay < 0 || s.AbortAfter < 0 {
+		return fmt.Errorf("message delay and abort time must be positive")
+	}
+
+	if s.MessageDropRate < 0.0 || s.MessageDropRate > 1.0 {
+		return fmt.Errorf("message drop rate must be in the range [0.0, 1.0]")
+	}
+
+	if s.aborts() && statusCode == 0 {
+		return fmt.Errorf("status code cannot be 0 (OK) when aborting streams")
+	}
+
+	return nil
+}
+
+// abortTimer returns a channel that fires when the stream must be aborted. If the disruption does not abort streams
+// after a time, the channel never fires.
+func (s StreamDisruption) abortTimer() <-chan time.Time {
+	if s.AbortAfter <= 0 {
+		return nil
+	}
+
+	return time.After(time.Duration(rand.Int63n(int64(s.AbortAfter))) + 1)
+}
+
+// disruptMessage applies the disruption to a message received by the proxy and returns if it must be forwarded.
+// The message is not forwarded if the context of the stream is done while it is delayed.
+func (s StreamDisruption) disruptMessage(ctx context.Context, metrics *protocol.MetricMap) bool {
+	metrics.Inc(MetricMessages)
+
+	if s.MessageDropRate > 0 && rand.Float32() < s.MessageDropRate {
+		metrics.Inc(MetricMessagesDropped)
+		return false
+	}
+
+	if s.MessageDelay > 0 {
+		metrics.Inc(MetricMessagesDelayed)
+
+		timer := time.NewTimer(s.MessageDelay)
+		defer timer.Stop()
+
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	return true
+}
+
+// supportedMetrics returns the metrics that the grpc proxy supports and thus should be pre-initialized to zero.
+func supportedMetrics() []string {
+	return []string{
+		protocol.MetricRequests,
+		protocol.MetricRequestsExcluded,
+		protocol.MetricRequestsDisrupted,
+		MetricMessages,
+		MetricMessagesDelayed,
+		MetricMessagesDropped,
+		MetricStreamsAborted,
+	}
+}
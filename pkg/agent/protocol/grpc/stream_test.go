@@ -0,0 +1,303 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/grafana/xk6-disruptor/pkg/agent/protocol"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// countMethod is a server streaming method that sends the number of messages requested
+const countMethod = "/disruptor.testproto.CountService/Count"
+
+// countServiceDesc describes a service with a server streaming method. It is defined manually to avoid generating
+// code for the test.
+var countServiceDesc = grpc.ServiceDesc{ //nolint:gochecknoglobals
+	ServiceName: "disruptor.testproto.CountService",
+	HandlerType: (*any)(nil),
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Count",
+			ServerStreams: true,
+			Handler: func(_ any, stream grpc.ServerStream) error {
+				request := &wrapperspb.UInt32Value{}
+				if err := stream.RecvMsg(request); err != nil {
+					return err
+				}
+
+				for i := range request.Value {
+					if err := stream.SendMsg(wrapperspb.String(fmt.Sprint(i))); err != nil {
+						return err
+					}
+					time.Sleep(10 * time.Millisecond)
+				}
+
+				return nil
+			},
+		},
+	},
+}
+
+// count requests the given number of messages and returns the number of messages received and the final status
+func count(conn *grpc.ClientConn, messages uint32) (int, codes.Code, error) {
+	stream, err := conn.NewStream(
+		context.Background(),
+		&grpc.StreamDesc{ServerStreams: true},
+		countMethod,
+		grpc.WaitForReady(true),
+	)
+	if err != nil {
+		return 0, codes.Unknown, err
+	}
+
+	if err = stream.SendMsg(wrapperspb.UInt32(messages)); err != nil {
+		return 0, codes.Unknown, err
+	}
+
+	if err = stream.CloseSend(); err != nil {
+		return 0, codes.Unknown, err
+	}
+
+	received := 0
+	for {
+		err = stream.RecvMsg(&wrapperspb.StringValue{})
+		if errors.Is(err, io.EOF) {
+			return received, codes.OK, nil
+		}
+		if err != nil {
+			return received, status.Code(err), nil
+		}
+		received++
+	}
+}
+
+func Test_StreamDisruption(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		title            string
+		disruption       Disruption
+		messages         uint32
+		expectedReceived int
+		expectedStatus   codes.Code
+		expectedMetrics  map[string]uint
+	}{
+		{
+			title:            "no disruption",
+			disruption:       Disruption{},
+			messages:         5,
+			expectedReceived: 5,
+			expectedStatus:   codes.OK,
+			expectedMetrics: map[string]uint{
+				MetricMessages:        6,
+				MetricMessagesDelayed: 0,
+				MetricMessagesDropped: 0,
+				MetricStreamsAborted:  0,
+			},
+		},
+		{
+			title: "abort after messages",
+			disruption: Disruption{
+				StatusCode:    uint32(codes.Unavailable),
+				StatusMessage: "aborted",
+				StreamDisruption: StreamDisruption{
+					AbortAfterMessages: 3,
+				},
+			},
+			messages:         5,
+			expectedReceived: 3,
+			expectedStatus:   codes.Unavailable,
+			expectedMetrics: map[string]uint{
+				MetricMessages:        4,
+				MetricMessagesDelayed: 0,
+				MetricMessagesDropped: 0,
+				MetricStreamsAborted:  1,
+			},
+		},
+		{
+			title: "drop all messages",
+			disruption: Disruption{
+				Rules: []Rule{
+					{
+						Match: Match{Methods: []string{countMethod}},
+						StreamDisruption: StreamDisruption{
+							MessageDropRate: 1.0,
+						},
+					},
+				},
+			},
+			messages:         0,
+			expectedReceived: 0,
+			expectedStatus:   codes.Internal,
+			expectedMetrics: map[string]uint{
+				MetricMessages:        1,
+				MetricMessagesDelayed: 0,
+				MetricMessagesDropped: 1,
+				MetricStreamsAborted:  0,
+			},
+		},
+		{
+			title: "delay messages",
+			disruption: Disruption{
+				StreamDisruption: StreamDisruption{
+					MessageDelay: 5 * time.Millisecond,
+				},
+			},
+			messages:         3,
+			expectedReceived: 3,
+			expectedStatus:   codes.OK,
+			expectedMetrics: map[string]uint{
+				MetricMessages:        4,
+				MetricMessagesDelayed: 4,
+				MetricMessagesDropped: 0,
+				MetricStreamsAborted:  0,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			t.Parallel()
+
+			upstreamListener, err := net.Listen("tcp", "localhost:0")
+			if err != nil {
+				t.Fatalf("error starting test upstream listener: %v", err)
+			}
+			srv := grpc.NewServer()
+			srv.RegisterService(&countServiceDesc, nil)
+			go func() {
+				_ = srv.Serve(upstreamListener)
+			}()
+			t.Cleanup(srv.Stop)
+
+			proxyListener, err := net.Listen("tcp", "localhost:0")
+			if err != nil {
+				t.Fatalf("error starting test proxy listener: %v", err)
+			}
+
+			proxy, err := NewProxy(proxyListener, upstreamListener.Addr().String(), tc.disruption)
+			if err != nil {
+				t.Fatalf("error creating proxy: %v", err)
+			}
+			t.Cleanup(func() {
+				_ = proxy.Stop()
+			})
+
+			go func() {
+				_ = proxy.Start()
+			}()
+
+			conn, err := grpc.DialContext(
+				t.Context(),
+				proxyListener.Addr().String(),
+				grpc.WithInsecure(),
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(func() {
+				_ = conn.Close()
+			})
+
+			received, code, err := count(conn, tc.messages)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if received != tc.expectedReceived {
+				t.Errorf("expected %d messages but received %d", tc.expectedReceived, received)
+			}
+
+			if code != tc.expectedStatus {
+				t.Errorf("expected '%s' but got '%s'", tc.expectedStatus, code)
+			}
+
+			metrics := proxy.Metrics()
+			for metric, expected := range tc.expectedMetrics {
+				if metrics[metric] != expected {
+					t.Errorf("expected %s to be %d but got %d", metric, expected, metrics[metric])
+				}
+			}
+		})
+	}
+}
+
+func Test_StreamDisruptionValidation(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		title       string
+		disruption  StreamDisruption
+		statusCode  uint32
+		expectError bool
+	}{
+		{
+			title:       "empty",
+			disruption:  StreamDisruption{},
+			expectError: false,
+		},
+		{
+			title:       "abort with status",
+			disruption:  StreamDisruption{AbortAfter: time.Second},
+			statusCode:  uint32(codes.Unavailable),
+			expectError: false,
+		},
+		{
+			title:       "abort without status",
+			disruption:  StreamDisruption{AbortAfterMessages: 1},
+			expectError: true,
+		},
+		{
+			title:       "invalid drop rate",
+			disruption:  StreamDisruption{MessageDropRate: 1.5},
+			expectError: true,
+		},
+		{
+			title:       "negative message delay",
+			disruption:  StreamDisruption{MessageDelay: -time.Second},
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			t.Parallel()
+
+			err := tc.disruption.validate(tc.statusCode)
+			if tc.expectError && err == nil {
+				t.Errorf("should had failed")
+			}
+
+			if !tc.expectError && err != nil {
+				t.Errorf("failed: %v", err)
+			}
+		})
+	}
+}
+
+func Test_DisruptMessageCanceled(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+
+	disruption := StreamDisruption{MessageDelay: time.Hour}
+
+	start := time.Now()
+	if disruption.disruptMessage(ctx, protocol.NewMetricMap(supportedMetrics()...)) {
+		t.Fatalf("expected message not to be forwarded once the stream is done")
+	}
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("expected delay to end when the stream is done, but took %v", elapsed)
+	}
+}
@@ -0,0 +1,218 @@
+package http
+
+import (
+	"crypto/tls"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// buildProtocols returns the Protocols with the given protocols enabled
+func buildProtocols(http1, http2, unencryptedHTTP2 bool) *http.Protocols {
+	protocols := new(http.Protocols)
+	protocols.SetHTTP1(http1)
+	protocols.SetHTTP2(http2)
+	protocols.SetUnencryptedHTTP2(unencryptedHTTP2)
+
+	return protocols
+}
+
+func Test_ProxyProtocols(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		title         string
+		tls           bool
+		clientProto   *http.Protocols
+		expectedProto string
+	}{
+		{
+			title:         "HTTP/1",
+			clientProto:   buildProtocols(true, false, false),
+			expectedProto: "HTTP/1.1",
+		},
+		{
+			title:         "h2c",
+			clientProto:   buildProtocols(false, false, true),
+			expectedProto: "HTTP/2.0",
+		},
+		{
+			title:         "HTTP/1 over TLS",
+			tls:           true,
+			clientProto:   buildProtocols(true, false, false),
+			expectedProto: "HTTP/1.1",
+		},
+		{
+			title:         "HTTP/2 over TLS",
+			tls:           true,
+			clientProto:   buildProtocols(false, true, false),
+			expectedProto: "HTTP/2.0",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			t.Parallel()
+
+			// the upstream returns the protocol of the request and a trailer
+			upstreamServer := httptest.NewUnstartedServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+				rw.Header().Set("Trailer", "X-Checksum")
+				rw.Header().Set("X-Proto", r.Proto)
+				rw.WriteHeader(http.StatusOK)
+				_, _ = rw.Write([]byte("body"))
+				rw.Header().Set("X-Checksum", "checksum")
+			}))
+			upstreamServer.Config.Protocols = buildProtocols(true, true, true)
+			upstreamServer.EnableHTTP2 = true
+			if tc.tls {
+				upstreamServer.StartTLS()
+			} else {
+				upstreamServer.Start()
+			}
+			t.Cleanup(upstreamServer.Close)
+
+			listener, err := net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatalf("creating listener: %v", err)
+			}
+
+			scheme := "http://"
+			var clientTLS *tls.Config
+			if tc.tls {
+				_, caData := buildCA(t)
+				cert, err := GenerateCertificate(caData, []string{"127.0.0.1"})
+				if err != nil {
+					t.Fatalf("generating certificate: %v", err)
+				}
+
+				listener = tls.NewListener(listener, &tls.Config{
+					Certificates: []tls.Certificate{cert},
+					MinVersion:   tls.VersionTLS12,
+					NextProtos:   []string{"h2", "http/1.1"},
+				})
+				scheme = "https://"
+				clientTLS = &tls.Config{
+					InsecureSkipVerify: true, //nolint:gosec // the certificate is not relevant for the test
+					MinVersion:         tls.VersionTLS12,
+				}
+			}
+
+			proxy, err := NewProxy(listener, upstreamServer.URL, Disruption{})
+			if err != nil {
+				t.Fatalf("creating proxy: %v", err)
+			}
+
+			go func() {
+				_ = proxy.Start()
+			}()
+			t.Cleanup(func() { _ = proxy.Force() })
+
+			client := &http.Client{
+				Transport: &http.Transport{
+					Protocols:       tc.clientProto,
+					TLSClientConfig: clientTLS,
+				},
+			}
+
+			resp, err := client.Get(scheme + listener.Addr().String())
+			if err != nil {
+				t.Fatalf("making request to proxy: %v", err)
+			}
+
+			body, err := io.ReadAll(resp.Body)
+			_ = resp.Body.Close()
+			if err != nil {
+				t.Fatalf("reading response body: %v", err)
+			}
+
+			if resp.Proto != tc.expectedProto {
+				t.Fatalf("expected response protocol %q but %q received", tc.expectedProto, resp.Proto)
+			}
+
+			if upstreamProto := resp.Header.Get("X-Proto"); upstreamProto != tc.expectedProto {
+				t.Fatalf("expected upstream protocol %q but %q received", tc.expectedProto, upstreamProto)
+			}
+
+			if string(body) != "body" {
+				t.Fatalf("expected body %q but %q received", "body", string(body))
+			}
+
+			if checksum := resp.Trailer.Get("X-Checksum"); checksum != "checksum" {
+				t.Fatalf("expected trailer %q but %q received", "checksum", checksum)
+			}
+		})
+	}
+}
+
+func Test_ProxyStreaming(t *testing.T) {
+	t.Parallel()
+
+	// the upstream sends the second part of the body only after the client receives the first one
+	release := make(chan struct{})
+	upstreamServer := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, _ *http.Request) {
+		rw.WriteHeader(http.StatusOK)
+		_, _ = rw.Write([]byte("first"))
+		_ = http.NewResponseController(rw).Flush()
+
+		select {
+		case <-release:
+		case <-time.After(5 * time.Second):
+		}
+
+		_, _ = rw.Write([]byte("second"))
+	}))
+	t.Cleanup(upstreamServer.Close)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("creating listener: %v", err)
+	}
+
+	proxy, err := NewProxy(listener, upstreamServer.URL, Disruption{})
+	if err != nil {
+		t.Fatalf("creating proxy: %v", err)
+	}
+
+	go func() {
+		_ = proxy.Start()
+	}()
+	t.Cleanup(func() { _ = proxy.Force() })
+
+	resp, err := http.Get("http://" + listener.Addr().String())
+	if err != nil {
+		t.Fatalf("making request to proxy: %v", err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	received := make(chan []byte)
+	go func() {
+		first := make([]byte, len("first"))
+		_, _ = io.ReadFull(resp.Body, first)
+		received <- first
+	}()
+
+	select {
+	case first := <-received:
+		if string(first) != "first" {
+			t.Fatalf("expected %q but %q received", "first", string(first))
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("first part of the body was not flushed")
+	}
+
+	close(release)
+
+	rest, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("reading response body: %v", err)
+	}
+
+	if string(rest) != "second" {
+		t.Fatalf("expected %q but %q received", "second", string(rest))
+	}
+}
@@ -2,7 +2,9 @@
 package http
 
 import (
+	"bytes"
 	"context"
+	"crypto/tls"
 	"errors"
 	"fmt"
 	"io"
@@ -10,10 +12,12 @@ import (
 	"net"
 	"net/http"
 	"net/url"
+	"slices"
 	"strings"
 	"time"
 
 	"github.com/grafana/xk6-disruptor/pkg/agent/protocol"
+	"github.com/grafana/xk6-disruptor/pkg/agent/schedule"
 )
 
 // Disruption specifies disruptions in http requests
@@ -30,6 +34,39 @@ type Disruption struct {
 	ErrorBody string
 	// List of url paths to be excluded from disruptions
 	Excluded []string
+	// Rules define the disruption of the requests that match them. The first rule that matches a request is applied.
+	// The requests that do not match any rule are disrupted with the settings above.
+	Rules []Rule
+	// Schedule varies the delays and error rates of the disruption and its rules during the injection
+	Schedule schedule.Schedule
+	ResponseDisruption
+	BodyThrottling
+	StreamDisruption
+}
+
+// defaultRule returns the rule for the requests that do not match any of the disruption's rules
+func (d Disruption) defaultRule() Rule {
+	return Rule{
+		AverageDelay:       d.AverageDelay,
+		DelayVariation:     d.DelayVariation,
+		ErrorRate:          d.ErrorRate,
+		ErrorCode:          d.ErrorCode,
+		ErrorBody:          d.ErrorBody,
+		ResponseDisruption: d.ResponseDisruption,
+		BodyThrottling:     d.BodyThrottling,
+		StreamDisruption:   d.StreamDisruption,
+	}
+}
+
+// rule returns the rule that applies to the request
+func (d Disruption) rule(r *http.Request) Rule {
+	for _, rule := range d.Rules {
+		if rule.Match.matches(r) {
+			return rule
+		}
+	}
+
+	return d.defaultRule()
 }
 
 // Proxy defines the parameters used by the proxy for processing http requests and its execution state
@@ -40,22 +77,84 @@ type proxy struct {
 	metrics    *protocol.MetricMap
 }
 
-// NewProxy return a new Proxy for HTTP requests
+// NewProxy return a new Proxy for HTTP requests.
+// If the upstream address uses the https scheme, the requests are re-encrypted to the upstream. As the upstream
+// is the target the proxy sits in front of, its certificate is not verified.
 func NewProxy(listener net.Listener, upstreamAddress string, d Disruption) (protocol.Proxy, error) {
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+	}
+
+	transport := newTransport(dialer)
+	transport.TLSClientConfig = &tls.Config{
+		InsecureSkipVerify: true, //nolint:gosec // the target's certificate is not issued for its address
+	}
+
+	return newProxy(listener, upstreamAddress, d, transport)
+}
+
+// NewEgressProxy returns a new Proxy for the HTTP requests sent by the target to an upstream. The connections to the
+// upstream are marked so they are not redirected back to the proxy. The certificate of an https upstream is verified,
+// as the upstream is addressed by the name the target uses for it.
+func NewEgressProxy(listener net.Listener, upstreamAddress string, d Disruption) (protocol.Proxy, error) {
+	return newProxy(listener, upstreamAddress, d, newTransport(protocol.MarkedDialer()))
+}
+
+// newTransport returns a transport for forwarding requests to the upstream with the settings of
+// http.DefaultTransport. The default transport is not cloned because it may have been already configured for HTTP/2,
+// preventing the transport to be restricted to HTTP/1.
+func newTransport(dialer *net.Dialer) *http.Transport {
+	return &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           dialer.DialContext,
+		MaxIdleConns:          100,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+}
+
+func newProxy(
+	listener net.Listener,
+	upstreamAddress string,
+	d Disruption,
+	transport *http.Transport,
+) (protocol.Proxy, error) {
 	if upstreamAddress == "" {
 		return nil, fmt.Errorf("proxy's forwarding address must be provided")
 	}
 
-	if d.DelayVariation > d.AverageDelay {
-		return nil, fmt.Errorf("variation must be less that average delay")
+	if err := validateFault(d.AverageDelay, d.DelayVariation, d.ErrorRate, d.ErrorCode); err != nil {
+		return nil, err
+	}
+
+	if err := d.ResponseDisruption.validate(); err != nil {
+		return nil, err
+	}
+
+	if err := d.BodyThrottling.validate(); err != nil {
+		return nil, err
+	}
+
+	if err := d.StreamDisruption.validate(); err != nil {
+		return nil, err
+	}
+
+	if err := validateStreamBody(d.ResponseDisruption, d.StreamDisruption); err != nil {
+		return nil, err
 	}
 
-	if d.ErrorRate < 0.0 || d.ErrorRate > 1.0 {
-		return nil, fmt.Errorf("error rate must be in the range [0.0, 1.0]")
+	if err := d.Schedule.Validate(); err != nil {
+		return nil, err
 	}
 
-	if d.ErrorRate > 0.0 && d.ErrorCode == 0 {
-		return nil, fmt.Errorf("error code must be a valid http error code")
+	// compile a copy of the rules to avoid modifying the caller's disruption
+	d.Rules = slices.Clone(d.Rules)
+	for i := range d.Rules {
+		if err := d.Rules[i].compile(); err != nil {
+			return nil, fmt.Errorf("invalid rule %d: %w", i, err)
+		}
 	}
 
 	upstreamURL, err := url.Parse(upstreamAddress)
@@ -63,20 +162,42 @@ func NewProxy(listener net.Listener, upstreamAddress string, d Disruption) (prot
 		return nil, err
 	}
 
+	// requests are forwarded to the upstream with the same protocol version they were received with
+	h2Transport := transport.Clone()
+	h2Transport.Protocols = new(http.Protocols)
+	if upstreamURL.Scheme == "https" {
+		h2Transport.Protocols.SetHTTP2(true)
+	} else {
+		h2Transport.Protocols.SetUnencryptedHTTP2(true)
+	}
+
+	transport.Protocols = new(http.Protocols)
+	transport.Protocols.SetHTTP1(true)
+
 	metrics := protocol.NewMetricMap(supportedMetrics()...)
 
 	handler := &httpHandler{
 		upstreamURL: *upstreamURL,
 		disruption:  d,
 		metrics:     metrics,
+		client:      &http.Client{Transport: transport},
+		h2Client:    &http.Client{Transport: h2Transport},
+		start:       time.Now(),
 	}
 
+	// accept HTTP/2 over TLS and cleartext HTTP/2 with prior knowledge (h2c), besides HTTP/1
+	protocols := new(http.Protocols)
+	protocols.SetHTTP1(true)
+	protocols.SetHTTP2(true)
+	protocols.SetUnencryptedHTTP2(true)
+
 	return &proxy{
 		listener:   listener,
 		disruption: d,
 		metrics:    metrics,
 		srv: &http.Server{
-			Handler: handler,
+			Handler:   handler,
+			Protocols: protocols,
 		},
 	}, nil
 }
@@ -86,6 +207,32 @@ type httpHandler struct {
 	upstreamURL url.URL
 	disruption  Disruption
 	metrics     *protocol.MetricMap
+	client      *http.Client
+	// client for forwarding HTTP/2 requests. If nil, client is used.
+	h2Client *http.Client
+	// start of the disruption, for evaluating its schedule
+	start time.Time
+}
+
+// upstreamRequest returns the request for forwarding the request to the upstream. The upstream request is canceled
+// if the client goes away, so long-lived responses such as event streams are not kept open.
+func (h *httpHandler) upstreamRequest(req *http.Request) *http.Request {
+	upstreamReq := req.Clone(req.Context())
+	upstreamReq.Host = h.upstreamURL.Host
+	upstreamReq.URL.Host = h.upstreamURL.Host
+	upstreamReq.URL.Scheme = h.upstreamURL.Scheme
+	upstreamReq.RequestURI = "" // It is an error to set this field in an HTTP client request.
+
+	return upstreamReq
+}
+
+// upstreamClient returns the client for forwarding the request, which keeps the protocol version of the request
+func (h *httpHandler) upstreamClient(req *http.Request) *http.Client {
+	if req.ProtoMajor == 2 && h.h2Client != nil {
+		return h.h2Client
+	}
+
+	return h.client
 }
 
 // isExcluded checks whether a request should be proxied through without any kind of modification whatsoever.
@@ -99,18 +246,18 @@ func (h *httpHandler) isExcluded(r *http.Request) bool {
 	return false
 }
 
-// forward forwards a request to the upstream URL.
-// Request is performed immediately, but response won't be sent before the duration specified in delay.
-func (h *httpHandler) forward(rw http.ResponseWriter, req *http.Request, delay time.Duration) {
-	timer := time.After(delay)
+// forward forwards a request to the upstream URL and applies the rule's response disruption and body throttling to
+// the upstream's response. Server-Sent Events streams and WebSocket connections are disrupted with the rule's stream
+// disruption. Request is performed immediately, but response won't be sent before the duration specified in delay.
+func (h *httpHandler) forward(rw http.ResponseWriter, req *http.Request, rule Rule, delay time.Duration) {
+	if isWebSocketUpgrade(req) {
+		h.forwardUpgrade(rw, req, rule, delay)
+		return
+	}
 
-	upstreamReq := req.Clone(context.Background())
-	upstreamReq.Host = h.upstreamURL.Host
-	upstreamReq.URL.Host = h.upstreamURL.Host
-	upstreamReq.URL.Scheme = h.upstreamURL.Scheme
-	upstreamReq.RequestURI = "" // It is an error to set this field in an HTTP client request.
+	timer := time.After(delay)
 
-	response, err := http.DefaultClient.Do(upstreamReq)
+	upstreamResp, err := h.upstreamClient(req).Do(h.upstreamRequest(req))
 	<-timer
 	if err != nil {
 		rw.WriteHeader(http.StatusBadGateway)
@@ -120,30 +267,73 @@ func (h *httpHandler) forward(rw http.ResponseWriter, req *http.Request, delay t
 
 	defer func() {
 		// Fully consume and then close upstream response body.
-		_, _ = io.Copy(io.Discard, response.Body)
-		_ = response.Body.Close()
+		_, _ = io.Copy(io.Discard, upstreamResp.Body)
+		_ = upstreamResp.Body.Close()
 	}()
 
 	// Mirror headers.
-	for key, values := range response.Header {
+	for key, values := range upstreamResp.Header {
 		for _, value := range values {
 			rw.Header().Add(key, value)
 		}
 	}
+	rule.modifyHeaders(rw.Header())
 
 	// Mirror status code.
-	rw.WriteHeader(response.StatusCode)
+	rw.WriteHeader(rule.modifyStatus(upstreamResp.StatusCode))
+
+	var body io.Reader = upstreamResp.Body
+	if rule.modifiesBody() {
+		// the body must be read completely for modifying it
+		content, err := io.ReadAll(upstreamResp.Body)
+		if err != nil {
+			return
+		}
+		body = bytes.NewReader(rule.modifyBody(content))
+	}
+
+	if isEventStream(upstreamResp.Header) && !rule.StreamDisruption.IsEmpty() {
+		// the body of a disrupted stream is never modified, as validated when the proxy is created
+		rule.StreamDisruption.writeEvents(req.Context(), rw, upstreamResp.Body)
+		return
+	}
 
 	// ignore errors writing body, nothing to do.
-	_, _ = io.Copy(rw, response.Body)
+	if rule.BodyThrottling.IsEmpty() {
+		// flush the body as it arrives, so streamed responses are not buffered
+		_, _ = io.Copy(flushWriter{rw: rw}, body)
+	} else {
+		_ = rule.BodyThrottling.write(req.Context(), rw, body)
+	}
+
+	// Mirror trailers, which are known once the body is read.
+	for key, values := range upstreamResp.Trailer {
+		for _, value := range values {
+			rw.Header().Add(http.TrailerPrefix+key, value)
+		}
+	}
 }
 
-// injectError waits sleeps the duration specified in delay and then writes the configured error downstream.
-func (h *httpHandler) injectError(rw http.ResponseWriter, delay time.Duration) {
+// flushWriter is an io.Writer that flushes the ResponseWriter after each write
+type flushWriter struct {
+	rw http.ResponseWriter
+}
+
+func (f flushWriter) Write(p []byte) (int, error) {
+	n, err := f.rw.Write(p)
+	if err != nil {
+		return n, err
+	}
+
+	return n, http.NewResponseController(f.rw).Flush()
+}
+
+// injectError waits sleeps the duration specified in delay and then writes the rule's error downstream.
+func (h *httpHandler) injectError(rw http.ResponseWriter, rule Rule, delay time.Duration) {
 	time.Sleep(delay)
 
-	rw.WriteHeader(h.disruption.ErrorCode)
-	_, _ = rw.Write([]byte(h.disruption.ErrorBody))
+	rw.WriteHeader(rule.ErrorCode)
+	_, _ = rw.Write([]byte(rule.ErrorBody))
 }
 
 func (h *httpHandler) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
@@ -152,24 +342,33 @@ func (h *httpHandler) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
 	if h.isExcluded(req) {
 		h.metrics.Inc(protocol.MetricRequestsExcluded)
 		//nolint:contextcheck // Unclear which context the linter requires us to propagate here.
-		h.forward(rw, req, 0)
+		h.forward(rw, req, Rule{}, 0)
 		return
 	}
 
-	delay := h.disruption.AverageDelay
-	if h.disruption.DelayVariation > 0 {
-		variation := int64(h.disruption.DelayVariation)
+	rule := h.disruption.rule(req)
+	if !h.disruption.Schedule.IsConstant() {
+		rule = rule.scaled(h.disruption.Schedule.Level(time.Since(h.start)))
+	}
+
+	delay := rule.AverageDelay
+	if rule.DelayVariation > 0 {
+		variation := int64(rule.DelayVariation)
 		delay += time.Duration(variation - 2*rand.Int63n(variation))
 	}
 
-	if h.disruption.ErrorRate > 0 && rand.Float32() <= h.disruption.ErrorRate {
+	if rule.ErrorRate > 0 && rand.Float32() <= rule.ErrorRate {
 		h.metrics.Inc(protocol.MetricRequestsDisrupted)
-		h.injectError(rw, delay)
+		h.injectError(rw, rule, delay)
 		return
 	}
 
+	if !rule.ResponseDisruption.IsEmpty() || !rule.BodyThrottling.IsEmpty() || !rule.StreamDisruption.IsEmpty() {
+		h.metrics.Inc(protocol.MetricRequestsDisrupted)
+	}
+
 	//nolint:contextcheck // Unclear which context the linter requires us to propagate here.
-	h.forward(rw, req, delay)
+	h.forward(rw, req, rule, delay)
 }
 
 // Start starts the execution of the proxy
@@ -46,6 +46,49 @@ func Test_Validations(t *testing.T) {
 			upstream:    "",
 			expectError: true,
 		},
+		{
+			title: "invalid rule regex",
+			disruption: Disruption{
+				Rules: []Rule{
+					{Match: Match{PathRegex: "/users/(["}},
+				},
+			},
+			upstream:    "http://127.0.0.1:80",
+			expectError: true,
+		},
+		{
+			title: "invalid rule error code",
+			disruption: Disruption{
+				Rules: []Rule{
+					{Match: Match{PathPrefix: "/api"}, ErrorRate: 0.5},
+				},
+			},
+			upstream:    "http://127.0.0.1:80",
+			expectError: true,
+		},
+		{
+			title: "modified body with stream disruption",
+			disruption: Disruption{
+				ResponseDisruption: ResponseDisruption{TruncateBody: 0.5},
+				StreamDisruption:   StreamDisruption{FrameDelay: 100},
+			},
+			upstream:    "http://127.0.0.1:80",
+			expectError: true,
+		},
+		{
+			title: "rule with modified body and stream disruption",
+			disruption: Disruption{
+				Rules: []Rule{
+					{
+						Match:              Match{PathPrefix: "/events"},
+						ResponseDisruption: ResponseDisruption{MalformedJSON: true},
+						StreamDisruption:   StreamDisruption{DropAfterFrames: 1},
+					},
+				},
+			},
+			upstream:    "http://127.0.0.1:80",
+			expectError: true,
+		},
 		{
 			title: "variation larger than average delay",
 			disruption: Disruption{
@@ -303,6 +346,7 @@ func Test_ProxyHandler(t *testing.T) {
 				upstreamURL: *upstreamURL,
 				disruption:  tc.disruption,
 				metrics:     protocol.NewMetricMap(supportedMetrics()...),
+				client:      http.DefaultClient,
 			}
 
 			proxyServer := httptest.NewServer(handler)
@@ -395,6 +439,7 @@ func Test_Metrics(t *testing.T) {
 				upstreamURL: *upstreamURL,
 				disruption:  tc.config,
 				metrics:     metrics,
+				client:      http.DefaultClient,
 			}
 
 			proxyServer := httptest.NewServer(handler)
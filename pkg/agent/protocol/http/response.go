@@ -0,0 +1,104 @@
+package http
+
+import (
+	"fmt"
+	"math/rand"
+	"net/http"
+)
+
+// malformedJSON is the payload that replaces the body of the responses when MalformedJSON is set
+const malformedJSON = `{"data": [{"id": 1, "name": "xk6-disruptor"}, {"id": 2,`
+
+// ResponseDisruption specifies modifications to the responses forwarded from the upstream
+type ResponseDisruption struct {
+	// Headers added to the response, replacing any existing value
+	SetHeaders map[string]string `json:"setHeaders,omitempty"`
+	// Headers removed from the response
+	RemoveHeaders []string `json:"removeHeaders,omitempty"`
+	// Status code that replaces the status code of the response, keeping its body
+	ResponseStatus int `json:"responseStatus,omitempty"`
+	// Fraction (in the range 0.0 to 1.0) of the body removed from its end
+	TruncateBody float32 `json:"truncateBody,omitempty"`
+	// Fraction (in the range 0.0 to 1.0) of the bytes of the body replaced by random bytes
+	CorruptBody float32 `json:"corruptBody,omitempty"`
+	// Replace the body with a malformed JSON payload
+	MalformedJSON bool `json:"malformedJson,omitempty"`
+}
+
+// IsEmpty returns if the ResponseDisruption does not define any modification
+func (r ResponseDisruption) IsEmpty() bool {
+	return len(r.SetHeaders) == 0 && len(r.RemoveHeaders) == 0 && r.ResponseStatus == 0 && !r.modifiesBody()
+}
+
+// modifiesBody returns if the ResponseDisruption modifies the body of the response
+func (r ResponseDisruption) modifiesBody() bool {
+	return r.TruncateBody > 0 || r.CorruptBody > 0 || r.MalformedJSON
+}
+
+// validate checks the ResponseDisruption is valid
+func (r ResponseDisruption) validate() error {
+	if r.ResponseStatus != 0 && (r.ResponseStatus < 100 || r.ResponseStatus > 599) {
+		return fmt.Errorf("response status must be a valid http status code")
+	}
+
+	if r.TruncateBody < 0.0 || r.TruncateBody > 1.0 {
+		return fmt.Errorf("body truncation must be in the range [0.0, 1.0]")
+	}
+
+	if r.CorruptBody < 0.0 || r.CorruptBody > 1.0 {
+		return fmt.Errorf("body corruption must be in the range [0.0, 1.0]")
+	}
+
+	return nil
+}
+
+// modifyHeaders applies the header modifications to the headers of a response
+func (r ResponseDisruption) modifyHeaders(headers http.Header) {
+	for _, name := range r.RemoveHeaders {
+		headers.Del(name)
+	}
+
+	for name, value := range r.SetHeaders {
+		headers.Set(name, value)
+	}
+
+	// the length of the body changes, so the upstream's length is no longer valid
+	if r.modifiesBody() {
+		headers.Del("Content-Length")
+	}
+
+	if r.MalformedJSON {
+		headers.Del("Content-Encoding")
+		headers.Set("Content-Type", "application/json")
+	}
+}
+
+// modifyStatus returns the status code for the response
+func (r ResponseDisruption) modifyStatus(status int) int {
+	if r.ResponseStatus != 0 {
+		return r.ResponseStatus
+	}
+
+	return status
+}
+
+// modifyBody applies the body modifications to the body of a response
+func (r ResponseDisruption) modifyBody(body []byte) []byte {
+	if r.MalformedJSON {
+		body = []byte(malformedJSON)
+	}
+
+	if r.TruncateBody > 0 {
+		body = body[:len(body)-int(float32(len(body))*r.TruncateBody)]
+	}
+
+	if r.CorruptBody > 0 {
+		for i := range body {
+			if rand.Float32() < r.CorruptBody {
+				body[i] = byte(rand.Intn(256))
+			}
+		}
+	}
+
+	return body
+}
@@ -0,0 +1,218 @@
+package http
+
+import (
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/grafana/xk6-disruptor/pkg/agent/protocol"
+)
+
+func Test_ResponseDisruption(t *testing.T) {
+	t.Parallel()
+
+	upstreamBody := `{"message": "hello world"}`
+
+	testCases := []struct {
+		title           string
+		disruption      ResponseDisruption
+		expectedStatus  int
+		expectedHeaders map[string]string
+		// check the body matches the expected body. Otherwise, only its length is checked
+		checkBody      bool
+		expectedBody   string
+		expectedLength int
+	}{
+		{
+			title:          "no disruption",
+			disruption:     ResponseDisruption{},
+			expectedStatus: http.StatusOK,
+			expectedHeaders: map[string]string{
+				"X-Upstream":   "upstream",
+				"Content-Type": "text/plain",
+			},
+			checkBody:    true,
+			expectedBody: upstreamBody,
+		},
+		{
+			title: "set and remove headers",
+			disruption: ResponseDisruption{
+				SetHeaders:    map[string]string{"Content-Type": "application/xml", "X-Disrupted": "true"},
+				RemoveHeaders: []string{"X-Upstream"},
+			},
+			expectedStatus: http.StatusOK,
+			expectedHeaders: map[string]string{
+				"X-Upstream":   "",
+				"X-Disrupted":  "true",
+				"Content-Type": "application/xml",
+			},
+			checkBody:    true,
+			expectedBody: upstreamBody,
+		},
+		{
+			title: "response status",
+			disruption: ResponseDisruption{
+				ResponseStatus: http.StatusServiceUnavailable,
+			},
+			expectedStatus: http.StatusServiceUnavailable,
+			checkBody:      true,
+			expectedBody:   upstreamBody,
+		},
+		{
+			title: "truncate body",
+			disruption: ResponseDisruption{
+				TruncateBody: 0.5,
+			},
+			expectedStatus: http.StatusOK,
+			checkBody:      true,
+			expectedBody:   upstreamBody[:len(upstreamBody)/2],
+		},
+		{
+			title: "truncate whole body",
+			disruption: ResponseDisruption{
+				TruncateBody: 1.0,
+			},
+			expectedStatus: http.StatusOK,
+			checkBody:      true,
+			expectedBody:   "",
+		},
+		{
+			title: "corrupt body",
+			disruption: ResponseDisruption{
+				CorruptBody: 1.0,
+			},
+			expectedStatus: http.StatusOK,
+			expectedLength: len(upstreamBody),
+		},
+		{
+			title: "malformed json",
+			disruption: ResponseDisruption{
+				MalformedJSON: true,
+			},
+			expectedStatus: http.StatusOK,
+			expectedHeaders: map[string]string{
+				"Content-Type": "application/json",
+			},
+			checkBody:    true,
+			expectedBody: malformedJSON,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			t.Parallel()
+
+			upstreamServer := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, _ *http.Request) {
+				rw.Header().Set("X-Upstream", "upstream")
+				rw.Header().Set("Content-Type", "text/plain")
+				rw.WriteHeader(http.StatusOK)
+				_, _ = rw.Write([]byte(upstreamBody))
+			}))
+			t.Cleanup(upstreamServer.Close)
+
+			upstreamURL, err := url.Parse(upstreamServer.URL)
+			if err != nil {
+				t.Fatalf("error parsing httptest url")
+			}
+
+			handler := &httpHandler{
+				upstreamURL: *upstreamURL,
+				disruption:  Disruption{ResponseDisruption: tc.disruption},
+				metrics:     protocol.NewMetricMap(supportedMetrics()...),
+				client:      http.DefaultClient,
+			}
+
+			proxyServer := httptest.NewServer(handler)
+			t.Cleanup(proxyServer.Close)
+
+			resp, err := http.Get(proxyServer.URL)
+			if err != nil {
+				t.Fatalf("making request to proxy: %v", err)
+			}
+
+			body, err := io.ReadAll(resp.Body)
+			_ = resp.Body.Close()
+			if err != nil {
+				t.Fatalf("reading response body: %v", err)
+			}
+
+			if resp.StatusCode != tc.expectedStatus {
+				t.Fatalf("expected status code '%d' but '%d' received", tc.expectedStatus, resp.StatusCode)
+			}
+
+			for name, value := range tc.expectedHeaders {
+				if actual := resp.Header.Get(name); actual != value {
+					t.Fatalf("expected header %q to be %q but %q received", name, value, actual)
+				}
+			}
+
+			if tc.checkBody && string(body) != tc.expectedBody {
+				t.Fatalf("expected body %q but %q received", tc.expectedBody, string(body))
+			}
+
+			if !tc.checkBody && len(body) != tc.expectedLength {
+				t.Fatalf("expected body length %d but %d received", tc.expectedLength, len(body))
+			}
+
+			if tc.disruption.MalformedJSON && json.Valid(body) {
+				t.Fatalf("expected malformed json but %q received", string(body))
+			}
+		})
+	}
+}
+
+func Test_ResponseDisruptionValidation(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		title       string
+		disruption  ResponseDisruption
+		expectError bool
+	}{
+		{
+			title:       "valid disruption",
+			disruption:  ResponseDisruption{ResponseStatus: 503, TruncateBody: 0.5, CorruptBody: 0.1},
+			expectError: false,
+		},
+		{
+			title:       "invalid response status",
+			disruption:  ResponseDisruption{ResponseStatus: 1000},
+			expectError: true,
+		},
+		{
+			title:       "invalid body truncation",
+			disruption:  ResponseDisruption{TruncateBody: 1.5},
+			expectError: true,
+		},
+		{
+			title:       "invalid body corruption",
+			disruption:  ResponseDisruption{CorruptBody: -0.1},
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			t.Parallel()
+
+			listener, err := net.Listen("tcp", "localhost:0")
+			if err != nil {
+				t.Fatalf("creating listener: %v", err)
+			}
+			t.Cleanup(func() { _ = listener.Close() })
+
+			_, err = NewProxy(listener, "http://127.0.0.1:80", Disruption{ResponseDisruption: tc.disruption})
+			if !tc.expectError && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if tc.expectError && err == nil {
+				t.Fatalf("expected error but none returned")
+			}
+		})
+	}
+}
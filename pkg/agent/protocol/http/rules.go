@@ -0,0 +1,207 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/grafana/xk6-disruptor/pkg/agent/schedule"
+)
+
+// Match selects requests by their attributes. A request matches if it satisfies all the criteria specified.
+// An empty Match selects all requests.
+type Match struct {
+	// PathPrefix selects the requests whose path starts with this prefix
+	PathPrefix string `json:"pathPrefix,omitempty"`
+	// PathGlob selects the requests whose path matches this glob pattern (e.g. "/users/*/orders").
+	// The syntax is the one of path.Match, so '*' does not match '/'.
+	PathGlob string `json:"pathGlob,omitempty"`
+	// PathRegex selects the requests whose path matches this regular expression
+	PathRegex string `json:"pathRegex,omitempty"`
+	// Methods selects the requests with any of these methods
+	Methods []string `json:"methods,omitempty"`
+	// Headers selects the requests that have all these headers. If the value of a header is empty, the header must
+	// be present with any value. Otherwise, any of its values must be equal to the given value.
+	Headers map[string]string `json:"headers,omitempty"`
+	// Query selects the requests that have all these query parameters, following the same rules as Headers
+	Query map[string]string `json:"query,omitempty"`
+
+	pathRegexp *regexp.Regexp
+}
+
+// Rule defines the disruption applied to the requests that match a Match
+type Rule struct {
+	Match Match `json:"match"`
+	// Average delay introduced to requests
+	AverageDelay time.Duration `json:"averageDelay,omitempty"`
+	// Variation in the delay (with respect of the average delay)
+	DelayVariation time.Duration `json:"delayVariation,omitempty"`
+	// Fraction (in the range 0.0 to 1.0) of requests that will return an error
+	ErrorRate float32 `json:"errorRate,omitempty"`
+	// Error code to be returned by requests selected in the error rate
+	ErrorCode int `json:"errorCode,omitempty"`
+	// Body to be returned when an error is injected
+	ErrorBody string `json:"errorBody,omitempty"`
+	ResponseDisruption
+	BodyThrottling
+	StreamDisruption
+}
+
+// ParseRules parses a list of rules in JSON format
+func ParseRules(values []string) ([]Rule, error) {
+	rules := []Rule{}
+	for _, value := range values {
+		decoder := json.NewDecoder(bytes.NewBufferString(value))
+		decoder.DisallowUnknownFields()
+
+		rule := Rule{}
+		if err := decoder.Decode(&rule); err != nil {
+			return nil, fmt.Errorf("parsing rule %q: %w", value, err)
+		}
+
+		rules = append(rules, rule)
+	}
+
+	return rules, nil
+}
+
+// compile validates the rule and compiles its match
+func (r *Rule) compile() error {
+	if err := validateFault(r.AverageDelay, r.DelayVariation, r.ErrorRate, r.ErrorCode); err != nil {
+		return err
+	}
+
+	if err := r.ResponseDisruption.validate(); err != nil {
+		return err
+	}
+
+	if err := r.BodyThrottling.validate(); err != nil {
+		return err
+	}
+
+	if err := r.StreamDisruption.validate(); err != nil {
+		return err
+	}
+
+	if err := validateStreamBody(r.ResponseDisruption, r.StreamDisruption); err != nil {
+		return err
+	}
+
+	if r.Match.PathGlob != "" {
+		if _, err := path.Match(r.Match.PathGlob, ""); err != nil {
+			return fmt.Errorf("invalid path glob %q: %w", r.Match.PathGlob, err)
+		}
+	}
+
+	if r.Match.PathRegex != "" {
+		re, err := regexp.Compile(r.Match.PathRegex)
+		if err != nil {
+			return fmt.Errorf("invalid path regex %q: %w", r.Match.PathRegex, err)
+		}
+		r.Match.pathRegexp = re
+	}
+
+	return nil
+}
+
+// scaled returns the rule with its delays and error rate multiplied by the level of a schedule. With a level of zero,
+// the rule does not disrupt the requests.
+func (r Rule) scaled(level float64) Rule {
+	if level == 0 {
+		return Rule{}
+	}
+
+	r.AverageDelay = schedule.Duration(r.AverageDelay, level)
+	r.DelayVariation = schedule.Duration(r.DelayVariation, level)
+	r.ErrorRate = float32(schedule.Rate(float64(r.ErrorRate), level))
+
+	return r
+}
+
+// validateFault checks the delay and error settings of a fault
+func validateFault(averageDelay, delayVariation time.Duration, errorRate float32, errorCode int) error {
+	if delayVariation > averageDelay {
+		return fmt.Errorf("variation must be less that average delay")
+	}
+
+	if errorRate < 0.0 || errorRate > 1.0 {
+		return fmt.Errorf("error rate must be in the range [0.0, 1.0]")
+	}
+
+	if errorRate > 0.0 && errorCode == 0 {
+		return fmt.Errorf("error code must be a valid http error code")
+	}
+
+	return nil
+}
+
+// matches returns if the request satisfies all the criteria of the Match
+func (m Match) matches(r *http.Request) bool {
+	if m.PathPrefix != "" && !strings.HasPrefix(r.URL.Path, m.PathPrefix) {
+		return false
+	}
+
+	if m.PathGlob != "" {
+		if matched, _ := path.Match(m.PathGlob, r.URL.Path); !matched {
+			return false
+		}
+	}
+
+	if m.pathRegexp != nil && !m.pathRegexp.MatchString(r.URL.Path) {
+		return false
+	}
+
+	if len(m.Methods) > 0 && !containsFold(m.Methods, r.Method) {
+		return false
+	}
+
+	for name, value := range m.Headers {
+		if !matchesValues(r.Header.Values(name), value) {
+			return false
+		}
+	}
+
+	query := r.URL.Query()
+	for name, value := range m.Query {
+		if !matchesValues(query[name], value) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// matchesValues returns if any of the values is equal to the expected value. An empty expected value matches any
+// value, but at least one value must exist.
+func matchesValues(values []string, expected string) bool {
+	if len(values) == 0 {
+		return false
+	}
+
+	if expected == "" {
+		return true
+	}
+
+	for _, v := range values {
+		if v == expected {
+			return true
+		}
+	}
+
+	return false
+}
+
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,363 @@
+package http
+
+import (
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/grafana/xk6-disruptor/pkg/agent/protocol"
+)
+
+func Test_Match(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		title    string
+		match    Match
+		method   string
+		target   string
+		headers  http.Header
+		expected bool
+	}{
+		{
+			title:    "empty match",
+			match:    Match{},
+			method:   "GET",
+			target:   "/any",
+			expected: true,
+		},
+		{
+			title:    "path prefix",
+			match:    Match{PathPrefix: "/api/"},
+			method:   "GET",
+			target:   "/api/users",
+			expected: true,
+		},
+		{
+			title:    "path prefix does not match",
+			match:    Match{PathPrefix: "/api/"},
+			method:   "GET",
+			target:   "/health",
+			expected: false,
+		},
+		{
+			title:    "path glob",
+			match:    Match{PathGlob: "/users/*/orders"},
+			method:   "GET",
+			target:   "/users/42/orders",
+			expected: true,
+		},
+		{
+			title:    "path glob does not match nested path",
+			match:    Match{PathGlob: "/users/*"},
+			method:   "GET",
+			target:   "/users/42/orders",
+			expected: false,
+		},
+		{
+			title:    "path regex",
+			match:    Match{PathRegex: "^/users/[0-9]+$"},
+			method:   "GET",
+			target:   "/users/42",
+			expected: true,
+		},
+		{
+			title:    "path regex does not match",
+			match:    Match{PathRegex: "^/users/[0-9]+$"},
+			method:   "GET",
+			target:   "/users/me",
+			expected: false,
+		},
+		{
+			title:    "method",
+			match:    Match{Methods: []string{"post", "PUT"}},
+			method:   "POST",
+			target:   "/",
+			expected: true,
+		},
+		{
+			title:    "method does not match",
+			match:    Match{Methods: []string{"POST"}},
+			method:   "GET",
+			target:   "/",
+			expected: false,
+		},
+		{
+			title:    "header presence",
+			match:    Match{Headers: map[string]string{"X-Canary": ""}},
+			method:   "GET",
+			target:   "/",
+			headers:  http.Header{"X-Canary": []string{"yes"}},
+			expected: true,
+		},
+		{
+			title:    "header value",
+			match:    Match{Headers: map[string]string{"X-Tenant": "acme"}},
+			method:   "GET",
+			target:   "/",
+			headers:  http.Header{"X-Tenant": []string{"other", "acme"}},
+			expected: true,
+		},
+		{
+			title:    "header missing",
+			match:    Match{Headers: map[string]string{"X-Canary": ""}},
+			method:   "GET",
+			target:   "/",
+			expected: false,
+		},
+		{
+			title:    "query parameter",
+			match:    Match{Query: map[string]string{"debug": "true", "page": ""}},
+			method:   "GET",
+			target:   "/?debug=true&page=2",
+			expected: true,
+		},
+		{
+			title:    "query parameter value does not match",
+			match:    Match{Query: map[string]string{"debug": "true"}},
+			method:   "GET",
+			target:   "/?debug=false",
+			expected: false,
+		},
+		{
+			title: "all criteria must match",
+			match: Match{
+				PathPrefix: "/api/",
+				Methods:    []string{"GET"},
+				Headers:    map[string]string{"X-Canary": ""},
+			},
+			method:   "GET",
+			target:   "/api/users",
+			expected: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			t.Parallel()
+
+			rule := Rule{Match: tc.match}
+			if err := rule.compile(); err != nil {
+				t.Fatalf("compiling rule: %v", err)
+			}
+
+			req := httptest.NewRequest(tc.method, tc.target, nil)
+			for k, values := range tc.headers {
+				for _, v := range values {
+					req.Header.Add(k, v)
+				}
+			}
+
+			if actual := rule.Match.matches(req); actual != tc.expected {
+				t.Fatalf("expected %t got %t", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func Test_ParseRules(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		title       string
+		values      []string
+		expected    []Rule
+		expectError bool
+	}{
+		{
+			title:    "no rules",
+			values:   []string{},
+			expected: []Rule{},
+		},
+		{
+			title: "rules",
+			values: []string{
+				`{"match":{"pathPrefix":"/api/","methods":["POST"]},"errorRate":1,"errorCode":503}`,
+				`{"match":{"headers":{"X-Canary":""}},"averageDelay":100000000}`,
+			},
+			expected: []Rule{
+				{
+					Match:     Match{PathPrefix: "/api/", Methods: []string{"POST"}},
+					ErrorRate: 1,
+					ErrorCode: 503,
+				},
+				{
+					Match:        Match{Headers: map[string]string{"X-Canary": ""}},
+					AverageDelay: 100 * time.Millisecond,
+				},
+			},
+		},
+		{
+			title:       "unknown field",
+			values:      []string{`{"match":{"path":"/api/"}}`},
+			expectError: true,
+		},
+		{
+			title:       "malformed rule",
+			values:      []string{`{"match":`},
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			t.Parallel()
+
+			actual, err := ParseRules(tc.values)
+			if tc.expectError && err == nil {
+				t.Fatalf("should had failed")
+			}
+
+			if !tc.expectError && err != nil {
+				t.Fatalf("failed: %v", err)
+			}
+
+			if tc.expectError {
+				return
+			}
+
+			if diff := cmp.Diff(tc.expected, actual, cmp.AllowUnexported(Match{})); diff != "" {
+				t.Fatalf("Parsed rules do not match expected:\n%s", diff)
+			}
+		})
+	}
+}
+
+func Test_ProxyRules(t *testing.T) {
+	t.Parallel()
+
+	disruption := Disruption{
+		ErrorRate: 1.0,
+		ErrorCode: http.StatusInternalServerError,
+		Rules: []Rule{
+			{
+				Match:     Match{PathPrefix: "/payments/", Methods: []string{"POST"}},
+				ErrorRate: 1.0,
+				ErrorCode: http.StatusServiceUnavailable,
+			},
+			{
+				Match: Match{PathRegex: "^/users/[0-9]+$"},
+			},
+		},
+	}
+
+	testCases := []struct {
+		method         string
+		path           string
+		expectedStatus int
+	}{
+		{method: "POST", path: "/payments/1", expectedStatus: http.StatusServiceUnavailable},
+		{method: "GET", path: "/payments/1", expectedStatus: http.StatusInternalServerError},
+		{method: "GET", path: "/users/42", expectedStatus: http.StatusOK},
+		{method: "GET", path: "/orders", expectedStatus: http.StatusInternalServerError},
+	}
+
+	upstreamServer := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, _ *http.Request) {
+		rw.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(upstreamServer.Close)
+
+	upstreamURL, err := url.Parse(upstreamServer.URL)
+	if err != nil {
+		t.Fatalf("error parsing httptest url")
+	}
+
+	for i := range disruption.Rules {
+		if err = disruption.Rules[i].compile(); err != nil {
+			t.Fatalf("compiling rule: %v", err)
+		}
+	}
+
+	handler := &httpHandler{
+		upstreamURL: *upstreamURL,
+		disruption:  disruption,
+		metrics:     protocol.NewMetricMap(supportedMetrics()...),
+		client:      http.DefaultClient,
+	}
+
+	proxyServer := httptest.NewServer(handler)
+	t.Cleanup(proxyServer.Close)
+
+	for _, tc := range testCases {
+		req, err := http.NewRequest(tc.method, proxyServer.URL+tc.path, nil)
+		if err != nil {
+			t.Fatalf("building request to proxy: %v", err)
+		}
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("making request to proxy: %v", err)
+		}
+		_ = resp.Body.Close()
+
+		if resp.StatusCode != tc.expectedStatus {
+			t.Fatalf("%s %s: expected status code '%d' but '%d' received", tc.method, tc.path, tc.expectedStatus,
+				resp.StatusCode)
+		}
+	}
+}
+
+func Test_RuleScaled(t *testing.T) {
+	t.Parallel()
+
+	rule := Rule{
+		AverageDelay:   100 * time.Millisecond,
+		DelayVariation: 20 * time.Millisecond,
+		ErrorRate:      0.4,
+		ErrorCode:      503,
+	}
+
+	testCases := []struct {
+		title             string
+		level             float64
+		expectedDelay     time.Duration
+		expectedVariation time.Duration
+		expectedRate      float32
+	}{
+		{
+			title:             "full level",
+			level:             1.0,
+			expectedDelay:     100 * time.Millisecond,
+			expectedVariation: 20 * time.Millisecond,
+			expectedRate:      0.4,
+		},
+		{
+			title:             "half level",
+			level:             0.5,
+			expectedDelay:     50 * time.Millisecond,
+			expectedVariation: 10 * time.Millisecond,
+			expectedRate:      0.2,
+		},
+		{
+			title:             "rate is limited",
+			level:             3.0,
+			expectedDelay:     300 * time.Millisecond,
+			expectedVariation: 60 * time.Millisecond,
+			expectedRate:      1.0,
+		},
+		{
+			title: "zero level",
+			level: 0.0,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			t.Parallel()
+
+			scaled := rule.scaled(tc.level)
+			if scaled.AverageDelay != tc.expectedDelay || scaled.DelayVariation != tc.expectedVariation {
+				t.Fatalf("expected delay %v±%v but got %v±%v",
+					tc.expectedDelay, tc.expectedVariation, scaled.AverageDelay, scaled.DelayVariation)
+			}
+
+			if math.Abs(float64(scaled.ErrorRate-tc.expectedRate)) > 1e-6 {
+				t.Fatalf("expected error rate %f but got %f", tc.expectedRate, scaled.ErrorRate)
+			}
+		})
+	}
+}
@@ -0,0 +1,155 @@
+package http
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"math/rand"
+	"mime"
+	"net/http"
+	"time"
+)
+
+// StreamDisruption specifies disruptions applied to long-lived connections: the frames of WebSocket connections and
+// the events of Server-Sent Events streams.
+type StreamDisruption struct {
+	// Delay introduced to each WebSocket frame, in either direction, and to each event of a stream
+	FrameDelay time.Duration `json:"frameDelay,omitempty"`
+	// Number of frames or events sent to the client after which the connection is dropped
+	DropAfterFrames uint `json:"dropAfterFrames,omitempty"`
+	// Maximum time after which the connection is dropped. The connection is dropped at a random time up to this
+	// duration.
+	DropAfter time.Duration `json:"dropAfter,omitempty"`
+	// Close code sent to the client in a close frame when a WebSocket connection is dropped. If 0, the connection is
+	// closed without a close frame.
+	CloseCode uint16 `json:"closeCode,omitempty"`
+}
+
+// IsEmpty returns if the StreamDisruption does not define any disruption
+func (s StreamDisruption) IsEmpty() bool {
+	return s == StreamDisruption{}
+}
+
+// validate checks the StreamDisruption is valid
+func (s StreamDisruption) validate() error {
+	if s.FrameDelay < 0 || s.DropAfter < 0 {
+		return fmt.Errorf("frame delay and drop time must be positive")
+	}
+
+	if s.CloseCode != 0 && (s.CloseCode < 1000 || s.CloseCode > 4999) {
+		return fmt.Errorf("close code must be in the range [1000, 4999]")
+	}
+
+	return nil
+}
+
+// validateStreamBody checks the body of the responses is not modified if their streams are disrupted. Modifying the
+// body requires reading it completely, but a stream is disrupted as its events arrive.
+func validateStreamBody(response ResponseDisruption, stream StreamDisruption) error {
+	if response.modifiesBody() && !stream.IsEmpty() {
+		return fmt.Errorf("the body of the responses cannot be modified if their stream is disrupted")
+	}
+
+	return nil
+}
+
+// dropTimer returns a channel that fires when the connection must be dropped. If the disruption does not drop
+// connections after a time, the channel never fires.
+func (s StreamDisruption) dropTimer() <-chan time.Time {
+	if s.DropAfter <= 0 {
+		return nil
+	}
+
+	return time.After(time.Duration(rand.Int63n(int64(s.DropAfter))) + 1)
+}
+
+// isEventStream returns if the response is a Server-Sent Events stream
+func isEventStream(header http.Header) bool {
+	mediaType, _, _ := mime.ParseMediaType(header.Get("Content-Type"))
+	return mediaType == "text/event-stream"
+}
+
+// writeEvents sends the events of a Server-Sent Events stream downstream, applying the disruption to each of them.
+// When the connection must be dropped, the body is closed and the response is aborted. If the context is done, the body
+// is closed and the remaining events are not sent.
+func (s StreamDisruption) writeEvents(ctx context.Context, rw http.ResponseWriter, body io.ReadCloser) {
+	events := make(chan []byte)
+	go func() {
+		defer close(events)
+
+		reader := bufio.NewReader(body)
+		event := []byte{}
+		for {
+			line, err := reader.ReadBytes('\n')
+			event = append(event, line...)
+			// events are terminated by an empty line
+			if len(line) > 0 && (string(line) == "\n" || string(line) == "\r\n") {
+				events <- event
+				event = []byte{}
+			}
+
+			if err != nil {
+				if len(event) > 0 {
+					events <- event
+				}
+				return
+			}
+		}
+	}()
+
+	controller := http.NewResponseController(rw)
+	timer := s.dropTimer()
+	sent := uint(0)
+	// the event waiting for its delay. No other event is received until it is sent.
+	var pending []byte
+	var delay <-chan time.Time
+	for {
+		received := events
+		if delay != nil {
+			received = nil
+		}
+
+		select {
+		case event, ok := <-received:
+			if !ok {
+				return
+			}
+
+			pending = event
+			delay = time.After(s.FrameDelay)
+		case <-delay:
+			delay = nil
+			if _, err := rw.Write(pending); err != nil {
+				stopEvents(body, events)
+				return
+			}
+			_ = controller.Flush()
+
+			sent++
+			if s.DropAfterFrames > 0 && sent >= s.DropAfterFrames {
+				dropEvents(body, events)
+			}
+		case <-timer:
+			dropEvents(body, events)
+		case <-ctx.Done():
+			stopEvents(body, events)
+			return
+		}
+	}
+}
+
+// dropEvents stops reading the events and aborts the response, so the connection is dropped
+func dropEvents(body io.Closer, events <-chan []byte) {
+	stopEvents(body, events)
+
+	// ErrAbortHandler aborts the response without logging a stack trace
+	panic(http.ErrAbortHandler)
+}
+
+// stopEvents closes the body of the stream and waits for the reader of the events to finish
+func stopEvents(body io.Closer, events <-chan []byte) {
+	_ = body.Close()
+	for range events { //nolint:revive // the remaining events are discarded
+	}
+}
@@ -0,0 +1,191 @@
+package http
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// eventsUpstream returns a server that sends the given number of events and then keeps the stream open until the
+// client disconnects
+func eventsUpstream(t *testing.T, events int) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.Header().Set("Content-Type", "text/event-stream")
+		rw.WriteHeader(http.StatusOK)
+
+		for i := range events {
+			_, _ = fmt.Fprintf(rw, "id: %d\ndata: event %d\n\n", i, i)
+			_ = http.NewResponseController(rw).Flush()
+		}
+
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func Test_EventStreamDisruption(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		title      string
+		disruption StreamDisruption
+		events     int
+		// events to be received. If the stream is not dropped, the test reads only these events.
+		expected int
+		// minimum time for receiving the expected events
+		minElapsed time.Duration
+		expectDrop bool
+	}{
+		{
+			title:      "no disruption",
+			disruption: StreamDisruption{},
+			events:     3,
+			expected:   3,
+			expectDrop: false,
+		},
+		{
+			title: "event delay",
+			disruption: StreamDisruption{
+				FrameDelay: 100 * time.Millisecond,
+			},
+			events:     3,
+			expected:   3,
+			minElapsed: 300 * time.Millisecond,
+			expectDrop: false,
+		},
+		{
+			title: "drop after events",
+			disruption: StreamDisruption{
+				DropAfterFrames: 2,
+			},
+			events:     5,
+			expected:   2,
+			expectDrop: true,
+		},
+		{
+			title: "drop after time",
+			disruption: StreamDisruption{
+				DropAfter: 100 * time.Millisecond,
+			},
+			events:     1,
+			expected:   1,
+			expectDrop: true,
+		},
+		{
+			title: "drop during event delay",
+			disruption: StreamDisruption{
+				FrameDelay: 2 * time.Second,
+				DropAfter:  100 * time.Millisecond,
+			},
+			events:     1,
+			expected:   0,
+			expectDrop: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			t.Parallel()
+
+			upstream := eventsUpstream(t, tc.events)
+			proxyAddress := startProxy(t, upstream.URL, Disruption{StreamDisruption: tc.disruption})
+
+			client := &http.Client{Timeout: 3 * time.Second}
+
+			start := time.Now()
+			resp, err := client.Get("http://" + proxyAddress)
+			// a stream dropped before any event is sent is dropped before the response headers
+			if err != nil && tc.expectDrop && tc.expected == 0 {
+				return
+			}
+			if err != nil {
+				t.Fatalf("making request to proxy: %v", err)
+			}
+			defer func() {
+				_ = resp.Body.Close()
+			}()
+
+			scanner := bufio.NewScanner(resp.Body)
+			received := 0
+			for scanner.Scan() {
+				if strings.HasPrefix(scanner.Text(), "data:") {
+					received++
+				}
+
+				if !tc.expectDrop && received == tc.expected {
+					break
+				}
+			}
+			elapsed := time.Since(start)
+
+			if received != tc.expected {
+				t.Fatalf("expected %d events but %d received", tc.expected, received)
+			}
+
+			if elapsed < tc.minElapsed {
+				t.Fatalf("expected events in at least %v but received in %v", tc.minElapsed, elapsed)
+			}
+
+			if tc.expectDrop && scanner.Err() == nil {
+				t.Fatalf("expected the stream to be dropped")
+			}
+		})
+	}
+}
+
+func Test_StreamDisruptionValidation(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		title       string
+		disruption  StreamDisruption
+		expectError bool
+	}{
+		{
+			title: "valid disruption",
+			disruption: StreamDisruption{
+				FrameDelay:      time.Second,
+				DropAfterFrames: 10,
+				DropAfter:       time.Second,
+				CloseCode:       1001,
+			},
+			expectError: false,
+		},
+		{
+			title:       "negative frame delay",
+			disruption:  StreamDisruption{FrameDelay: -time.Second},
+			expectError: true,
+		},
+		{
+			title:       "invalid close code",
+			disruption:  StreamDisruption{CloseCode: 999},
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			t.Parallel()
+
+			err := tc.disruption.validate()
+			if !tc.expectError && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if tc.expectError && err == nil {
+				t.Fatalf("expected error but none returned")
+			}
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// throttleInterval is the interval at which chunks of the body are sent when the body rate is limited
+const throttleInterval = 100 * time.Millisecond
+
+// BodyThrottling specifies how the body of the responses is sent downstream. The headers of the response are sent
+// after the delay of the disruption, and then the body is sent following the throttling.
+type BodyThrottling struct {
+	// Maximum rate, in bytes per second, at which the body is sent. If 0, the body is not rate-limited
+	BodyRate uint `json:"bodyRate,omitempty"`
+	// Delay between sending the headers and the first byte of the body
+	FirstByteDelay time.Duration `json:"firstByteDelay,omitempty"`
+	// Number of bytes of the body sent before the body stalls
+	StallAfter uint `json:"stallAfter,omitempty"`
+	// Time the body stalls after sending the number of bytes specified in StallAfter
+	StallDuration time.Duration `json:"stallDuration,omitempty"`
+}
+
+// IsEmpty returns if the BodyThrottling does not define any throttling
+func (b BodyThrottling) IsEmpty() bool {
+	return b == BodyThrottling{}
+}
+
+// validate checks the BodyThrottling is valid
+func (b BodyThrottling) validate() error {
+	if b.FirstByteDelay < 0 || b.StallDuration < 0 {
+		return fmt.Errorf("first byte delay and stall duration must be positive")
+	}
+
+	if b.StallDuration > 0 && b.StallAfter == 0 {
+		return fmt.Errorf("stall duration requires the number of bytes after which the body stalls")
+	}
+
+	return nil
+}
+
+// chunkSize returns the maximum number of bytes sent at once
+func (b BodyThrottling) chunkSize() int {
+	if b.BodyRate == 0 {
+		return 32 * 1024
+	}
+
+	return max(int(time.Duration(b.BodyRate)*throttleInterval/time.Second), 1)
+}
+
+// sleep waits for the given duration. Returns the error of the context if it is done before.
+func sleep(ctx context.Context, duration time.Duration) error {
+	timer := time.NewTimer(duration)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// write sends the body downstream following the throttling. Each chunk of the body is flushed as soon as it is
+// written, so the client receives the body at the throttled rate. Stops when the context is done, for example because
+// the client closed the connection.
+func (b BodyThrottling) write(ctx context.Context, rw http.ResponseWriter, body io.Reader) error {
+	controller := http.NewResponseController(rw)
+
+	// send the headers before waiting for the first byte
+	_ = controller.Flush()
+	if err := sleep(ctx, b.FirstByteDelay); err != nil {
+		return err
+	}
+
+	buffer := make([]byte, b.chunkSize())
+	written := uint(0)
+	stalled := false
+	for {
+		chunk := buffer
+		// do not cross the stall point within a chunk
+		if !stalled && b.StallDuration > 0 && written+uint(len(chunk)) > b.StallAfter {
+			chunk = chunk[:b.StallAfter-written]
+		}
+
+		n, err := body.Read(chunk)
+		if n > 0 {
+			// wait the time it takes to send the chunk at the body rate
+			if b.BodyRate > 0 {
+				if serr := sleep(ctx, time.Duration(n)*time.Second/time.Duration(b.BodyRate)); serr != nil {
+					return serr
+				}
+			}
+
+			if _, werr := rw.Write(chunk[:n]); werr != nil {
+				return werr
+			}
+			_ = controller.Flush()
+			written += uint(n)
+		}
+
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if !stalled && b.StallDuration > 0 && written == b.StallAfter {
+			stalled = true
+			if err = sleep(ctx, b.StallDuration); err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,231 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/grafana/xk6-disruptor/pkg/agent/protocol"
+)
+
+func Test_BodyThrottling(t *testing.T) {
+	t.Parallel()
+
+	upstreamBody := bytes.Repeat([]byte("x"), 100)
+
+	testCases := []struct {
+		title      string
+		throttling BodyThrottling
+		response   ResponseDisruption
+		// minimum time for receiving the whole body, since the headers were received
+		minBody      time.Duration
+		expectedBody []byte
+	}{
+		{
+			title:        "no throttling",
+			throttling:   BodyThrottling{},
+			expectedBody: upstreamBody,
+		},
+		{
+			title: "body rate",
+			throttling: BodyThrottling{
+				BodyRate: 500,
+			},
+			minBody:      200 * time.Millisecond,
+			expectedBody: upstreamBody,
+		},
+		{
+			title: "first byte delay",
+			throttling: BodyThrottling{
+				FirstByteDelay: 200 * time.Millisecond,
+			},
+			minBody:      200 * time.Millisecond,
+			expectedBody: upstreamBody,
+		},
+		{
+			title: "stall",
+			throttling: BodyThrottling{
+				StallAfter:    50,
+				StallDuration: 200 * time.Millisecond,
+			},
+			minBody:      200 * time.Millisecond,
+			expectedBody: upstreamBody,
+		},
+		{
+			title: "stall after the end of the body",
+			throttling: BodyThrottling{
+				StallAfter:    1000,
+				StallDuration: time.Hour,
+			},
+			expectedBody: upstreamBody,
+		},
+		{
+			title: "modified body",
+			throttling: BodyThrottling{
+				BodyRate: 500,
+			},
+			response: ResponseDisruption{
+				TruncateBody: 0.5,
+			},
+			minBody:      100 * time.Millisecond,
+			expectedBody: upstreamBody[:50],
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			t.Parallel()
+
+			upstreamServer := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, _ *http.Request) {
+				rw.WriteHeader(http.StatusOK)
+				_, _ = rw.Write(upstreamBody)
+			}))
+			t.Cleanup(upstreamServer.Close)
+
+			upstreamURL, err := url.Parse(upstreamServer.URL)
+			if err != nil {
+				t.Fatalf("error parsing httptest url")
+			}
+
+			handler := &httpHandler{
+				upstreamURL: *upstreamURL,
+				disruption: Disruption{
+					ResponseDisruption: tc.response,
+					BodyThrottling:     tc.throttling,
+				},
+				metrics: protocol.NewMetricMap(supportedMetrics()...),
+				client:  http.DefaultClient,
+			}
+
+			proxyServer := httptest.NewServer(handler)
+			t.Cleanup(proxyServer.Close)
+
+			start := time.Now()
+			resp, err := http.Get(proxyServer.URL)
+			if err != nil {
+				t.Fatalf("making request to proxy: %v", err)
+			}
+			headers := time.Since(start)
+
+			body, err := io.ReadAll(resp.Body)
+			_ = resp.Body.Close()
+			if err != nil {
+				t.Fatalf("reading response body: %v", err)
+			}
+			elapsed := time.Since(start) - headers
+
+			if elapsed < tc.minBody {
+				t.Fatalf("expected body in at least %v but received in %v", tc.minBody, elapsed)
+			}
+
+			// the headers must not wait for the throttling of the body
+			if headers > 100*time.Millisecond {
+				t.Fatalf("expected headers without waiting for the body but received after %v", headers)
+			}
+
+			if !bytes.Equal(body, tc.expectedBody) {
+				t.Fatalf("expected body %q but %q received", tc.expectedBody, body)
+			}
+		})
+	}
+}
+
+func Test_BodyThrottlingCancel(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		title      string
+		throttling BodyThrottling
+	}{
+		{
+			title:      "first byte delay",
+			throttling: BodyThrottling{FirstByteDelay: time.Hour},
+		},
+		{
+			title:      "body rate",
+			throttling: BodyThrottling{BodyRate: 1},
+		},
+		{
+			title:      "stall",
+			throttling: BodyThrottling{StallAfter: 10, StallDuration: time.Hour},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			t.Parallel()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+
+			start := time.Now()
+			err := tc.throttling.write(ctx, httptest.NewRecorder(), bytes.NewReader(bytes.Repeat([]byte("x"), 100)))
+			if !errors.Is(err, context.DeadlineExceeded) {
+				t.Fatalf("expected deadline exceeded but got %v", err)
+			}
+
+			if elapsed := time.Since(start); elapsed > time.Second {
+				t.Fatalf("expected write to stop when the context is done but took %v", elapsed)
+			}
+		})
+	}
+}
+
+func Test_BodyThrottlingValidation(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		title       string
+		throttling  BodyThrottling
+		expectError bool
+	}{
+		{
+			title: "valid throttling",
+			throttling: BodyThrottling{
+				BodyRate:       1024,
+				FirstByteDelay: time.Second,
+				StallAfter:     512,
+				StallDuration:  time.Second,
+			},
+			expectError: false,
+		},
+		{
+			title:       "negative first byte delay",
+			throttling:  BodyThrottling{FirstByteDelay: -time.Second},
+			expectError: true,
+		},
+		{
+			title:       "stall without bytes",
+			throttling:  BodyThrottling{StallDuration: time.Second},
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			t.Parallel()
+
+			listener, err := net.Listen("tcp", "localhost:0")
+			if err != nil {
+				t.Fatalf("creating listener: %v", err)
+			}
+			t.Cleanup(func() { _ = listener.Close() })
+
+			_, err = NewProxy(listener, "http://127.0.0.1:80", Disruption{BodyThrottling: tc.throttling})
+			if !tc.expectError && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if tc.expectError && err == nil {
+				t.Fatalf("expected error but none returned")
+			}
+		})
+	}
+}
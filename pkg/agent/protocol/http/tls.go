@@ -0,0 +1,84 @@
+package http
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"math/big"
+	"net"
+	"time"
+)
+
+// certificateValidity is the validity of the certificates generated by GenerateCertificate
+const certificateValidity = 30 * 24 * time.Hour
+
+// LoadCertificate returns the certificate and private key contained in PEM encoded data
+func LoadCertificate(data []byte) (tls.Certificate, error) {
+	// X509KeyPair ignores the blocks that are not certificates when parsing the certificate and takes the first
+	// private key when parsing the key, so both can be parsed from the same data
+	cert, err := tls.X509KeyPair(data, data)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("loading certificate: %w", err)
+	}
+
+	return cert, nil
+}
+
+// GenerateCertificate returns a certificate for the given hosts, signed by the CA whose certificate and private key
+// are contained in PEM encoded data. Hosts can be either DNS names or IP addresses.
+func GenerateCertificate(caData []byte, hosts []string) (tls.Certificate, error) {
+	if len(hosts) == 0 {
+		return tls.Certificate{}, fmt.Errorf("at least one host is required for generating a certificate")
+	}
+
+	ca, err := LoadCertificate(caData)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("loading CA: %w", err)
+	}
+
+	caCert, err := x509.ParseCertificate(ca.Certificate[0])
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("parsing CA certificate: %w", err)
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("generating key: %w", err)
+	}
+
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("generating serial number: %w", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: hosts[0]},
+		NotBefore:    time.Now().Add(-time.Hour), // tolerate clock skews
+		NotAfter:     time.Now().Add(certificateValidity),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+
+	for _, host := range hosts {
+		if ip := net.ParseIP(host); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, host)
+		}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, caCert, key.Public(), ca.PrivateKey)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("signing certificate: %w", err)
+	}
+
+	return tls.Certificate{
+		Certificate: [][]byte{der, caCert.Raw},
+		PrivateKey:  key,
+	}, nil
+}
@@ -0,0 +1,249 @@
+package http
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/grafana/xk6-disruptor/pkg/agent/protocol"
+)
+
+// buildCA returns the certificate of a self-signed CA and its certificate and key in PEM format
+func buildCA(t *testing.T) (*x509.Certificate, []byte) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generating key: %v", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	if err != nil {
+		t.Fatalf("creating certificate: %v", err)
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("parsing certificate: %v", err)
+	}
+
+	keyDer, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatalf("marshaling key: %v", err)
+	}
+
+	data := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	data = append(data, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDer})...)
+
+	return cert, data
+}
+
+func Test_GenerateCertificate(t *testing.T) {
+	t.Parallel()
+
+	caCert, caData := buildCA(t)
+
+	testCases := []struct {
+		title       string
+		caData      []byte
+		hosts       []string
+		verifyHost  string
+		expectError bool
+	}{
+		{
+			title:       "dns name",
+			caData:      caData,
+			hosts:       []string{"app.example.com", "192.0.2.6"},
+			verifyHost:  "app.example.com",
+			expectError: false,
+		},
+		{
+			title:       "ip address",
+			caData:      caData,
+			hosts:       []string{"app.example.com", "192.0.2.6"},
+			verifyHost:  "192.0.2.6",
+			expectError: false,
+		},
+		{
+			title:       "no hosts",
+			caData:      caData,
+			hosts:       []string{},
+			expectError: true,
+		},
+		{
+			title:       "invalid CA",
+			caData:      []byte("invalid"),
+			hosts:       []string{"app.example.com"},
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			t.Parallel()
+
+			cert, err := GenerateCertificate(tc.caData, tc.hosts)
+			if tc.expectError && err == nil {
+				t.Fatalf("expected error but none returned")
+			}
+
+			if !tc.expectError && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if tc.expectError {
+				return
+			}
+
+			leaf, err := x509.ParseCertificate(cert.Certificate[0])
+			if err != nil {
+				t.Fatalf("parsing generated certificate: %v", err)
+			}
+
+			roots := x509.NewCertPool()
+			roots.AddCert(caCert)
+
+			_, err = leaf.Verify(x509.VerifyOptions{DNSName: tc.verifyHost, Roots: roots})
+			if err != nil {
+				t.Fatalf("verifying generated certificate: %v", err)
+			}
+		})
+	}
+}
+
+func Test_TLSProxy(t *testing.T) {
+	t.Parallel()
+
+	caCert, caData := buildCA(t)
+
+	// the upstream serves TLS with a certificate the proxy does not trust
+	upstreamServer := httptest.NewTLSServer(http.HandlerFunc(func(rw http.ResponseWriter, _ *http.Request) {
+		rw.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(upstreamServer.Close)
+
+	cert, err := GenerateCertificate(caData, []string{"127.0.0.1"})
+	if err != nil {
+		t.Fatalf("generating certificate: %v", err)
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("creating listener: %v", err)
+	}
+	listener = tls.NewListener(listener, &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	})
+
+	proxy, err := NewProxy(listener, upstreamServer.URL, Disruption{
+		ErrorRate: 0.0,
+	})
+	if err != nil {
+		t.Fatalf("creating proxy: %v", err)
+	}
+
+	go func() {
+		_ = proxy.Start()
+	}()
+	t.Cleanup(func() { _ = proxy.Force() })
+
+	roots := x509.NewCertPool()
+	roots.AddCert(caCert)
+	client := &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{RootCAs: roots, MinVersion: tls.VersionTLS12},
+		},
+	}
+
+	resp, err := client.Get("https://" + listener.Addr().String())
+	if err != nil {
+		t.Fatalf("making request to proxy: %v", err)
+	}
+	_ = resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status code '%d' but '%d' received", http.StatusOK, resp.StatusCode)
+	}
+}
+
+func Test_UpstreamCertificate(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		title    string
+		newProxy func(net.Listener, string, Disruption) (protocol.Proxy, error)
+		expected int
+	}{
+		{
+			title:    "ingress proxy does not verify the certificate",
+			newProxy: NewProxy,
+			expected: http.StatusOK,
+		},
+		{
+			// the egress proxy is built with an unmarked dialer, as marking connections requires privileges
+			title: "egress proxy verifies the certificate",
+			newProxy: func(listener net.Listener, upstream string, d Disruption) (protocol.Proxy, error) {
+				return newProxy(listener, upstream, d, newTransport(&net.Dialer{}))
+			},
+			expected: http.StatusBadGateway,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			t.Parallel()
+
+			// the upstream serves TLS with a certificate that is not trusted
+			upstreamServer := httptest.NewTLSServer(http.HandlerFunc(func(rw http.ResponseWriter, _ *http.Request) {
+				rw.WriteHeader(http.StatusOK)
+			}))
+			t.Cleanup(upstreamServer.Close)
+
+			listener, err := net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatalf("creating listener: %v", err)
+			}
+
+			proxy, err := tc.newProxy(listener, upstreamServer.URL, Disruption{})
+			if err != nil {
+				t.Fatalf("creating proxy: %v", err)
+			}
+
+			go func() {
+				_ = proxy.Start()
+			}()
+			t.Cleanup(func() { _ = proxy.Force() })
+
+			resp, err := http.Get("http://" + listener.Addr().String())
+			if err != nil {
+				t.Fatalf("making request to proxy: %v", err)
+			}
+			_ = resp.Body.Close()
+
+			if resp.StatusCode != tc.expected {
+				t.Fatalf("expected status code '%d' but '%d' received", tc.expected, resp.StatusCode)
+			}
+		})
+	}
+}
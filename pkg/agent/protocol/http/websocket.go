@@ -0,0 +1,230 @@
+package http
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// opClose is the opcode of WebSocket close frames
+const opClose = 0x8
+
+// errConnectionDropped is returned when forwarding the frames of a connection that must be dropped
+var errConnectionDropped = errors.New("connection dropped")
+
+// isWebSocketUpgrade returns if the request asks for upgrading the connection to the WebSocket protocol
+func isWebSocketUpgrade(req *http.Request) bool {
+	if !strings.EqualFold(req.Header.Get("Upgrade"), "websocket") {
+		return false
+	}
+
+	for _, value := range req.Header.Values("Connection") {
+		for _, token := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(token), "upgrade") {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// forwardUpgrade forwards a WebSocket upgrade request to the upstream. If the upstream accepts the upgrade, the
+// frames are forwarded in both directions applying the rule's stream disruption. Otherwise, the upstream's response
+// is forwarded as any other response.
+func (h *httpHandler) forwardUpgrade(rw http.ResponseWriter, req *http.Request, rule Rule, delay time.Duration) {
+	timer := time.After(delay)
+
+	// upgrades are only supported in HTTP/1
+	upstreamResp, err := h.client.Do(h.upstreamRequest(req))
+	<-timer
+	if err != nil {
+		rw.WriteHeader(http.StatusBadGateway)
+		_, _ = fmt.Fprint(rw, err)
+		return
+	}
+
+	upstreamConn, isConn := upstreamResp.Body.(io.ReadWriteCloser)
+	if upstreamResp.StatusCode != http.StatusSwitchingProtocols || !isConn {
+		defer func() {
+			_ = upstreamResp.Body.Close()
+		}()
+
+		for key, values := range upstreamResp.Header {
+			for _, value := range values {
+				rw.Header().Add(key, value)
+			}
+		}
+		rw.WriteHeader(upstreamResp.StatusCode)
+		_, _ = io.Copy(rw, upstreamResp.Body)
+		return
+	}
+	defer func() {
+		_ = upstreamConn.Close()
+	}()
+
+	clientConn, clientBuf, err := http.NewResponseController(rw).Hijack()
+	if err != nil {
+		return
+	}
+	defer func() {
+		_ = clientConn.Close()
+	}()
+
+	// complete the upgrade with the upstream's response
+	_, _ = fmt.Fprintf(clientBuf, "HTTP/1.1 %s\r\n", upstreamResp.Status)
+	_ = upstreamResp.Header.Write(clientBuf)
+	_, _ = clientBuf.WriteString("\r\n")
+	if err = clientBuf.Flush(); err != nil {
+		return
+	}
+
+	rule.StreamDisruption.forwardFrames(clientConn, clientBuf.Reader, upstreamConn)
+}
+
+// forwardFrames forwards the WebSocket frames between the client and the upstream until either side closes its
+// connection or the connection is dropped by the disruption
+func (s StreamDisruption) forwardFrames(clientConn net.Conn, clientReader io.Reader, upstreamConn io.ReadWriteCloser) {
+	client := &lockedWriter{w: clientConn}
+	upstream := &lockedWriter{w: upstreamConn}
+	errs := make(chan error, 2)
+
+	go func() {
+		if s.FrameDelay == 0 {
+			_, err := io.Copy(upstream, clientReader)
+			errs <- err
+			return
+		}
+
+		for {
+			if err := s.forwardFrame(upstream, clientReader); err != nil {
+				errs <- err
+				return
+			}
+		}
+	}()
+
+	go func() {
+		if s.IsEmpty() {
+			_, err := io.Copy(client, upstreamConn)
+			errs <- err
+			return
+		}
+
+		sent := uint(0)
+		for {
+			if err := s.forwardFrame(client, upstreamConn); err != nil {
+				errs <- err
+				return
+			}
+
+			sent++
+			if s.DropAfterFrames > 0 && sent >= s.DropAfterFrames {
+				errs <- errConnectionDropped
+				return
+			}
+		}
+	}()
+
+	select {
+	case err := <-errs:
+		if !errors.Is(err, errConnectionDropped) {
+			return
+		}
+	case <-s.dropTimer():
+	}
+
+	// stop forwarding frames from the upstream before sending the close frame
+	_ = upstreamConn.Close()
+	if s.CloseCode != 0 {
+		_, _ = client.Write(closeFrame(s.CloseCode))
+	}
+}
+
+// forwardFrame forwards a frame from src to dst after the frame delay. The frame is not decoded, only its header is
+// parsed for knowing its length.
+func (s StreamDisruption) forwardFrame(dst *lockedWriter, src io.Reader) error {
+	header, length, err := readFrameHeader(src)
+	if err != nil {
+		return err
+	}
+
+	time.Sleep(s.FrameDelay)
+
+	// write the whole frame holding the lock, so it is not interleaved with a close frame
+	dst.mutex.Lock()
+	defer dst.mutex.Unlock()
+
+	if _, err = dst.w.Write(header); err != nil {
+		return err
+	}
+
+	_, err = io.CopyN(dst.w, src, int64(length)) //nolint:gosec // frames larger than 2^63 bytes are not realistic
+	return err
+}
+
+// readFrameHeader reads the header of a WebSocket frame and returns it along with the length of the payload.
+// The header includes the masking key, if present.
+func readFrameHeader(r io.Reader) ([]byte, uint64, error) {
+	header := make([]byte, 2, 14)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, 0, err
+	}
+
+	masked := header[1]&0x80 != 0
+	length := uint64(header[1] & 0x7f)
+
+	extended := 0
+	switch length {
+	case 126:
+		extended = 2
+	case 127:
+		extended = 8
+	}
+
+	rest := extended
+	if masked {
+		rest += 4
+	}
+
+	header = header[:2+rest]
+	if _, err := io.ReadFull(r, header[2:]); err != nil {
+		return nil, 0, err
+	}
+
+	switch extended {
+	case 2:
+		length = uint64(binary.BigEndian.Uint16(header[2:4]))
+	case 8:
+		length = binary.BigEndian.Uint64(header[2:10])
+	}
+
+	return header, length, nil
+}
+
+// closeFrame returns an unmasked close frame with the given close code, as sent by servers
+func closeFrame(code uint16) []byte {
+	frame := []byte{0x80 | opClose, 2, 0, 0}
+	binary.BigEndian.PutUint16(frame[2:], code)
+
+	return frame
+}
+
+// lockedWriter serializes the writes to a writer
+type lockedWriter struct {
+	mutex sync.Mutex
+	w     io.Writer
+}
+
+func (l *lockedWriter) Write(p []byte) (int, error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	return l.w.Write(p)
+}
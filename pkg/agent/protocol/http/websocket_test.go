@@ -0,0 +1,241 @@
+package http
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// websocketUpstream returns a server that sends the given number of messages when a client connects and then echoes
+// the messages it receives
+func websocketUpstream(t *testing.T, messages int) *httptest.Server {
+	t.Helper()
+
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(rw, r, nil)
+		if err != nil {
+			return
+		}
+		defer func() {
+			_ = conn.Close()
+		}()
+
+		for i := range messages {
+			if err = conn.WriteMessage(websocket.TextMessage, []byte{byte('a' + i)}); err != nil {
+				return
+			}
+		}
+
+		for {
+			kind, message, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+
+			if err = conn.WriteMessage(kind, message); err != nil {
+				return
+			}
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+// startProxy starts a proxy for the upstream and returns its address
+func startProxy(t *testing.T, upstreamURL string, disruption Disruption) string {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("creating listener: %v", err)
+	}
+
+	proxy, err := NewProxy(listener, upstreamURL, disruption)
+	if err != nil {
+		t.Fatalf("creating proxy: %v", err)
+	}
+
+	go func() {
+		_ = proxy.Start()
+	}()
+	t.Cleanup(func() { _ = proxy.Force() })
+
+	return listener.Addr().String()
+}
+
+func Test_WebSocketDisruption(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		title      string
+		disruption StreamDisruption
+		// messages sent by the upstream when the client connects
+		messages int
+		// messages sent by the client and echoed by the upstream
+		echo []string
+		// messages expected before the connection is closed
+		expected []string
+		// minimum time for receiving the expected messages
+		minElapsed time.Duration
+		// expected close code. 0 if the connection must not be closed
+		expectedClose int
+	}{
+		{
+			title:         "no disruption",
+			disruption:    StreamDisruption{},
+			messages:      2,
+			echo:          []string{"hello"},
+			expected:      []string{"a", "b", "hello"},
+			expectedClose: 0,
+		},
+		{
+			title: "frame delay",
+			disruption: StreamDisruption{
+				FrameDelay: 100 * time.Millisecond,
+			},
+			echo:          []string{"hello"},
+			expected:      []string{"hello"},
+			minElapsed:    200 * time.Millisecond,
+			expectedClose: 0,
+		},
+		{
+			title: "drop after frames with close code",
+			disruption: StreamDisruption{
+				DropAfterFrames: 2,
+				CloseCode:       4000,
+			},
+			messages:      5,
+			expected:      []string{"a", "b"},
+			expectedClose: 4000,
+		},
+		{
+			title: "drop after frames without close code",
+			disruption: StreamDisruption{
+				DropAfterFrames: 2,
+			},
+			messages:      5,
+			expected:      []string{"a", "b"},
+			expectedClose: websocket.CloseAbnormalClosure,
+		},
+		{
+			title: "drop after time",
+			disruption: StreamDisruption{
+				DropAfter: 100 * time.Millisecond,
+				CloseCode: websocket.CloseGoingAway,
+			},
+			messages:      1,
+			expected:      []string{"a"},
+			expectedClose: websocket.CloseGoingAway,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			t.Parallel()
+
+			upstream := websocketUpstream(t, tc.messages)
+			proxyAddress := startProxy(t, upstream.URL, Disruption{StreamDisruption: tc.disruption})
+
+			start := time.Now()
+			conn, resp, err := websocket.DefaultDialer.Dial("ws://"+proxyAddress, nil)
+			if err != nil {
+				t.Fatalf("connecting to proxy: %v", err)
+			}
+			_ = resp.Body.Close()
+			defer func() {
+				_ = conn.Close()
+			}()
+
+			for _, message := range tc.echo {
+				if err = conn.WriteMessage(websocket.TextMessage, []byte(message)); err != nil {
+					t.Fatalf("sending message: %v", err)
+				}
+			}
+
+			received := []string{}
+			for range tc.expected {
+				_, message, err := conn.ReadMessage()
+				if err != nil {
+					t.Fatalf("receiving message: %v", err)
+				}
+				received = append(received, string(message))
+			}
+			elapsed := time.Since(start)
+
+			if strings.Join(received, ",") != strings.Join(tc.expected, ",") {
+				t.Fatalf("expected messages %v but %v received", tc.expected, received)
+			}
+
+			if elapsed < tc.minElapsed {
+				t.Fatalf("expected messages in at least %v but received in %v", tc.minElapsed, elapsed)
+			}
+
+			if tc.expectedClose == 0 {
+				return
+			}
+
+			_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+			_, _, err = conn.ReadMessage()
+
+			// connections closed without a close frame are reported as an abnormal closure
+			var closeErr *websocket.CloseError
+			if !errors.As(err, &closeErr) || closeErr.Code != tc.expectedClose {
+				t.Fatalf("expected close code %d but got %v", tc.expectedClose, err)
+			}
+		})
+	}
+}
+
+func Test_IsWebSocketUpgrade(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		title    string
+		headers  map[string]string
+		expected bool
+	}{
+		{
+			title:    "upgrade",
+			headers:  map[string]string{"Connection": "Upgrade", "Upgrade": "websocket"},
+			expected: true,
+		},
+		{
+			title:    "upgrade with other connection options",
+			headers:  map[string]string{"Connection": "keep-alive, Upgrade", "Upgrade": "WebSocket"},
+			expected: true,
+		},
+		{
+			title:    "other protocol",
+			headers:  map[string]string{"Connection": "Upgrade", "Upgrade": "h2c"},
+			expected: false,
+		},
+		{
+			title:    "no upgrade",
+			headers:  map[string]string{},
+			expected: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			for name, value := range tc.headers {
+				req.Header.Set(name, value)
+			}
+
+			if actual := isWebSocketUpgrade(req); actual != tc.expected {
+				t.Fatalf("expected %t but %t returned", tc.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,222 @@
+package kafka
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// errMalformed is returned when the data does not follow the Kafka protocol
+var errMalformed = errors.New("malformed Kafka message")
+
+// decoder decodes the primitive types of the Kafka protocol. Flexible versions of the messages use compact encodings
+// for strings, bytes and arrays, and have tagged fields. The first error is kept and all further reads fail.
+type decoder struct {
+	data     []byte
+	flexible bool
+	err      error
+}
+
+func (d *decoder) read(n int) []byte {
+	if d.err != nil {
+		return nil
+	}
+
+	if n < 0 || len(d.data) < n {
+		d.err = fmt.Errorf("%w: unexpected end of data", errMalformed)
+		return nil
+	}
+
+	value := d.data[:n]
+	d.data = d.data[n:]
+	return value
+}
+
+func (d *decoder) int8() int8 {
+	value := d.read(1)
+	if value == nil {
+		return 0
+	}
+
+	return int8(value[0])
+}
+
+func (d *decoder) int16() int16 {
+	value := d.read(2)
+	if value == nil {
+		return 0
+	}
+
+	return int16(binary.BigEndian.Uint16(value)) //nolint:gosec // decoding a signed value
+}
+
+func (d *decoder) int32() int32 {
+	value := d.read(4)
+	if value == nil {
+		return 0
+	}
+
+	return int32(binary.BigEndian.Uint32(value)) //nolint:gosec // decoding a signed value
+}
+
+func (d *decoder) int64() int64 {
+	value := d.read(8)
+	if value == nil {
+		return 0
+	}
+
+	return int64(binary.BigEndian.Uint64(value)) //nolint:gosec // decoding a signed value
+}
+
+func (d *decoder) uvarint() uint64 {
+	if d.err != nil {
+		return 0
+	}
+
+	value, n := binary.Uvarint(d.data)
+	if n <= 0 {
+		d.err = fmt.Errorf("%w: invalid varint", errMalformed)
+		return 0
+	}
+
+	d.data = d.data[n:]
+	return value
+}
+
+// compactLength decodes the length of a compact value, which is encoded as the length plus one. Null values have a
+// length of -1.
+func (d *decoder) compactLength() int {
+	length := d.uvarint()
+	if length > uint64(len(d.data))+1 {
+		d.err = fmt.Errorf("%w: invalid length", errMalformed)
+		return 0
+	}
+
+	return int(length) - 1 //nolint:gosec // length is limited by the data
+}
+
+// arrayLength decodes the number of elements of an array. Null arrays have a length of -1.
+func (d *decoder) arrayLength() int {
+	if d.flexible {
+		return d.compactLength()
+	}
+
+	length := int(d.int32())
+	// every element takes at least one byte
+	if length > len(d.data) {
+		d.err = fmt.Errorf("%w: invalid array length", errMalformed)
+		return 0
+	}
+
+	return length
+}
+
+// string decodes a string, which can be null
+func (d *decoder) string() string {
+	length := 0
+	if d.flexible {
+		length = d.compactLength()
+	} else {
+		length = int(d.int16())
+	}
+
+	if length < 0 {
+		return ""
+	}
+
+	return string(d.read(length))
+}
+
+// bytes skips a value of type bytes, which can be null
+func (d *decoder) bytes() {
+	length := 0
+	if d.flexible {
+		length = d.compactLength()
+	} else {
+		length = int(d.int32())
+	}
+
+	if length > 0 {
+		d.read(length)
+	}
+}
+
+// taggedFields skips the tagged fields of a flexible version
+func (d *decoder) taggedFields() {
+	if !d.flexible {
+		return
+	}
+
+	for range d.uvarint() {
+		d.uvarint()
+		d.read(int(d.uvarint())) //nolint:gosec // the length is checked by read
+		if d.err != nil {
+			return
+		}
+	}
+}
+
+// encoder encodes the primitive types of the Kafka protocol
+type encoder struct {
+	data     []byte
+	flexible bool
+}
+
+func (e *encoder) int16(value int16) {
+	e.data = binary.BigEndian.AppendUint16(e.data, uint16(value)) //nolint:gosec // encoding a signed value
+}
+
+func (e *encoder) int32(value int32) {
+	e.data = binary.BigEndian.AppendUint32(e.data, uint32(value)) //nolint:gosec // encoding a signed value
+}
+
+func (e *encoder) int64(value int64) {
+	e.data = binary.BigEndian.AppendUint64(e.data, uint64(value)) //nolint:gosec // encoding a signed value
+}
+
+// arrayLength encodes the number of elements of an array. Null arrays have a length of -1.
+func (e *encoder) arrayLength(length int) {
+	if e.flexible {
+		e.data = binary.AppendUvarint(e.data, uint64(length+1)) //nolint:gosec // length is at least -1
+		return
+	}
+
+	e.int32(int32(length)) //nolint:gosec // length is limited by the size of the messages
+}
+
+func (e *encoder) string(value string) {
+	if e.flexible {
+		e.data = binary.AppendUvarint(e.data, uint64(len(value)+1))
+	} else {
+		e.int16(int16(len(value))) //nolint:gosec // length is limited by the size of the messages
+	}
+
+	e.data = append(e.data, value...)
+}
+
+// nullString encodes a null string
+func (e *encoder) nullString() {
+	if e.flexible {
+		e.data = append(e.data, 0)
+		return
+	}
+
+	e.int16(-1)
+}
+
+// emptyBytes encodes an empty value of type bytes
+func (e *encoder) emptyBytes() {
+	if e.flexible {
+		e.data = append(e.data, 1)
+		return
+	}
+
+	e.int32(0)
+}
+
+// taggedFields encodes an empty set of tagged fields in a flexible version
+func (e *encoder) taggedFields() {
+	if e.flexible {
+		e.data = append(e.data, 0)
+	}
+}
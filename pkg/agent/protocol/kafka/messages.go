@@ -0,0 +1,361 @@
+package kafka
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// maxMessageLength is the maximum length of a request accepted by the proxy, which is the default maximum accepted
+// by Kafka brokers
+const maxMessageLength = 100 * 1024 * 1024
+
+// minRequestLength is the length of the shortest request header
+const minRequestLength = 10
+
+// keys of the APIs whose requests are decoded by the proxy
+const (
+	produceKey     = 0
+	fetchKey       = 1
+	apiVersionsKey = 18
+)
+
+// maxDecodedVersion is the latest version of the Produce and Fetch requests decoded by the proxy. Later versions
+// identify the topics by their id instead of their name. The proxy limits the versions the brokers advertise to the
+// clients, so they do not use later versions.
+const maxDecodedVersion = 12
+
+// first flexible version of the Produce and Fetch APIs
+const (
+	produceFlexibleVersion     = 9
+	fetchFlexibleVersion       = 12
+	apiVersionsFlexibleVersion = 3
+)
+
+// topic is a topic included in a Produce or Fetch request
+type topic struct {
+	name       string
+	partitions []int32
+}
+
+// request is a request sent by a client
+type request struct {
+	// raw is the encoding of the request, as sent by the client
+	raw           []byte
+	apiKey        int16
+	version       int16
+	correlationID int32
+	// acks is the number of acknowledgments required by a Produce request. Produce requests with no acknowledgments
+	// do not receive a response.
+	acks int16
+	// decoded is true if the topics of a Produce or Fetch request were decoded
+	decoded bool
+	topics  []topic
+}
+
+// expectsResponse returns if the broker sends a response to the request
+func (r request) expectsResponse() bool {
+	return r.apiKey != produceKey || r.acks != 0
+}
+
+// hasTopic returns if the request includes any of the topics
+func (r request) hasTopic(names []string) bool {
+	for _, t := range r.topics {
+		for _, name := range names {
+			if t.name == name {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// peekLength returns the length of the message the reader starts with, without consuming it. Returns false if the
+// data does not start with a valid length, such as a TLS handshake.
+func peekLength(r *bufio.Reader) (int, bool, error) {
+	header, err := r.Peek(4)
+	if err != nil {
+		return 0, false, err
+	}
+
+	length := int(int32(binary.BigEndian.Uint32(header))) //nolint:gosec // decoding a signed value
+	return length, length >= minRequestLength && length <= maxMessageLength, nil
+}
+
+// readMessage reads a message prefixed by its length, returning the encoding of the message including the length
+func readMessage(r io.Reader) ([]byte, error) {
+	header := make([]byte, 4)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, err
+	}
+
+	length := int64(int32(binary.BigEndian.Uint32(header))) //nolint:gosec // decoding a signed value
+	if length < 4 || length > maxMessageLength {
+		return nil, fmt.Errorf("%w: invalid length %d", errMalformed, length)
+	}
+
+	message := make([]byte, 4+length)
+	copy(message, header)
+	if _, err := io.ReadFull(r, message[4:]); err != nil {
+		return nil, err
+	}
+
+	return message, nil
+}
+
+// readRequest reads a request sent by a client, decoding the topics of Produce and Fetch requests
+func readRequest(r io.Reader) (request, error) {
+	raw, err := readMessage(r)
+	if err != nil {
+		return request{}, err
+	}
+
+	d := &decoder{data: raw[4:]}
+	req := request{
+		raw:           raw,
+		apiKey:        d.int16(),
+		version:       d.int16(),
+		correlationID: d.int32(),
+		acks:          -1,
+	}
+	if d.err != nil {
+		return request{}, d.err
+	}
+
+	switch req.apiKey {
+	case produceKey:
+		decodeProduceRequest(d, &req)
+	case fetchKey:
+		decodeFetchRequest(d, &req)
+	}
+
+	return req, nil
+}
+
+// decodeHeader skips the rest of the header of a request. The client id is never a compact string.
+func decodeHeader(d *decoder, flexible bool) {
+	d.string()
+	d.flexible = flexible
+	d.taggedFields()
+}
+
+// decodeProduceRequest decodes the acknowledgments and the topics of a Produce request. The acknowledgments are
+// decoded in all versions, as they determine if the request receives a response.
+func decodeProduceRequest(d *decoder, req *request) {
+	decodeHeader(d, req.version >= produceFlexibleVersion)
+
+	if req.version >= 3 {
+		// transactional id
+		d.string()
+	}
+	req.acks = d.int16()
+	if d.err != nil {
+		// assume the request receives a response, as it is the default
+		req.acks = -1
+		return
+	}
+
+	if req.version > maxDecodedVersion {
+		return
+	}
+
+	// timeout
+	d.int32()
+
+	topics := make([]topic, 0, max(d.arrayLength(), 0))
+	for range cap(topics) {
+		t := topic{name: d.string()}
+		for range d.arrayLength() {
+			t.partitions = append(t.partitions, d.int32())
+			// records
+			d.bytes()
+			d.taggedFields()
+		}
+		d.taggedFields()
+		topics = append(topics, t)
+	}
+
+	req.topics = topics
+	req.decoded = d.err == nil
+}
+
+// decodeFetchRequest decodes the topics of a Fetch request
+func decodeFetchRequest(d *decoder, req *request) {
+	if req.version > maxDecodedVersion {
+		return
+	}
+
+	decodeHeader(d, req.version >= fetchFlexibleVersion)
+
+	// replica id, max wait and min bytes
+	d.read(12)
+	if req.version >= 3 {
+		// max bytes
+		d.int32()
+	}
+	if req.version >= 4 {
+		// isolation level
+		d.int8()
+	}
+	if req.version >= 7 {
+		// session id and epoch
+		d.read(8)
+	}
+
+	topics := make([]topic, 0, max(d.arrayLength(), 0))
+	for range cap(topics) {
+		t := topic{name: d.string()}
+		for range d.arrayLength() {
+			t.partitions = append(t.partitions, d.int32())
+			if req.version >= 9 {
+				// current leader epoch
+				d.int32()
+			}
+			// fetch offset
+			d.int64()
+			if req.version >= 12 {
+				// last fetched epoch
+				d.int32()
+			}
+			if req.version >= 5 {
+				// log start offset
+				d.int64()
+			}
+			// partition max bytes
+			d.int32()
+			d.taggedFields()
+		}
+		d.taggedFields()
+		topics = append(topics, t)
+	}
+
+	req.topics = topics
+	req.decoded = d.err == nil
+}
+
+// errorResponse returns the encoding of a response to a Produce or Fetch request that returns the error code for
+// all the partitions of the request
+func errorResponse(req request, code int16) []byte {
+	e := &encoder{data: make([]byte, 4, 64)}
+	e.int32(req.correlationID)
+
+	switch req.apiKey {
+	case produceKey:
+		e.flexible = req.version >= produceFlexibleVersion
+		e.taggedFields()
+		encodeProduceResponse(e, req, code)
+	case fetchKey:
+		e.flexible = req.version >= fetchFlexibleVersion
+		e.taggedFields()
+		encodeFetchResponse(e, req, code)
+	}
+
+	binary.BigEndian.PutUint32(e.data, uint32(len(e.data)-4)) //nolint:gosec // length is limited
+	return e.data
+}
+
+func encodeProduceResponse(e *encoder, req request, code int16) {
+	e.arrayLength(len(req.topics))
+	for _, t := range req.topics {
+		e.string(t.name)
+		e.arrayLength(len(t.partitions))
+		for _, partition := range t.partitions {
+			e.int32(partition)
+			e.int16(code)
+			// base offset
+			e.int64(-1)
+			if req.version >= 2 {
+				// log append time
+				e.int64(-1)
+			}
+			if req.version >= 5 {
+				// log start offset
+				e.int64(-1)
+			}
+			if req.version >= 8 {
+				// record errors and error message
+				e.arrayLength(0)
+				e.nullString()
+			}
+			e.taggedFields()
+		}
+		e.taggedFields()
+	}
+
+	if req.version >= 1 {
+		// throttle time
+		e.int32(0)
+	}
+	e.taggedFields()
+}
+
+func encodeFetchResponse(e *encoder, req request, code int16) {
+	if req.version >= 1 {
+		// throttle time
+		e.int32(0)
+	}
+	if req.version >= 7 {
+		// no error and no session, so the client starts a new fetch session
+		e.int16(0)
+		e.int32(0)
+	}
+
+	e.arrayLength(len(req.topics))
+	for _, t := range req.topics {
+		e.string(t.name)
+		e.arrayLength(len(t.partitions))
+		for _, partition := range t.partitions {
+			e.int32(partition)
+			e.int16(code)
+			// high watermark
+			e.int64(-1)
+			if req.version >= 4 {
+				// last stable offset
+				e.int64(-1)
+			}
+			if req.version >= 5 {
+				// log start offset
+				e.int64(-1)
+			}
+			if req.version >= 4 {
+				// aborted transactions
+				e.arrayLength(-1)
+			}
+			if req.version >= 11 {
+				// preferred read replica
+				e.int32(-1)
+			}
+			// records
+			e.emptyBytes()
+			e.taggedFields()
+		}
+		e.taggedFields()
+	}
+	e.taggedFields()
+}
+
+// limitVersions limits the latest version of the Produce and Fetch APIs advertised in a response to an ApiVersions
+// request to the latest version decoded by the proxy. The response is modified in place.
+func limitVersions(response []byte, version int16) error {
+	// skip the length and the correlation id. The header of the responses to ApiVersions has no tagged fields.
+	d := &decoder{data: response[8:]}
+
+	// if the version is not supported, the broker returns an error in the first version
+	code := d.int16()
+	d.flexible = version >= apiVersionsFlexibleVersion && code == 0
+
+	for range d.arrayLength() {
+		key := d.int16()
+		// min version
+		d.int16()
+		maxVersion := d.data
+		if d.int16() > maxDecodedVersion && (key == produceKey || key == fetchKey) {
+			binary.BigEndian.PutUint16(maxVersion, maxDecodedVersion)
+		}
+		d.taggedFields()
+	}
+
+	return d.err
+}
@@ -0,0 +1,226 @@
+package kafka
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// frame sets the length of a message encoded after a placeholder for the length
+func frame(message []byte) []byte {
+	binary.BigEndian.PutUint32(message, uint32(len(message)-4)) //nolint:gosec // length is limited
+	return message
+}
+
+// requestHeader returns an encoder with the header of a request
+func requestHeader(apiKey int16, version int16, correlationID int32, flexible bool) *encoder {
+	e := &encoder{data: make([]byte, 4)}
+	e.int16(apiKey)
+	e.int16(version)
+	e.int32(correlationID)
+	// the client id is never a compact string
+	e.string("test-client")
+	e.flexible = flexible
+	e.taggedFields()
+
+	return e
+}
+
+func produceRequest(version int16, correlationID int32, acks int16, topics ...topic) []byte {
+	e := requestHeader(produceKey, version, correlationID, version >= produceFlexibleVersion)
+	if version >= 3 {
+		// transactional id
+		e.nullString()
+	}
+	e.int16(acks)
+	// timeout
+	e.int32(1000)
+	e.arrayLength(len(topics))
+	for _, t := range topics {
+		e.string(t.name)
+		e.arrayLength(len(t.partitions))
+		for _, partition := range t.partitions {
+			e.int32(partition)
+			// records
+			e.emptyBytes()
+			e.taggedFields()
+		}
+		e.taggedFields()
+	}
+	e.taggedFields()
+
+	return frame(e.data)
+}
+
+//nolint:cyclop // the fields depend on the version
+func fetchRequest(version int16, correlationID int32, topics ...topic) []byte {
+	e := requestHeader(fetchKey, version, correlationID, version >= fetchFlexibleVersion)
+	// replica id, max wait and min bytes
+	e.int32(-1)
+	e.int32(500)
+	e.int32(1)
+	if version >= 3 {
+		// max bytes
+		e.int32(1024)
+	}
+	if version >= 4 {
+		// isolation level
+		e.data = append(e.data, 0)
+	}
+	if version >= 7 {
+		// session id and epoch
+		e.int32(0)
+		e.int32(-1)
+	}
+	e.arrayLength(len(topics))
+	for _, t := range topics {
+		e.string(t.name)
+		e.arrayLength(len(t.partitions))
+		for _, partition := range t.partitions {
+			e.int32(partition)
+			if version >= 9 {
+				// current leader epoch
+				e.int32(-1)
+			}
+			// fetch offset
+			e.int64(0)
+			if version >= 12 {
+				// last fetched epoch
+				e.int32(-1)
+			}
+			if version >= 5 {
+				// log start offset
+				e.int64(-1)
+			}
+			// partition max bytes
+			e.int32(1024)
+			e.taggedFields()
+		}
+		e.taggedFields()
+	}
+	if version >= 7 {
+		// forgotten topics
+		e.arrayLength(0)
+	}
+	if version >= 11 {
+		// rack id
+		e.string("")
+	}
+	e.taggedFields()
+
+	return frame(e.data)
+}
+
+func apiVersionsRequest(version int16, correlationID int32) []byte {
+	e := requestHeader(apiVersionsKey, version, correlationID, version >= apiVersionsFlexibleVersion)
+	if version >= apiVersionsFlexibleVersion {
+		// client software name and version
+		e.string("test")
+		e.string("1.0")
+		e.taggedFields()
+	}
+
+	return frame(e.data)
+}
+
+// apiVersionsResponse returns a response to an ApiVersions request with the given latest versions of the Produce and
+// Fetch APIs
+func apiVersionsResponse(version int16, correlationID int32, produceVersion int16, fetchVersion int16) []byte {
+	e := &encoder{data: make([]byte, 4)}
+	e.int32(correlationID)
+	e.flexible = version >= apiVersionsFlexibleVersion
+
+	// error code
+	e.int16(0)
+	e.arrayLength(3)
+	for _, api := range [][]int16{{produceKey, 3, produceVersion}, {fetchKey, 4, fetchVersion}, {apiVersionsKey, 0, 4}} {
+		e.int16(api[0])
+		e.int16(api[1])
+		e.int16(api[2])
+		e.taggedFields()
+	}
+	if version >= 1 {
+		// throttle time
+		e.int32(0)
+	}
+	e.taggedFields()
+
+	return frame(e.data)
+}
+
+func Test_ReadRequest(t *testing.T) {
+	t.Parallel()
+
+	topics := []topic{{name: "orders", partitions: []int32{0, 1}}, {name: "users", partitions: []int32{2}}}
+
+	testCases := []struct {
+		title    string
+		request  []byte
+		expected request
+	}{
+		{
+			title:    "produce",
+			request:  produceRequest(3, 1, -1, topics...),
+			expected: request{apiKey: produceKey, version: 3, correlationID: 1, acks: -1, decoded: true, topics: topics},
+		},
+		{
+			title:    "flexible produce without acknowledgments",
+			request:  produceRequest(9, 2, 0, topics...),
+			expected: request{apiKey: produceKey, version: 9, correlationID: 2, acks: 0, decoded: true, topics: topics},
+		},
+		{
+			title:    "produce with topic ids",
+			request:  produceRequest(13, 3, 1),
+			expected: request{apiKey: produceKey, version: 13, correlationID: 3, acks: 1, decoded: false},
+		},
+		{
+			title:    "fetch",
+			request:  fetchRequest(4, 4, topics...),
+			expected: request{apiKey: fetchKey, version: 4, correlationID: 4, acks: -1, decoded: true, topics: topics},
+		},
+		{
+			title:    "flexible fetch",
+			request:  fetchRequest(12, 5, topics...),
+			expected: request{apiKey: fetchKey, version: 12, correlationID: 5, acks: -1, decoded: true, topics: topics},
+		},
+		{
+			title:    "other API",
+			request:  apiVersionsRequest(3, 6),
+			expected: request{apiKey: apiVersionsKey, version: 3, correlationID: 6, acks: -1},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			t.Parallel()
+
+			req, err := readRequest(bytes.NewReader(tc.request))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			tc.expected.raw = tc.request
+			if diff := cmp.Diff(tc.expected, req, cmp.AllowUnexported(request{}, topic{})); diff != "" {
+				t.Fatalf("expected request does not match returned:\n%s", diff)
+			}
+		})
+	}
+}
+
+func Test_LimitVersions(t *testing.T) {
+	t.Parallel()
+
+	for _, version := range []int16{0, 3} {
+		response := apiVersionsResponse(version, 1, 13, 17)
+		if err := limitVersions(response, version); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		expected := apiVersionsResponse(version, 1, maxDecodedVersion, maxDecodedVersion)
+		if !bytes.Equal(expected, response) {
+			t.Fatalf("expected response %v but got %v in version %d", expected, response, version)
+		}
+	}
+}
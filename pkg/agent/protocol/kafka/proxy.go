@@ -0,0 +1,286 @@
+// Package kafka implements a proxy that applies disruptions to the requests sent to a Kafka broker.
+// The proxy decodes the requests for selecting them by their API and topics. The responses to the requests are delayed,
+// and Produce and Fetch requests can also return an error for all their partitions instead of being forwarded to the
+// broker.
+//
+// The proxy limits the versions of the Produce and Fetch APIs advertised by the broker to the versions that identify
+// topics by their name. Connections that use TLS are forwarded without disruption.
+package kafka
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"slices"
+	"time"
+
+	"github.com/grafana/xk6-disruptor/pkg/agent/protocol"
+	"github.com/grafana/xk6-disruptor/pkg/agent/protocol/tcp"
+)
+
+// maxPending is the maximum number of requests waiting for their response in a connection before the proxy stops
+// reading requests from the client
+const maxPending = 128
+
+// Disruption specifies disruptions in the requests sent to a Kafka broker
+type Disruption struct {
+	// Average delay introduced to requests
+	AverageDelay time.Duration
+	// Variation in the delay (with respect of the average delay)
+	DelayVariation time.Duration
+	// Fraction (in the range 0.0 to 1.0) of Produce and Fetch requests that will return an error
+	ErrorRate float32
+	// Error returned for all the partitions of the requests selected to return an error, given by its name
+	// (e.g. "NOT_LEADER_OR_FOLLOWER") or its numeric code
+	Error string
+	// Rules define the disruption of the requests that match them. The first rule that matches a request is applied.
+	// The requests that do not match any rule are disrupted with the settings above.
+	Rules []Rule
+}
+
+// NewProxy return a new Proxy for the requests sent to the upstream
+func NewProxy(listener net.Listener, upstreamAddress string, d Disruption) (protocol.Proxy, error) {
+	return newProxy(listener, upstreamAddress, d, &net.Dialer{})
+}
+
+// NewEgressProxy returns a new Proxy for the requests sent by the target to an upstream. The connections to the
+// upstream are marked so they are not redirected back to the proxy.
+func NewEgressProxy(listener net.Listener, upstreamAddress string, d Disruption) (protocol.Proxy, error) {
+	return newProxy(listener, upstreamAddress, d, protocol.MarkedDialer())
+}
+
+func newProxy(
+	listener net.Listener,
+	upstreamAddress string,
+	d Disruption,
+	dialer *net.Dialer,
+) (protocol.Proxy, error) {
+	defaultRule := Rule{
+		AverageDelay:   d.AverageDelay,
+		DelayVariation: d.DelayVariation,
+		ErrorRate:      d.ErrorRate,
+		Error:          d.Error,
+	}
+	if err := defaultRule.compile(); err != nil {
+		return nil, err
+	}
+
+	// compile a copy of the rules, so the caller's rules are not modified
+	rules := slices.Clone(d.Rules)
+	for i := range rules {
+		if err := rules[i].compile(); err != nil {
+			return nil, fmt.Errorf("invalid rule %d: %w", i, err)
+		}
+	}
+
+	metrics := protocol.NewMetricMap(supportedMetrics()...)
+	h := &handler{
+		rules:       rules,
+		defaultRule: defaultRule,
+		metrics:     metrics,
+	}
+
+	return tcp.NewConnectionProxy(listener, upstreamAddress, dialer, h, metrics)
+}
+
+// handler forwards the requests sent in the connections accepted by the proxy, applying the disruption
+type handler struct {
+	rules []Rule
+	// defaultRule applies to the requests that do not match any rule
+	defaultRule Rule
+	metrics     *protocol.MetricMap
+}
+
+// rule returns the rule that applies to a request
+func (h *handler) rule(req request) Rule {
+	for _, rule := range h.rules {
+		if rule.Match.matches(req) {
+			return rule
+		}
+	}
+
+	return h.defaultRule
+}
+
+// pending is a request waiting for its response to be sent to the client. Responses are sent in the order the
+// requests are received, so the responses of the disrupted requests are sent after the responses of the preceding
+// requests.
+type pending struct {
+	// response sent to the client instead of the broker's response. If nil, the request was forwarded to the broker.
+	response []byte
+	// due is the time the response can be sent to the client: the time the request was received plus its delay
+	due time.Time
+	// apiKey and version of the request
+	apiKey  int16
+	version int16
+}
+
+// Handle forwards the requests sent by the client to the upstream, and their responses back to the client
+func (h *handler) Handle(ctx context.Context, client net.Conn, dial tcp.DialFunc) {
+	upstream, err := dial()
+	if err != nil {
+		return
+	}
+
+	clientReader := bufio.NewReader(client)
+	_, isKafka, err := peekLength(clientReader)
+	if err != nil {
+		return
+	}
+
+	if !isKafka {
+		passthrough(client, clientReader, upstream)
+		return
+	}
+
+	// the delayed responses are discarded when the client closes the connection
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	responses := make(chan pending, maxPending)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		forwardResponses(ctx, client, upstream, responses)
+		// stop reading requests if the responses cannot be forwarded
+		_ = client.Close()
+	}()
+
+	h.forwardRequests(clientReader, upstream, responses, done)
+	close(responses)
+	cancel()
+
+	// the client closed the connection, so the pending responses are discarded
+	_ = upstream.Close()
+	<-done
+}
+
+// passthrough forwards the connection without decoding it
+func passthrough(client net.Conn, clientReader io.Reader, upstream net.Conn) {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		_, _ = io.Copy(upstream, clientReader)
+		_ = upstream.Close()
+	}()
+
+	_, _ = io.Copy(client, upstream)
+	_ = client.Close()
+	<-done
+}
+
+// forwardRequests reads the requests sent by the client and forwards them to the upstream, unless the disruption
+// responds to them. Delayed requests are forwarded immediately, and their responses are delayed. The requests that do
+// not expect a response are not delayed.
+func (h *handler) forwardRequests(
+	client io.Reader,
+	upstream io.Writer,
+	responses chan<- pending,
+	done <-chan struct{},
+) {
+	for {
+		req, err := readRequest(client)
+		if err != nil {
+			return
+		}
+
+		response, delay := h.disrupt(req)
+		p := pending{response: response, due: time.Now().Add(delay), apiKey: req.apiKey, version: req.version}
+		if p.response == nil {
+			if _, err = upstream.Write(req.raw); err != nil {
+				return
+			}
+		}
+
+		if !req.expectsResponse() {
+			continue
+		}
+
+		select {
+		case responses <- p:
+		case <-done:
+			return
+		}
+	}
+}
+
+// disrupt applies the disruption to a request. Returns the response to the request, if the request must not be
+// forwarded, and the delay of the response.
+func (h *handler) disrupt(req request) ([]byte, time.Duration) {
+	h.metrics.Inc(protocol.MetricRequests)
+
+	rule := h.rule(req)
+
+	delay := rule.AverageDelay
+	if rule.DelayVariation > 0 {
+		variation := int64(rule.DelayVariation)
+		delay += time.Duration(variation - 2*rand.Int63n(variation))
+	}
+
+	// errors can only be returned to the Produce and Fetch requests whose topics were decoded
+	isError := req.decoded && rule.ErrorRate > 0 && rand.Float32() <= rule.ErrorRate
+	if isError || delay > 0 {
+		h.metrics.Inc(protocol.MetricRequestsDisrupted)
+	}
+
+	if isError {
+		return errorResponse(req, rule.errorCode), delay
+	}
+
+	return nil, delay
+}
+
+// forwardResponses sends the responses to the client's requests, in the order of the requests and once they are due.
+// Stops when the context is done.
+func forwardResponses(ctx context.Context, client io.Writer, upstream io.Reader, responses <-chan pending) {
+	reader := bufio.NewReader(upstream)
+	for p := range responses {
+		response := p.response
+		if response == nil {
+			var err error
+			if response, err = readMessage(reader); err != nil {
+				return
+			}
+
+			if p.apiKey == apiVersionsKey {
+				if err = limitVersions(response, p.version); err != nil {
+					return
+				}
+			}
+		}
+
+		if !wait(ctx, p.due) {
+			return
+		}
+
+		if _, err := client.Write(response); err != nil {
+			return
+		}
+	}
+}
+
+// wait waits until the given time. Returns false if the context is done before.
+func wait(ctx context.Context, until time.Time) bool {
+	timer := time.NewTimer(time.Until(until))
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// supportedMetrics returns the metrics that the kafka proxy supports and thus should be pre-initialized to zero
+func supportedMetrics() []string {
+	return []string{
+		protocol.MetricRequests,
+		protocol.MetricRequestsDisrupted,
+	}
+}
@@ -0,0 +1,420 @@
+package kafka
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/grafana/xk6-disruptor/pkg/agent/protocol"
+)
+
+// heartbeatKey is the key of an API whose requests are not decoded by the proxy
+const heartbeatKey = 12
+
+// kafkaBroker starts a broker that implements the ApiVersions, Produce and Fetch APIs, and returns its address.
+// Produce and Fetch requests succeed for all their partitions and the requests to other APIs receive a response
+// with no error. If tls is true, the broker echoes all the data received.
+func kafkaBroker(t *testing.T, tls bool) string {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("creating listener: %v", err)
+	}
+	t.Cleanup(func() { _ = listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+
+			if tls {
+				go func() {
+					defer func() {
+						_ = conn.Close()
+					}()
+					_, _ = io.Copy(conn, conn)
+				}()
+				continue
+			}
+
+			go serveKafka(conn)
+		}
+	}()
+
+	return listener.Addr().String()
+}
+
+func serveKafka(conn net.Conn) {
+	defer func() {
+		_ = conn.Close()
+	}()
+
+	reader := bufio.NewReader(conn)
+	for {
+		req, err := readRequest(reader)
+		if err != nil {
+			return
+		}
+
+		var response []byte
+		switch req.apiKey {
+		case apiVersionsKey:
+			response = apiVersionsResponse(req.version, req.correlationID, 13, 17)
+		case produceKey, fetchKey:
+			if !req.expectsResponse() {
+				continue
+			}
+			response = errorResponse(req, 0)
+		default:
+			e := &encoder{data: make([]byte, 4)}
+			e.int32(req.correlationID)
+			e.int16(0)
+			response = frame(e.data)
+		}
+
+		if _, err = conn.Write(response); err != nil {
+			return
+		}
+	}
+}
+
+func heartbeatRequest(correlationID int32) []byte {
+	return frame(requestHeader(heartbeatKey, 4, correlationID, true).data)
+}
+
+func heartbeatResponse(correlationID int32) []byte {
+	e := &encoder{data: make([]byte, 4)}
+	e.int32(correlationID)
+	e.int16(0)
+	return frame(e.data)
+}
+
+// response returns the response to a Produce or Fetch request with the given error code
+func response(t *testing.T, encoded []byte, code int16) []byte {
+	t.Helper()
+
+	req, err := readRequest(bytes.NewReader(encoded))
+	if err != nil {
+		t.Fatalf("decoding request: %v", err)
+	}
+
+	return errorResponse(req, code)
+}
+
+func Test_Validations(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		title       string
+		disruption  Disruption
+		upstream    string
+		expectError bool
+	}{
+		{
+			title:       "valid defaults",
+			disruption:  Disruption{},
+			upstream:    ":9092",
+			expectError: false,
+		},
+		{
+			title:       "valid error name",
+			disruption:  Disruption{ErrorRate: 0.1, Error: "not_leader_or_follower"},
+			upstream:    ":9092",
+			expectError: false,
+		},
+		{
+			title:       "valid error code",
+			disruption:  Disruption{ErrorRate: 0.1, Error: "7"},
+			upstream:    ":9092",
+			expectError: false,
+		},
+		{
+			title:       "invalid upstream address",
+			disruption:  Disruption{},
+			upstream:    "",
+			expectError: true,
+		},
+		{
+			title:       "error rate without error",
+			disruption:  Disruption{ErrorRate: 0.1},
+			upstream:    ":9092",
+			expectError: true,
+		},
+		{
+			title:       "unknown error",
+			disruption:  Disruption{ErrorRate: 0.1, Error: "LEADER_GONE"},
+			upstream:    ":9092",
+			expectError: true,
+		},
+		{
+			title: "unknown API",
+			disruption: Disruption{
+				Rules: []Rule{{Match: Match{APIs: []string{"Consume"}}, AverageDelay: time.Second}},
+			},
+			upstream:    ":9092",
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			t.Parallel()
+
+			listener, err := net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatalf("creating listener: %v", err)
+			}
+			defer func() {
+				_ = listener.Close()
+			}()
+
+			_, err = NewProxy(listener, tc.upstream, tc.disruption)
+			if !tc.expectError && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if tc.expectError && err == nil {
+				t.Fatalf("expected error but none returned")
+			}
+		})
+	}
+}
+
+//nolint:funlen,maintidx // the test cases are long
+func Test_ProxyDisruption(t *testing.T) {
+	t.Parallel()
+
+	orders := topic{name: "orders", partitions: []int32{0, 1}}
+	users := topic{name: "users", partitions: []int32{0}}
+
+	produce := produceRequest(9, 1, -1, orders)
+	produceUsers := produceRequest(3, 2, 1, users)
+	produceNoAcks := produceRequest(9, 3, 0, orders)
+	fetch := fetchRequest(12, 4, orders, users)
+	fetchV4 := fetchRequest(4, 5, orders)
+
+	testCases := []struct {
+		title      string
+		disruption Disruption
+		// requests sent in a single batch
+		requests [][]byte
+		// responses expected for the requests that receive a response
+		expected [][]byte
+		// minimum time for receiving the responses
+		minElapsed time.Duration
+		// maximum time for receiving the responses, if not zero
+		maxElapsed      time.Duration
+		expectedMetrics map[string]uint
+	}{
+		{
+			title:      "no disruption",
+			disruption: Disruption{},
+			requests:   [][]byte{produce, produceNoAcks, fetch, heartbeatRequest(6)},
+			expected:   [][]byte{response(t, produce, 0), response(t, fetch, 0), heartbeatResponse(6)},
+			expectedMetrics: map[string]uint{
+				protocol.MetricRequests:          4,
+				protocol.MetricRequestsDisrupted: 0,
+			},
+		},
+		{
+			title:      "error in produce and fetch",
+			disruption: Disruption{ErrorRate: 1.0, Error: "NOT_LEADER_OR_FOLLOWER"},
+			requests:   [][]byte{produce, heartbeatRequest(6), fetchV4, produceNoAcks, produceUsers},
+			expected: [][]byte{
+				response(t, produce, 6),
+				heartbeatResponse(6),
+				response(t, fetchV4, 6),
+				response(t, produceUsers, 6),
+			},
+			expectedMetrics: map[string]uint{
+				protocol.MetricRequests:          5,
+				protocol.MetricRequestsDisrupted: 4,
+			},
+		},
+		{
+			title: "error in topic",
+			disruption: Disruption{
+				Rules: []Rule{{Match: Match{Topics: []string{"users"}}, ErrorRate: 1.0, Error: "REQUEST_TIMED_OUT"}},
+			},
+			requests: [][]byte{produce, produceUsers, fetch},
+			expected: [][]byte{response(t, produce, 0), response(t, produceUsers, 7), response(t, fetch, 7)},
+			expectedMetrics: map[string]uint{
+				protocol.MetricRequests:          3,
+				protocol.MetricRequestsDisrupted: 2,
+			},
+		},
+		{
+			title: "delay in API",
+			disruption: Disruption{
+				Rules: []Rule{{Match: Match{APIs: []string{"heartbeat"}}, AverageDelay: 100 * time.Millisecond}},
+			},
+			requests:   [][]byte{heartbeatRequest(1), produce, heartbeatRequest(2)},
+			expected:   [][]byte{heartbeatResponse(1), response(t, produce, 0), heartbeatResponse(2)},
+			minElapsed: 100 * time.Millisecond,
+			// the delays of pipelined requests do not accumulate
+			maxElapsed: 190 * time.Millisecond,
+			expectedMetrics: map[string]uint{
+				protocol.MetricRequests:          3,
+				protocol.MetricRequestsDisrupted: 2,
+			},
+		},
+		{
+			title:      "api versions are limited",
+			disruption: Disruption{},
+			requests:   [][]byte{apiVersionsRequest(0, 1), apiVersionsRequest(3, 2)},
+			expected: [][]byte{
+				apiVersionsResponse(0, 1, maxDecodedVersion, maxDecodedVersion),
+				apiVersionsResponse(3, 2, maxDecodedVersion, maxDecodedVersion),
+			},
+			expectedMetrics: map[string]uint{
+				protocol.MetricRequests:          2,
+				protocol.MetricRequestsDisrupted: 0,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			t.Parallel()
+
+			upstream := kafkaBroker(t, false)
+
+			listener, err := net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatalf("creating listener: %v", err)
+			}
+
+			proxy, err := NewProxy(listener, upstream, tc.disruption)
+			if err != nil {
+				t.Fatalf("creating proxy: %v", err)
+			}
+
+			go func() {
+				_ = proxy.Start()
+			}()
+			t.Cleanup(func() { _ = proxy.Force() })
+
+			conn, err := net.Dial("tcp", listener.Addr().String())
+			if err != nil {
+				t.Fatalf("connecting to proxy: %v", err)
+			}
+			defer func() {
+				_ = conn.Close()
+			}()
+			_ = conn.SetDeadline(time.Now().Add(2 * time.Second))
+
+			start := time.Now()
+			if _, err = conn.Write(bytes.Join(tc.requests, nil)); err != nil {
+				t.Fatalf("sending requests: %v", err)
+			}
+
+			reader := bufio.NewReader(conn)
+			responses := [][]byte{}
+			for range tc.expected {
+				response, err := readMessage(reader)
+				if err != nil {
+					t.Fatalf("receiving response: %v", err)
+				}
+				responses = append(responses, response)
+			}
+			elapsed := time.Since(start)
+
+			if diff := cmp.Diff(tc.expected, responses); diff != "" {
+				t.Fatalf("expected responses do not match returned:\n%s", diff)
+			}
+
+			if elapsed < tc.minElapsed {
+				t.Fatalf("expected responses in at least %v but received in %v", tc.minElapsed, elapsed)
+			}
+
+			if tc.maxElapsed > 0 && elapsed > tc.maxElapsed {
+				t.Fatalf("expected responses in at most %v but received in %v", tc.maxElapsed, elapsed)
+			}
+
+			if diff := cmp.Diff(tc.expectedMetrics, proxy.Metrics()); diff != "" {
+				t.Fatalf("expected metrics do not match returned:\n%s", diff)
+			}
+		})
+	}
+}
+
+func Test_ProxyTLS(t *testing.T) {
+	t.Parallel()
+
+	upstream := kafkaBroker(t, true)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("creating listener: %v", err)
+	}
+
+	proxy, err := NewProxy(listener, upstream, Disruption{ErrorRate: 1.0, Error: "NOT_LEADER_OR_FOLLOWER"})
+	if err != nil {
+		t.Fatalf("creating proxy: %v", err)
+	}
+
+	go func() {
+		_ = proxy.Start()
+	}()
+	t.Cleanup(func() { _ = proxy.Force() })
+
+	conn, err := net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatalf("connecting to proxy: %v", err)
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+	_ = conn.SetDeadline(time.Now().Add(2 * time.Second))
+
+	// the start of a TLS handshake is forwarded without decoding it
+	handshake := []byte{0x16, 0x03, 0x01, 0x02, 0x00, 0x01}
+	if _, err = conn.Write(handshake); err != nil {
+		t.Fatalf("sending data: %v", err)
+	}
+
+	received := make([]byte, len(handshake))
+	if _, err = io.ReadFull(conn, received); err != nil {
+		t.Fatalf("receiving data: %v", err)
+	}
+
+	if !bytes.Equal(handshake, received) {
+		t.Fatalf("expected %v but %v received", handshake, received)
+	}
+
+	if requests := proxy.Metrics()[protocol.MetricRequests]; requests != 0 {
+		t.Fatalf("expected no requests but %d counted", requests)
+	}
+}
+
+func Test_ForwardResponsesCanceled(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+
+	responses := make(chan pending, 1)
+	responses <- pending{response: []byte("response"), due: time.Now().Add(time.Hour)}
+	close(responses)
+
+	client := &bytes.Buffer{}
+
+	start := time.Now()
+	forwardResponses(ctx, client, &bytes.Buffer{}, responses)
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("expected delay to end when the context is done, but took %v", elapsed)
+	}
+
+	if client.Len() > 0 {
+		t.Fatalf("unexpected response sent to the client: %q", client.String())
+	}
+}
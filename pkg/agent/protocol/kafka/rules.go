@@ -0,0 +1,178 @@
+package kafka
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// apiKeys maps the names of the most common APIs to their keys
+var apiKeys = map[string]int16{ //nolint:gochecknoglobals
+	"PRODUCE":                0,
+	"FETCH":                  1,
+	"LISTOFFSETS":            2,
+	"METADATA":               3,
+	"OFFSETCOMMIT":           8,
+	"OFFSETFETCH":            9,
+	"FINDCOORDINATOR":        10,
+	"JOINGROUP":              11,
+	"HEARTBEAT":              12,
+	"LEAVEGROUP":             13,
+	"SYNCGROUP":              14,
+	"DESCRIBEGROUPS":         15,
+	"LISTGROUPS":             16,
+	"APIVERSIONS":            18,
+	"INITPRODUCERID":         22,
+	"ADDPARTITIONSTOTXN":     24,
+	"ADDOFFSETSTOTXN":        25,
+	"ENDTXN":                 26,
+	"TXNOFFSETCOMMIT":        28,
+	"CONSUMERGROUPHEARTBEAT": 68,
+}
+
+// errorCodes maps the names of the errors that can be returned to Produce and Fetch requests to their codes
+var errorCodes = map[string]int16{ //nolint:gochecknoglobals
+	"UNKNOWN_SERVER_ERROR":             -1,
+	"OFFSET_OUT_OF_RANGE":              1,
+	"CORRUPT_MESSAGE":                  2,
+	"UNKNOWN_TOPIC_OR_PARTITION":       3,
+	"LEADER_NOT_AVAILABLE":             5,
+	"NOT_LEADER_OR_FOLLOWER":           6,
+	"REQUEST_TIMED_OUT":                7,
+	"NETWORK_EXCEPTION":                13,
+	"NOT_ENOUGH_REPLICAS":              19,
+	"NOT_ENOUGH_REPLICAS_AFTER_APPEND": 20,
+	"TOPIC_AUTHORIZATION_FAILED":       29,
+	"KAFKA_STORAGE_ERROR":              56,
+	"FENCED_LEADER_EPOCH":              74,
+	"UNKNOWN_LEADER_EPOCH":             75,
+	"OFFSET_NOT_AVAILABLE":             78,
+	"THROTTLING_QUOTA_EXCEEDED":        89,
+}
+
+// parseAPIKey returns the key of an API given by its name, such as "Produce", or its numeric key
+func parseAPIKey(value string) (int16, error) {
+	if key, found := apiKeys[strings.ToUpper(value)]; found {
+		return key, nil
+	}
+
+	key, err := strconv.ParseInt(value, 10, 16)
+	if err != nil || key < 0 {
+		return 0, fmt.Errorf("unknown API %q", value)
+	}
+
+	return int16(key), nil
+}
+
+// parseErrorCode returns the code of an error given by its name, such as "NOT_LEADER_OR_FOLLOWER", or its numeric
+// code
+func parseErrorCode(value string) (int16, error) {
+	if code, found := errorCodes[strings.ToUpper(value)]; found {
+		return code, nil
+	}
+
+	code, err := strconv.ParseInt(value, 10, 16)
+	if err != nil || code == 0 {
+		return 0, fmt.Errorf("unknown error %q", value)
+	}
+
+	return int16(code), nil
+}
+
+// Match selects requests by their API and topics. A request matches if it satisfies all the criteria specified.
+// An empty Match selects all requests.
+type Match struct {
+	// APIs selects the requests to any of these APIs, given by their name (e.g. "Produce", "JoinGroup") or their
+	// numeric key. Names are not case-sensitive
+	APIs []string `json:"apis,omitempty"`
+	// Topics selects the Produce and Fetch requests that include any of these topics
+	Topics []string `json:"topics,omitempty"`
+
+	apiKeys []int16
+}
+
+// Rule defines the disruption applied to the requests that match a Match
+type Rule struct {
+	Match Match `json:"match"`
+	// Average delay introduced to requests
+	AverageDelay time.Duration `json:"averageDelay,omitempty"`
+	// Variation in the delay (with respect of the average delay)
+	DelayVariation time.Duration `json:"delayVariation,omitempty"`
+	// Fraction (in the range 0.0 to 1.0) of Produce and Fetch requests that will return an error
+	ErrorRate float32 `json:"errorRate,omitempty"`
+	// Error returned for all the partitions of the requests selected to return an error, given by its name
+	// (e.g. "NOT_LEADER_OR_FOLLOWER") or its numeric code
+	Error string `json:"error,omitempty"`
+
+	errorCode int16
+}
+
+// ParseRules parses a list of rules in JSON format
+func ParseRules(values []string) ([]Rule, error) {
+	rules := []Rule{}
+	for _, value := range values {
+		decoder := json.NewDecoder(bytes.NewBufferString(value))
+		decoder.DisallowUnknownFields()
+
+		rule := Rule{}
+		if err := decoder.Decode(&rule); err != nil {
+			return nil, fmt.Errorf("parsing rule %q: %w", value, err)
+		}
+
+		rules = append(rules, rule)
+	}
+
+	return rules, nil
+}
+
+// compile validates the rule and compiles its error and match
+func (r *Rule) compile() error {
+	if r.DelayVariation > r.AverageDelay {
+		return fmt.Errorf("variation must be less that average delay")
+	}
+
+	if r.ErrorRate < 0.0 || r.ErrorRate > 1.0 {
+		return fmt.Errorf("error rate must be in the range [0.0, 1.0]")
+	}
+
+	if r.ErrorRate > 0.0 && r.Error == "" {
+		return fmt.Errorf("error is required when the error rate is set")
+	}
+
+	r.errorCode = 0
+	if r.Error != "" {
+		code, err := parseErrorCode(r.Error)
+		if err != nil {
+			return err
+		}
+		r.errorCode = code
+	}
+
+	r.Match.apiKeys = nil
+	for _, api := range r.Match.APIs {
+		key, err := parseAPIKey(api)
+		if err != nil {
+			return err
+		}
+		r.Match.apiKeys = append(r.Match.apiKeys, key)
+	}
+
+	return nil
+}
+
+// matches returns if the request satisfies all the criteria of the Match
+func (m Match) matches(req request) bool {
+	if len(m.apiKeys) > 0 && !slices.Contains(m.apiKeys, req.apiKey) {
+		return false
+	}
+
+	if len(m.Topics) > 0 && !req.hasTopic(m.Topics) {
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,134 @@
+package kafka
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func Test_ParseRules(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		title       string
+		values      []string
+		expected    []Rule
+		expectError bool
+	}{
+		{
+			title: "valid rules",
+			values: []string{
+				`{"match":{"apis":["Produce"],"topics":["orders"]},"errorRate":1,"error":"NOT_LEADER_OR_FOLLOWER"}`,
+				`{"match":{"apis":["JoinGroup","SyncGroup"]},"averageDelay":100000000}`,
+			},
+			expected: []Rule{
+				{
+					Match:     Match{APIs: []string{"Produce"}, Topics: []string{"orders"}},
+					ErrorRate: 1,
+					Error:     "NOT_LEADER_OR_FOLLOWER",
+				},
+				{
+					Match:        Match{APIs: []string{"JoinGroup", "SyncGroup"}},
+					AverageDelay: 100 * time.Millisecond,
+				},
+			},
+		},
+		{
+			title:       "unknown field",
+			values:      []string{`{"match":{"api":"Produce"}}`},
+			expectError: true,
+		},
+		{
+			title:       "malformed rule",
+			values:      []string{`{"match":`},
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			t.Parallel()
+
+			rules, err := ParseRules(tc.values)
+			if !tc.expectError && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if tc.expectError && err == nil {
+				t.Fatalf("expected error but none returned")
+			}
+
+			if tc.expectError {
+				return
+			}
+
+			if diff := cmp.Diff(tc.expected, rules, cmpopts.IgnoreUnexported(Rule{}, Match{})); diff != "" {
+				t.Fatalf("expected rules do not match returned:\n%s", diff)
+			}
+		})
+	}
+}
+
+func Test_RuleCompile(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		title        string
+		rule         Rule
+		expectedCode int16
+		expectedKeys []int16
+		expectError  bool
+	}{
+		{
+			title:        "names",
+			rule:         Rule{Match: Match{APIs: []string{"produce", "Fetch"}}, ErrorRate: 1, Error: "request_timed_out"},
+			expectedCode: 7,
+			expectedKeys: []int16{0, 1},
+		},
+		{
+			title:        "numeric values",
+			rule:         Rule{Match: Match{APIs: []string{"22"}}, ErrorRate: 1, Error: "-1"},
+			expectedCode: -1,
+			expectedKeys: []int16{22},
+		},
+		{
+			title:       "no error code",
+			rule:        Rule{ErrorRate: 1, Error: "0"},
+			expectError: true,
+		},
+		{
+			title:       "invalid delay variation",
+			rule:        Rule{AverageDelay: time.Second, DelayVariation: 2 * time.Second},
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			t.Parallel()
+
+			err := tc.rule.compile()
+			if !tc.expectError && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if tc.expectError && err == nil {
+				t.Fatalf("expected error but none returned")
+			}
+
+			if tc.expectError {
+				return
+			}
+
+			if tc.rule.errorCode != tc.expectedCode {
+				t.Fatalf("expected error code %d but got %d", tc.expectedCode, tc.rule.errorCode)
+			}
+
+			if diff := cmp.Diff(tc.expectedKeys, tc.rule.Match.apiKeys); diff != "" {
+				t.Fatalf("expected API keys do not match returned:\n%s", diff)
+			}
+		})
+	}
+}
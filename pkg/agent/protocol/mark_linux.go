@@ -0,0 +1,21 @@
+//go:build linux
+// +build linux
+
+package protocol
+
+import (
+	"syscall"
+)
+
+// markConn sets the ProxyMark in the socket of a connection
+func markConn(_, _ string, c syscall.RawConn) error {
+	var err error
+	cerr := c.Control(func(fd uintptr) {
+		err = syscall.SetsockoptInt(int(fd), syscall.SOL_SOCKET, syscall.SO_MARK, ProxyMark)
+	})
+	if cerr != nil {
+		return cerr
+	}
+
+	return err
+}
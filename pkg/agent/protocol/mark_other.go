@@ -0,0 +1,14 @@
+//go:build !linux
+// +build !linux
+
+package protocol
+
+import (
+	"fmt"
+	"syscall"
+)
+
+// markConn is not supported in this platform
+func markConn(_, _ string, _ syscall.RawConn) error {
+	return fmt.Errorf("marking connections is not supported in this platform")
+}
@@ -0,0 +1,166 @@
+package postgres
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+const (
+	// maxStartupLength is the maximum length of a startup packet accepted by PostgreSQL
+	maxStartupLength = 10000
+	// maxMessageLength is the maximum length of a message accepted by the proxy
+	maxMessageLength = 1 << 30
+)
+
+// codes sent in the startup packet for requesting an encrypted connection or cancelling a query
+const (
+	cancelRequestCode   = 80877102
+	sslRequestCode      = 80877103
+	gssEncRequestCode   = 80877104
+	startupHeaderLength = 8
+)
+
+// types of the messages sent by the client
+const (
+	queryMessage    = 'Q'
+	parseMessage    = 'P'
+	bindMessage     = 'B'
+	executeMessage  = 'E'
+	closeMessage    = 'C'
+	syncMessage     = 'S'
+	functionMessage = 'F'
+)
+
+// types of the messages sent by the server
+const (
+	errorMessage = 'E'
+	readyMessage = 'Z'
+)
+
+// transaction status reported by the server in the ReadyForQuery message
+const (
+	statusIdle          = 'I'
+	statusInTransaction = 'T'
+	statusFailed        = 'E'
+)
+
+// errMalformed is returned when the data does not follow the PostgreSQL protocol
+var errMalformed = errors.New("malformed PostgreSQL message")
+
+// message is a message of the PostgreSQL protocol exchanged after the startup packet
+type message struct {
+	kind    byte
+	payload []byte
+}
+
+// encode returns the encoding of the message
+func (m message) encode() []byte {
+	encoded := make([]byte, 5, 5+len(m.payload))
+	encoded[0] = m.kind
+	binary.BigEndian.PutUint32(encoded[1:], uint32(len(m.payload)+4)) //nolint:gosec // length is limited
+	return append(encoded, m.payload...)
+}
+
+// readHeader reads the type and the length of the payload of a message
+func readHeader(r io.Reader) (byte, int64, error) {
+	header := make([]byte, 5)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return 0, 0, err
+	}
+
+	length := int64(binary.BigEndian.Uint32(header[1:]))
+	if length < 4 || length > maxMessageLength {
+		return 0, 0, fmt.Errorf("%w: invalid length %d", errMalformed, length)
+	}
+
+	return header[0], length - 4, nil
+}
+
+// readPayload reads the payload of a message with the given length
+func readPayload(r io.Reader, length int64) ([]byte, error) {
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return nil, err
+	}
+
+	return payload, nil
+}
+
+// copyMessage copies a message with the given type and payload length to the writer without decoding its payload
+func copyMessage(w io.Writer, r io.Reader, kind byte, length int64) error {
+	header := make([]byte, 5)
+	header[0] = kind
+	binary.BigEndian.PutUint32(header[1:], uint32(length+4)) //nolint:gosec // length is limited
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+
+	_, err := io.CopyN(w, r, length)
+	return err
+}
+
+// peekStartup returns the code of the startup packet sent by the client, without consuming it. Returns false if the
+// data sent by the client is not a startup packet, such as a TLS handshake sent without requesting it first.
+func peekStartup(r *bufio.Reader) (uint32, bool, error) {
+	header, err := r.Peek(startupHeaderLength)
+	if err != nil {
+		return 0, false, err
+	}
+
+	length := binary.BigEndian.Uint32(header)
+	if length < startupHeaderLength || length > maxStartupLength {
+		return 0, false, nil
+	}
+
+	return binary.BigEndian.Uint32(header[4:]), true, nil
+}
+
+// readStartup reads the startup packet sent by the client
+func readStartup(r *bufio.Reader) ([]byte, error) {
+	header, err := r.Peek(4)
+	if err != nil {
+		return nil, err
+	}
+
+	packet := make([]byte, binary.BigEndian.Uint32(header))
+	if _, err = io.ReadFull(r, packet); err != nil {
+		return nil, err
+	}
+
+	return packet, nil
+}
+
+// cstring returns the null-terminated string at the start of the data and the data that follows it
+func cstring(data []byte) (string, []byte, error) {
+	end := bytes.IndexByte(data, 0)
+	if end < 0 {
+		return "", nil, fmt.Errorf("%w: unterminated string", errMalformed)
+	}
+
+	return string(data[:end]), data[end+1:], nil
+}
+
+// errorResponse returns an ErrorResponse message with the given severity, SQLSTATE code and message
+func errorResponse(severity string, code string, text string) message {
+	payload := &bytes.Buffer{}
+	for _, field := range []struct {
+		kind  byte
+		value string
+	}{
+		{'S', severity},
+		{'V', severity},
+		{'C', code},
+		{'M', text},
+	} {
+		payload.WriteByte(field.kind)
+		payload.WriteString(field.value)
+		payload.WriteByte(0)
+	}
+	payload.WriteByte(0)
+
+	return message{kind: errorMessage, payload: payload.Bytes()}
+}
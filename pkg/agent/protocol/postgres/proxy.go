@@ -0,0 +1,485 @@
+// Package postgres implements a proxy that applies disruptions to the queries sent to a PostgreSQL server.
+// The proxy decodes the messages of the PostgreSQL protocol for selecting the queries, which are delayed, fail with
+// an error or terminate the connection to the server.
+//
+// Errors are injected by replacing the query with a request the server rejects, and replacing the server's error
+// with the injected one. Therefore, the state of the transaction in the server is the same as if the query had failed.
+// Connections that negotiate TLS or GSSAPI encryption are forwarded without disruption.
+package postgres
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"slices"
+	"sync/atomic"
+	"time"
+
+	"github.com/grafana/xk6-disruptor/pkg/agent/protocol"
+	"github.com/grafana/xk6-disruptor/pkg/agent/protocol/tcp"
+)
+
+// DefaultErrorMessage is the message of the errors injected if no message is specified
+const DefaultErrorMessage = "injected error"
+
+// maxPending is the maximum number of injected errors waiting for the server's reply in a connection before the proxy
+// stops reading messages from the client
+const maxPending = 128
+
+// invalidQuery is sent instead of the queries selected to return an error. It is rejected by the server, which
+// aborts the transaction in progress.
+const invalidQuery = "xk6-disruptor injected error"
+
+// invalidPortal is executed instead of the portals selected to return an error. The server fails to execute it as it
+// does not exist.
+const invalidPortal = "xk6-disruptor injected error"
+
+// terminationCode and terminationMessage are returned by the server when a connection is terminated by an
+// administrator
+const (
+	terminationCode    = "57P01"
+	terminationMessage = "terminating connection due to administrator command"
+)
+
+// Disruption specifies disruptions in the queries sent to a PostgreSQL server
+type Disruption struct {
+	// Average delay introduced to queries
+	AverageDelay time.Duration
+	// Variation in the delay (with respect of the average delay)
+	DelayVariation time.Duration
+	// Fraction (in the range 0.0 to 1.0) of queries that will return an error
+	ErrorRate float32
+	// SQLSTATE code of the error returned by the queries selected to return an error (e.g. "40001")
+	ErrorCode string
+	// Message of the error returned by the queries selected to return an error. If empty, DefaultErrorMessage is
+	// returned.
+	ErrorMessage string
+	// Fraction (in the range 0.0 to 1.0) of queries that terminate the connection to the server, aborting the
+	// transaction in progress
+	TerminateRate float32
+	// Rules define the disruption of the queries that match them. The first rule that matches a query is applied.
+	// The queries that do not match any rule are disrupted with the settings above.
+	Rules []Rule
+}
+
+// rule returns the rule that applies to a query
+func (d Disruption) rule(query string, inTransaction bool) Rule {
+	for _, rule := range d.Rules {
+		if rule.Match.matches(query, inTransaction) {
+			return rule
+		}
+	}
+
+	return Rule{
+		AverageDelay:   d.AverageDelay,
+		DelayVariation: d.DelayVariation,
+		ErrorRate:      d.ErrorRate,
+		ErrorCode:      d.ErrorCode,
+		ErrorMessage:   d.ErrorMessage,
+		TerminateRate:  d.TerminateRate,
+	}
+}
+
+// NewProxy return a new Proxy for the queries sent to the upstream
+func NewProxy(listener net.Listener, upstreamAddress string, d Disruption) (protocol.Proxy, error) {
+	return newProxy(listener, upstreamAddress, d, &net.Dialer{})
+}
+
+// NewEgressProxy returns a new Proxy for the queries sent by the target to an upstream. The connections to the
+// upstream are marked so they are not redirected back to the proxy.
+func NewEgressProxy(listener net.Listener, upstreamAddress string, d Disruption) (protocol.Proxy, error) {
+	return newProxy(listener, upstreamAddress, d, protocol.MarkedDialer())
+}
+
+func newProxy(
+	listener net.Listener,
+	upstreamAddress string,
+	d Disruption,
+	dialer *net.Dialer,
+) (protocol.Proxy, error) {
+	if err := d.rule("", false).validate(); err != nil {
+		return nil, err
+	}
+
+	// compile a copy of the rules, so the caller's rules are not modified
+	d.Rules = slices.Clone(d.Rules)
+	for i := range d.Rules {
+		if err := d.Rules[i].compile(); err != nil {
+			return nil, fmt.Errorf("invalid rule %d: %w", i, err)
+		}
+	}
+
+	metrics := protocol.NewMetricMap(supportedMetrics()...)
+
+	return tcp.NewConnectionProxy(listener, upstreamAddress, dialer, &handler{disruption: d, metrics: metrics}, metrics)
+}
+
+// handler forwards the messages sent in the connections accepted by the proxy, applying the disruption
+type handler struct {
+	disruption Disruption
+	metrics    *protocol.MetricMap
+}
+
+// Handle forwards the messages sent by the client to the upstream, and the messages sent by the upstream back to the
+// client
+func (h *handler) Handle(_ context.Context, client net.Conn, dial tcp.DialFunc) {
+	upstream, err := dial()
+	if err != nil {
+		return
+	}
+
+	s := &session{
+		handler:        h,
+		client:         client,
+		clientReader:   bufio.NewReader(client),
+		upstream:       upstream,
+		upstreamReader: bufio.NewReader(upstream),
+		statements:     map[string]string{},
+		portals:        map[string]string{},
+		// the startup is the first cycle
+		cycle:      1,
+		injections: make(chan injection, maxPending),
+		done:       make(chan struct{}),
+	}
+
+	decoded, err := s.startup()
+	if err != nil {
+		return
+	}
+
+	if !decoded {
+		s.passthrough()
+		return
+	}
+
+	s.status.Store(statusIdle)
+	go func() {
+		defer close(s.done)
+
+		s.forwardReplies()
+		// stop reading messages if the replies cannot be forwarded
+		_ = client.Close()
+	}()
+
+	// the server must reply to the messages sent before the connection is terminated
+	if !s.forwardMessages() {
+		_ = upstream.Close()
+	}
+	<-s.done
+}
+
+// injection is a disruption of a query whose reply from the server must be replaced
+type injection struct {
+	// cycle is the number of the cycle of the query. A cycle is the sequence of messages that ends with a message the
+	// server replies to with a ReadyForQuery message.
+	cycle uint64
+	// reply replaces the error returned by the server in the cycle
+	reply message
+	// terminate terminates the connection when the server completes the cycle
+	terminate bool
+}
+
+// session is a connection forwarded by the proxy
+type session struct {
+	*handler
+	client         net.Conn
+	clientReader   *bufio.Reader
+	upstream       net.Conn
+	upstreamReader *bufio.Reader
+	// statements maps the name of the prepared statements to their query
+	statements map[string]string
+	// portals maps the name of the portals to their query
+	portals map[string]string
+	// cycle is the number of the current cycle of the messages sent by the client
+	cycle uint64
+	// status is the transaction status reported by the server in the last ReadyForQuery message
+	status     atomic.Uint32
+	injections chan injection
+	done       chan struct{}
+}
+
+// startup forwards the startup packets sent by the client. Returns false if the messages that follow cannot be
+// decoded, as the connection is encrypted or it is not a regular connection.
+func (s *session) startup() (bool, error) {
+	for {
+		code, isStartup, err := peekStartup(s.clientReader)
+		if err != nil || !isStartup {
+			return false, err
+		}
+
+		packet, err := readStartup(s.clientReader)
+		if err != nil {
+			return false, err
+		}
+
+		if _, err = s.upstream.Write(packet); err != nil {
+			return false, err
+		}
+
+		switch code {
+		case sslRequestCode, gssEncRequestCode:
+			// the server accepts the encryption with 'S' or 'G', or rejects it with 'N'
+			response, err := s.upstreamReader.ReadByte()
+			if err != nil {
+				return false, err
+			}
+
+			if _, err = s.client.Write([]byte{response}); err != nil {
+				return false, err
+			}
+
+			if response != 'N' {
+				return false, nil
+			}
+		case cancelRequestCode:
+			return false, nil
+		default:
+			return true, nil
+		}
+	}
+}
+
+// passthrough forwards the connection without decoding it
+func (s *session) passthrough() {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		_, _ = io.Copy(s.upstream, s.clientReader)
+		_ = s.upstream.Close()
+	}()
+
+	_, _ = io.Copy(s.client, s.upstreamReader)
+	_ = s.client.Close()
+	<-done
+}
+
+// forwardMessages reads the messages sent by the client and forwards them to the upstream, applying the disruption
+// to the queries. Returns true if the connection must be terminated after the server replies to the messages
+// already forwarded.
+func (s *session) forwardMessages() bool {
+	for {
+		kind, length, err := readHeader(s.clientReader)
+		if err != nil {
+			return false
+		}
+
+		switch kind {
+		case queryMessage, parseMessage, bindMessage, executeMessage, closeMessage:
+		default:
+			if err = copyMessage(s.upstream, s.clientReader, kind, length); err != nil {
+				return false
+			}
+
+			if kind == syncMessage || kind == functionMessage {
+				s.cycle++
+			}
+
+			continue
+		}
+
+		payload, err := readPayload(s.clientReader, length)
+		if err != nil {
+			return false
+		}
+
+		msg := message{kind: kind, payload: payload}
+		query, isQuery, err := s.track(msg)
+		if err != nil {
+			return false
+		}
+
+		if isQuery {
+			var inj *injection
+			msg, inj = s.disrupt(msg, query)
+			if inj != nil {
+				select {
+				case s.injections <- *inj:
+				case <-s.done:
+					return false
+				}
+
+				if inj.terminate {
+					_, _ = s.upstream.Write(msg.encode())
+					return true
+				}
+			}
+		}
+
+		if _, err = s.upstream.Write(msg.encode()); err != nil {
+			return false
+		}
+
+		if kind == queryMessage {
+			s.cycle++
+		}
+	}
+}
+
+// track keeps track of the queries of the prepared statements and portals. Returns the query executed by the
+// message, if any.
+func (s *session) track(msg message) (string, bool, error) {
+	switch msg.kind {
+	case queryMessage:
+		query, _, err := cstring(msg.payload)
+		return query, err == nil, err
+	case parseMessage:
+		name, rest, err := cstring(msg.payload)
+		if err != nil {
+			return "", false, err
+		}
+
+		query, _, err := cstring(rest)
+		s.statements[name] = query
+		return "", false, err
+	case bindMessage:
+		portal, rest, err := cstring(msg.payload)
+		if err != nil {
+			return "", false, err
+		}
+
+		statement, _, err := cstring(rest)
+		s.portals[portal] = s.statements[statement]
+		return "", false, err
+	case executeMessage:
+		portal, _, err := cstring(msg.payload)
+		return s.portals[portal], err == nil, err
+	case closeMessage:
+		if len(msg.payload) == 0 {
+			return "", false, errMalformed
+		}
+
+		name, _, err := cstring(msg.payload[1:])
+		if msg.payload[0] == 'S' {
+			delete(s.statements, name)
+		} else {
+			delete(s.portals, name)
+		}
+		return "", false, err
+	default:
+		return "", false, nil
+	}
+}
+
+// disrupt applies the disruption to a message that executes a query. Returns the message to be forwarded and the
+// injection to be applied to the reply, if any.
+func (s *session) disrupt(msg message, query string) (message, *injection) {
+	s.metrics.Inc(protocol.MetricRequests)
+
+	status := s.status.Load()
+	rule := s.disruption.rule(query, status == statusInTransaction || status == statusFailed)
+
+	delay := rule.AverageDelay
+	if rule.DelayVariation > 0 {
+		variation := int64(rule.DelayVariation)
+		delay += time.Duration(variation - 2*rand.Int63n(variation))
+	}
+
+	terminate := rule.TerminateRate > 0 && rand.Float32() <= rule.TerminateRate
+	isError := !terminate && rule.ErrorRate > 0 && rand.Float32() <= rule.ErrorRate
+	if terminate || isError || delay > 0 {
+		s.metrics.Inc(protocol.MetricRequestsDisrupted)
+	}
+
+	time.Sleep(delay)
+
+	switch {
+	case terminate:
+		// the server completes the cycle of the messages already forwarded
+		return message{kind: syncMessage}, &injection{cycle: s.cycle, terminate: true}
+	case isError:
+		text := rule.ErrorMessage
+		if text == "" {
+			text = DefaultErrorMessage
+		}
+		inj := &injection{cycle: s.cycle, reply: errorResponse("ERROR", rule.ErrorCode, text)}
+
+		if msg.kind == queryMessage {
+			return message{kind: queryMessage, payload: append([]byte(invalidQuery), 0)}, inj
+		}
+
+		payload := binary.BigEndian.AppendUint32(append([]byte(invalidPortal), 0), 0)
+		return message{kind: executeMessage, payload: payload}, inj
+	default:
+		return msg, nil
+	}
+}
+
+// forwardReplies forwards the messages sent by the server to the client, replacing the errors of the injections
+func (s *session) forwardReplies() {
+	// the cycle of the startup is the first one completed
+	completed := uint64(0)
+	pending := []injection{}
+	for {
+		kind, length, err := readHeader(s.upstreamReader)
+		if err != nil {
+			return
+		}
+
+		if kind != errorMessage && kind != readyMessage {
+			if err = copyMessage(s.client, s.upstreamReader, kind, length); err != nil {
+				return
+			}
+
+			continue
+		}
+
+		payload, err := readPayload(s.upstreamReader, length)
+		if err != nil {
+			return
+		}
+		msg := message{kind: kind, payload: payload}
+
+		pending = s.receiveInjections(pending, completed)
+		current := len(pending) > 0 && pending[0].cycle == completed
+
+		switch {
+		case kind == errorMessage && current && !pending[0].terminate:
+			msg = pending[0].reply
+			pending = pending[1:]
+		case kind == readyMessage && current && pending[0].terminate:
+			_, _ = s.client.Write(errorResponse("FATAL", terminationCode, terminationMessage).encode())
+			return
+		case kind == readyMessage:
+			if len(payload) > 0 {
+				s.status.Store(uint32(payload[0]))
+			}
+			completed++
+		}
+
+		if _, err = s.client.Write(msg.encode()); err != nil {
+			return
+		}
+	}
+}
+
+// receiveInjections adds the injections received to the pending injections, and discards the injections of
+// the cycles already completed, as the server did not return an error for them
+func (s *session) receiveInjections(pending []injection, completed uint64) []injection {
+	for received := true; received; {
+		select {
+		case inj := <-s.injections:
+			pending = append(pending, inj)
+		default:
+			received = false
+		}
+	}
+
+	for len(pending) > 0 && pending[0].cycle < completed {
+		pending = pending[1:]
+	}
+
+	return pending
+}
+
+// supportedMetrics returns the metrics that the postgres proxy supports and thus should be pre-initialized to zero
+func supportedMetrics() []string {
+	return []string{
+		protocol.MetricRequests,
+		protocol.MetricRequestsDisrupted,
+	}
+}
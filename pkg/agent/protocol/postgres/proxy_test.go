@@ -0,0 +1,547 @@
+package postgres
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/grafana/xk6-disruptor/pkg/agent/protocol"
+)
+
+// postgresUpstream starts a server that implements a minimal subset of the PostgreSQL protocol and returns its
+// address. The server executes the BEGIN, COMMIT and ROLLBACK statements and the queries that start with SELECT, and
+// returns an error for any other query. If acceptSSL is true, the server accepts the requests for encrypted
+// connections and echoes all the data received after accepting it.
+func postgresUpstream(t *testing.T, acceptSSL bool) string {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("creating listener: %v", err)
+	}
+	t.Cleanup(func() { _ = listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+
+			go servePostgres(conn, acceptSSL)
+		}
+	}()
+
+	return listener.Addr().String()
+}
+
+//nolint:gocognit,cyclop // the server implements a minimal version of the protocol
+func servePostgres(conn net.Conn, acceptSSL bool) {
+	defer func() {
+		_ = conn.Close()
+	}()
+
+	reader := bufio.NewReader(conn)
+	for {
+		packet, err := readStartup(reader)
+		if err != nil || len(packet) < startupHeaderLength {
+			return
+		}
+
+		if binary.BigEndian.Uint32(packet[4:]) != sslRequestCode {
+			break
+		}
+
+		if acceptSSL {
+			_, _ = conn.Write([]byte{'S'})
+			_, _ = io.Copy(conn, reader)
+			return
+		}
+
+		_, _ = conn.Write([]byte{'N'})
+	}
+
+	status := byte(statusIdle)
+	reply := func(messages ...message) {
+		for _, msg := range messages {
+			_, _ = conn.Write(msg.encode())
+		}
+	}
+	ready := func() message {
+		return message{kind: readyMessage, payload: []byte{status}}
+	}
+	fail := func(code string) message {
+		if status == statusInTransaction {
+			status = statusFailed
+		}
+		return errorResponse("ERROR", code, "failed")
+	}
+	execute := func(query string) message {
+		switch {
+		case query == "BEGIN":
+			status = statusInTransaction
+		case query == "COMMIT" || query == "ROLLBACK":
+			status = statusIdle
+		case status == statusFailed:
+			return fail("25P02")
+		case !strings.HasPrefix(query, "SELECT"):
+			return fail("42601")
+		}
+
+		tag := strings.Fields(query)[0]
+		if tag == "SELECT" {
+			tag = "SELECT 1"
+		}
+		return message{kind: 'C', payload: append([]byte(tag), 0)}
+	}
+
+	reply(message{kind: 'R', payload: []byte{0, 0, 0, 0}}, ready())
+
+	statements := map[string]string{}
+	portals := map[string]string{}
+	// after an error, the messages of the extended protocol are skipped until the next Sync
+	skipping := false
+	for {
+		kind, length, err := readHeader(reader)
+		if err != nil {
+			return
+		}
+
+		payload, err := readPayload(reader, length)
+		if err != nil {
+			return
+		}
+
+		first, rest, _ := cstring(payload)
+		second, _, _ := cstring(rest)
+
+		switch {
+		case kind == 'X':
+			return
+		case kind == queryMessage:
+			reply(execute(first), ready())
+		case kind == syncMessage:
+			skipping = false
+			reply(ready())
+		case skipping:
+		case kind == parseMessage:
+			statements[first] = second
+			reply(message{kind: '1'})
+		case kind == bindMessage:
+			portals[first] = statements[second]
+			reply(message{kind: '2'})
+		case kind == executeMessage:
+			query, found := portals[first]
+			result := fail("34000")
+			if found {
+				result = execute(query)
+			}
+			skipping = result.kind == errorMessage
+			reply(result)
+		}
+	}
+}
+
+// frontend messages used in the tests
+func query(q string) message {
+	return message{kind: queryMessage, payload: append([]byte(q), 0)}
+}
+
+func parse(name string, q string) message {
+	return message{kind: parseMessage, payload: append(append(append([]byte(name), 0), q...), 0, 0, 0)}
+}
+
+func bind(portal string, statement string) message {
+	payload := append(append(append([]byte(portal), 0), statement...), 0, 0, 0, 0, 0, 0, 0)
+	return message{kind: bindMessage, payload: payload}
+}
+
+func execute(portal string) message {
+	return message{kind: executeMessage, payload: append([]byte(portal), 0, 0, 0, 0, 0)}
+}
+
+func sync() message {
+	return message{kind: syncMessage}
+}
+
+// startupPacket returns a startup packet with the given code
+func startupPacket(code uint32) []byte {
+	packet := binary.BigEndian.AppendUint32(nil, startupHeaderLength)
+	return binary.BigEndian.AppendUint32(packet, code)
+}
+
+// connect opens a connection to the proxy, negotiates the encryption and completes the startup
+func connect(t *testing.T, address string) (net.Conn, *bufio.Reader) {
+	t.Helper()
+
+	conn, err := net.Dial("tcp", address)
+	if err != nil {
+		t.Fatalf("connecting to proxy: %v", err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	_ = conn.SetDeadline(time.Now().Add(2 * time.Second))
+
+	reader := bufio.NewReader(conn)
+	if _, err = conn.Write(startupPacket(sslRequestCode)); err != nil {
+		t.Fatalf("requesting encryption: %v", err)
+	}
+
+	if response, err := reader.ReadByte(); err != nil || response != 'N' {
+		t.Fatalf("expected encryption rejected but got %q (%v)", response, err)
+	}
+
+	// protocol version 3.0 without parameters
+	if _, err = conn.Write(startupPacket(196608)); err != nil {
+		t.Fatalf("sending startup message: %v", err)
+	}
+
+	if replies := receive(t, reader); !cmp.Equal(replies, []string{"R", "Z:I"}) {
+		t.Fatalf("unexpected startup replies %q", replies)
+	}
+
+	return conn, reader
+}
+
+// receive returns a summary of the messages received until a ReadyForQuery message is received or the connection
+// is closed
+func receive(t *testing.T, reader *bufio.Reader) []string {
+	t.Helper()
+
+	replies := []string{}
+	for {
+		kind, length, err := readHeader(reader)
+		if errors.Is(err, io.EOF) {
+			return replies
+		}
+		if err != nil {
+			t.Fatalf("receiving reply: %v", err)
+		}
+
+		payload, err := readPayload(reader, length)
+		if err != nil {
+			t.Fatalf("receiving reply: %v", err)
+		}
+
+		summary := string(kind)
+		switch kind {
+		case readyMessage:
+			replies = append(replies, summary+":"+string(payload))
+			return replies
+		case errorMessage:
+			// the code is the third field, after the severities
+			fields := strings.Split(string(payload), "\x00")
+			summary += ":" + fields[2][1:]
+		case 'C':
+			tag, _, _ := cstring(payload)
+			summary += ":" + tag
+		}
+
+		replies = append(replies, summary)
+	}
+}
+
+func Test_Validations(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		title       string
+		disruption  Disruption
+		upstream    string
+		expectError bool
+	}{
+		{
+			title:       "valid defaults",
+			disruption:  Disruption{},
+			upstream:    ":5432",
+			expectError: false,
+		},
+		{
+			title:       "valid error",
+			disruption:  Disruption{ErrorRate: 0.1, ErrorCode: "40001", ErrorMessage: "could not serialize access"},
+			upstream:    ":5432",
+			expectError: false,
+		},
+		{
+			title:       "invalid upstream address",
+			disruption:  Disruption{},
+			upstream:    "",
+			expectError: true,
+		},
+		{
+			title:       "error rate without error code",
+			disruption:  Disruption{ErrorRate: 0.1},
+			upstream:    ":5432",
+			expectError: true,
+		},
+		{
+			title:       "invalid error code",
+			disruption:  Disruption{ErrorRate: 0.1, ErrorCode: "4001"},
+			upstream:    ":5432",
+			expectError: true,
+		},
+		{
+			title:       "invalid terminate rate",
+			disruption:  Disruption{TerminateRate: 1.1},
+			upstream:    ":5432",
+			expectError: true,
+		},
+		{
+			title: "invalid rule",
+			disruption: Disruption{
+				Rules: []Rule{{Match: Match{Query: "^SELECT ("}}},
+			},
+			upstream:    ":5432",
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			t.Parallel()
+
+			listener, err := net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatalf("creating listener: %v", err)
+			}
+			defer func() {
+				_ = listener.Close()
+			}()
+
+			_, err = NewProxy(listener, tc.upstream, tc.disruption)
+			if !tc.expectError && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if tc.expectError && err == nil {
+				t.Fatalf("expected error but none returned")
+			}
+		})
+	}
+}
+
+func Test_ProxyDisruption(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		title      string
+		disruption Disruption
+		// messages sent in each step. The replies to a step are received before sending the next one
+		steps [][]message
+		// replies expected for all the steps
+		expected []string
+		// minimum time for receiving the replies
+		minElapsed      time.Duration
+		expectedMetrics map[string]uint
+	}{
+		{
+			title:      "no disruption",
+			disruption: Disruption{},
+			steps: [][]message{
+				{query("SELECT 1")},
+				{parse("", "SELECT 1"), bind("", ""), execute(""), sync()},
+			},
+			expected: []string{"C:SELECT 1", "Z:I", "1", "2", "C:SELECT 1", "Z:I"},
+			expectedMetrics: map[string]uint{
+				protocol.MetricRequests:          2,
+				protocol.MetricRequestsDisrupted: 0,
+			},
+		},
+		{
+			title:      "error in all queries",
+			disruption: Disruption{ErrorRate: 1.0, ErrorCode: "53300"},
+			steps: [][]message{
+				{query("SELECT 1")},
+				{parse("", "SELECT 1"), bind("", ""), execute(""), sync()},
+			},
+			expected: []string{"E:53300", "Z:I", "1", "2", "E:53300", "Z:I"},
+			expectedMetrics: map[string]uint{
+				protocol.MetricRequests:          2,
+				protocol.MetricRequestsDisrupted: 2,
+			},
+		},
+		{
+			title: "error aborts the transaction",
+			disruption: Disruption{
+				Rules: []Rule{{Match: Match{Query: "^SELECT"}, ErrorRate: 1.0, ErrorCode: "40001"}},
+			},
+			steps: [][]message{
+				{query("BEGIN")},
+				{query("SELECT 1")},
+				{query("ROLLBACK")},
+			},
+			expected: []string{"C:BEGIN", "Z:T", "E:40001", "Z:E", "C:ROLLBACK", "Z:I"},
+			expectedMetrics: map[string]uint{
+				protocol.MetricRequests:          3,
+				protocol.MetricRequestsDisrupted: 1,
+			},
+		},
+		{
+			title: "error in prepared statement in transaction",
+			disruption: Disruption{
+				Rules: []Rule{{Match: Match{InTransaction: true}, ErrorRate: 1.0, ErrorCode: "40001"}},
+			},
+			steps: [][]message{
+				{parse("stmt", "SELECT 1"), sync()},
+				{bind("", "stmt"), execute(""), sync()},
+				{query("BEGIN")},
+				{bind("", "stmt"), execute(""), sync()},
+			},
+			expected: []string{"1", "Z:I", "2", "C:SELECT 1", "Z:I", "C:BEGIN", "Z:T", "2", "E:40001", "Z:E"},
+			expectedMetrics: map[string]uint{
+				protocol.MetricRequests:          3,
+				protocol.MetricRequestsDisrupted: 1,
+			},
+		},
+		{
+			title:      "delay",
+			disruption: Disruption{AverageDelay: 100 * time.Millisecond},
+			steps: [][]message{
+				{query("SELECT 1")},
+				{parse("", "SELECT 1"), bind("", ""), execute(""), sync()},
+			},
+			expected:   []string{"C:SELECT 1", "Z:I", "1", "2", "C:SELECT 1", "Z:I"},
+			minElapsed: 200 * time.Millisecond,
+			expectedMetrics: map[string]uint{
+				protocol.MetricRequests:          2,
+				protocol.MetricRequestsDisrupted: 2,
+			},
+		},
+		{
+			title: "terminate in transaction",
+			disruption: Disruption{
+				Rules: []Rule{{Match: Match{InTransaction: true, Query: "^SELECT"}, TerminateRate: 1.0}},
+			},
+			steps: [][]message{
+				{query("SELECT 1")},
+				{query("BEGIN")},
+				{query("SELECT 1")},
+			},
+			expected: []string{"C:SELECT 1", "Z:I", "C:BEGIN", "Z:T", "E:57P01"},
+			expectedMetrics: map[string]uint{
+				protocol.MetricRequests:          3,
+				protocol.MetricRequestsDisrupted: 1,
+			},
+		},
+		{
+			title:      "terminate extended query",
+			disruption: Disruption{TerminateRate: 1.0},
+			steps: [][]message{
+				{parse("", "SELECT 1"), bind("", ""), execute(""), sync()},
+			},
+			expected: []string{"1", "2", "E:57P01"},
+			expectedMetrics: map[string]uint{
+				protocol.MetricRequests:          1,
+				protocol.MetricRequestsDisrupted: 1,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			t.Parallel()
+
+			upstream := postgresUpstream(t, false)
+
+			listener, err := net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatalf("creating listener: %v", err)
+			}
+
+			proxy, err := NewProxy(listener, upstream, tc.disruption)
+			if err != nil {
+				t.Fatalf("creating proxy: %v", err)
+			}
+
+			go func() {
+				_ = proxy.Start()
+			}()
+			t.Cleanup(func() { _ = proxy.Force() })
+
+			conn, reader := connect(t, listener.Addr().String())
+
+			start := time.Now()
+			replies := []string{}
+			for _, step := range tc.steps {
+				for _, msg := range step {
+					if _, err = conn.Write(msg.encode()); err != nil {
+						t.Fatalf("sending message: %v", err)
+					}
+				}
+
+				replies = append(replies, receive(t, reader)...)
+			}
+			elapsed := time.Since(start)
+
+			if diff := cmp.Diff(tc.expected, replies); diff != "" {
+				t.Fatalf("expected replies do not match returned:\n%s", diff)
+			}
+
+			if elapsed < tc.minElapsed {
+				t.Fatalf("expected replies in at least %v but received in %v", tc.minElapsed, elapsed)
+			}
+
+			if diff := cmp.Diff(tc.expectedMetrics, proxy.Metrics()); diff != "" {
+				t.Fatalf("expected metrics do not match returned:\n%s", diff)
+			}
+		})
+	}
+}
+
+func Test_ProxyEncryptedConnection(t *testing.T) {
+	t.Parallel()
+
+	upstream := postgresUpstream(t, true)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("creating listener: %v", err)
+	}
+
+	proxy, err := NewProxy(listener, upstream, Disruption{ErrorRate: 1.0, ErrorCode: "40001"})
+	if err != nil {
+		t.Fatalf("creating proxy: %v", err)
+	}
+
+	go func() {
+		_ = proxy.Start()
+	}()
+	t.Cleanup(func() { _ = proxy.Force() })
+
+	conn, err := net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatalf("connecting to proxy: %v", err)
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+	_ = conn.SetDeadline(time.Now().Add(2 * time.Second))
+
+	if _, err = conn.Write(startupPacket(sslRequestCode)); err != nil {
+		t.Fatalf("requesting encryption: %v", err)
+	}
+
+	// the data that follows is forwarded without decoding it
+	if _, err = conn.Write(query("SELECT 1").encode()); err != nil {
+		t.Fatalf("sending data: %v", err)
+	}
+
+	expected := append([]byte{'S'}, query("SELECT 1").encode()...)
+	received := make([]byte, len(expected))
+	if _, err = io.ReadFull(conn, received); err != nil {
+		t.Fatalf("receiving data: %v", err)
+	}
+
+	if !cmp.Equal(expected, received) {
+		t.Fatalf("expected %q but %q received", expected, received)
+	}
+
+	if requests := proxy.Metrics()[protocol.MetricRequests]; requests != 0 {
+		t.Fatalf("expected no queries but %d counted", requests)
+	}
+}
@@ -0,0 +1,121 @@
+package postgres
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// sqlStateRegexp matches a valid SQLSTATE code
+var sqlStateRegexp = regexp.MustCompile(`^[0-9A-Z]{5}$`)
+
+// Match selects queries by their text and the state of the transaction they are executed in. A query matches if it
+// satisfies all the criteria specified. An empty Match selects all queries.
+type Match struct {
+	// Query selects the queries whose text matches this regular expression (e.g. "(?i)^\\s*UPDATE orders")
+	Query string `json:"query,omitempty"`
+	// InTransaction selects only the queries executed inside a transaction block
+	InTransaction bool `json:"inTransaction,omitempty"`
+
+	queryRegexp *regexp.Regexp
+}
+
+// Rule defines the disruption applied to the queries that match a Match
+type Rule struct {
+	Match Match `json:"match"`
+	// Average delay introduced to queries
+	AverageDelay time.Duration `json:"averageDelay,omitempty"`
+	// Variation in the delay (with respect of the average delay)
+	DelayVariation time.Duration `json:"delayVariation,omitempty"`
+	// Fraction (in the range 0.0 to 1.0) of queries that will return an error
+	ErrorRate float32 `json:"errorRate,omitempty"`
+	// SQLSTATE code of the error returned by the queries selected to return an error (e.g. "40001")
+	ErrorCode string `json:"errorCode,omitempty"`
+	// Message of the error returned by the queries selected to return an error. If empty, DefaultErrorMessage is
+	// returned.
+	ErrorMessage string `json:"errorMessage,omitempty"`
+	// Fraction (in the range 0.0 to 1.0) of queries that terminate the connection to the server, aborting the
+	// transaction in progress
+	TerminateRate float32 `json:"terminateRate,omitempty"`
+}
+
+// ParseRules parses a list of rules in JSON format
+func ParseRules(values []string) ([]Rule, error) {
+	rules := []Rule{}
+	for _, value := range values {
+		decoder := json.NewDecoder(bytes.NewBufferString(value))
+		decoder.DisallowUnknownFields()
+
+		rule := Rule{}
+		if err := decoder.Decode(&rule); err != nil {
+			return nil, fmt.Errorf("parsing rule %q: %w", value, err)
+		}
+
+		rules = append(rules, rule)
+	}
+
+	return rules, nil
+}
+
+// validate checks the delay, error and termination settings of the rule
+func (r Rule) validate() error {
+	if r.DelayVariation > r.AverageDelay {
+		return fmt.Errorf("variation must be less that average delay")
+	}
+
+	if r.ErrorRate < 0.0 || r.ErrorRate > 1.0 {
+		return fmt.Errorf("error rate must be in the range [0.0, 1.0]")
+	}
+
+	if r.ErrorRate > 0.0 && r.ErrorCode == "" {
+		return fmt.Errorf("error code is required when the error rate is set")
+	}
+
+	if r.ErrorCode != "" && !sqlStateRegexp.MatchString(r.ErrorCode) {
+		return fmt.Errorf("error code %q is not a valid SQLSTATE code", r.ErrorCode)
+	}
+
+	if strings.ContainsRune(r.ErrorMessage, 0) {
+		return fmt.Errorf("error message cannot contain null characters")
+	}
+
+	if r.TerminateRate < 0.0 || r.TerminateRate > 1.0 {
+		return fmt.Errorf("terminate rate must be in the range [0.0, 1.0]")
+	}
+
+	return nil
+}
+
+// compile validates the rule and compiles its match
+func (r *Rule) compile() error {
+	if err := r.validate(); err != nil {
+		return err
+	}
+
+	r.Match.queryRegexp = nil
+	if r.Match.Query != "" {
+		re, err := regexp.Compile(r.Match.Query)
+		if err != nil {
+			return fmt.Errorf("invalid query pattern %q: %w", r.Match.Query, err)
+		}
+		r.Match.queryRegexp = re
+	}
+
+	return nil
+}
+
+// matches returns if the query satisfies all the criteria of the Match
+func (m Match) matches(query string, inTransaction bool) bool {
+	if m.InTransaction && !inTransaction {
+		return false
+	}
+
+	if m.queryRegexp != nil && !m.queryRegexp.MatchString(query) {
+		return false
+	}
+
+	return true
+}
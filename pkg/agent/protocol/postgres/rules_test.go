@@ -0,0 +1,132 @@
+package postgres
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func Test_Match(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		title         string
+		match         Match
+		query         string
+		inTransaction bool
+		expected      bool
+	}{
+		{
+			title:    "empty match",
+			match:    Match{},
+			query:    "SELECT 1",
+			expected: true,
+		},
+		{
+			title:    "matching query",
+			match:    Match{Query: "(?i)^\\s*update orders"},
+			query:    "  UPDATE orders SET status = $1",
+			expected: true,
+		},
+		{
+			title:    "not matching query",
+			match:    Match{Query: "(?i)^\\s*update orders"},
+			query:    "SELECT * FROM orders",
+			expected: false,
+		},
+		{
+			title:         "query in transaction",
+			match:         Match{InTransaction: true},
+			query:         "SELECT 1",
+			inTransaction: true,
+			expected:      true,
+		},
+		{
+			title:         "query outside transaction",
+			match:         Match{InTransaction: true},
+			query:         "SELECT 1",
+			inTransaction: false,
+			expected:      false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			t.Parallel()
+
+			rule := Rule{Match: tc.match}
+			if err := rule.compile(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if matches := rule.Match.matches(tc.query, tc.inTransaction); matches != tc.expected {
+				t.Fatalf("expected %t but got %t", tc.expected, matches)
+			}
+		})
+	}
+}
+
+func Test_ParseRules(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		title       string
+		values      []string
+		expected    []Rule
+		expectError bool
+	}{
+		{
+			title: "valid rules",
+			values: []string{
+				`{"match":{"query":"^UPDATE","inTransaction":true},"errorRate":1,"errorCode":"40001"}`,
+				`{"match":{},"averageDelay":100000000,"terminateRate":0.1}`,
+			},
+			expected: []Rule{
+				{
+					Match:     Match{Query: "^UPDATE", InTransaction: true},
+					ErrorRate: 1,
+					ErrorCode: "40001",
+				},
+				{
+					AverageDelay:  100 * time.Millisecond,
+					TerminateRate: 0.1,
+				},
+			},
+		},
+		{
+			title:       "unknown field",
+			values:      []string{`{"match":{"statement":"^UPDATE"}}`},
+			expectError: true,
+		},
+		{
+			title:       "malformed rule",
+			values:      []string{`{"match":`},
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			t.Parallel()
+
+			rules, err := ParseRules(tc.values)
+			if !tc.expectError && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if tc.expectError && err == nil {
+				t.Fatalf("expected error but none returned")
+			}
+
+			if tc.expectError {
+				return
+			}
+
+			if diff := cmp.Diff(tc.expected, rules, cmpopts.IgnoreUnexported(Match{})); diff != "" {
+				t.Fatalf("expected rules do not match returned:\n%s", diff)
+			}
+		})
+	}
+}
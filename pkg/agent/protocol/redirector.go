@@ -1,6 +1,7 @@
 package protocol
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/grafana/xk6-disruptor/pkg/iptables"
@@ -19,12 +20,13 @@ type TrafficRedirectionSpec struct {
 type Redirector struct {
 	*TrafficRedirectionSpec
 	iptables iptables.Iptables
+	ruleset  *iptables.RuleSet
 }
 
 // NewTrafficRedirector creates instances of an iptables traffic redirector
 func NewTrafficRedirector(
 	tr *TrafficRedirectionSpec,
-	iptables iptables.Iptables,
+	ipt iptables.Iptables,
 ) (*Redirector, error) {
 	if tr.DestinationPort == 0 || tr.RedirectPort == 0 {
 		return nil, fmt.Errorf("DestinationPort and RedirectPort must be specified")
@@ -40,7 +42,8 @@ func NewTrafficRedirector(
 
 	return &Redirector{
 		TrafficRedirectionSpec: tr,
-		iptables:               iptables,
+		iptables:               ipt,
+		ruleset:                iptables.NewRuleSet(ipt),
 	}, nil
 }
 
@@ -141,42 +144,23 @@ func (tr *Redirector) resetProxyRule() iptables.Rule {
 	}
 }
 
-// Start applies the TrafficRedirect
+// Start applies the TrafficRedirect. Either all the rules are added or none of them is.
 func (tr *Redirector) Start() error {
 	// Remove reset rule for the proxy in case it exists from a previous run.
 	_ = tr.iptables.Remove(tr.resetProxyRule())
 
-	// TODO: Use iptables.RuleSet instead, which takes care of automatically cleaning the rules.
-	for _, rule := range tr.rules() {
-		err := tr.iptables.Add(rule)
-		if err != nil {
-			return fmt.Errorf("adding rules: %w", err)
-		}
+	if err := tr.ruleset.Add(tr.rules()...); err != nil {
+		return fmt.Errorf("adding rules: %w", err)
 	}
 
 	return nil
 }
 
-// Stop stops the TrafficRedirect.
-// Stop will continue attempting to remove all the rules it deployed even if removing one fails.
+// Stop stops the TrafficRedirect by removing all the rules it deployed at once. The reset rule for the proxy is added
+// before, and outlives the redirector until the next run.
 func (tr *Redirector) Stop() error {
-	var errors []error
-
-	for _, rule := range tr.rules() {
-		err := tr.iptables.Remove(rule)
-		if err != nil {
-			errors = append(errors, err)
-		}
-	}
-
-	if err := tr.iptables.Add(tr.resetProxyRule()); err != nil {
-		errors = append(errors, err)
-	}
-
-	// TODO: Replace with errors.Join.
-	if len(errors) > 0 {
-		return errors[0]
-	}
-
-	return nil
+	return errors.Join(
+		tr.iptables.Add(tr.resetProxyRule()),
+		tr.ruleset.Remove(),
+	)
 }
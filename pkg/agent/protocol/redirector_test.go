@@ -2,6 +2,7 @@ package protocol
 
 import (
 	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
@@ -67,10 +68,12 @@ func Test_Commands(t *testing.T) {
 		title        string
 		redirect     TrafficRedirectionSpec
 		expectedCmds []string
-		expectError  bool
-		fakeError    error
-		fakeOutput   []byte
-		testFunction func(TrafficRedirector) error
+		// expectedRules are the rules added with iptables-restore
+		expectedRules []string
+		expectError   bool
+		fakeError     error
+		fakeOutput    []byte
+		testFunction  func(TrafficRedirector) error
 	}{
 		{
 			title: "Start valid redirect",
@@ -81,13 +84,16 @@ func Test_Commands(t *testing.T) {
 			testFunction: func(tr TrafficRedirector) error {
 				return tr.Start()
 			},
-			//nolint:lll
 			expectedCmds: []string{
-				"iptables -t filter -D INPUT -p tcp --dport 8080 -j REJECT --reject-with tcp-reset",
-				"iptables -t nat -A OUTPUT -s 127.0.0.0/8 -d 127.0.0.1/32 -p tcp --dport 80 -j REDIRECT --to-port 8080",
-				"iptables -t nat -A PREROUTING ! -i lo -p tcp --dport 80 -j REDIRECT --to-port 8080",
-				"iptables -t filter -A INPUT -i lo -s 127.0.0.0/8 -d 127.0.0.1/32 -p tcp --dport 80 -m state --state ESTABLISHED -j REJECT --reject-with tcp-reset",
-				"iptables -t filter -A INPUT ! -i lo -p tcp --dport 80 -m state --state ESTABLISHED -j REJECT --reject-with tcp-reset",
+				"iptables -t filter -D XK6-DISRUPTOR-INPUT -p tcp --dport 8080 -j REJECT --reject-with tcp-reset",
+				"iptables-restore --noflush",
+			},
+			//nolint:lll
+			expectedRules: []string{
+				"-t filter -i lo -s 127.0.0.0/8 -d 127.0.0.1/32 -p tcp --dport 80 -m state --state ESTABLISHED -j REJECT --reject-with tcp-reset",
+				"-t filter ! -i lo -p tcp --dport 80 -m state --state ESTABLISHED -j REJECT --reject-with tcp-reset",
+				"-t nat -s 127.0.0.0/8 -d 127.0.0.1/32 -p tcp --dport 80 -j REDIRECT --to-port 8080",
+				"-t nat ! -i lo -p tcp --dport 80 -j REDIRECT --to-port 8080",
 			},
 			expectError: false,
 			fakeError:   nil,
@@ -100,15 +106,26 @@ func Test_Commands(t *testing.T) {
 				RedirectPort:    8080,
 			},
 			testFunction: func(tr TrafficRedirector) error {
+				if err := tr.Start(); err != nil {
+					return err
+				}
+
 				return tr.Stop()
 			},
-			//nolint:lll
+			// the rules are removed after adding the reset rule for the proxy
 			expectedCmds: []string{
-				"iptables -t nat -D OUTPUT -s 127.0.0.0/8 -d 127.0.0.1/32 -p tcp --dport 80 -j REDIRECT --to-port 8080",
-				"iptables -t nat -D PREROUTING ! -i lo -p tcp --dport 80 -j REDIRECT --to-port 8080",
-				"iptables -t filter -D INPUT -i lo -s 127.0.0.0/8 -d 127.0.0.1/32 -p tcp --dport 80 -m state --state ESTABLISHED -j REJECT --reject-with tcp-reset",
-				"iptables -t filter -D INPUT ! -i lo -p tcp --dport 80 -m state --state ESTABLISHED -j REJECT --reject-with tcp-reset",
-				"iptables -t filter -A INPUT -p tcp --dport 8080 -j REJECT --reject-with tcp-reset",
+				"iptables -t filter -D XK6-DISRUPTOR-INPUT -p tcp --dport 8080 -j REJECT --reject-with tcp-reset",
+				"iptables-restore --noflush",
+				"iptables -t filter -C INPUT -j XK6-DISRUPTOR-INPUT",
+				"iptables -t filter -A XK6-DISRUPTOR-INPUT -p tcp --dport 8080 -j REJECT --reject-with tcp-reset",
+				"iptables-restore --noflush",
+			},
+			//nolint:lll
+			expectedRules: []string{
+				"-t filter -i lo -s 127.0.0.0/8 -d 127.0.0.1/32 -p tcp --dport 80 -m state --state ESTABLISHED -j REJECT --reject-with tcp-reset",
+				"-t filter ! -i lo -p tcp --dport 80 -m state --state ESTABLISHED -j REJECT --reject-with tcp-reset",
+				"-t nat -s 127.0.0.0/8 -d 127.0.0.1/32 -p tcp --dport 80 -j REDIRECT --to-port 8080",
+				"-t nat ! -i lo -p tcp --dport 80 -j REDIRECT --to-port 8080",
 			},
 			expectError: false,
 			fakeError:   nil,
@@ -175,6 +192,30 @@ func Test_Commands(t *testing.T) {
 			if diff := cmp.Diff(tc.expectedCmds, executor.CmdHistory()); diff != "" {
 				t.Fatalf("Actual commands differ from expected:\n%s", diff)
 			}
+
+			if diff := cmp.Diff(tc.expectedRules, restoredRules(executor.InputHistory())); diff != "" {
+				t.Fatalf("Added rules differ from expected:\n%s", diff)
+			}
 		})
 	}
 }
+
+// restoredRules returns the rules added to the chains of the agent in the inputs passed to iptables-restore, preceded
+// by their table
+func restoredRules(inputs []string) []string {
+	rules := []string{}
+	for _, input := range inputs {
+		table := ""
+		for _, line := range strings.Split(input, "\n") {
+			fields := strings.SplitN(line, " ", 3)
+			switch {
+			case strings.HasPrefix(line, "*"):
+				table = strings.TrimPrefix(line, "*")
+			case len(fields) == 3 && fields[0] == "-A" && strings.HasPrefix(fields[1], iptables.ChainPrefix):
+				rules = append(rules, fmt.Sprintf("-t %s %s", table, fields[2]))
+			}
+		}
+	}
+
+	return rules
+}
@@ -0,0 +1,437 @@
+// Package redis implements a proxy that applies disruptions to the commands sent to a Redis server.
+// The proxy decodes the RESP protocol for selecting the commands, whose replies are delayed, or which are replied with
+// an error instead of being forwarded to the server.
+package redis
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/grafana/xk6-disruptor/pkg/agent/protocol"
+	"github.com/grafana/xk6-disruptor/pkg/agent/protocol/tcp"
+)
+
+// DefaultError is the error returned by the commands selected to return an error if no error is specified
+const DefaultError = "ERR injected error"
+
+// maxPending is the maximum number of commands waiting for their reply in a connection before the proxy stops reading
+// commands from the client
+const maxPending = 128
+
+// passthroughCommands are the commands after which the server sends messages that are not replies to a command, or
+// whose replies are push messages in RESP3. After forwarding them, the connection is forwarded without disruption.
+//
+//nolint:gochecknoglobals
+var passthroughCommands = []string{
+	"SUBSCRIBE", "PSUBSCRIBE", "SSUBSCRIBE", "UNSUBSCRIBE", "PUNSUBSCRIBE", "SUNSUBSCRIBE", "MONITOR",
+}
+
+// OtherCommands is the name used in the metrics of the commands that are not named in the disruption
+const OtherCommands = "other"
+
+// discardCommand is the command forwarded instead of an EXEC whose reply is replaced with an error, so the
+// transaction is not executed
+const discardCommand = "*1\r\n$7\r\nDISCARD\r\n"
+
+// CommandMetric returns the name of the metric with the number of times a command was received by the proxy
+func CommandMetric(name string) string {
+	return "commands_" + strings.ToLower(name) + "_total"
+}
+
+// CommandDisruptedMetric returns the name of the metric with the number of times a command was disrupted by the proxy
+func CommandDisruptedMetric(name string) string {
+	return "commands_" + strings.ToLower(name) + "_disrupted"
+}
+
+// Disruption specifies disruptions in the commands sent to a Redis server
+type Disruption struct {
+	// Average delay introduced to commands
+	AverageDelay time.Duration
+	// Variation in the delay (with respect of the average delay)
+	DelayVariation time.Duration
+	// Fraction (in the range 0.0 to 1.0) of commands that will return an error
+	ErrorRate float32
+	// Error returned by the commands selected to return an error. It starts with the error code, e.g.
+	// "READONLY You can't write against a read only replica.". If empty, DefaultError is returned.
+	Error string
+	// List of commands to be excluded from disruptions
+	Excluded []string
+	// Rules define the disruption of the commands that match them. The first rule that matches a command is applied.
+	// The commands that do not match any rule are disrupted with the settings above.
+	Rules []Rule
+}
+
+// rule returns the rule that applies to a command
+func (d Disruption) rule(cmd command) Rule {
+	for _, rule := range d.Rules {
+		if rule.Match.matches(cmd) {
+			return rule
+		}
+	}
+
+	return Rule{
+		AverageDelay:   d.AverageDelay,
+		DelayVariation: d.DelayVariation,
+		ErrorRate:      d.ErrorRate,
+		Error:          d.Error,
+	}
+}
+
+// NewProxy return a new Proxy for the commands sent to the upstream
+func NewProxy(listener net.Listener, upstreamAddress string, d Disruption) (protocol.Proxy, error) {
+	return newProxy(listener, upstreamAddress, d, &net.Dialer{})
+}
+
+// NewEgressProxy returns a new Proxy for the commands sent by the target to an upstream. The connections to the
+// upstream are marked so they are not redirected back to the proxy.
+func NewEgressProxy(listener net.Listener, upstreamAddress string, d Disruption) (protocol.Proxy, error) {
+	return newProxy(listener, upstreamAddress, d, protocol.MarkedDialer())
+}
+
+func newProxy(
+	listener net.Listener,
+	upstreamAddress string,
+	d Disruption,
+	dialer *net.Dialer,
+) (protocol.Proxy, error) {
+	if err := validateFault(d.AverageDelay, d.DelayVariation, d.ErrorRate, d.Error); err != nil {
+		return nil, err
+	}
+
+	// compile a copy of the rules, so the caller's rules are not modified
+	d.Rules = slices.Clone(d.Rules)
+	for i := range d.Rules {
+		if err := d.Rules[i].compile(); err != nil {
+			return nil, fmt.Errorf("invalid rule %d: %w", i, err)
+		}
+	}
+
+	commands := metricCommands(d)
+	metrics := protocol.NewMetricMap(supportedMetrics(commands)...)
+	h := &handler{disruption: d, metrics: metrics, commands: commands}
+
+	return tcp.NewConnectionProxy(listener, upstreamAddress, dialer, h, metrics)
+}
+
+// metricCommands returns the commands named in the disruption, which have their own metrics. The number of commands
+// a client can send is unbounded, so the rest of the commands share the metrics of OtherCommands.
+func metricCommands(d Disruption) []string {
+	commands := []string{}
+	for _, name := range d.Excluded {
+		commands = append(commands, strings.ToUpper(name))
+	}
+
+	for _, rule := range d.Rules {
+		for _, name := range rule.Match.Commands {
+			commands = append(commands, strings.ToUpper(name))
+		}
+	}
+
+	slices.Sort(commands)
+
+	return slices.Compact(commands)
+}
+
+// handler forwards the commands sent in the connections accepted by the proxy, applying the disruption
+type handler struct {
+	disruption Disruption
+	metrics    *protocol.MetricMap
+	// commands that have their own metrics
+	commands []string
+}
+
+// pending is a command waiting for its reply to be sent to the client. Replies are sent in the order the commands
+// are received, so the replies of the disrupted commands are sent after the replies of the preceding commands.
+type pending struct {
+	// reply sent to the client instead of the server's reply. If nil, the command was forwarded to the server.
+	reply []byte
+	// due is the time the reply can be sent to the client: the time the command was received plus its delay
+	due time.Time
+	// passthrough forwards everything the server sends after the reply without disruption
+	passthrough bool
+	// replaced is set if the command was forwarded to the server but its reply is replaced with reply
+	replaced bool
+}
+
+// frame is a value sent by the server
+type frame struct {
+	data []byte
+	// push is set for the out-of-band push messages of RESP3, which are not replies to a command
+	push bool
+}
+
+// Handle forwards the commands sent by the client to the upstream, and their replies back to the client
+func (h *handler) Handle(ctx context.Context, client net.Conn, dial tcp.DialFunc) {
+	upstream, err := dial()
+	if err != nil {
+		return
+	}
+
+	// the delayed replies are discarded when the client closes the connection
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	replies := make(chan pending, maxPending)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		forwardReplies(ctx, client, upstream, replies)
+		// stop reading commands if the replies cannot be forwarded
+		_ = client.Close()
+	}()
+
+	h.forwardCommands(client, upstream, replies, done)
+	close(replies)
+	cancel()
+
+	// the client closed the connection, so the pending replies, such as those of blocking commands, are discarded
+	_ = upstream.Close()
+	<-done
+}
+
+// forwardCommands reads the commands sent by the client and forwards them to the upstream, unless the disruption
+// replies to them. Delayed commands are forwarded immediately, and their replies are delayed.
+//
+// The commands of a transaction are queued by the server until EXEC, so only EXEC is disrupted. Replying to MULTI or to
+// a queued command would make the client and the server disagree on the commands in the transaction. If EXEC is
+// replied with an error, the transaction is discarded instead of executed.
+func (h *handler) forwardCommands(client io.Reader, upstream io.Writer, replies chan<- pending, done <-chan struct{}) {
+	reader := bufio.NewReader(client)
+	transaction := false
+	for {
+		cmd, err := readCommand(reader)
+		if err != nil {
+			return
+		}
+
+		// EXEC and DISCARD end the transaction. DISCARD is not disrupted, as MULTI and the queued commands.
+		name := cmd.name()
+		exec := transaction && name == "EXEC"
+		queued := (transaction || name == "MULTI") && !exec
+		transaction = queued && name != "DISCARD"
+
+		reply, delay := h.disrupt(cmd, queued)
+		p := pending{reply: reply, due: time.Now().Add(delay)}
+		raw := cmd.raw
+		if p.reply != nil && exec {
+			raw = []byte(discardCommand)
+			p.replaced = true
+		}
+
+		if p.reply == nil || p.replaced {
+			if _, err = upstream.Write(raw); err != nil {
+				return
+			}
+
+			p.passthrough = slices.Contains(passthroughCommands, name)
+		}
+
+		select {
+		case replies <- p:
+		case <-done:
+			return
+		}
+
+		if p.passthrough {
+			_, _ = io.Copy(upstream, reader)
+			return
+		}
+	}
+}
+
+// disrupt applies the disruption to a command. Returns the reply to the command, if the command must not be
+// forwarded, and the delay of the reply. Commands queued in a transaction are excluded from the disruption.
+func (h *handler) disrupt(cmd command, queued bool) ([]byte, time.Duration) {
+	metricName := OtherCommands
+	if _, found := slices.BinarySearch(h.commands, cmd.name()); found {
+		metricName = cmd.name()
+	}
+
+	h.metrics.Inc(protocol.MetricRequests)
+	h.metrics.Inc(CommandMetric(metricName))
+
+	if queued || slices.ContainsFunc(h.disruption.Excluded, func(name string) bool {
+		return strings.EqualFold(name, cmd.name())
+	}) {
+		h.metrics.Inc(protocol.MetricRequestsExcluded)
+		return nil, 0
+	}
+
+	rule := h.disruption.rule(cmd)
+
+	delay := rule.AverageDelay
+	if rule.DelayVariation > 0 {
+		variation := int64(rule.DelayVariation)
+		delay += time.Duration(variation - 2*rand.Int63n(variation))
+	}
+
+	isError := rule.ErrorRate > 0 && rand.Float32() <= rule.ErrorRate
+	if isError || delay > 0 {
+		h.metrics.Inc(protocol.MetricRequestsDisrupted)
+		h.metrics.Inc(CommandDisruptedMetric(metricName))
+	}
+
+	if isError && rule.Error == "" {
+		return errorReply(DefaultError), delay
+	}
+
+	if isError {
+		return errorReply(rule.Error), delay
+	}
+
+	return nil, delay
+}
+
+// forwardReplies sends the replies to the client's commands, in the order of the commands and once they are due.
+// Push messages are sent as soon as the replies the server sent before them are sent.
+func forwardReplies(ctx context.Context, client io.Writer, upstream io.Reader, replies <-chan pending) {
+	done := make(chan struct{})
+	defer close(done)
+
+	frames := make(chan frame)
+	go readFrames(upstream, frames, done)
+
+	w := &replyWriter{client: client, frames: frames}
+	for {
+		// a reply received before its command is dequeued is kept until the command is dequeued
+		pendingFrames := frames
+		if w.next != nil {
+			pendingFrames = nil
+		}
+
+		select {
+		case p, ok := <-replies:
+			if !ok || !w.forward(ctx, p) {
+				return
+			}
+		case f, ok := <-pendingFrames:
+			if !ok {
+				return
+			}
+
+			if !f.push {
+				w.next = &f
+				continue
+			}
+
+			if _, err := client.Write(f.data); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// replyWriter writes the frames received from the server to the client
+type replyWriter struct {
+	client io.Writer
+	frames <-chan frame
+	// next is a frame received before the command it replies to was dequeued
+	next *frame
+}
+
+// frame returns the next frame received from the server
+func (w *replyWriter) frame() (frame, bool) {
+	if w.next != nil {
+		f := *w.next
+		w.next = nil
+		return f, true
+	}
+
+	f, ok := <-w.frames
+	return f, ok
+}
+
+// forward sends the reply to a command once it is due, after the push messages the server sent before it.
+// Returns false if the connection cannot be forwarded anymore, or the context is done before the reply is due.
+func (w *replyWriter) forward(ctx context.Context, p pending) bool {
+	// the reply of the server is read even if it is replaced, so it is not taken as the reply of the next command
+	reply := p.reply
+	awaiting := reply == nil || p.replaced
+	for awaiting && !p.passthrough {
+		f, ok := w.frame()
+		if !ok {
+			return false
+		}
+
+		if !f.push {
+			awaiting = false
+			if reply == nil {
+				reply = f.data
+			}
+			continue
+		}
+
+		if _, err := w.client.Write(f.data); err != nil {
+			return false
+		}
+	}
+
+	timer := time.NewTimer(time.Until(p.due))
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+		return false
+	}
+
+	if p.passthrough {
+		for {
+			f, ok := w.frame()
+			if !ok {
+				return false
+			}
+
+			if _, err := w.client.Write(f.data); err != nil {
+				return false
+			}
+		}
+	}
+
+	_, err := w.client.Write(reply)
+
+	return err == nil
+}
+
+// readFrames reads the values sent by the server until the connection fails or done is closed
+func readFrames(upstream io.Reader, frames chan<- frame, done <-chan struct{}) {
+	defer close(frames)
+
+	reader := bufio.NewReader(upstream)
+	for {
+		data, push, err := readReply(reader)
+		if err != nil {
+			return
+		}
+
+		select {
+		case frames <- frame{data: data, push: push}:
+		case <-done:
+			return
+		}
+	}
+}
+
+// supportedMetrics returns the metrics that the redis proxy supports and thus should be pre-initialized to zero,
+// including the metrics of the given commands and of OtherCommands
+func supportedMetrics(commands []string) []string {
+	metrics := []string{
+		protocol.MetricRequests,
+		protocol.MetricRequestsExcluded,
+		protocol.MetricRequestsDisrupted,
+	}
+
+	for _, name := range commands {
+		metrics = append(metrics, CommandMetric(name), CommandDisruptedMetric(name))
+	}
+
+	return append(metrics, CommandMetric(OtherCommands), CommandDisruptedMetric(OtherCommands))
+}
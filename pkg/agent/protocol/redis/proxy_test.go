@@ -0,0 +1,590 @@
+package redis
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/grafana/xk6-disruptor/pkg/agent/protocol"
+)
+
+// redisUpstream starts a server that implements the PING, SET, GET, MULTI, EXEC, DISCARD, SUBSCRIBE and CLIENT TRACKING
+// commands and returns its address. Subscribed clients receive two messages right after subscribing. Clients that enable tracking receive
+// a push message invalidating all the keys shortly after the reply.
+func redisUpstream(t *testing.T) string {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("creating listener: %v", err)
+	}
+	t.Cleanup(func() { _ = listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+
+			go serveRedis(conn)
+		}
+	}()
+
+	return listener.Addr().String()
+}
+
+func serveRedis(conn net.Conn) {
+	defer func() {
+		_ = conn.Close()
+	}()
+
+	values := map[string]string{}
+	execute := func(cmd command) string {
+		switch cmd.name() {
+		case "PING":
+			return "+PONG\r\n"
+		case "SET":
+			values[cmd.args[1]] = cmd.args[2]
+			return "+OK\r\n"
+		case "GET":
+			value, found := values[cmd.args[1]]
+			if !found {
+				return "$-1\r\n"
+			}
+			return fmt.Sprintf("$%d\r\n%s\r\n", len(value), value)
+		default:
+			return "-ERR unknown command\r\n"
+		}
+	}
+
+	// queued has the commands of the transaction. nil if no transaction was started
+	var queued []command
+	reader := bufio.NewReader(conn)
+	for {
+		cmd, err := readCommand(reader)
+		if err != nil {
+			return
+		}
+
+		var reply, push string
+		switch {
+		case cmd.name() == "MULTI":
+			queued = []command{}
+			reply = "+OK\r\n"
+		case cmd.name() == "EXEC" && queued != nil:
+			reply = fmt.Sprintf("*%d\r\n", len(queued))
+			for _, c := range queued {
+				reply += execute(c)
+			}
+			queued = nil
+		case cmd.name() == "DISCARD" && queued != nil:
+			queued = nil
+			reply = "+OK\r\n"
+		case cmd.name() == "EXEC" || cmd.name() == "DISCARD":
+			reply = fmt.Sprintf("-ERR %s without MULTI\r\n", cmd.name())
+		case queued != nil:
+			queued = append(queued, cmd)
+			reply = "+QUEUED\r\n"
+		case cmd.name() == "SUBSCRIBE":
+			channel := cmd.args[1]
+			reply = fmt.Sprintf("*3\r\n$9\r\nsubscribe\r\n$%d\r\n%s\r\n:1\r\n", len(channel), channel)
+			for _, message := range []string{"hello", "bye"} {
+				reply += fmt.Sprintf(
+					"*3\r\n$7\r\nmessage\r\n$%d\r\n%s\r\n$%d\r\n%s\r\n",
+					len(channel), channel, len(message), message,
+				)
+			}
+		case cmd.name() == "CLIENT":
+			reply = "+OK\r\n"
+			push = ">2\r\n$10\r\ninvalidate\r\n_\r\n"
+		default:
+			reply = execute(cmd)
+		}
+
+		if _, err = conn.Write([]byte(reply)); err != nil {
+			return
+		}
+
+		if push != "" {
+			time.Sleep(10 * time.Millisecond)
+			if _, err = conn.Write([]byte(push)); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// encodeCommand returns the encoding of a command as an array of bulk strings
+func encodeCommand(args ...string) string {
+	encoded := fmt.Sprintf("*%d\r\n", len(args))
+	for _, arg := range args {
+		encoded += fmt.Sprintf("$%d\r\n%s\r\n", len(arg), arg)
+	}
+
+	return encoded
+}
+
+func Test_Validations(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		title       string
+		disruption  Disruption
+		upstream    string
+		expectError bool
+	}{
+		{
+			title:       "valid defaults",
+			disruption:  Disruption{},
+			upstream:    ":6379",
+			expectError: false,
+		},
+		{
+			title:       "invalid upstream address",
+			disruption:  Disruption{},
+			upstream:    "",
+			expectError: true,
+		},
+		{
+			title:       "invalid error rate",
+			disruption:  Disruption{ErrorRate: 1.1},
+			upstream:    ":6379",
+			expectError: true,
+		},
+		{
+			title: "invalid rule",
+			disruption: Disruption{
+				Rules: []Rule{{Match: Match{Keys: []string{"user:[0-9"}}}},
+			},
+			upstream:    ":6379",
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			t.Parallel()
+
+			listener, err := net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatalf("creating listener: %v", err)
+			}
+			defer func() {
+				_ = listener.Close()
+			}()
+
+			_, err = NewProxy(listener, tc.upstream, tc.disruption)
+			if !tc.expectError && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if tc.expectError && err == nil {
+				t.Fatalf("expected error but none returned")
+			}
+		})
+	}
+}
+
+func Test_ProxyDisruption(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		title      string
+		disruption Disruption
+		// commands sent in a single pipeline
+		commands [][]string
+		// replies expected for each command
+		expected []string
+		// minimum time for receiving the replies
+		minElapsed time.Duration
+		// maximum time for receiving the replies, if not zero
+		maxElapsed      time.Duration
+		expectedMetrics map[string]uint
+	}{
+		{
+			title:      "no disruption",
+			disruption: Disruption{},
+			commands:   [][]string{{"PING"}, {"SET", "user:1", "alice"}, {"GET", "user:1"}},
+			expected:   []string{"+PONG\r\n", "+OK\r\n", "$5\r\nalice\r\n"},
+			expectedMetrics: map[string]uint{
+				protocol.MetricRequests:               3,
+				protocol.MetricRequestsExcluded:       0,
+				protocol.MetricRequestsDisrupted:      0,
+				CommandMetric(OtherCommands):          3,
+				CommandDisruptedMetric(OtherCommands): 0,
+			},
+		},
+		{
+			title: "error in all commands",
+			disruption: Disruption{
+				ErrorRate: 1.0,
+				Error:     "LOADING Redis is loading the dataset in memory",
+			},
+			commands: [][]string{{"PING"}, {"GET", "user:1"}},
+			expected: []string{
+				"-LOADING Redis is loading the dataset in memory\r\n",
+				"-LOADING Redis is loading the dataset in memory\r\n",
+			},
+			expectedMetrics: map[string]uint{
+				protocol.MetricRequests:               2,
+				protocol.MetricRequestsExcluded:       0,
+				protocol.MetricRequestsDisrupted:      2,
+				CommandMetric(OtherCommands):          2,
+				CommandDisruptedMetric(OtherCommands): 2,
+			},
+		},
+		{
+			title: "error in excluded command",
+			disruption: Disruption{
+				ErrorRate: 1.0,
+				Excluded:  []string{"ping"},
+			},
+			commands: [][]string{{"PING"}, {"GET", "user:1"}},
+			expected: []string{"+PONG\r\n", "-ERR injected error\r\n"},
+			expectedMetrics: map[string]uint{
+				protocol.MetricRequests:               2,
+				protocol.MetricRequestsExcluded:       1,
+				protocol.MetricRequestsDisrupted:      1,
+				CommandMetric("PING"):                 1,
+				CommandDisruptedMetric("PING"):        0,
+				CommandMetric(OtherCommands):          1,
+				CommandDisruptedMetric(OtherCommands): 1,
+			},
+		},
+		{
+			title: "rule by command keeps the order of replies",
+			disruption: Disruption{
+				Rules: []Rule{
+					{
+						Match:     Match{Commands: []string{"GET"}},
+						ErrorRate: 1.0,
+						Error:     "READONLY You can't write against a read only replica.",
+					},
+				},
+			},
+			commands: [][]string{{"SET", "user:1", "alice"}, {"GET", "user:1"}, {"PING"}},
+			expected: []string{"+OK\r\n", "-READONLY You can't write against a read only replica.\r\n", "+PONG\r\n"},
+			expectedMetrics: map[string]uint{
+				protocol.MetricRequests:               3,
+				protocol.MetricRequestsExcluded:       0,
+				protocol.MetricRequestsDisrupted:      1,
+				CommandMetric("GET"):                  1,
+				CommandDisruptedMetric("GET"):         1,
+				CommandMetric(OtherCommands):          2,
+				CommandDisruptedMetric(OtherCommands): 0,
+			},
+		},
+		{
+			title: "rule by key",
+			disruption: Disruption{
+				Rules: []Rule{
+					{
+						Match:     Match{Keys: []string{"user:*"}},
+						ErrorRate: 1.0,
+						Error:     "ERR injected error",
+					},
+				},
+			},
+			commands: [][]string{{"SET", "user:1", "alice"}, {"SET", "order:1", "book"}, {"GET", "order:1"}},
+			expected: []string{"-ERR injected error\r\n", "+OK\r\n", "$4\r\nbook\r\n"},
+			expectedMetrics: map[string]uint{
+				protocol.MetricRequests:               3,
+				protocol.MetricRequestsExcluded:       0,
+				protocol.MetricRequestsDisrupted:      1,
+				CommandMetric(OtherCommands):          3,
+				CommandDisruptedMetric(OtherCommands): 1,
+			},
+		},
+		{
+			title: "commands in a transaction are not disrupted",
+			disruption: Disruption{
+				Rules: []Rule{
+					{
+						Match:     Match{Commands: []string{"SET"}},
+						ErrorRate: 1.0,
+					},
+				},
+			},
+			commands: [][]string{{"MULTI"}, {"SET", "user:1", "alice"}, {"EXEC"}, {"GET", "user:1"}},
+			expected: []string{"+OK\r\n", "+QUEUED\r\n", "*1\r\n+OK\r\n", "$5\r\nalice\r\n"},
+			expectedMetrics: map[string]uint{
+				protocol.MetricRequests:               4,
+				protocol.MetricRequestsExcluded:       2,
+				protocol.MetricRequestsDisrupted:      0,
+				CommandMetric("SET"):                  1,
+				CommandDisruptedMetric("SET"):         0,
+				CommandMetric(OtherCommands):          3,
+				CommandDisruptedMetric(OtherCommands): 0,
+			},
+		},
+		{
+			title: "error in exec discards the transaction",
+			disruption: Disruption{
+				ErrorRate: 1.0,
+				Excluded:  []string{"GET"},
+			},
+			commands: [][]string{{"MULTI"}, {"SET", "user:1", "alice"}, {"EXEC"}, {"GET", "user:1"}},
+			expected: []string{"+OK\r\n", "+QUEUED\r\n", "-ERR injected error\r\n", "$-1\r\n"},
+			expectedMetrics: map[string]uint{
+				protocol.MetricRequests:               4,
+				protocol.MetricRequestsExcluded:       3,
+				protocol.MetricRequestsDisrupted:      1,
+				CommandMetric("GET"):                  1,
+				CommandDisruptedMetric("GET"):         0,
+				CommandMetric(OtherCommands):          3,
+				CommandDisruptedMetric(OtherCommands): 1,
+			},
+		},
+		{
+			title: "delay",
+			disruption: Disruption{
+				Rules: []Rule{
+					{
+						Match:        Match{Commands: []string{"GET"}},
+						AverageDelay: 100 * time.Millisecond,
+					},
+				},
+			},
+			commands:   [][]string{{"PING"}, {"GET", "user:1"}, {"GET", "user:2"}},
+			expected:   []string{"+PONG\r\n", "$-1\r\n", "$-1\r\n"},
+			minElapsed: 100 * time.Millisecond,
+			// the delays of pipelined commands do not accumulate
+			maxElapsed: 190 * time.Millisecond,
+			expectedMetrics: map[string]uint{
+				protocol.MetricRequests:               3,
+				protocol.MetricRequestsExcluded:       0,
+				protocol.MetricRequestsDisrupted:      2,
+				CommandMetric("GET"):                  2,
+				CommandDisruptedMetric("GET"):         2,
+				CommandMetric(OtherCommands):          1,
+				CommandDisruptedMetric(OtherCommands): 0,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			t.Parallel()
+
+			upstream := redisUpstream(t)
+
+			listener, err := net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatalf("creating listener: %v", err)
+			}
+
+			proxy, err := NewProxy(listener, upstream, tc.disruption)
+			if err != nil {
+				t.Fatalf("creating proxy: %v", err)
+			}
+
+			go func() {
+				_ = proxy.Start()
+			}()
+			t.Cleanup(func() { _ = proxy.Force() })
+
+			conn, err := net.Dial("tcp", listener.Addr().String())
+			if err != nil {
+				t.Fatalf("connecting to proxy: %v", err)
+			}
+			defer func() {
+				_ = conn.Close()
+			}()
+			_ = conn.SetDeadline(time.Now().Add(2 * time.Second))
+
+			start := time.Now()
+			pipeline := ""
+			for _, cmd := range tc.commands {
+				pipeline += encodeCommand(cmd...)
+			}
+			if _, err = conn.Write([]byte(pipeline)); err != nil {
+				t.Fatalf("sending commands: %v", err)
+			}
+
+			reader := bufio.NewReader(conn)
+			replies := []string{}
+			for range tc.expected {
+				reply, _, err := readReply(reader)
+				if err != nil {
+					t.Fatalf("receiving reply: %v", err)
+				}
+				replies = append(replies, string(reply))
+			}
+			elapsed := time.Since(start)
+
+			if diff := cmp.Diff(tc.expected, replies); diff != "" {
+				t.Fatalf("expected replies do not match returned:\n%s", diff)
+			}
+
+			if elapsed < tc.minElapsed {
+				t.Fatalf("expected replies in at least %v but received in %v", tc.minElapsed, elapsed)
+			}
+
+			if tc.maxElapsed > 0 && elapsed > tc.maxElapsed {
+				t.Fatalf("expected replies in at most %v but received in %v", tc.maxElapsed, elapsed)
+			}
+
+			if diff := cmp.Diff(tc.expectedMetrics, proxy.Metrics()); diff != "" {
+				t.Fatalf("expected metrics do not match returned:\n%s", diff)
+			}
+		})
+	}
+}
+
+func Test_ProxySubscription(t *testing.T) {
+	t.Parallel()
+
+	upstream := redisUpstream(t)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("creating listener: %v", err)
+	}
+
+	// commands sent after subscribing are forwarded without disruption
+	proxy, err := NewProxy(listener, upstream, Disruption{
+		Rules: []Rule{{Match: Match{Commands: []string{"PING"}}, ErrorRate: 1.0, Error: "ERR injected error"}},
+	})
+	if err != nil {
+		t.Fatalf("creating proxy: %v", err)
+	}
+
+	go func() {
+		_ = proxy.Start()
+	}()
+	t.Cleanup(func() { _ = proxy.Force() })
+
+	conn, err := net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatalf("connecting to proxy: %v", err)
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+	_ = conn.SetDeadline(time.Now().Add(2 * time.Second))
+
+	if _, err = conn.Write([]byte(encodeCommand("SUBSCRIBE", "news"))); err != nil {
+		t.Fatalf("sending command: %v", err)
+	}
+
+	reader := bufio.NewReader(conn)
+	messages := []string{}
+	for range 3 {
+		message, _, err := readReply(reader)
+		if err != nil {
+			t.Fatalf("receiving message: %v", err)
+		}
+		messages = append(messages, string(message))
+	}
+
+	if !strings.Contains(messages[0], "subscribe") || !strings.Contains(messages[2], "bye") {
+		t.Fatalf("unexpected messages %q", messages)
+	}
+
+	if _, err = conn.Write([]byte(encodeCommand("PING"))); err != nil {
+		t.Fatalf("sending command: %v", err)
+	}
+
+	reply, _, err := readReply(reader)
+	if err != nil {
+		t.Fatalf("receiving reply: %v", err)
+	}
+
+	if string(reply) != "+PONG\r\n" {
+		t.Fatalf("expected PONG but %q received", reply)
+	}
+}
+
+func Test_ProxyPushMessages(t *testing.T) {
+	t.Parallel()
+
+	upstream := redisUpstream(t)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("creating listener: %v", err)
+	}
+
+	proxy, err := NewProxy(listener, upstream, Disruption{AverageDelay: 10 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("creating proxy: %v", err)
+	}
+
+	go func() {
+		_ = proxy.Start()
+	}()
+	t.Cleanup(func() { _ = proxy.Force() })
+
+	conn, err := net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatalf("connecting to proxy: %v", err)
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+	_ = conn.SetDeadline(time.Now().Add(2 * time.Second))
+
+	if _, err = conn.Write([]byte(encodeCommand("CLIENT", "TRACKING", "ON"))); err != nil {
+		t.Fatalf("sending command: %v", err)
+	}
+
+	// the push message is received without sending another command
+	reader := bufio.NewReader(conn)
+	expected := []string{"+OK\r\n", ">2\r\n$10\r\ninvalidate\r\n_\r\n"}
+	received := []string{}
+	for range expected {
+		message, _, err := readReply(reader)
+		if err != nil {
+			t.Fatalf("receiving message: %v", err)
+		}
+		received = append(received, string(message))
+	}
+
+	if diff := cmp.Diff(expected, received); diff != "" {
+		t.Fatalf("expected messages do not match received:\n%s", diff)
+	}
+
+	// the following replies are not affected by the push message
+	if _, err = conn.Write([]byte(encodeCommand("PING"))); err != nil {
+		t.Fatalf("sending command: %v", err)
+	}
+
+	reply, _, err := readReply(reader)
+	if err != nil {
+		t.Fatalf("receiving reply: %v", err)
+	}
+
+	if string(reply) != "+PONG\r\n" {
+		t.Fatalf("expected PONG but %q received", reply)
+	}
+}
+
+func Test_ForwardCanceled(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+
+	client := &bytes.Buffer{}
+	w := &replyWriter{client: client}
+
+	start := time.Now()
+	if w.forward(ctx, pending{reply: []byte("+OK\r\n"), due: time.Now().Add(time.Hour)}) {
+		t.Fatalf("expected forwarding to stop once the context is done")
+	}
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("expected delay to end when the context is done, but took %v", elapsed)
+	}
+
+	if client.Len() > 0 {
+		t.Fatalf("unexpected reply sent to the client: %q", client.String())
+	}
+}
@@ -0,0 +1,217 @@
+package redis
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// maxBulkLength is the maximum length of a bulk string accepted by Redis
+const maxBulkLength = 512 * 1024 * 1024
+
+// errMalformed is returned when the data does not follow the RESP protocol
+var errMalformed = errors.New("malformed RESP data")
+
+// command is a command sent by a client
+type command struct {
+	// raw is the encoding of the command, as sent by the client
+	raw []byte
+	// args are the name of the command followed by its arguments
+	args []string
+}
+
+// name returns the name of the command in upper case
+func (c command) name() string {
+	if len(c.args) == 0 {
+		return ""
+	}
+
+	return strings.ToUpper(c.args[0])
+}
+
+// key returns the key of the command. The key of most commands is their first argument.
+func (c command) key() (string, bool) {
+	if len(c.args) < 2 {
+		return "", false
+	}
+
+	return c.args[1], true
+}
+
+// readCommand reads a command sent by a client. Commands are arrays of bulk strings, but they can also be sent
+// inline, as a line with the arguments separated by spaces. Empty commands are skipped, as the server does not reply
+// to them.
+func readCommand(r *bufio.Reader) (command, error) {
+	for {
+		first, err := r.Peek(1)
+		if err != nil {
+			return command{}, err
+		}
+
+		if first[0] == '*' {
+			cmd, err := readArrayCommand(r)
+			if err != nil || len(cmd.args) > 0 {
+				return cmd, err
+			}
+
+			continue
+		}
+
+		line, err := r.ReadBytes('\n')
+		if err != nil {
+			if errors.Is(err, io.EOF) && len(line) > 0 {
+				return command{}, io.ErrUnexpectedEOF
+			}
+
+			return command{}, err
+		}
+
+		args := strings.Fields(string(line))
+		if len(args) > 0 {
+			return command{raw: line, args: args}, nil
+		}
+	}
+}
+
+// readArrayCommand reads a command sent as an array of bulk strings
+func readArrayCommand(r *bufio.Reader) (command, error) {
+	raw := &bytes.Buffer{}
+
+	header, err := readLine(r)
+	if err != nil {
+		return command{}, err
+	}
+	raw.Write(header)
+
+	count, err := parseLength(header)
+	if err != nil {
+		return command{}, err
+	}
+
+	// the capacity is limited, as the count is sent by the client
+	args := make([]string, 0, min(max(count, 0), 64))
+	for range count {
+		start := raw.Len()
+		if err = readValue(r, raw); err != nil {
+			return command{}, err
+		}
+
+		arg := raw.Bytes()[start:]
+		if arg[0] != '$' || bytes.HasPrefix(arg, []byte("$-")) {
+			return command{}, fmt.Errorf("%w: command arguments must be non-null bulk strings", errMalformed)
+		}
+
+		// skip the length and remove the trailing CRLF
+		arg = arg[bytes.IndexByte(arg, '\n')+1 : len(arg)-2]
+		args = append(args, string(arg))
+	}
+
+	return command{raw: raw.Bytes(), args: args}, nil
+}
+
+// readReply reads the reply to a command or an out-of-band push message, and returns if it is a push message.
+// Attributes sent by the server before the value are returned along with it.
+func readReply(r *bufio.Reader) ([]byte, bool, error) {
+	reply := &bytes.Buffer{}
+	for {
+		start := reply.Len()
+		if err := readValue(r, reply); err != nil {
+			return nil, false, err
+		}
+
+		kind := reply.Bytes()[start]
+		if kind != '|' {
+			return reply.Bytes(), kind == '>', nil
+		}
+	}
+}
+
+// readValue reads a RESP value, including all the elements of aggregate values, and appends its encoding to the
+// buffer. Both RESP2 and RESP3 types are supported.
+func readValue(r *bufio.Reader, buffer *bytes.Buffer) error {
+	line, err := readLine(r)
+	if err != nil {
+		return err
+	}
+	buffer.Write(line)
+
+	switch line[0] {
+	case '+', '-', ':', '_', '#', ',', '(':
+		return nil
+	case '$', '!', '=':
+		length, err := parseLength(line)
+		if err != nil {
+			return err
+		}
+
+		if length < 0 {
+			return nil
+		}
+
+		if length > maxBulkLength {
+			return fmt.Errorf("%w: bulk string of %d bytes", errMalformed, length)
+		}
+
+		// copy the data and the trailing CRLF
+		_, err = io.CopyN(buffer, r, int64(length)+2)
+		return err
+	case '*', '~', '>', '%', '|':
+		count, err := parseLength(line)
+		if err != nil {
+			return err
+		}
+
+		// maps and attributes have a key and a value for each element
+		if line[0] == '%' || line[0] == '|' {
+			count *= 2
+		}
+
+		for range count {
+			if err = readValue(r, buffer); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	default:
+		return fmt.Errorf("%w: unknown type %q", errMalformed, line[0])
+	}
+}
+
+// readLine reads a line terminated by CRLF, which is included in the line returned
+func readLine(r *bufio.Reader) ([]byte, error) {
+	line, err := r.ReadBytes('\n')
+	if err != nil {
+		if errors.Is(err, io.EOF) && len(line) > 0 {
+			return nil, io.ErrUnexpectedEOF
+		}
+
+		return nil, err
+	}
+
+	if len(line) < 3 || line[len(line)-2] != '\r' {
+		return nil, fmt.Errorf("%w: invalid line %q", errMalformed, line)
+	}
+
+	return line, nil
+}
+
+// parseLength parses the length of an aggregate or bulk string from its header line. A negative length denotes a
+// null value.
+func parseLength(line []byte) (int, error) {
+	length, err := strconv.Atoi(string(line[1 : len(line)-2]))
+	if err != nil {
+		return 0, fmt.Errorf("%w: invalid length %q", errMalformed, line[1:len(line)-2])
+	}
+
+	return length, nil
+}
+
+// errorReply returns the encoding of an error reply with the given message
+func errorReply(message string) []byte {
+	return []byte("-" + message + "\r\n")
+}
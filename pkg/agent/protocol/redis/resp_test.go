@@ -0,0 +1,195 @@
+package redis
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func Test_ReadCommand(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		title        string
+		data         string
+		expectedArgs []string
+		expectedRaw  string
+		expectError  bool
+	}{
+		{
+			title:        "array command",
+			data:         "*3\r\n$3\r\nSET\r\n$3\r\nkey\r\n$5\r\nvalue\r\n",
+			expectedArgs: []string{"SET", "key", "value"},
+			expectedRaw:  "*3\r\n$3\r\nSET\r\n$3\r\nkey\r\n$5\r\nvalue\r\n",
+			expectError:  false,
+		},
+		{
+			title:        "binary argument",
+			data:         "*2\r\n$3\r\nGET\r\n$4\r\na\r\nb\r\n",
+			expectedArgs: []string{"GET", "a\r\nb"},
+			expectedRaw:  "*2\r\n$3\r\nGET\r\n$4\r\na\r\nb\r\n",
+			expectError:  false,
+		},
+		{
+			title:        "inline command",
+			data:         "GET  key\r\n",
+			expectedArgs: []string{"GET", "key"},
+			expectedRaw:  "GET  key\r\n",
+			expectError:  false,
+		},
+		{
+			title:        "empty commands are skipped",
+			data:         "\r\n*0\r\nPING\n",
+			expectedArgs: []string{"PING"},
+			expectedRaw:  "PING\n",
+			expectError:  false,
+		},
+		{
+			title:       "argument is not a bulk string",
+			data:        "*2\r\n$3\r\nGET\r\n:1\r\n",
+			expectError: true,
+		},
+		{
+			title:       "invalid length",
+			data:        "*x\r\n",
+			expectError: true,
+		},
+		{
+			title:       "truncated command",
+			data:        "*2\r\n$3\r\nGET\r\n$3\r\nke",
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			t.Parallel()
+
+			cmd, err := readCommand(bufio.NewReader(bytes.NewBufferString(tc.data)))
+			if tc.expectError && err == nil {
+				t.Fatalf("expected error but none returned")
+			}
+
+			if !tc.expectError && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if tc.expectError {
+				return
+			}
+
+			if diff := cmp.Diff(tc.expectedArgs, cmd.args); diff != "" {
+				t.Fatalf("expected args do not match returned:\n%s", diff)
+			}
+
+			if string(cmd.raw) != tc.expectedRaw {
+				t.Fatalf("expected raw command %q but %q returned", tc.expectedRaw, cmd.raw)
+			}
+		})
+	}
+}
+
+func Test_ReadReply(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		title       string
+		data        string
+		expected    string
+		push        bool
+		expectError bool
+	}{
+		{
+			title:    "simple string",
+			data:     "+OK\r\n+PONG\r\n",
+			expected: "+OK\r\n",
+		},
+		{
+			title:    "error",
+			data:     "-ERR unknown command\r\n",
+			expected: "-ERR unknown command\r\n",
+		},
+		{
+			title:    "bulk string",
+			data:     "$5\r\nhello\r\n:1\r\n",
+			expected: "$5\r\nhello\r\n",
+		},
+		{
+			title:    "null bulk string",
+			data:     "$-1\r\n:1\r\n",
+			expected: "$-1\r\n",
+		},
+		{
+			title:    "nested arrays",
+			data:     "*2\r\n*1\r\n:1\r\n$1\r\na\r\n:1\r\n",
+			expected: "*2\r\n*1\r\n:1\r\n$1\r\na\r\n",
+		},
+		{
+			title:    "map",
+			data:     "%1\r\n+key\r\n#t\r\n:1\r\n",
+			expected: "%1\r\n+key\r\n#t\r\n",
+		},
+		{
+			title:    "push message",
+			data:     ">2\r\n$10\r\ninvalidate\r\n_\r\n+OK\r\n",
+			expected: ">2\r\n$10\r\ninvalidate\r\n_\r\n",
+			push:     true,
+		},
+		{
+			title:    "attribute before reply",
+			data:     "|1\r\n+ttl\r\n:3600\r\n,3.14\r\n:1\r\n",
+			expected: "|1\r\n+ttl\r\n:3600\r\n,3.14\r\n",
+		},
+		{
+			title:       "unknown type",
+			data:        "?1\r\n",
+			expectError: true,
+		},
+		{
+			title:       "missing CR",
+			data:        "+OK\n",
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			t.Parallel()
+
+			reply, push, err := readReply(bufio.NewReader(bytes.NewBufferString(tc.data)))
+			if tc.expectError && err == nil {
+				t.Fatalf("expected error but none returned")
+			}
+
+			if !tc.expectError && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if string(reply) != tc.expected {
+				t.Fatalf("expected reply %q but %q returned", tc.expected, reply)
+			}
+
+			if push != tc.push {
+				t.Fatalf("expected push %t but %t returned", tc.push, push)
+			}
+		})
+	}
+}
+
+func Test_ReadReplyEOF(t *testing.T) {
+	t.Parallel()
+
+	_, _, err := readReply(bufio.NewReader(bytes.NewBufferString("")))
+	if !errors.Is(err, io.EOF) {
+		t.Fatalf("expected EOF but got %v", err)
+	}
+
+	_, _, err = readReply(bufio.NewReader(bytes.NewBufferString("+OK")))
+	if !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Fatalf("expected unexpected EOF but got %v", err)
+	}
+}
@@ -0,0 +1,162 @@
+package redis
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Match selects commands by their name and key. A command matches if it satisfies all the criteria specified.
+// An empty Match selects all commands.
+//
+// Keys are given as glob-style patterns, with the syntax of the patterns of the KEYS command: '*' matches any
+// sequence of characters, '?' matches any character and '[...]' matches any of the characters between the brackets.
+// The key of a command is its first argument, which is the key for most commands.
+type Match struct {
+	// Commands selects the commands with any of these names. Names are not case-sensitive
+	Commands []string `json:"commands,omitempty"`
+	// Keys selects the commands whose key matches any of these patterns
+	Keys []string `json:"keys,omitempty"`
+
+	keyPatterns []*regexp.Regexp
+}
+
+// Rule defines the disruption applied to the commands that match a Match
+type Rule struct {
+	Match Match `json:"match"`
+	// Average delay introduced to commands
+	AverageDelay time.Duration `json:"averageDelay,omitempty"`
+	// Variation in the delay (with respect of the average delay)
+	DelayVariation time.Duration `json:"delayVariation,omitempty"`
+	// Fraction (in the range 0.0 to 1.0) of commands that will return an error
+	ErrorRate float32 `json:"errorRate,omitempty"`
+	// Error returned by the commands selected to return an error. It starts with the error code, e.g.
+	// "LOADING Redis is loading the dataset in memory". If empty, DefaultError is returned.
+	Error string `json:"error,omitempty"`
+}
+
+// ParseRules parses a list of rules in JSON format
+func ParseRules(values []string) ([]Rule, error) {
+	rules := []Rule{}
+	for _, value := range values {
+		decoder := json.NewDecoder(bytes.NewBufferString(value))
+		decoder.DisallowUnknownFields()
+
+		rule := Rule{}
+		if err := decoder.Decode(&rule); err != nil {
+			return nil, fmt.Errorf("parsing rule %q: %w", value, err)
+		}
+
+		rules = append(rules, rule)
+	}
+
+	return rules, nil
+}
+
+// compile validates the rule and compiles its match
+func (r *Rule) compile() error {
+	if err := validateFault(r.AverageDelay, r.DelayVariation, r.ErrorRate, r.Error); err != nil {
+		return err
+	}
+
+	r.Match.keyPatterns = nil
+	for _, pattern := range r.Match.Keys {
+		re, err := globRegexp(pattern)
+		if err != nil {
+			return fmt.Errorf("invalid key pattern %q: %w", pattern, err)
+		}
+		r.Match.keyPatterns = append(r.Match.keyPatterns, re)
+	}
+
+	return nil
+}
+
+// validateFault checks the delay and error settings of a fault
+func validateFault(averageDelay, delayVariation time.Duration, errorRate float32, errorMessage string) error {
+	if delayVariation > averageDelay {
+		return fmt.Errorf("variation must be less that average delay")
+	}
+
+	if errorRate < 0.0 || errorRate > 1.0 {
+		return fmt.Errorf("error rate must be in the range [0.0, 1.0]")
+	}
+
+	if strings.ContainsAny(errorMessage, "\r\n") {
+		return fmt.Errorf("error cannot contain line breaks")
+	}
+
+	return nil
+}
+
+// globRegexp returns a regular expression equivalent to a glob-style pattern
+func globRegexp(pattern string) (*regexp.Regexp, error) {
+	expr := strings.Builder{}
+	// keys can contain any character, including line breaks
+	expr.WriteString("(?s)^")
+
+	runes := []rune(pattern)
+	for i := 0; i < len(runes); i++ {
+		switch runes[i] {
+		case '*':
+			expr.WriteString(".*")
+		case '?':
+			expr.WriteString(".")
+		case '\\':
+			i++
+			if i == len(runes) {
+				return nil, fmt.Errorf("trailing escape character")
+			}
+			expr.WriteString(regexp.QuoteMeta(string(runes[i])))
+		case '[':
+			end := slices.Index(runes[i+1:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated character class")
+			}
+
+			class := string(runes[i+1 : i+1+end])
+			// the negation of the class is written as '^' in both syntaxes
+			negated := strings.HasPrefix(class, "^")
+			class = strings.TrimPrefix(class, "^")
+
+			expr.WriteString("[")
+			if negated {
+				expr.WriteString("^")
+			}
+			// ranges are kept, as '-' is not escaped
+			expr.WriteString(regexp.QuoteMeta(class))
+			expr.WriteString("]")
+
+			i += end + 1
+		default:
+			expr.WriteString(regexp.QuoteMeta(string(runes[i])))
+		}
+	}
+
+	expr.WriteString("$")
+
+	return regexp.Compile(expr.String())
+}
+
+// matches returns if the command satisfies all the criteria of the Match
+func (m Match) matches(cmd command) bool {
+	if len(m.Commands) > 0 && !slices.ContainsFunc(m.Commands, func(name string) bool {
+		return strings.EqualFold(name, cmd.name())
+	}) {
+		return false
+	}
+
+	if len(m.keyPatterns) > 0 {
+		key, hasKey := cmd.key()
+		if !hasKey || !slices.ContainsFunc(m.keyPatterns, func(re *regexp.Regexp) bool {
+			return re.MatchString(key)
+		}) {
+			return false
+		}
+	}
+
+	return true
+}
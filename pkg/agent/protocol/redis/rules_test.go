@@ -0,0 +1,194 @@
+package redis
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func Test_Match(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		title    string
+		match    Match
+		args     []string
+		expected bool
+	}{
+		{
+			title:    "empty match",
+			match:    Match{},
+			args:     []string{"GET", "user:1"},
+			expected: true,
+		},
+		{
+			title:    "command name is not case-sensitive",
+			match:    Match{Commands: []string{"get", "MGET"}},
+			args:     []string{"Get", "user:1"},
+			expected: true,
+		},
+		{
+			title:    "command does not match",
+			match:    Match{Commands: []string{"SET"}},
+			args:     []string{"GET", "user:1"},
+			expected: false,
+		},
+		{
+			title:    "key prefix",
+			match:    Match{Keys: []string{"user:*"}},
+			args:     []string{"GET", "user:1"},
+			expected: true,
+		},
+		{
+			title:    "key does not match",
+			match:    Match{Keys: []string{"user:*"}},
+			args:     []string{"GET", "order:1"},
+			expected: false,
+		},
+		{
+			title:    "command without key",
+			match:    Match{Keys: []string{"*"}},
+			args:     []string{"PING"},
+			expected: false,
+		},
+		{
+			title:    "single character",
+			match:    Match{Keys: []string{"user:?"}},
+			args:     []string{"GET", "user:10"},
+			expected: false,
+		},
+		{
+			title:    "character range",
+			match:    Match{Keys: []string{"user:[0-4]"}},
+			args:     []string{"GET", "user:3"},
+			expected: true,
+		},
+		{
+			title:    "negated character class",
+			match:    Match{Keys: []string{"user:[^0-4]"}},
+			args:     []string{"GET", "user:3"},
+			expected: false,
+		},
+		{
+			title:    "escaped special character",
+			match:    Match{Keys: []string{`user:\*`}},
+			args:     []string{"GET", "user:1"},
+			expected: false,
+		},
+		{
+			title:    "regexp characters are literal",
+			match:    Match{Keys: []string{"user.(1)"}},
+			args:     []string{"GET", "user.(1)"},
+			expected: true,
+		},
+		{
+			title:    "command and key",
+			match:    Match{Commands: []string{"SET"}, Keys: []string{"user:*"}},
+			args:     []string{"GET", "user:1"},
+			expected: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			t.Parallel()
+
+			rule := Rule{Match: tc.match}
+			if err := rule.compile(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if actual := rule.Match.matches(command{args: tc.args}); actual != tc.expected {
+				t.Fatalf("expected %t but %t returned", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func Test_RuleValidation(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		title       string
+		rule        Rule
+		expectError bool
+	}{
+		{
+			title: "valid rule",
+			rule: Rule{
+				Match:        Match{Commands: []string{"GET"}, Keys: []string{"user:*"}},
+				AverageDelay: 100 * time.Millisecond,
+				ErrorRate:    0.1,
+				Error:        "LOADING Redis is loading the dataset in memory",
+			},
+			expectError: false,
+		},
+		{
+			title:       "invalid error rate",
+			rule:        Rule{ErrorRate: 1.1},
+			expectError: true,
+		},
+		{
+			title:       "error with line breaks",
+			rule:        Rule{ErrorRate: 0.1, Error: "ERR\r\n+OK"},
+			expectError: true,
+		},
+		{
+			title:       "variation larger than average delay",
+			rule:        Rule{AverageDelay: 100, DelayVariation: 200},
+			expectError: true,
+		},
+		{
+			title:       "unterminated character class",
+			rule:        Rule{Match: Match{Keys: []string{"user:[0-9"}}},
+			expectError: true,
+		},
+		{
+			title:       "trailing escape",
+			rule:        Rule{Match: Match{Keys: []string{`user:\`}}},
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			t.Parallel()
+
+			err := tc.rule.compile()
+			if !tc.expectError && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if tc.expectError && err == nil {
+				t.Fatalf("expected error but none returned")
+			}
+		})
+	}
+}
+
+func Test_ParseRules(t *testing.T) {
+	t.Parallel()
+
+	rules, err := ParseRules([]string{
+		`{"match":{"commands":["GET"],"keys":["user:*"]},"errorRate":1,"error":"READONLY replica"}`,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []Rule{
+		{
+			Match:     Match{Commands: []string{"GET"}, Keys: []string{"user:*"}},
+			ErrorRate: 1,
+			Error:     "READONLY replica",
+		},
+	}
+	if diff := cmp.Diff(expected, rules, cmp.AllowUnexported(Match{})); diff != "" {
+		t.Fatalf("expected rules do not match returned:\n%s", diff)
+	}
+
+	if _, err = ParseRules([]string{`{"match":{"command":"GET"}}`}); err == nil {
+		t.Fatalf("expected error for unknown field")
+	}
+}
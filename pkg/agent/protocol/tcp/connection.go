@@ -0,0 +1,131 @@
+package tcp
+
+import (
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+// throttleInterval is the interval at which chunks of data are forwarded when the bandwidth is limited
+const throttleInterval = 100 * time.Millisecond
+
+// errReset is returned when forwarding the data of a connection that must be reset
+var errReset = errors.New("connection reset")
+
+// connection forwards the data between a client and the upstream applying the disruption
+type connection struct {
+	client     net.Conn
+	upstream   net.Conn
+	disruption Disruption
+	// bytes forwarded in both directions
+	mutex     sync.Mutex
+	forwarded uint
+}
+
+// forward forwards the data in both directions until both sides close the connection, either side fails or the
+// connection is reset by the disruption
+func (c *connection) forward() {
+	errs := make(chan error, 2)
+	go func() {
+		errs <- c.copy(c.upstream, c.client)
+	}()
+	go func() {
+		errs <- c.copy(c.client, c.upstream)
+	}()
+
+	for range 2 {
+		err := <-errs
+		switch {
+		case errors.Is(err, errReset):
+			reset(c.client)
+			reset(c.upstream)
+		case err != nil:
+			// unblock the other direction
+			_ = c.client.Close()
+			_ = c.upstream.Close()
+		}
+	}
+
+	_ = c.client.Close()
+	_ = c.upstream.Close()
+}
+
+// copy forwards the data from src to dst applying the disruption to each chunk. When src reaches the end of the
+// stream, the write side of dst is closed so the peer receives the end of the stream as well.
+func (c *connection) copy(dst net.Conn, src net.Conn) error {
+	buffer := make([]byte, c.disruption.chunkSize())
+	for {
+		n, err := src.Read(buffer)
+		if n > 0 {
+			n, exhausted := c.take(n)
+
+			time.Sleep(c.disruption.ChunkDelay)
+			// wait the time it takes to send the chunk at the bandwidth
+			if c.disruption.Bandwidth > 0 {
+				time.Sleep(time.Duration(n) * time.Second / time.Duration(c.disruption.Bandwidth))
+			}
+
+			if n > 0 {
+				if _, werr := dst.Write(buffer[:n]); werr != nil {
+					return werr
+				}
+			}
+
+			if exhausted {
+				return errReset
+			}
+		}
+
+		if errors.Is(err, io.EOF) {
+			closeWrite(dst)
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// take reserves up to n bytes from the bytes that can be forwarded before the connection is reset. Returns the number
+// of bytes reserved and if the connection must be reset after forwarding them.
+func (c *connection) take(n int) (int, bool) {
+	if c.disruption.ResetAfterBytes == 0 {
+		return n, false
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	reserved := min(uint(n), c.disruption.ResetAfterBytes-c.forwarded)
+	c.forwarded += reserved
+
+	return int(reserved), c.forwarded >= c.disruption.ResetAfterBytes //nolint:gosec // reserved is at most n
+}
+
+// chunkSize returns the maximum number of bytes forwarded at once
+func (d Disruption) chunkSize() int {
+	if d.Bandwidth == 0 {
+		return 32 * 1024
+	}
+
+	return max(int(time.Duration(d.Bandwidth)*throttleInterval/time.Second), 1)
+}
+
+// reset closes the connection sending a reset to the peer instead of the end of the stream
+func reset(conn net.Conn) {
+	if tcpConn, ok := conn.(*net.TCPConn); ok {
+		_ = tcpConn.SetLinger(0)
+	}
+
+	_ = conn.Close()
+}
+
+// closeWrite closes the write side of the connection, if supported
+func closeWrite(conn net.Conn) {
+	if closer, ok := conn.(interface{ CloseWrite() error }); ok {
+		_ = closer.CloseWrite()
+	}
+}
@@ -0,0 +1,92 @@
+package tcp
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"time"
+
+	"github.com/grafana/xk6-disruptor/pkg/agent/protocol"
+)
+
+// Disruption specifies disruptions in TCP connections
+type Disruption struct {
+	// Delay introduced when a connection is accepted, before connecting to the upstream
+	ConnectDelay time.Duration
+	// Delay introduced to each chunk of data forwarded, in either direction
+	ChunkDelay time.Duration
+	// Maximum rate, in bytes per second, at which data is forwarded in each direction. If 0, the rate is not limited
+	Bandwidth uint
+	// Fraction (in the range 0.0 to 1.0) of connections that are accepted but never forwarded to the upstream
+	HalfOpenRate float32
+	// Number of bytes forwarded, adding both directions, after which the connection is reset
+	ResetAfterBytes uint
+}
+
+// validate checks the Disruption is valid
+func (d Disruption) validate() error {
+	if d.ConnectDelay < 0 || d.ChunkDelay < 0 {
+		return fmt.Errorf("connect delay and chunk delay must be positive")
+	}
+
+	if d.HalfOpenRate < 0.0 || d.HalfOpenRate > 1.0 {
+		return fmt.Errorf("half open rate must be in the range [0.0, 1.0]")
+	}
+
+	return nil
+}
+
+// disruptsForwarding returns if the disruption affects the connections that are forwarded to the upstream
+func (d Disruption) disruptsForwarding() bool {
+	return d.ConnectDelay > 0 || d.ChunkDelay > 0 || d.Bandwidth > 0 || d.ResetAfterBytes > 0
+}
+
+// handler applies the disruption to the connections accepted by the proxy. Each connection is counted as a request.
+type handler struct {
+	disruption Disruption
+	metrics    *protocol.MetricMap
+}
+
+// Handle forwards a connection to the upstream, applying the disruption
+func (h *handler) Handle(ctx context.Context, client net.Conn, dial DialFunc) {
+	h.metrics.Inc(protocol.MetricRequests)
+
+	halfOpen := h.disruption.HalfOpenRate > 0 && rand.Float32() <= h.disruption.HalfOpenRate
+	if halfOpen || h.disruption.disruptsForwarding() {
+		h.metrics.Inc(protocol.MetricRequestsDisrupted)
+	}
+
+	if halfOpen {
+		// keep the connection open until the client closes it, discarding anything it sends
+		_, _ = io.Copy(io.Discard, client)
+		return
+	}
+
+	select {
+	case <-time.After(h.disruption.ConnectDelay):
+	case <-ctx.Done():
+		return
+	}
+
+	upstream, err := dial()
+	if err != nil {
+		return
+	}
+
+	c := &connection{
+		client:     client,
+		upstream:   upstream,
+		disruption: h.disruption,
+	}
+	c.forward()
+}
+
+// supportedMetrics returns the metrics that the tcp proxy supports and thus should be pre-initialized to zero
+func supportedMetrics() []string {
+	return []string{
+		protocol.MetricRequests,
+		protocol.MetricRequestsDisrupted,
+	}
+}
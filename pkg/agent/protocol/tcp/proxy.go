@@ -0,0 +1,207 @@
+// Package tcp implements a proxy that applies disruptions to TCP connections.
+// The proxy does not decode the data it forwards, so it can disrupt any protocol that runs over TCP. The proxy is
+// also the base for the proxies of protocols that run over TCP, which implement a Handler for their connections.
+package tcp
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+
+	"github.com/grafana/xk6-disruptor/pkg/agent/protocol"
+)
+
+// DialFunc opens a connection to the upstream
+type DialFunc func() (net.Conn, error)
+
+// Handler handles the connections accepted by a proxy
+type Handler interface {
+	// Handle forwards a connection accepted by the proxy to the upstream, which is connected by calling dial.
+	// The connections are closed when Handle returns or when the proxy is stopped.
+	Handle(ctx context.Context, client net.Conn, dial DialFunc)
+}
+
+// proxy defines the parameters used by the proxy for forwarding connections and its execution state
+type proxy struct {
+	listener        net.Listener
+	upstreamAddress string
+	dialer          *net.Dialer
+	handler         Handler
+	metrics         *protocol.MetricMap
+	ctx             context.Context
+	cancel          func()
+	// connections open by the proxy, to the clients and to the upstream
+	mutex sync.Mutex
+	conns map[net.Conn]struct{}
+	open  sync.WaitGroup
+}
+
+// NewProxy return a new Proxy for the TCP connections sent to the upstream
+func NewProxy(listener net.Listener, upstreamAddress string, d Disruption) (protocol.Proxy, error) {
+	return newDisruptionProxy(listener, upstreamAddress, d, &net.Dialer{})
+}
+
+// NewEgressProxy returns a new Proxy for the TCP connections opened by the target to an upstream. The connections to
+// the upstream are marked so they are not redirected back to the proxy.
+func NewEgressProxy(listener net.Listener, upstreamAddress string, d Disruption) (protocol.Proxy, error) {
+	return newDisruptionProxy(listener, upstreamAddress, d, protocol.MarkedDialer())
+}
+
+func newDisruptionProxy(
+	listener net.Listener,
+	upstreamAddress string,
+	d Disruption,
+	dialer *net.Dialer,
+) (protocol.Proxy, error) {
+	if err := d.validate(); err != nil {
+		return nil, err
+	}
+
+	metrics := protocol.NewMetricMap(supportedMetrics()...)
+
+	return NewConnectionProxy(listener, upstreamAddress, dialer, &handler{disruption: d, metrics: metrics}, metrics)
+}
+
+// NewConnectionProxy returns a Proxy that passes the connections it accepts to the handler, which forwards them to
+// the upstream using the dialer. The proxy reports the metrics updated by the handler.
+func NewConnectionProxy(
+	listener net.Listener,
+	upstreamAddress string,
+	dialer *net.Dialer,
+	handler Handler,
+	metrics *protocol.MetricMap,
+) (protocol.Proxy, error) {
+	if upstreamAddress == "" {
+		return nil, fmt.Errorf("proxy's forwarding address must be provided")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &proxy{
+		listener:        listener,
+		upstreamAddress: upstreamAddress,
+		dialer:          dialer,
+		handler:         handler,
+		metrics:         metrics,
+		ctx:             ctx,
+		cancel:          cancel,
+		conns:           map[net.Conn]struct{}{},
+	}, nil
+}
+
+// Start starts the execution of the proxy
+func (p *proxy) Start() error {
+	for {
+		conn, err := p.listener.Accept()
+		if err != nil {
+			if p.ctx.Err() != nil {
+				return nil
+			}
+
+			return fmt.Errorf("proxy terminated with error: %w", err)
+		}
+
+		if !p.track(conn) {
+			_ = conn.Close()
+			continue
+		}
+
+		go p.handle(conn)
+	}
+}
+
+// Stop stops the execution of the proxy. As connections can be long-lived, they are closed instead of drained, but
+// Stop waits for them to be closed.
+func (p *proxy) Stop() error {
+	p.close()
+	p.open.Wait()
+
+	return nil
+}
+
+// Metrics returns runtime metrics for the proxy
+func (p *proxy) Metrics() map[string]uint {
+	return p.metrics.Map()
+}
+
+// Force stops the proxy without waiting for connections to be closed
+func (p *proxy) Force() error {
+	p.close()
+
+	return nil
+}
+
+// handle passes a connection to the handler. The connections the handler opens to the upstream are closed along with
+// the client's connection when the handler returns.
+func (p *proxy) handle(client net.Conn) {
+	var mutex sync.Mutex
+	conns := []net.Conn{client}
+	defer func() {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		for _, conn := range conns {
+			p.untrack(conn)
+		}
+	}()
+
+	dial := func() (net.Conn, error) {
+		conn, err := p.dialer.DialContext(p.ctx, "tcp", p.upstreamAddress)
+		if err != nil {
+			return nil, err
+		}
+
+		if !p.track(conn) {
+			_ = conn.Close()
+			return nil, net.ErrClosed
+		}
+
+		mutex.Lock()
+		defer mutex.Unlock()
+		conns = append(conns, conn)
+
+		return conn, nil
+	}
+
+	p.handler.Handle(p.ctx, client, dial)
+}
+
+// close stops accepting connections and closes the open connections
+func (p *proxy) close() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.cancel()
+	_ = p.listener.Close()
+
+	for conn := range p.conns {
+		_ = conn.Close()
+	}
+}
+
+// track adds a connection to the connections open by the proxy. Returns false if the proxy is stopped and the
+// connection must not be used.
+func (p *proxy) track(conn net.Conn) bool {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.ctx.Err() != nil {
+		return false
+	}
+
+	p.conns[conn] = struct{}{}
+	p.open.Add(1)
+
+	return true
+}
+
+// untrack closes a connection and removes it from the connections open by the proxy
+func (p *proxy) untrack(conn net.Conn) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	_ = conn.Close()
+	delete(p.conns, conn)
+	p.open.Done()
+}
@@ -0,0 +1,351 @@
+package tcp
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/grafana/xk6-disruptor/pkg/agent/protocol"
+)
+
+// echoUpstream starts a server that echoes the data it receives and returns its address and the counter of the
+// connections it accepted
+func echoUpstream(t *testing.T) (string, *atomic.Int32) {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("creating listener: %v", err)
+	}
+	t.Cleanup(func() { _ = listener.Close() })
+
+	accepted := &atomic.Int32{}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			accepted.Add(1)
+
+			go func() {
+				defer func() {
+					_ = conn.Close()
+				}()
+				_, _ = io.Copy(conn, conn)
+			}()
+		}
+	}()
+
+	return listener.Addr().String(), accepted
+}
+
+func Test_Validations(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		title       string
+		disruption  Disruption
+		upstream    string
+		expectError bool
+	}{
+		{
+			title:       "valid defaults",
+			disruption:  Disruption{},
+			upstream:    ":6379",
+			expectError: false,
+		},
+		{
+			title: "valid disruption",
+			disruption: Disruption{
+				ConnectDelay:    time.Second,
+				ChunkDelay:      10 * time.Millisecond,
+				Bandwidth:       1024,
+				HalfOpenRate:    0.1,
+				ResetAfterBytes: 4096,
+			},
+			upstream:    ":6379",
+			expectError: false,
+		},
+		{
+			title:       "invalid upstream address",
+			disruption:  Disruption{},
+			upstream:    "",
+			expectError: true,
+		},
+		{
+			title:       "negative connect delay",
+			disruption:  Disruption{ConnectDelay: -time.Second},
+			upstream:    ":6379",
+			expectError: true,
+		},
+		{
+			title:       "invalid half open rate",
+			disruption:  Disruption{HalfOpenRate: 1.1},
+			upstream:    ":6379",
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			t.Parallel()
+
+			listener, err := net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatalf("creating listener: %v", err)
+			}
+			defer func() {
+				_ = listener.Close()
+			}()
+
+			_, err = NewProxy(listener, tc.upstream, tc.disruption)
+			if !tc.expectError && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if tc.expectError && err == nil {
+				t.Fatalf("expected error but none returned")
+			}
+		})
+	}
+}
+
+func Test_ProxyDisruption(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		title      string
+		disruption Disruption
+		// data sent by the client
+		data []byte
+		// data expected back from the upstream before the connection ends
+		expected []byte
+		// minimum time for receiving the expected data
+		minElapsed time.Duration
+		// expect the connection to be reset after receiving the expected data
+		expectReset bool
+		// expect the connection to be held open without forwarding any data
+		expectHalfOpen  bool
+		expectedMetrics map[string]uint
+	}{
+		{
+			title:      "no disruption",
+			disruption: Disruption{},
+			data:       []byte("ping"),
+			expected:   []byte("ping"),
+			expectedMetrics: map[string]uint{
+				protocol.MetricRequests:          1,
+				protocol.MetricRequestsDisrupted: 0,
+			},
+		},
+		{
+			title:      "connect delay",
+			disruption: Disruption{ConnectDelay: 200 * time.Millisecond},
+			data:       []byte("ping"),
+			expected:   []byte("ping"),
+			minElapsed: 200 * time.Millisecond,
+			expectedMetrics: map[string]uint{
+				protocol.MetricRequests:          1,
+				protocol.MetricRequestsDisrupted: 1,
+			},
+		},
+		{
+			title:      "chunk delay",
+			disruption: Disruption{ChunkDelay: 100 * time.Millisecond},
+			data:       []byte("ping"),
+			expected:   []byte("ping"),
+			// delayed in both directions
+			minElapsed: 200 * time.Millisecond,
+			expectedMetrics: map[string]uint{
+				protocol.MetricRequests:          1,
+				protocol.MetricRequestsDisrupted: 1,
+			},
+		},
+		{
+			title:      "bandwidth",
+			disruption: Disruption{Bandwidth: 10000},
+			data:       bytes.Repeat([]byte("x"), 3000),
+			expected:   bytes.Repeat([]byte("x"), 3000),
+			minElapsed: 300 * time.Millisecond,
+			expectedMetrics: map[string]uint{
+				protocol.MetricRequests:          1,
+				protocol.MetricRequestsDisrupted: 1,
+			},
+		},
+		{
+			title:       "reset after bytes in response",
+			disruption:  Disruption{ResetAfterBytes: 6},
+			data:        []byte("ping"),
+			expected:    []byte("pi"),
+			expectReset: true,
+			expectedMetrics: map[string]uint{
+				protocol.MetricRequests:          1,
+				protocol.MetricRequestsDisrupted: 1,
+			},
+		},
+		{
+			title:       "reset after bytes in request",
+			disruption:  Disruption{ResetAfterBytes: 2},
+			data:        []byte("ping"),
+			expected:    []byte{},
+			expectReset: true,
+			expectedMetrics: map[string]uint{
+				protocol.MetricRequests:          1,
+				protocol.MetricRequestsDisrupted: 1,
+			},
+		},
+		{
+			title:          "half open",
+			disruption:     Disruption{HalfOpenRate: 1.0},
+			data:           []byte("ping"),
+			expected:       []byte{},
+			expectHalfOpen: true,
+			expectedMetrics: map[string]uint{
+				protocol.MetricRequests:          1,
+				protocol.MetricRequestsDisrupted: 1,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			t.Parallel()
+
+			upstream, accepted := echoUpstream(t)
+
+			listener, err := net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatalf("creating listener: %v", err)
+			}
+
+			proxy, err := NewProxy(listener, upstream, tc.disruption)
+			if err != nil {
+				t.Fatalf("creating proxy: %v", err)
+			}
+
+			go func() {
+				_ = proxy.Start()
+			}()
+			t.Cleanup(func() { _ = proxy.Force() })
+
+			start := time.Now()
+			conn, err := net.Dial("tcp", listener.Addr().String())
+			if err != nil {
+				t.Fatalf("connecting to proxy: %v", err)
+			}
+			defer func() {
+				_ = conn.Close()
+			}()
+
+			if _, err = conn.Write(tc.data); err != nil {
+				t.Fatalf("sending data: %v", err)
+			}
+
+			_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+			if tc.expectHalfOpen {
+				_ = conn.SetReadDeadline(time.Now().Add(200 * time.Millisecond))
+			}
+
+			received := make([]byte, len(tc.expected))
+			if _, err = io.ReadFull(conn, received); err != nil {
+				t.Fatalf("receiving data: %v", err)
+			}
+			elapsed := time.Since(start)
+
+			if !bytes.Equal(received, tc.expected) {
+				t.Fatalf("expected %q but %q received", tc.expected, received)
+			}
+
+			if elapsed < tc.minElapsed {
+				t.Fatalf("expected data in at least %v but received in %v", tc.minElapsed, elapsed)
+			}
+
+			if tc.expectReset {
+				_, err = conn.Read(make([]byte, 1))
+				if err == nil || errors.Is(err, io.EOF) {
+					t.Fatalf("expected connection reset but got %v", err)
+				}
+			}
+
+			if tc.expectHalfOpen {
+				_, err = conn.Read(make([]byte, 1))
+				if !errors.Is(err, os.ErrDeadlineExceeded) {
+					t.Fatalf("expected connection held open but got %v", err)
+				}
+
+				if accepted.Load() != 0 {
+					t.Fatalf("expected no connection to the upstream")
+				}
+			}
+
+			if diff := cmp.Diff(tc.expectedMetrics, proxy.Metrics()); diff != "" {
+				t.Fatalf("expected metrics do not match returned:\n%s", diff)
+			}
+		})
+	}
+}
+
+func Test_ProxyStop(t *testing.T) {
+	t.Parallel()
+
+	upstream, _ := echoUpstream(t)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("creating listener: %v", err)
+	}
+
+	proxy, err := NewProxy(listener, upstream, Disruption{})
+	if err != nil {
+		t.Fatalf("creating proxy: %v", err)
+	}
+
+	done := make(chan error)
+	go func() {
+		done <- proxy.Start()
+	}()
+
+	conn, err := net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatalf("connecting to proxy: %v", err)
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+
+	// wait for the connection to be forwarded
+	if _, err = conn.Write([]byte("ping")); err != nil {
+		t.Fatalf("sending data: %v", err)
+	}
+	if _, err = io.ReadFull(conn, make([]byte, 4)); err != nil {
+		t.Fatalf("receiving data: %v", err)
+	}
+
+	// stop must close the open connections instead of waiting for the client to close them
+	stopped := make(chan error)
+	go func() {
+		stopped <- proxy.Stop()
+	}()
+
+	select {
+	case <-stopped:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("proxy did not stop")
+	}
+
+	if err = <-done; err != nil {
+		t.Fatalf("unexpected error from proxy: %v", err)
+	}
+
+	_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	if _, err = conn.Read(make([]byte, 1)); err == nil {
+		t.Fatalf("expected connection closed")
+	}
+}
@@ -0,0 +1,162 @@
+// Package schedule implements profiles that vary the intensity of a fault during its injection.
+// A Schedule returns a level for the time elapsed since the fault was injected. The disruptors multiply the delays
+// and rates of the fault by the level, and do not disrupt while the level is zero.
+package schedule
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"time"
+)
+
+const (
+	// KindConstant keeps the level at 1.0 for the whole injection. It is the default.
+	KindConstant = "constant"
+	// KindRamp changes the level linearly from From to To during Duration, and keeps it at To afterwards
+	KindRamp = "ramp"
+	// KindSteps changes the level to the level of each step when its time is reached. The level is 0.0 before the
+	// first step.
+	KindSteps = "steps"
+	// KindFlap alternates periods of On duration with level 1.0 and periods of Off duration with level 0.0, starting
+	// with an On period
+	KindFlap = "flap"
+	// KindSine oscillates the level between From and To following a sine wave with the given Period, starting at From
+	KindSine = "sine"
+)
+
+// UpdateInterval is the interval at which the disruptors that cannot evaluate the schedule for each request, such as
+// the network disruptors, update the fault
+const UpdateInterval = time.Second
+
+// Step is a change of level at a time since the start of the injection
+type Step struct {
+	// At is the time since the start of the injection
+	At time.Duration `json:"at"`
+	// Level from At until the next step
+	Level float64 `json:"level"`
+}
+
+// Schedule defines how the level of a fault changes over time
+type Schedule struct {
+	// Kind of schedule. Defaults to KindConstant
+	Kind string `json:"kind,omitempty"`
+	// From is the initial level of a ramp, and the minimum level of a sine
+	From float64 `json:"from,omitempty"`
+	// To is the final level of a ramp, and the maximum level of a sine
+	To float64 `json:"to,omitempty"`
+	// Duration of a ramp
+	Duration time.Duration `json:"duration,omitempty"`
+	// Steps of a step function, sorted by time
+	Steps []Step `json:"steps,omitempty"`
+	// On is the duration of the periods of a flap with level 1.0
+	On time.Duration `json:"on,omitempty"`
+	// Off is the duration of the periods of a flap with level 0.0
+	Off time.Duration `json:"off,omitempty"`
+	// Period of a sine
+	Period time.Duration `json:"period,omitempty"`
+}
+
+// Parse parses a schedule in JSON format. An empty value returns a constant schedule.
+func Parse(value string) (Schedule, error) {
+	schedule := Schedule{}
+	if value == "" {
+		return schedule, nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewBufferString(value))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&schedule); err != nil {
+		return Schedule{}, fmt.Errorf("parsing schedule %q: %w", value, err)
+	}
+
+	return schedule, schedule.Validate()
+}
+
+// IsConstant returns if the level of the schedule does not change
+func (s Schedule) IsConstant() bool {
+	return s.Kind == "" || s.Kind == KindConstant
+}
+
+// Validate checks the parameters of the schedule are valid for its kind
+//
+//nolint:cyclop // each kind has its own parameters
+func (s Schedule) Validate() error {
+	if s.From < 0 || s.To < 0 {
+		return fmt.Errorf("schedule levels must be positive")
+	}
+
+	switch s.Kind {
+	case "", KindConstant:
+		return nil
+	case KindRamp:
+		if s.Duration <= 0 {
+			return fmt.Errorf("ramp schedule requires a duration")
+		}
+	case KindSteps:
+		if len(s.Steps) == 0 {
+			return fmt.Errorf("steps schedule requires at least one step")
+		}
+		for i, step := range s.Steps {
+			if step.Level < 0 {
+				return fmt.Errorf("schedule levels must be positive")
+			}
+			if i > 0 && step.At <= s.Steps[i-1].At {
+				return fmt.Errorf("schedule steps must be sorted by time")
+			}
+		}
+	case KindFlap:
+		if s.On <= 0 || s.Off <= 0 {
+			return fmt.Errorf("flap schedule requires on and off durations")
+		}
+	case KindSine:
+		if s.Period <= 0 {
+			return fmt.Errorf("sine schedule requires a period")
+		}
+	default:
+		return fmt.Errorf("unknown schedule kind %q", s.Kind)
+	}
+
+	return nil
+}
+
+// Level returns the level of the schedule at the given time since the start of the injection
+func (s Schedule) Level(elapsed time.Duration) float64 {
+	switch s.Kind {
+	case KindRamp:
+		if elapsed >= s.Duration {
+			return s.To
+		}
+		return s.From + (s.To-s.From)*float64(elapsed)/float64(s.Duration)
+	case KindSteps:
+		level := 0.0
+		for _, step := range s.Steps {
+			if elapsed < step.At {
+				break
+			}
+			level = step.Level
+		}
+		return level
+	case KindFlap:
+		if elapsed%(s.On+s.Off) < s.On {
+			return 1.0
+		}
+		return 0.0
+	case KindSine:
+		phase := 2 * math.Pi * float64(elapsed%s.Period) / float64(s.Period)
+		return s.From + (s.To-s.From)*(1-math.Cos(phase))/2
+	default:
+		return 1.0
+	}
+}
+
+// Duration returns a duration multiplied by a level
+func Duration(d time.Duration, level float64) time.Duration {
+	return time.Duration(float64(d) * level)
+}
+
+// Rate returns a rate multiplied by a level, limited to 1.0
+func Rate(rate float64, level float64) float64 {
+	return math.Min(rate*level, 1.0)
+}
@@ -0,0 +1,129 @@
+package schedule
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func Test_Parse(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		title       string
+		value       string
+		expectError bool
+	}{
+		{
+			title:       "empty",
+			value:       "",
+			expectError: false,
+		},
+		{
+			title:       "ramp",
+			value:       `{"kind":"ramp","from":0,"to":1,"duration":60000000000}`,
+			expectError: false,
+		},
+		{
+			title:       "steps",
+			value:       `{"kind":"steps","steps":[{"at":0,"level":0.5},{"at":30000000000,"level":1}]}`,
+			expectError: false,
+		},
+		{
+			title:       "ramp without duration",
+			value:       `{"kind":"ramp","to":1}`,
+			expectError: true,
+		},
+		{
+			title:       "unsorted steps",
+			value:       `{"kind":"steps","steps":[{"at":2,"level":0.5},{"at":1,"level":1}]}`,
+			expectError: true,
+		},
+		{
+			title:       "flap without off duration",
+			value:       `{"kind":"flap","on":1000000000}`,
+			expectError: true,
+		},
+		{
+			title:       "sine without period",
+			value:       `{"kind":"sine","from":0.5,"to":1.5}`,
+			expectError: true,
+		},
+		{
+			title:       "negative level",
+			value:       `{"kind":"ramp","from":-1,"to":1,"duration":1000000000}`,
+			expectError: true,
+		},
+		{
+			title:       "unknown kind",
+			value:       `{"kind":"square"}`,
+			expectError: true,
+		},
+		{
+			title:       "unknown field",
+			value:       `{"kind":"sine","amplitude":1,"period":1000000000}`,
+			expectError: true,
+		},
+		{
+			title:       "malformed",
+			value:       `{"kind":`,
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := Parse(tc.value)
+			if !tc.expectError && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if tc.expectError && err == nil {
+				t.Fatalf("expected error but none returned")
+			}
+		})
+	}
+}
+
+func Test_Level(t *testing.T) {
+	t.Parallel()
+
+	ramp := Schedule{Kind: KindRamp, From: 0.2, To: 1.0, Duration: 10 * time.Second}
+	steps := Schedule{Kind: KindSteps, Steps: []Step{{At: time.Second, Level: 0.5}, {At: 3 * time.Second, Level: 1}}}
+	flap := Schedule{Kind: KindFlap, On: 2 * time.Second, Off: time.Second}
+	sine := Schedule{Kind: KindSine, From: 0.5, To: 1.5, Period: 4 * time.Second}
+
+	testCases := []struct {
+		title    string
+		schedule Schedule
+		elapsed  time.Duration
+		expected float64
+	}{
+		{title: "constant", schedule: Schedule{}, elapsed: time.Hour, expected: 1.0},
+		{title: "ramp start", schedule: ramp, elapsed: 0, expected: 0.2},
+		{title: "ramp middle", schedule: ramp, elapsed: 5 * time.Second, expected: 0.6},
+		{title: "ramp end", schedule: ramp, elapsed: time.Minute, expected: 1.0},
+		{title: "before first step", schedule: steps, elapsed: 0, expected: 0.0},
+		{title: "first step", schedule: steps, elapsed: 2 * time.Second, expected: 0.5},
+		{title: "last step", schedule: steps, elapsed: 3 * time.Second, expected: 1.0},
+		{title: "flap on", schedule: flap, elapsed: time.Second, expected: 1.0},
+		{title: "flap off", schedule: flap, elapsed: 2 * time.Second, expected: 0.0},
+		{title: "flap on again", schedule: flap, elapsed: 3 * time.Second, expected: 1.0},
+		{title: "sine start", schedule: sine, elapsed: 0, expected: 0.5},
+		{title: "sine middle", schedule: sine, elapsed: time.Second, expected: 1.0},
+		{title: "sine peak", schedule: sine, elapsed: 6 * time.Second, expected: 1.5},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			t.Parallel()
+
+			level := tc.schedule.Level(tc.elapsed)
+			if math.Abs(level-tc.expected) > 1e-9 {
+				t.Fatalf("expected level %f but got %f", tc.expected, level)
+			}
+		})
+	}
+}
@@ -0,0 +1,362 @@
+package stressors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const (
+	// DefaultDiskBlockSize is the default size of each read and write of the DiskIOStressor
+	DefaultDiskBlockSize = 1024 * 1024
+	// DefaultDiskFileSize is the default size of the file read and written by each DiskIOStressor worker
+	DefaultDiskFileSize = 64 * 1024 * 1024
+	// maxBallastFileSize is the maximum size of each ballast file written by the DiskFillStressor
+	maxBallastFileSize = 1024 * 1024 * 1024
+	// ioFilePattern is the pattern for naming the files used by the DiskIOStressor workers
+	ioFilePattern = "xk6-disruptor-io-*"
+	// ballastFilePrefix is the prefix of the ballast files created by the DiskFillStressor
+	ballastFilePrefix = "xk6-disruptor-ballast-"
+)
+
+// DiskIODisruption defines a disruption that generates read and write I/O load on a path
+type DiskIODisruption struct {
+	// Path is the directory where the I/O load is generated
+	Path string
+	// Workers is the number of concurrent workers generating I/O load. Default 1
+	Workers int
+	// BlockSize is the size of each read and write. Default 1Mi
+	BlockSize uint64
+	// FileSize is the size of the file each worker writes and then reads back. Default 64Mi
+	FileSize uint64
+}
+
+// DiskIOStressor generates sustained I/O load on a path
+type DiskIOStressor struct {
+	Disruption DiskIODisruption
+}
+
+// DiskFillDisruption defines a disruption that fills the filesystem of a path
+type DiskFillDisruption struct {
+	// Path is the directory where the ballast files are written
+	Path string
+	// Usage is the target usage of the filesystem as a percentage
+	Usage int
+	// SizeLimit is the size of a volume that is limited by the size of its files instead of by the size of its
+	// filesystem, such as an emptyDir volume with a size limit that shares the filesystem of the node. If set, the
+	// usage is computed from the size of the files in Path, so the filesystem is not filled beyond this size.
+	SizeLimit uint64
+}
+
+// DiskFillStressor fills the filesystem of a path up to a target usage writing ballast files
+type DiskFillStressor struct {
+	Disruption DiskFillDisruption
+}
+
+// validatePath checks the path exists and is a directory
+func validatePath(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("invalid path: %w", err)
+	}
+
+	if !info.IsDir() {
+		return fmt.Errorf("invalid path %q: not a directory", path)
+	}
+
+	return nil
+}
+
+// NewDiskIOStressor creates a DiskIOStressor for the given disruption
+func NewDiskIOStressor(disruption DiskIODisruption) (*DiskIOStressor, error) {
+	if err := validatePath(disruption.Path); err != nil {
+		return nil, err
+	}
+
+	if disruption.Workers == 0 {
+		disruption.Workers = 1
+	}
+
+	if disruption.BlockSize == 0 {
+		disruption.BlockSize = DefaultDiskBlockSize
+	}
+
+	if disruption.FileSize == 0 {
+		disruption.FileSize = DefaultDiskFileSize
+	}
+
+	if disruption.Workers < 0 {
+		return nil, fmt.Errorf("number of workers must be positive")
+	}
+
+	if disruption.BlockSize > disruption.FileSize {
+		return nil, fmt.Errorf("block size must not be larger than file size")
+	}
+
+	return &DiskIOStressor{
+		Disruption: disruption,
+	}, nil
+}
+
+// Apply generates I/O load for the given duration
+func (s *DiskIOStressor) Apply(ctx context.Context, duration time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, duration)
+	defer cancel()
+
+	doneCh := make(chan error, s.Disruption.Workers)
+	for range s.Disruption.Workers {
+		go func() {
+			doneCh <- s.work(ctx)
+		}()
+	}
+
+	// wait for all workers to finish. Return the first error, if any
+	var err error
+	for range s.Disruption.Workers {
+		if werr := <-doneCh; werr != nil && err == nil {
+			err = werr
+			cancel()
+		}
+	}
+
+	return err
+}
+
+// work repeatedly writes a file, syncs it to the disk and reads it back until the context is done
+func (s *DiskIOStressor) work(ctx context.Context) error {
+	file, err := os.CreateTemp(s.Disruption.Path, ioFilePattern)
+	if err != nil {
+		return fmt.Errorf("creating file: %w", err)
+	}
+
+	defer func() {
+		_ = file.Close()
+		_ = os.Remove(file.Name())
+	}()
+
+	block := make([]byte, s.Disruption.BlockSize)
+	for i := range block {
+		block[i] = byte(i)
+	}
+
+	blocks := s.Disruption.FileSize / s.Disruption.BlockSize
+	for {
+		if _, err = file.Seek(0, io.SeekStart); err != nil {
+			return fmt.Errorf("writing file: %w", err)
+		}
+
+		for range blocks {
+			if ctx.Err() != nil {
+				return nil
+			}
+
+			if _, err = file.Write(block); err != nil {
+				return fmt.Errorf("writing file: %w", err)
+			}
+		}
+
+		if err = file.Sync(); err != nil {
+			return fmt.Errorf("syncing file: %w", err)
+		}
+
+		if _, err = file.Seek(0, io.SeekStart); err != nil {
+			return fmt.Errorf("reading file: %w", err)
+		}
+
+		for range blocks {
+			if ctx.Err() != nil {
+				return nil
+			}
+
+			if _, err = io.ReadFull(file, block); err != nil {
+				return fmt.Errorf("reading file: %w", err)
+			}
+		}
+	}
+}
+
+// NewDiskFillStressor creates a DiskFillStressor for the given disruption
+func NewDiskFillStressor(disruption DiskFillDisruption) (*DiskFillStressor, error) {
+	if err := validatePath(disruption.Path); err != nil {
+		return nil, err
+	}
+
+	if disruption.Usage < 1 || disruption.Usage > 100 {
+		return nil, fmt.Errorf("disk usage must be in the range [1, 100]")
+	}
+
+	return &DiskFillStressor{
+		Disruption: disruption,
+	}, nil
+}
+
+// Apply writes ballast files until the filesystem reaches the target usage and keeps them for the given duration.
+// The ballast files are removed when done.
+func (s *DiskFillStressor) Apply(ctx context.Context, duration time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, duration)
+	defer cancel()
+
+	// remove any ballast left by a previous execution, so it is not accounted as used space
+	err := s.removeBallast()
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = s.removeBallast()
+	}()
+
+	size, err := s.ballastSize()
+	if err != nil {
+		return err
+	}
+
+	err = s.fill(ctx, size)
+	if err != nil {
+		return err
+	}
+
+	<-ctx.Done()
+
+	return nil
+}
+
+// ballastSize returns the size of the ballast to write for reaching the target usage
+func (s *DiskFillStressor) ballastSize() (uint64, error) {
+	total, available, err := DiskUsage(s.Disruption.Path)
+	if err != nil {
+		return 0, fmt.Errorf("getting disk usage: %w", err)
+	}
+
+	used := total - available
+	if s.Disruption.SizeLimit > 0 {
+		used, err = filesSize(s.Disruption.Path)
+		if err != nil {
+			return 0, fmt.Errorf("getting disk usage: %w", err)
+		}
+		total = s.Disruption.SizeLimit
+	}
+
+	target := total / 100 * uint64(s.Disruption.Usage)
+	if target <= used {
+		return 0, nil
+	}
+
+	return min(target-used, available), nil
+}
+
+// filesSize returns the size of the regular files in a directory and its subdirectories
+func filesSize(root string) (uint64, error) {
+	size := uint64(0)
+	err := filepath.WalkDir(root, func(_ string, entry fs.DirEntry, err error) error {
+		if err != nil || !entry.Type().IsRegular() {
+			return err
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+
+		size += uint64(info.Size()) //nolint:gosec // file sizes are not negative
+
+		return nil
+	})
+
+	return size, err
+}
+
+// fill writes ballast files with the given size in total
+func (s *DiskFillStressor) fill(ctx context.Context, size uint64) error {
+	block := make([]byte, DefaultDiskBlockSize)
+
+	for n := 0; size > 0; n++ {
+		fileSize := min(size, maxBallastFileSize)
+
+		name := filepath.Join(s.Disruption.Path, fmt.Sprintf("%s%d", ballastFilePrefix, n))
+		file, err := os.Create(name) //nolint:gosec
+		if err != nil {
+			return fmt.Errorf("creating ballast file: %w", err)
+		}
+
+		written := uint64(0)
+		for written < fileSize {
+			if ctx.Err() != nil {
+				_ = file.Close()
+				return nil
+			}
+
+			chunk := min(fileSize-written, uint64(len(block)))
+			_, err = file.Write(block[:chunk])
+			if err != nil {
+				_ = file.Close()
+				// a full disk is not an error, as the target usage is (almost) reached
+				if errors.Is(err, syscall.ENOSPC) {
+					return nil
+				}
+				return fmt.Errorf("writing ballast file: %w", err)
+			}
+
+			written += chunk
+		}
+
+		err = file.Sync()
+		_ = file.Close()
+		if err != nil {
+			return fmt.Errorf("syncing ballast file: %w", err)
+		}
+
+		size -= fileSize
+	}
+
+	return nil
+}
+
+// removeBallast removes the ballast files from the path
+func (s *DiskFillStressor) removeBallast() error {
+	files, err := filepath.Glob(filepath.Join(s.Disruption.Path, ballastFilePrefix+"*"))
+	if err != nil {
+		return err
+	}
+
+	var errs error
+	for _, file := range files {
+		if err := os.Remove(file); err != nil && !errors.Is(err, os.ErrNotExist) {
+			errs = errors.Join(errs, fmt.Errorf("removing ballast file: %w", err))
+		}
+	}
+
+	return errs
+}
+
+// RemoveBallast removes the ballast files written by any DiskFillStressor in a directory and its subdirectories.
+// It is intended for removing the ballast left behind by a process that could not remove it, for example because it
+// was killed. A directory that does not exist is ignored.
+func RemoveBallast(root string) error {
+	var errs error
+	err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+
+		if entry.Type().IsRegular() && strings.HasPrefix(entry.Name(), ballastFilePrefix) {
+			if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				errs = errors.Join(errs, fmt.Errorf("removing ballast file: %w", err))
+			}
+		}
+
+		return nil
+	})
+
+	return errors.Join(errs, err)
+}
@@ -0,0 +1,302 @@
+package stressors
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func Test_NewDiskIOStressor(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	file := filepath.Join(dir, "file")
+	if err := os.WriteFile(file, []byte{}, 0o600); err != nil {
+		t.Fatalf("creating file: %v", err)
+	}
+
+	testCases := []struct {
+		title       string
+		disruption  DiskIODisruption
+		expected    DiskIODisruption
+		expectError bool
+	}{
+		{
+			title:      "defaults",
+			disruption: DiskIODisruption{Path: dir},
+			expected: DiskIODisruption{
+				Path:      dir,
+				Workers:   1,
+				BlockSize: DefaultDiskBlockSize,
+				FileSize:  DefaultDiskFileSize,
+			},
+		},
+		{
+			title:       "path does not exist",
+			disruption:  DiskIODisruption{Path: filepath.Join(dir, "missing")},
+			expectError: true,
+		},
+		{
+			title:       "path is not a directory",
+			disruption:  DiskIODisruption{Path: file},
+			expectError: true,
+		},
+		{
+			title:       "negative workers",
+			disruption:  DiskIODisruption{Path: dir, Workers: -1},
+			expectError: true,
+		},
+		{
+			title:       "block larger than file",
+			disruption:  DiskIODisruption{Path: dir, BlockSize: 2048, FileSize: 1024},
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			t.Parallel()
+
+			s, err := NewDiskIOStressor(tc.disruption)
+			if tc.expectError && err == nil {
+				t.Fatalf("should had failed")
+			}
+
+			if !tc.expectError && err != nil {
+				t.Fatalf("failed: %v", err)
+			}
+
+			if tc.expectError {
+				return
+			}
+
+			if s.Disruption != tc.expected {
+				t.Fatalf("expected %v got %v", tc.expected, s.Disruption)
+			}
+		})
+	}
+}
+
+func Test_DiskIOStressorApply(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	s, err := NewDiskIOStressor(DiskIODisruption{
+		Path:      dir,
+		Workers:   2,
+		BlockSize: 4096,
+		FileSize:  64 * 1024,
+	})
+	if err != nil {
+		t.Fatalf("failed: %v", err)
+	}
+
+	err = s.Apply(t.Context(), 200*time.Millisecond)
+	if err != nil {
+		t.Fatalf("failed: %v", err)
+	}
+
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("reading dir: %v", err)
+	}
+
+	if len(files) != 0 {
+		t.Fatalf("expected files to be removed, found %d", len(files))
+	}
+}
+
+func Test_NewDiskFillStressor(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	testCases := []struct {
+		title       string
+		disruption  DiskFillDisruption
+		expectError bool
+	}{
+		{
+			title:      "valid usage",
+			disruption: DiskFillDisruption{Path: dir, Usage: 90},
+		},
+		{
+			title:       "zero usage",
+			disruption:  DiskFillDisruption{Path: dir},
+			expectError: true,
+		},
+		{
+			title:       "usage above 100",
+			disruption:  DiskFillDisruption{Path: dir, Usage: 101},
+			expectError: true,
+		},
+		{
+			title:       "path does not exist",
+			disruption:  DiskFillDisruption{Path: filepath.Join(dir, "missing"), Usage: 90},
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := NewDiskFillStressor(tc.disruption)
+			if tc.expectError && err == nil {
+				t.Fatalf("should had failed")
+			}
+
+			if !tc.expectError && err != nil {
+				t.Fatalf("failed: %v", err)
+			}
+		})
+	}
+}
+
+func Test_DiskFillStressorBallast(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	// leftover from a previous execution, overwritten when filling
+	err := os.WriteFile(filepath.Join(dir, ballastFilePrefix+"0"), []byte("ballast"), 0o600)
+	if err != nil {
+		t.Fatalf("creating file: %v", err)
+	}
+
+	// file not created by the stressor
+	err = os.WriteFile(filepath.Join(dir, "data"), []byte("data"), 0o600)
+	if err != nil {
+		t.Fatalf("creating file: %v", err)
+	}
+
+	s, err := NewDiskFillStressor(DiskFillDisruption{Path: dir, Usage: 90})
+	if err != nil {
+		t.Fatalf("failed: %v", err)
+	}
+
+	size := uint64(3*DefaultDiskBlockSize + 10)
+	err = s.fill(context.Background(), size)
+	if err != nil {
+		t.Fatalf("failed: %v", err)
+	}
+
+	info, err := os.Stat(filepath.Join(dir, ballastFilePrefix+"0"))
+	if err != nil {
+		t.Fatalf("ballast file not created: %v", err)
+	}
+
+	if uint64(info.Size()) != size {
+		t.Fatalf("expected ballast of %d bytes got %d", size, info.Size())
+	}
+
+	err = s.removeBallast()
+	if err != nil {
+		t.Fatalf("failed: %v", err)
+	}
+
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("reading dir: %v", err)
+	}
+
+	if len(files) != 1 || files[0].Name() != "data" {
+		t.Fatalf("expected only ballast files to be removed, found %v", files)
+	}
+}
+
+func Test_RemoveBallast(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	nested := filepath.Join(dir, "volume", "data")
+	if err := os.MkdirAll(nested, 0o700); err != nil {
+		t.Fatalf("creating dir: %v", err)
+	}
+
+	files := map[string]bool{
+		filepath.Join(dir, ballastFilePrefix+"0"):    false,
+		filepath.Join(nested, ballastFilePrefix+"0"): false,
+		filepath.Join(nested, ballastFilePrefix+"1"): false,
+		filepath.Join(nested, "data"):                true,
+	}
+	for file := range files {
+		if err := os.WriteFile(file, []byte("ballast"), 0o600); err != nil {
+			t.Fatalf("creating file: %v", err)
+		}
+	}
+
+	if err := RemoveBallast(dir); err != nil {
+		t.Fatalf("failed: %v", err)
+	}
+
+	for file, kept := range files {
+		_, err := os.Stat(file)
+		if kept && err != nil {
+			t.Fatalf("expected %s to be kept: %v", file, err)
+		}
+
+		if !kept && err == nil {
+			t.Fatalf("expected %s to be removed", file)
+		}
+	}
+
+	// a path that does not exist is ignored
+	if err := RemoveBallast(filepath.Join(dir, "missing")); err != nil {
+		t.Fatalf("failed: %v", err)
+	}
+}
+
+func Test_DiskFillStressorBallastSize(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	err := os.WriteFile(filepath.Join(dir, "data"), make([]byte, 1000), 0o600)
+	if err != nil {
+		t.Fatalf("creating file: %v", err)
+	}
+
+	testCases := []struct {
+		title     string
+		usage     int
+		sizeLimit uint64
+		expected  uint64
+	}{
+		{
+			title:     "below size limit",
+			usage:     50,
+			sizeLimit: 10000,
+			expected:  4000,
+		},
+		{
+			title:     "size limit already reached",
+			usage:     5,
+			sizeLimit: 10000,
+			expected:  0,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			t.Parallel()
+
+			s, err := NewDiskFillStressor(DiskFillDisruption{Path: dir, Usage: tc.usage, SizeLimit: tc.sizeLimit})
+			if err != nil {
+				t.Fatalf("failed: %v", err)
+			}
+
+			size, err := s.ballastSize()
+			if err != nil {
+				t.Fatalf("failed: %v", err)
+			}
+
+			if size != tc.expected {
+				t.Fatalf("expected ballast of %d bytes got %d", tc.expected, size)
+			}
+		})
+	}
+}
@@ -0,0 +1,348 @@
+package stressors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// memoryRampInterval is the interval between allocations when ramping up the memory
+const memoryRampInterval = 100 * time.Millisecond
+
+// pageSize is the size of the memory pages touched to ensure allocated memory is resident
+const pageSize = 4096
+
+// unlimitedMemory is the value reported by cgroups v1 when no memory limit is set.
+// The actual value is the max int64 rounded down to the page size, so any value above this threshold is considered
+// unlimited.
+const unlimitedMemory = math.MaxInt64 / 2
+
+// procPath is the mount point of the proc filesystem
+const procPath = "/proc"
+
+// cgroupPath is the mount point of the cgroup filesystem
+const cgroupPath = "/sys/fs/cgroup"
+
+// ErrNoMemoryLimit is returned when a memory stress is specified as a percentage but the container has no memory limit
+var ErrNoMemoryLimit = errors.New("container has no memory limit")
+
+// ErrNoTargetProcess is returned when no process of the target container is visible from the agent
+var ErrNoTargetProcess = errors.New("target container process not found")
+
+// MemoryDisruption defines a disruption that stress the Memory.
+// If a target container is given, the stressor joins the cgroup of the target container before allocating the memory,
+// so the memory is accounted to it: the target container can be OOM-killed when the allocated memory exceeds its
+// limit. Otherwise, the memory is accounted to the cgroup of the agent's container.
+type MemoryDisruption struct {
+	// Bytes is the amount of memory to allocate
+	Bytes uint64
+	// Percentage is the amount of memory to allocate as a percentage of the container's memory limit.
+	// Ignored if Bytes is set.
+	Percentage int
+	// RampUp is the time for reaching the target memory. If zero, all memory is allocated at once.
+	RampUp time.Duration
+	// Container is the ID of the target container
+	Container string
+}
+
+// ParseMemory parses an amount of memory expressed either as a quantity (e.g. "256Mi") or as a percentage of the
+// container's memory limit (e.g. "50%")
+func ParseMemory(value string) (MemoryDisruption, error) {
+	if value == "" {
+		return MemoryDisruption{}, nil
+	}
+
+	if percentage, found := strings.CutSuffix(value, "%"); found {
+		p, err := strconv.Atoi(percentage)
+		if err != nil || p < 1 || p > 100 {
+			return MemoryDisruption{}, fmt.Errorf("memory percentage must be in the range [1, 100]: %q", value)
+		}
+
+		return MemoryDisruption{Percentage: p}, nil
+	}
+
+	bytes, err := ParseBytes(value)
+	if err != nil {
+		return MemoryDisruption{}, fmt.Errorf("invalid memory: %w", err)
+	}
+
+	return MemoryDisruption{Bytes: bytes}, nil
+}
+
+// ParseBytes parses an amount of bytes expressed as a quantity (e.g. "1024", "256Mi" or "1G")
+func ParseBytes(value string) (uint64, error) {
+	quantity, err := resource.ParseQuantity(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid quantity %q: %w", value, err)
+	}
+
+	bytes, ok := quantity.AsInt64()
+	if !ok || bytes < 0 {
+		return 0, fmt.Errorf("invalid quantity %q", value)
+	}
+
+	return uint64(bytes), nil
+}
+
+// TargetCgroup is the memory cgroup of the target container
+type TargetCgroup struct {
+	// limitFiles are the files that can expose the memory limit of the cgroup
+	limitFiles []string
+}
+
+// FindTargetCgroup returns the memory cgroup of the container with the given ID. The cgroup is resolved from the
+// processes of the container, which are visible from the agent because it shares the process namespace of its target
+// container.
+func FindTargetCgroup(container string) (TargetCgroup, error) {
+	pid, err := containerProcess(procPath, container)
+	if err != nil {
+		return TargetCgroup{}, err
+	}
+
+	files, err := cgroupMemoryLimitFiles(procPath, cgroupPath, pid)
+	if err != nil {
+		return TargetCgroup{}, err
+	}
+
+	return TargetCgroup{limitFiles: files}, nil
+}
+
+// MemoryLimit returns the memory limit of the cgroup
+func (c TargetCgroup) MemoryLimit() (uint64, error) {
+	return memoryLimit(c.limitFiles)
+}
+
+// Join moves the current process to the cgroup, so the resources it uses afterwards are accounted to the target
+// container
+func (c TargetCgroup) Join() error {
+	return joinCgroup(c.limitFiles, os.Getpid())
+}
+
+// containerProcess returns the process with the lowest pid in the cgroup of the container with the given ID. The
+// container runtimes name the cgroup of a container after its ID.
+func containerProcess(proc string, container string) (int, error) {
+	if container == "" {
+		return 0, fmt.Errorf("target container must be specified")
+	}
+
+	entries, err := os.ReadDir(proc)
+	if err != nil {
+		return 0, fmt.Errorf("listing processes: %w", err)
+	}
+
+	pids := []int{}
+	for _, entry := range entries {
+		if pid, err := strconv.Atoi(entry.Name()); err == nil && entry.IsDir() {
+			pids = append(pids, pid)
+		}
+	}
+	slices.Sort(pids)
+
+	for _, pid := range pids {
+		// the process may have ended after listing it
+		cgroup, err := os.ReadFile(filepath.Join(proc, strconv.Itoa(pid), "cgroup")) //nolint:gosec
+		if err != nil || !strings.Contains(string(cgroup), container) {
+			continue
+		}
+
+		return pid, nil
+	}
+
+	return 0, ErrNoTargetProcess
+}
+
+// cgroupMemoryLimitFiles returns the files that can expose the memory limit of the cgroup of a process, for cgroups v2
+// and v1. The cgroup is resolved from /proc/<pid>/cgroup under the cgroup filesystem of the agent. If the cgroup is
+// outside the cgroup namespace of the agent, the cgroup filesystem seen by the process is used instead.
+func cgroupMemoryLimitFiles(proc string, cgroupRoot string, pid int) ([]string, error) {
+	dir := filepath.Join(proc, strconv.Itoa(pid))
+
+	content, err := os.ReadFile(filepath.Join(dir, "cgroup")) //nolint:gosec
+	if err != nil {
+		return nil, fmt.Errorf("reading cgroup of process %d: %w", pid, err)
+	}
+
+	files := []string{}
+	for _, line := range strings.Split(string(content), "\n") {
+		// each line has the format hierarchy-ID:controller-list:cgroup-path
+		fields := strings.SplitN(line, ":", 3)
+		if len(fields) != 3 || strings.Contains(fields[2], "..") {
+			continue
+		}
+
+		switch {
+		case fields[0] == "0" && fields[1] == "":
+			files = append(files, filepath.Join(cgroupRoot, fields[2], "memory.max"))
+		case slices.Contains(strings.Split(fields[1], ","), "memory"):
+			files = append(files, filepath.Join(cgroupRoot, "memory", fields[2], "memory.limit_in_bytes"))
+		}
+	}
+
+	processRoot := filepath.Join(dir, "root", cgroupPath)
+
+	return append(
+		files,
+		filepath.Join(processRoot, "memory.max"),
+		filepath.Join(processRoot, "memory", "memory.limit_in_bytes"),
+	), nil
+}
+
+// memoryLimit returns the memory limit from the first of the given files that exists
+func memoryLimit(files []string) (uint64, error) {
+	for _, file := range files {
+		content, err := os.ReadFile(file) //nolint:gosec
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return 0, fmt.Errorf("reading memory limit: %w", err)
+		}
+
+		value := strings.TrimSpace(string(content))
+		if value == "max" {
+			return 0, ErrNoMemoryLimit
+		}
+
+		limit, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("parsing memory limit %q: %w", value, err)
+		}
+
+		if limit >= unlimitedMemory {
+			return 0, ErrNoMemoryLimit
+		}
+
+		return limit, nil
+	}
+
+	return 0, ErrNoMemoryLimit
+}
+
+// joinCgroup moves a process to the cgroup of the first of the given memory limit files that exists, which is the
+// cgroup the memory limit is read from
+func joinCgroup(files []string, pid int) error {
+	for _, file := range files {
+		_, err := os.Stat(file)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("reading memory cgroup: %w", err)
+		}
+
+		// the cgroup.procs file always exists in a cgroup, so it is not created if missing
+		procs, err := os.OpenFile(filepath.Join(filepath.Dir(file), "cgroup.procs"), os.O_WRONLY, 0) //nolint:gosec
+		if err != nil {
+			return fmt.Errorf("joining the cgroup of the target container: %w", err)
+		}
+		_, err = procs.WriteString(strconv.Itoa(pid))
+		_ = procs.Close()
+		if err != nil {
+			return fmt.Errorf("joining the cgroup of the target container: %w", err)
+		}
+
+		return nil
+	}
+
+	return fmt.Errorf("memory cgroup of the target container not found")
+}
+
+// MemoryStressor defines a stressor for Memory
+type MemoryStressor struct {
+	// Target is the amount of memory to allocate
+	Target uint64
+	// RampUp is the time for reaching the target memory
+	RampUp time.Duration
+}
+
+// NewMemoryStressor creates a MemoryStressor for the given disruption. The limit is used for calculating the target
+// memory when the disruption is expressed as a percentage.
+func NewMemoryStressor(disruption MemoryDisruption, limit uint64) (*MemoryStressor, error) {
+	if disruption.RampUp < 0 {
+		return nil, fmt.Errorf("memory ramp up must be positive")
+	}
+
+	target := disruption.Bytes
+	if target == 0 && disruption.Percentage > 0 {
+		if limit == 0 {
+			return nil, ErrNoMemoryLimit
+		}
+		target = limit / 100 * uint64(disruption.Percentage)
+	}
+
+	if target == 0 {
+		return nil, fmt.Errorf("memory to allocate must be specified")
+	}
+
+	return &MemoryStressor{
+		Target: target,
+		RampUp: disruption.RampUp,
+	}, nil
+}
+
+// Apply allocates the target memory, ramping it up if requested, and holds it until the context is done.
+// When done, the allocated memory is released back to the OS.
+func (s *MemoryStressor) Apply(ctx context.Context) error {
+	// hold the references to the allocated memory to prevent it from being garbage collected
+	allocated := [][]byte{}
+
+	defer func() {
+		allocated = nil
+		debug.FreeOSMemory()
+	}()
+
+	steps := uint64(1)
+	if s.RampUp > 0 {
+		steps = max(uint64(s.RampUp/memoryRampInterval), 1)
+	}
+
+	ticker := time.NewTicker(memoryRampInterval)
+	defer ticker.Stop()
+
+	var total uint64
+	for step := uint64(1); step <= steps; step++ {
+		// calculate the chunk as the difference with the expected total for this step to avoid accumulating rounding
+		// errors
+		chunk := s.Target/steps*step - total
+		if step == steps {
+			chunk = s.Target - total
+		}
+
+		allocated = append(allocated, allocate(chunk))
+		total += chunk
+
+		if step == steps {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+
+	<-ctx.Done()
+
+	return nil
+}
+
+// allocate allocates the given amount of memory and touches each page to ensure it is resident
+func allocate(size uint64) []byte {
+	buffer := make([]byte, size)
+	for i := 0; i < len(buffer); i += pageSize {
+		buffer[i] = 1
+	}
+
+	return buffer
+}
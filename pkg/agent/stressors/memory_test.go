@@ -0,0 +1,422 @@
+package stressors
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func Test_ParseMemory(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		title       string
+		value       string
+		expected    MemoryDisruption
+		expectError bool
+	}{
+		{
+			title:    "empty",
+			value:    "",
+			expected: MemoryDisruption{},
+		},
+		{
+			title:    "bytes",
+			value:    "1024",
+			expected: MemoryDisruption{Bytes: 1024},
+		},
+		{
+			title:    "binary quantity",
+			value:    "256Mi",
+			expected: MemoryDisruption{Bytes: 256 * 1024 * 1024},
+		},
+		{
+			title:    "decimal quantity",
+			value:    "1G",
+			expected: MemoryDisruption{Bytes: 1000 * 1000 * 1000},
+		},
+		{
+			title:    "percentage",
+			value:    "50%",
+			expected: MemoryDisruption{Percentage: 50},
+		},
+		{
+			title:       "percentage above 100",
+			value:       "150%",
+			expectError: true,
+		},
+		{
+			title:       "invalid percentage",
+			value:       "half%",
+			expectError: true,
+		},
+		{
+			title:       "invalid quantity",
+			value:       "lots",
+			expectError: true,
+		},
+		{
+			title:       "negative quantity",
+			value:       "-1Mi",
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			t.Parallel()
+
+			memory, err := ParseMemory(tc.value)
+			if tc.expectError && err == nil {
+				t.Fatalf("should had failed")
+			}
+
+			if !tc.expectError && err != nil {
+				t.Fatalf("failed: %v", err)
+			}
+
+			if memory != tc.expected {
+				t.Fatalf("expected %v got %v", tc.expected, memory)
+			}
+		})
+	}
+}
+
+func Test_MemoryLimit(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		title       string
+		content     string
+		expected    uint64
+		expectError error
+	}{
+		{
+			title:    "cgroup limit",
+			content:  "1073741824\n",
+			expected: 1073741824,
+		},
+		{
+			title:       "cgroup v2 without limit",
+			content:     "max\n",
+			expectError: ErrNoMemoryLimit,
+		},
+		{
+			title:       "cgroup v1 without limit",
+			content:     "9223372036854771712\n",
+			expectError: ErrNoMemoryLimit,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			t.Parallel()
+
+			file := filepath.Join(t.TempDir(), "memory.max")
+			err := os.WriteFile(file, []byte(tc.content), 0o600)
+			if err != nil {
+				t.Fatalf("creating limit file: %v", err)
+			}
+
+			// the first file does not exist to check files are tried in order
+			limit, err := memoryLimit([]string{filepath.Join(t.TempDir(), "missing"), file})
+			if !errors.Is(err, tc.expectError) {
+				t.Fatalf("expected error %v got %v", tc.expectError, err)
+			}
+
+			if limit != tc.expected {
+				t.Fatalf("expected %d got %d", tc.expected, limit)
+			}
+		})
+	}
+}
+
+func Test_NewMemoryStressor(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		title       string
+		disruption  MemoryDisruption
+		limit       uint64
+		expected    uint64
+		expectError bool
+	}{
+		{
+			title:      "bytes",
+			disruption: MemoryDisruption{Bytes: 1024},
+			expected:   1024,
+		},
+		{
+			title:      "bytes take precedence over percentage",
+			disruption: MemoryDisruption{Bytes: 1024, Percentage: 50},
+			limit:      4096,
+			expected:   1024,
+		},
+		{
+			title:      "percentage",
+			disruption: MemoryDisruption{Percentage: 50},
+			limit:      1000,
+			expected:   500,
+		},
+		{
+			title:       "percentage without limit",
+			disruption:  MemoryDisruption{Percentage: 50},
+			expectError: true,
+		},
+		{
+			title:       "no memory",
+			disruption:  MemoryDisruption{},
+			expectError: true,
+		},
+		{
+			title:       "negative ramp up",
+			disruption:  MemoryDisruption{Bytes: 1024, RampUp: -1},
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			t.Parallel()
+
+			s, err := NewMemoryStressor(tc.disruption, tc.limit)
+			if tc.expectError && err == nil {
+				t.Fatalf("should had failed")
+			}
+
+			if !tc.expectError && err != nil {
+				t.Fatalf("failed: %v", err)
+			}
+
+			if tc.expectError {
+				return
+			}
+
+			if s.Target != tc.expected {
+				t.Fatalf("expected target %d got %d", tc.expected, s.Target)
+			}
+		})
+	}
+}
+
+func Test_MemoryStressorApply(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		title    string
+		rampUp   time.Duration
+		duration time.Duration
+	}{
+		{
+			title:    "allocate at once",
+			rampUp:   0,
+			duration: 200 * time.Millisecond,
+		},
+		{
+			title:    "ramp up",
+			rampUp:   300 * time.Millisecond,
+			duration: 500 * time.Millisecond,
+		},
+		{
+			title:    "duration shorter than ramp up",
+			rampUp:   time.Second,
+			duration: 200 * time.Millisecond,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			t.Parallel()
+
+			s, err := NewMemoryStressor(MemoryDisruption{Bytes: 1024 * 1024, RampUp: tc.rampUp}, 0)
+			if err != nil {
+				t.Fatalf("failed: %v", err)
+			}
+
+			ctx, cancel := context.WithTimeout(t.Context(), tc.duration)
+			defer cancel()
+
+			start := time.Now()
+			err = s.Apply(ctx)
+			if err != nil {
+				t.Fatalf("failed: %v", err)
+			}
+
+			if elapsed := time.Since(start); elapsed < tc.duration {
+				t.Fatalf("stressor returned after %s, before the duration %s", elapsed, tc.duration)
+			}
+		})
+	}
+}
+
+// writeProcess writes the cgroup file of a process in a fake proc filesystem
+func writeProcess(t *testing.T, dir string, cgroup string) {
+	t.Helper()
+
+	err := os.MkdirAll(dir, 0o750)
+	if err != nil {
+		t.Fatalf("creating process directory: %v", err)
+	}
+
+	err = os.WriteFile(filepath.Join(dir, "cgroup"), []byte(cgroup), 0o600)
+	if err != nil {
+		t.Fatalf("writing cgroup: %v", err)
+	}
+}
+
+func Test_ContainerProcess(t *testing.T) {
+	t.Parallel()
+
+	type process struct {
+		pid    string
+		cgroup string
+	}
+
+	testCases := []struct {
+		title       string
+		container   string
+		processes   []process
+		expected    int
+		expectError error
+	}{
+		{
+			title:     "container process",
+			container: "a1b2c3",
+			processes: []process{
+				{pid: "1", cgroup: "0::/kubepods/pod1/f0e1d2\n"},
+				{pid: "7", cgroup: "0::/kubepods/pod1/a1b2c3\n"},
+				{pid: "12", cgroup: "0::/kubepods/pod1/d4e5f6\n"},
+				{pid: "20", cgroup: "0::/kubepods/pod1/a1b2c3\n"},
+			},
+			expected: 7,
+		},
+		{
+			title:     "container outside the namespace of the agent",
+			container: "a1b2c3",
+			processes: []process{
+				{pid: "1", cgroup: "0::/../cri-containerd-a1b2c3.scope\n"},
+				{pid: "12", cgroup: "0::/\n"},
+			},
+			expected: 1,
+		},
+		{
+			title:     "container processes not visible",
+			container: "a1b2c3",
+			processes: []process{
+				{pid: "1", cgroup: "0::/kubepods/pod1/d4e5f6\n"},
+			},
+			expectError: ErrNoTargetProcess,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			t.Parallel()
+
+			proc := t.TempDir()
+			for _, p := range tc.processes {
+				writeProcess(t, filepath.Join(proc, p.pid), p.cgroup)
+			}
+
+			pid, err := containerProcess(proc, tc.container)
+			if !errors.Is(err, tc.expectError) {
+				t.Fatalf("expected error %v got %v", tc.expectError, err)
+			}
+
+			if pid != tc.expected {
+				t.Fatalf("expected pid %d got %d", tc.expected, pid)
+			}
+		})
+	}
+}
+
+func Test_JoinCgroup(t *testing.T) {
+	t.Parallel()
+
+	cgroup := t.TempDir()
+	for _, file := range []string{"memory.max", "cgroup.procs"} {
+		err := os.WriteFile(filepath.Join(cgroup, file), nil, 0o600)
+		if err != nil {
+			t.Fatalf("creating %s: %v", file, err)
+		}
+	}
+
+	// the first file does not exist to check the cgroup of the first existing file is joined
+	files := []string{filepath.Join(t.TempDir(), "memory.max"), filepath.Join(cgroup, "memory.max")}
+	err := joinCgroup(files, 7)
+	if err != nil {
+		t.Fatalf("failed: %v", err)
+	}
+
+	procs, err := os.ReadFile(filepath.Join(cgroup, "cgroup.procs")) //nolint:gosec
+	if err != nil {
+		t.Fatalf("reading cgroup.procs: %v", err)
+	}
+
+	if string(procs) != "7" {
+		t.Fatalf("expected pid 7 in cgroup.procs got %q", procs)
+	}
+
+	err = joinCgroup(files[:1], 7)
+	if err == nil {
+		t.Fatalf("should had failed joining a missing cgroup")
+	}
+}
+
+func Test_CgroupMemoryLimitFiles(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		title    string
+		cgroup   string
+		expected []string
+	}{
+		{
+			title:  "cgroup v2",
+			cgroup: "0::/kubepods/pod1/app\n",
+			expected: []string{
+				"/sys/fs/cgroup/kubepods/pod1/app/memory.max",
+			},
+		},
+		{
+			title:  "cgroup v1",
+			cgroup: "12:cpu,cpuacct:/kubepods/pod1/app\n4:memory:/kubepods/pod1/app\n",
+			expected: []string{
+				"/sys/fs/cgroup/memory/kubepods/pod1/app/memory.limit_in_bytes",
+			},
+		},
+		{
+			title:    "cgroup outside the namespace of the agent",
+			cgroup:   "0::/../pod1/app\n",
+			expected: []string{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			t.Parallel()
+
+			proc := t.TempDir()
+			writeProcess(t, filepath.Join(proc, "7"), tc.cgroup)
+
+			files, err := cgroupMemoryLimitFiles(proc, "/sys/fs/cgroup", 7)
+			if err != nil {
+				t.Fatalf("failed: %v", err)
+			}
+
+			// the cgroup filesystem seen by the process is always used as a fallback
+			expected := append(
+				tc.expected,
+				filepath.Join(proc, "7", "root", "sys", "fs", "cgroup", "memory.max"),
+				filepath.Join(proc, "7", "root", "sys", "fs", "cgroup", "memory", "memory.limit_in_bytes"),
+			)
+			if !slices.Equal(files, expected) {
+				t.Fatalf("expected %v got %v", expected, files)
+			}
+		})
+	}
+}
@@ -79,6 +79,7 @@ func (s *CPUStressor) Apply(ctx context.Context) error {
 // ResourceDisruption defines a disruption that stress the CPU and Memory of a target
 type ResourceDisruption struct {
 	CPUDisruption
+	MemoryDisruption
 }
 
 // ResourceStressOptions defines options that control the resource stressing
@@ -93,6 +94,10 @@ type ResourceStressOptions struct {
 type ResourceStressor struct {
 	Options    ResourceStressOptions
 	Disruption ResourceDisruption
+	// memory stressor. nil if no memory stress is requested
+	memory *MemoryStressor
+	// cgroup of the target container of the memory stress. nil if no target container is given
+	cgroup *TargetCgroup
 }
 
 // NewResourceStressor creates a new ResourceStressor using the given options
@@ -101,22 +106,81 @@ func NewResourceStressor(disruption ResourceDisruption, options ResourceStressOp
 		options.Slice = DefaultSlice
 	}
 
+	if options.Slice < 0 {
+		return nil, fmt.Errorf("CPU stress slice must be positive")
+	}
+
+	if disruption.CPUs < 0 {
+		return nil, fmt.Errorf("number of CPUs to stress must be positive")
+	}
+
+	if disruption.CPUs > 0 && (disruption.Load < 1 || disruption.Load > 100) {
+		return nil, fmt.Errorf("CPU load must be in the range [1, 100]")
+	}
+
+	var memory *MemoryStressor
+	var cgroup *TargetCgroup
+	if disruption.MemoryDisruption != (MemoryDisruption{}) {
+		// the memory limit is only needed when the memory is specified as a percentage
+		percentage := disruption.Bytes == 0 && disruption.Percentage > 0
+		if percentage && disruption.Container == "" {
+			return nil, fmt.Errorf("memory as a percentage of the limit requires a target container")
+		}
+
+		var limit uint64
+		if disruption.Container != "" {
+			c, err := FindTargetCgroup(disruption.Container)
+			if err != nil {
+				return nil, err
+			}
+			cgroup = &c
+
+			if percentage {
+				limit, err = c.MemoryLimit()
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		m, err := NewMemoryStressor(disruption.MemoryDisruption, limit)
+		if err != nil {
+			return nil, err
+		}
+		memory = m
+	}
+
+	if disruption.CPUs == 0 && memory == nil {
+		return nil, fmt.Errorf("at least one CPU or an amount of memory must be stressed")
+	}
+
 	return &ResourceStressor{
 		Options:    options,
 		Disruption: disruption,
+		memory:     memory,
+		cgroup:     cgroup,
 	}, nil
 }
 
 // Apply applies the resource stress disruption for a given duration
 func (r *ResourceStressor) Apply(ctx context.Context, duration time.Duration) error {
-	if r.Disruption.CPUs == 0 {
-		return fmt.Errorf("at least one CPU must be stressed")
+	// the memory allocated by the stressors is accounted to the target container only if they run in its cgroup.
+	// The whole process joins the cgroup, so the CPU consumed by the stressors is accounted to it too.
+	if r.cgroup != nil {
+		if err := r.cgroup.Join(); err != nil {
+			return err
+		}
 	}
 
 	stressorsCtx, done := context.WithTimeout(ctx, duration)
 	defer done()
 
-	doneCh := make(chan error, r.Disruption.CPUs)
+	pending := r.Disruption.CPUs
+	if r.memory != nil {
+		pending++
+	}
+
+	doneCh := make(chan error, pending)
 	// create a CPUStressor for each CPU
 	for range r.Disruption.CPUs {
 		go func() {
@@ -128,8 +192,13 @@ func (r *ResourceStressor) Apply(ctx context.Context, duration time.Duration) er
 		}()
 	}
 
+	if r.memory != nil {
+		go func() {
+			doneCh <- r.memory.Apply(stressorsCtx)
+		}()
+	}
+
 	// wait for all stressors to finish or context to be done
-	pending := r.Disruption.CPUs
 	for pending > 0 {
 		select {
 		case <-ctx.Done():
@@ -0,0 +1,95 @@
+package stressors
+
+import (
+	"testing"
+	"time"
+)
+
+func Test_NewResourceStressor(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		title         string
+		disruption    ResourceDisruption
+		options       ResourceStressOptions
+		expectError   bool
+		expectedSlice time.Duration
+	}{
+		{
+			title: "default slice",
+			disruption: ResourceDisruption{
+				CPUDisruption: CPUDisruption{Load: 50, CPUs: 1},
+			},
+			options:       ResourceStressOptions{},
+			expectError:   false,
+			expectedSlice: DefaultSlice,
+		},
+		{
+			title: "custom slice",
+			disruption: ResourceDisruption{
+				CPUDisruption: CPUDisruption{Load: 50, CPUs: 1},
+			},
+			options:       ResourceStressOptions{Slice: 10 * time.Millisecond},
+			expectError:   false,
+			expectedSlice: 10 * time.Millisecond,
+		},
+		{
+			title: "negative slice",
+			disruption: ResourceDisruption{
+				CPUDisruption: CPUDisruption{Load: 50, CPUs: 1},
+			},
+			options:     ResourceStressOptions{Slice: -1},
+			expectError: true,
+		},
+		{
+			title: "zero load",
+			disruption: ResourceDisruption{
+				CPUDisruption: CPUDisruption{Load: 0, CPUs: 1},
+			},
+			expectError: true,
+		},
+		{
+			title: "memory only",
+			disruption: ResourceDisruption{
+				MemoryDisruption: MemoryDisruption{Bytes: 1024},
+			},
+			expectError:   false,
+			expectedSlice: DefaultSlice,
+		},
+		{
+			title:       "nothing to stress",
+			disruption:  ResourceDisruption{},
+			expectError: true,
+		},
+		{
+			title: "load above 100",
+			disruption: ResourceDisruption{
+				CPUDisruption: CPUDisruption{Load: 101, CPUs: 1},
+			},
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			t.Parallel()
+
+			s, err := NewResourceStressor(tc.disruption, tc.options)
+			if tc.expectError && err == nil {
+				t.Fatalf("should had failed")
+			}
+
+			if !tc.expectError && err != nil {
+				t.Fatalf("failed: %v", err)
+			}
+
+			if tc.expectError {
+				return
+			}
+
+			if s.Options.Slice != tc.expectedSlice {
+				t.Fatalf("expected slice %s got %s", tc.expectedSlice, s.Options.Slice)
+			}
+		})
+	}
+}
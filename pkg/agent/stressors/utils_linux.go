@@ -14,3 +14,15 @@ func CPUTime() time.Duration {
 	_ = syscall.Getrusage(syscall.RUSAGE_THREAD, usage)
 	return time.Duration(usage.Utime.Nano()+usage.Stime.Nano()) * time.Nanosecond
 }
+
+// DiskUsage returns the total and available bytes of the filesystem that contains the given path
+func DiskUsage(path string) (total uint64, available uint64, err error) {
+	stat := syscall.Statfs_t{}
+	err = syscall.Statfs(path, &stat)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	//nolint:gosec // block size is always positive
+	return stat.Blocks * uint64(stat.Bsize), stat.Bavail * uint64(stat.Bsize), nil
+}
@@ -4,6 +4,7 @@
 package stressors
 
 import (
+	"errors"
 	"time"
 )
 
@@ -13,3 +14,8 @@ import (
 func CPUTime() time.Duration {
 	panic("unsupported platform")
 }
+
+// DiskUsage is only supported in linux
+func DiskUsage(_ string) (uint64, uint64, error) {
+	return 0, 0, errors.New("unsupported platform")
+}
@@ -40,11 +40,8 @@ func (d Disruptor) Apply(ctx context.Context, duration time.Duration) error {
 	defer ruleset.Remove()
 
 	config := randomNFQConfig()
-	for _, r := range d.rules(config) {
-		err := ruleset.Add(r)
-		if err != nil {
-			return err
-		}
+	if err := ruleset.Add(d.rules(config)...); err != nil {
+		return err
 	}
 
 	queue, err := nfqueue.Open(&nfqueue.Config{
@@ -62,10 +62,34 @@ type jsProtocolFaultInjector struct {
 	ctx context.Context // this context controls the object's lifecycle
 	rt  *sobek.Runtime
 	disruptors.ProtocolFaultInjector
+	// handles of the faults started in the background
+	handles *faultHandles
 }
 
 // injectHTTPFaults is a proxy method. Validates parameters and delegates to the Protocol Disruptor method
 func (p *jsProtocolFaultInjector) InjectHTTPFaults(args ...sobek.Value) {
+	fault, duration, opts := p.httpFaultArgs(args)
+
+	err := p.ProtocolFaultInjector.InjectHTTPFaults(p.ctx, fault, duration, opts)
+	if err != nil {
+		common.Throw(p.rt, fmt.Errorf("error injecting fault: %w", err))
+	}
+}
+
+// StartHTTPFaults is a proxy method. Validates parameters and starts the Protocol Disruptor method in the background,
+// returning a handle for controlling it
+func (p *jsProtocolFaultInjector) StartHTTPFaults(args ...sobek.Value) *sobek.Object {
+	fault, duration, opts := p.httpFaultArgs(args)
+
+	return startFault(p.ctx, p.rt, p.handles, func(ctx context.Context) error {
+		return p.ProtocolFaultInjector.InjectHTTPFaults(ctx, fault, duration, opts)
+	})
+}
+
+// httpFaultArgs converts the arguments of the methods that inject HTTP faults
+func (p *jsProtocolFaultInjector) httpFaultArgs(
+	args []sobek.Value,
+) (disruptors.HTTPFault, time.Duration, disruptors.HTTPDisruptionOptions) {
 	if len(args) < 2 {
 		common.Throw(p.rt, fmt.Errorf("HTTPFault and duration are required"))
 	}
@@ -90,14 +114,33 @@ func (p *jsProtocolFaultInjector) InjectHTTPFaults(args ...sobek.Value) {
 		}
 	}
 
-	err = p.ProtocolFaultInjector.InjectHTTPFaults(p.ctx, fault, duration, opts)
+	return fault, duration, opts
+}
+
+// InjectGrpcFaults is a proxy method. Validates parameters and delegates to the PodDisruptor method
+func (p *jsProtocolFaultInjector) InjectGrpcFaults(args ...sobek.Value) {
+	fault, duration, opts := p.grpcFaultArgs(args)
+
+	err := p.ProtocolFaultInjector.InjectGrpcFaults(p.ctx, fault, duration, opts)
 	if err != nil {
 		common.Throw(p.rt, fmt.Errorf("error injecting fault: %w", err))
 	}
 }
 
-// InjectGrpcFaults is a proxy method. Validates parameters and delegates to the PodDisruptor method
-func (p *jsProtocolFaultInjector) InjectGrpcFaults(args ...sobek.Value) {
+// StartGrpcFaults is a proxy method. Validates parameters and starts the PodDisruptor method in the background,
+// returning a handle for controlling it
+func (p *jsProtocolFaultInjector) StartGrpcFaults(args ...sobek.Value) *sobek.Object {
+	fault, duration, opts := p.grpcFaultArgs(args)
+
+	return startFault(p.ctx, p.rt, p.handles, func(ctx context.Context) error {
+		return p.ProtocolFaultInjector.InjectGrpcFaults(ctx, fault, duration, opts)
+	})
+}
+
+// grpcFaultArgs converts the arguments of the methods that inject grpc faults
+func (p *jsProtocolFaultInjector) grpcFaultArgs(
+	args []sobek.Value,
+) (disruptors.GrpcFault, time.Duration, disruptors.GrpcDisruptionOptions) {
 	if len(args) < 2 {
 		common.Throw(p.rt, fmt.Errorf("GrpcFault and duration are required"))
 	}
@@ -122,12 +165,173 @@ func (p *jsProtocolFaultInjector) InjectGrpcFaults(args ...sobek.Value) {
 		}
 	}
 
-	err = p.ProtocolFaultInjector.InjectGrpcFaults(p.ctx, fault, duration, opts)
+	return fault, duration, opts
+}
+
+// InjectTCPFaults is a proxy method. Validates parameters and delegates to the Protocol Disruptor method
+func (p *jsProtocolFaultInjector) InjectTCPFaults(args ...sobek.Value) {
+	fault, duration, opts := p.tcpFaultArgs(args)
+
+	err := p.ProtocolFaultInjector.InjectTCPFaults(p.ctx, fault, duration, opts)
+	if err != nil {
+		common.Throw(p.rt, fmt.Errorf("error injecting fault: %w", err))
+	}
+}
+
+// tcpFaultArgs converts the arguments of the methods that inject TCP faults
+func (p *jsProtocolFaultInjector) tcpFaultArgs(
+	args []sobek.Value,
+) (disruptors.TCPFault, time.Duration, disruptors.TCPDisruptionOptions) {
+	if len(args) < 2 {
+		common.Throw(p.rt, fmt.Errorf("TCPFault and duration are required"))
+	}
+
+	fault := disruptors.TCPFault{}
+	err := convertValue(p.rt, args[0], &fault)
+	if err != nil {
+		common.Throw(p.rt, fmt.Errorf("invalid fault argument: %w", err))
+	}
+
+	var duration time.Duration
+	err = convertValue(p.rt, args[1], &duration)
+	if err != nil {
+		common.Throw(p.rt, fmt.Errorf("invalid duration argument: %w", err))
+	}
+
+	opts := disruptors.TCPDisruptionOptions{}
+	if len(args) > 2 {
+		err = convertValue(p.rt, args[2], &opts)
+		if err != nil {
+			common.Throw(p.rt, fmt.Errorf("invalid options argument: %w", err))
+		}
+	}
+
+	return fault, duration, opts
+}
+
+// InjectRedisFaults is a proxy method. Validates parameters and delegates to the Protocol Disruptor method
+func (p *jsProtocolFaultInjector) InjectRedisFaults(args ...sobek.Value) {
+	fault, duration, opts := p.redisFaultArgs(args)
+
+	err := p.ProtocolFaultInjector.InjectRedisFaults(p.ctx, fault, duration, opts)
 	if err != nil {
 		common.Throw(p.rt, fmt.Errorf("error injecting fault: %w", err))
 	}
 }
 
+// redisFaultArgs converts the arguments of the methods that inject Redis faults
+func (p *jsProtocolFaultInjector) redisFaultArgs(
+	args []sobek.Value,
+) (disruptors.RedisFault, time.Duration, disruptors.RedisDisruptionOptions) {
+	if len(args) < 2 {
+		common.Throw(p.rt, fmt.Errorf("RedisFault and duration are required"))
+	}
+
+	fault := disruptors.RedisFault{}
+	err := convertValue(p.rt, args[0], &fault)
+	if err != nil {
+		common.Throw(p.rt, fmt.Errorf("invalid fault argument: %w", err))
+	}
+
+	var duration time.Duration
+	err = convertValue(p.rt, args[1], &duration)
+	if err != nil {
+		common.Throw(p.rt, fmt.Errorf("invalid duration argument: %w", err))
+	}
+
+	opts := disruptors.RedisDisruptionOptions{}
+	if len(args) > 2 {
+		err = convertValue(p.rt, args[2], &opts)
+		if err != nil {
+			common.Throw(p.rt, fmt.Errorf("invalid options argument: %w", err))
+		}
+	}
+
+	return fault, duration, opts
+}
+
+// InjectPostgresFaults is a proxy method. Validates parameters and delegates to the Protocol Disruptor method
+func (p *jsProtocolFaultInjector) InjectPostgresFaults(args ...sobek.Value) {
+	fault, duration, opts := p.postgresFaultArgs(args)
+
+	err := p.ProtocolFaultInjector.InjectPostgresFaults(p.ctx, fault, duration, opts)
+	if err != nil {
+		common.Throw(p.rt, fmt.Errorf("error injecting fault: %w", err))
+	}
+}
+
+// postgresFaultArgs converts the arguments of the methods that inject PostgreSQL faults
+func (p *jsProtocolFaultInjector) postgresFaultArgs(
+	args []sobek.Value,
+) (disruptors.PostgresFault, time.Duration, disruptors.PostgresDisruptionOptions) {
+	if len(args) < 2 {
+		common.Throw(p.rt, fmt.Errorf("PostgresFault and duration are required"))
+	}
+
+	fault := disruptors.PostgresFault{}
+	err := convertValue(p.rt, args[0], &fault)
+	if err != nil {
+		common.Throw(p.rt, fmt.Errorf("invalid fault argument: %w", err))
+	}
+
+	var duration time.Duration
+	err = convertValue(p.rt, args[1], &duration)
+	if err != nil {
+		common.Throw(p.rt, fmt.Errorf("invalid duration argument: %w", err))
+	}
+
+	opts := disruptors.PostgresDisruptionOptions{}
+	if len(args) > 2 {
+		err = convertValue(p.rt, args[2], &opts)
+		if err != nil {
+			common.Throw(p.rt, fmt.Errorf("invalid options argument: %w", err))
+		}
+	}
+
+	return fault, duration, opts
+}
+
+// InjectKafkaFaults is a proxy method. Validates parameters and delegates to the Protocol Disruptor method
+func (p *jsProtocolFaultInjector) InjectKafkaFaults(args ...sobek.Value) {
+	fault, duration, opts := p.kafkaFaultArgs(args)
+
+	err := p.ProtocolFaultInjector.InjectKafkaFaults(p.ctx, fault, duration, opts)
+	if err != nil {
+		common.Throw(p.rt, fmt.Errorf("error injecting fault: %w", err))
+	}
+}
+
+// kafkaFaultArgs converts the arguments of the methods that inject Kafka faults
+func (p *jsProtocolFaultInjector) kafkaFaultArgs(
+	args []sobek.Value,
+) (disruptors.KafkaFault, time.Duration, disruptors.KafkaDisruptionOptions) {
+	if len(args) < 2 {
+		common.Throw(p.rt, fmt.Errorf("KafkaFault and duration are required"))
+	}
+
+	fault := disruptors.KafkaFault{}
+	err := convertValue(p.rt, args[0], &fault)
+	if err != nil {
+		common.Throw(p.rt, fmt.Errorf("invalid fault argument: %w", err))
+	}
+
+	var duration time.Duration
+	err = convertValue(p.rt, args[1], &duration)
+	if err != nil {
+		common.Throw(p.rt, fmt.Errorf("invalid duration argument: %w", err))
+	}
+
+	opts := disruptors.KafkaDisruptionOptions{}
+	if len(args) > 2 {
+		err = convertValue(p.rt, args[2], &opts)
+		if err != nil {
+			common.Throw(p.rt, fmt.Errorf("invalid options argument: %w", err))
+		}
+	}
+
+	return fault, duration, opts
+}
+
 // jsPodFaultInjector implements methods for injecting faults into Pods
 type jsPodFaultInjector struct {
 	ctx context.Context
@@ -154,11 +358,50 @@ func (p *jsPodFaultInjector) TerminatePods(args ...sobek.Value) {
 	}
 }
 
+// jsFaultCleaner implements methods for stopping the faults injected by a disruptor
+type jsFaultCleaner struct {
+	ctx context.Context
+	rt  *sobek.Runtime
+	disruptors.FaultCleaner
+	// handles of the faults started in the background by the disruptor
+	handles *faultHandles
+}
+
+// cleanupTimeout is the maximum time for cleaning up the targets of a disruptor
+const cleanupTimeout = time.Minute
+
+// Cleanup is a proxy method. Delegates to the Fault Cleaner method, which stops any fault running in the targets,
+// including the faults injected by other disruptors
+func (p *jsFaultCleaner) Cleanup() {
+	err := p.cleanup()
+	if err != nil {
+		common.Throw(p.rt, fmt.Errorf("error cleaning up targets: %w", err))
+	}
+}
+
+// StopAll stops the faults started in the background by the disruptor. Stopping a fault removes the resources used
+// for injecting it in the targets. Unlike Cleanup, the faults injected by other disruptors are not affected.
+func (p *jsFaultCleaner) StopAll() {
+	p.handles.stopAll()
+}
+
+// cleanup cleans up the targets of the disruptor. The cleanup runs even if the disruptor's context is done.
+func (p *jsFaultCleaner) cleanup() error {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(p.ctx), cleanupTimeout)
+	defer cancel()
+
+	return p.FaultCleaner.Cleanup(ctx)
+}
+
 type jsPodDisruptor struct {
 	jsDisruptor
 	jsProtocolFaultInjector
 	jsPodFaultInjector
 	jsNetworkFaultInjector
+	jsResourceFaultInjector
+	jsDiskFaultInjector
+	jsDNSFaultInjector
+	jsFaultCleaner
 }
 
 // buildJsPodDisruptor builds a goja object that implements the PodDisruptor API
@@ -166,7 +409,9 @@ func buildJsPodDisruptor(
 	ctx context.Context,
 	rt *sobek.Runtime,
 	disruptor disruptors.PodDisruptor,
+	teardown *Teardown,
 ) (*sobek.Object, error) {
+	handles := &faultHandles{}
 	d := &jsPodDisruptor{
 		jsDisruptor: jsDisruptor{
 			ctx:       ctx,
@@ -177,6 +422,7 @@ func buildJsPodDisruptor(
 			ctx:                   ctx,
 			rt:                    rt,
 			ProtocolFaultInjector: disruptor,
+			handles:               handles,
 		},
 		jsPodFaultInjector: jsPodFaultInjector{
 			ctx:              ctx,
@@ -187,9 +433,33 @@ func buildJsPodDisruptor(
 			ctx:                  ctx,
 			rt:                   rt,
 			NetworkFaultInjector: disruptor,
+			handles:              handles,
+		},
+		jsResourceFaultInjector: jsResourceFaultInjector{
+			ctx:                   ctx,
+			rt:                    rt,
+			ResourceFaultInjector: disruptor,
+		},
+		jsDiskFaultInjector: jsDiskFaultInjector{
+			ctx:               ctx,
+			rt:                rt,
+			DiskFaultInjector: disruptor,
+		},
+		jsDNSFaultInjector: jsDNSFaultInjector{
+			ctx:              ctx,
+			rt:               rt,
+			DNSFaultInjector: disruptor,
+		},
+		jsFaultCleaner: jsFaultCleaner{
+			ctx:          ctx,
+			rt:           rt,
+			FaultCleaner: disruptor,
+			handles:      handles,
 		},
 	}
 
+	teardown.add(cleanupOnTeardown(ctx, handles, disruptor))
+
 	return buildObject(rt, d)
 }
 
@@ -198,10 +468,32 @@ type jsNetworkFaultInjector struct {
 	ctx context.Context
 	rt  *sobek.Runtime
 	disruptors.NetworkFaultInjector
+	// handles of the faults started in the background
+	handles *faultHandles
 }
 
 // InjectNetworkFaults is a proxy method. Validates parameters and delegates to the Network Fault Injector method
 func (p *jsNetworkFaultInjector) InjectNetworkFaults(args ...sobek.Value) {
+	fault, duration := p.networkFaultArgs(args)
+
+	err := p.NetworkFaultInjector.InjectNetworkFaults(p.ctx, fault, duration)
+	if err != nil {
+		common.Throw(p.rt, fmt.Errorf("error injecting fault: %w", err))
+	}
+}
+
+// StartNetworkFaults is a proxy method. Validates parameters and starts the Network Fault Injector method in the
+// background, returning a handle for controlling it
+func (p *jsNetworkFaultInjector) StartNetworkFaults(args ...sobek.Value) *sobek.Object {
+	fault, duration := p.networkFaultArgs(args)
+
+	return startFault(p.ctx, p.rt, p.handles, func(ctx context.Context) error {
+		return p.NetworkFaultInjector.InjectNetworkFaults(ctx, fault, duration)
+	})
+}
+
+// networkFaultArgs converts the arguments of the methods that inject network faults
+func (p *jsNetworkFaultInjector) networkFaultArgs(args []sobek.Value) (disruptors.NetworkFault, time.Duration) {
 	if len(args) < 2 {
 		common.Throw(p.rt, fmt.Errorf("NetworkFault and duration are required"))
 	}
@@ -218,16 +510,138 @@ func (p *jsNetworkFaultInjector) InjectNetworkFaults(args ...sobek.Value) {
 		common.Throw(p.rt, fmt.Errorf("invalid duration argument: %w", err))
 	}
 
-	err = p.NetworkFaultInjector.InjectNetworkFaults(p.ctx, fault, duration)
+	return fault, duration
+}
+
+// jsResourceFaultInjector implements methods for injecting faults that stress resources
+type jsResourceFaultInjector struct {
+	ctx context.Context
+	rt  *sobek.Runtime
+	disruptors.ResourceFaultInjector
+}
+
+// InjectStressFaults is a proxy method. Validates parameters and delegates to the Resource Fault Injector method
+func (p *jsResourceFaultInjector) InjectStressFaults(args ...sobek.Value) {
+	fault, duration := p.stressFaultArgs(args)
+
+	err := p.ResourceFaultInjector.InjectStressFaults(p.ctx, fault, duration)
+	if err != nil {
+		common.Throw(p.rt, fmt.Errorf("error injecting fault: %w", err))
+	}
+}
+
+// stressFaultArgs converts the arguments of the methods that inject stress faults
+func (p *jsResourceFaultInjector) stressFaultArgs(args []sobek.Value) (disruptors.StressFault, time.Duration) {
+	if len(args) < 2 {
+		common.Throw(p.rt, fmt.Errorf("StressFault and duration are required"))
+	}
+
+	fault := disruptors.StressFault{}
+	err := convertValue(p.rt, args[0], &fault)
+	if err != nil {
+		common.Throw(p.rt, fmt.Errorf("invalid fault argument: %w", err))
+	}
+
+	var duration time.Duration
+	err = convertValue(p.rt, args[1], &duration)
+	if err != nil {
+		common.Throw(p.rt, fmt.Errorf("invalid duration argument: %w", err))
+	}
+
+	return fault, duration
+}
+
+// jsDiskFaultInjector implements methods for injecting faults that stress the disk
+type jsDiskFaultInjector struct {
+	ctx context.Context
+	rt  *sobek.Runtime
+	disruptors.DiskFaultInjector
+}
+
+// InjectDiskFaults is a proxy method. Validates parameters and delegates to the Disk Fault Injector method
+func (p *jsDiskFaultInjector) InjectDiskFaults(args ...sobek.Value) {
+	fault, duration := p.diskFaultArgs(args)
+
+	err := p.DiskFaultInjector.InjectDiskFaults(p.ctx, fault, duration)
+	if err != nil {
+		common.Throw(p.rt, fmt.Errorf("error injecting fault: %w", err))
+	}
+}
+
+// diskFaultArgs converts the arguments of the methods that inject disk faults
+func (p *jsDiskFaultInjector) diskFaultArgs(args []sobek.Value) (disruptors.DiskFault, time.Duration) {
+	if len(args) < 2 {
+		common.Throw(p.rt, fmt.Errorf("DiskFault and duration are required"))
+	}
+
+	fault := disruptors.DiskFault{}
+	err := convertValue(p.rt, args[0], &fault)
+	if err != nil {
+		common.Throw(p.rt, fmt.Errorf("invalid fault argument: %w", err))
+	}
+
+	var duration time.Duration
+	err = convertValue(p.rt, args[1], &duration)
+	if err != nil {
+		common.Throw(p.rt, fmt.Errorf("invalid duration argument: %w", err))
+	}
+
+	return fault, duration
+}
+
+// jsDNSFaultInjector implements methods for injecting faults in DNS queries
+type jsDNSFaultInjector struct {
+	ctx context.Context
+	rt  *sobek.Runtime
+	disruptors.DNSFaultInjector
+}
+
+// InjectDNSFaults is a proxy method. Validates parameters and delegates to the DNS Fault Injector method
+func (p *jsDNSFaultInjector) InjectDNSFaults(args ...sobek.Value) {
+	fault, duration, opts := p.dnsFaultArgs(args)
+
+	err := p.DNSFaultInjector.InjectDNSFaults(p.ctx, fault, duration, opts)
 	if err != nil {
 		common.Throw(p.rt, fmt.Errorf("error injecting fault: %w", err))
 	}
 }
 
+// dnsFaultArgs converts the arguments of the methods that inject DNS faults
+func (p *jsDNSFaultInjector) dnsFaultArgs(
+	args []sobek.Value,
+) (disruptors.DNSFault, time.Duration, disruptors.DNSDisruptionOptions) {
+	if len(args) < 2 {
+		common.Throw(p.rt, fmt.Errorf("DNSFault and duration are required"))
+	}
+
+	fault := disruptors.DNSFault{}
+	err := convertValue(p.rt, args[0], &fault)
+	if err != nil {
+		common.Throw(p.rt, fmt.Errorf("invalid fault argument: %w", err))
+	}
+
+	var duration time.Duration
+	err = convertValue(p.rt, args[1], &duration)
+	if err != nil {
+		common.Throw(p.rt, fmt.Errorf("invalid duration argument: %w", err))
+	}
+
+	opts := disruptors.DNSDisruptionOptions{}
+	if len(args) > 2 {
+		err = convertValue(p.rt, args[2], &opts)
+		if err != nil {
+			common.Throw(p.rt, fmt.Errorf("invalid options argument: %w", err))
+		}
+	}
+
+	return fault, duration, opts
+}
+
 type jsServiceDisruptor struct {
 	jsDisruptor
 	jsProtocolFaultInjector
 	jsPodFaultInjector
+	jsFaultCleaner
 }
 
 // buildJsServiceDisruptor builds a goja object that implements the ServiceDisruptor API
@@ -235,7 +649,9 @@ func buildJsServiceDisruptor(
 	ctx context.Context,
 	rt *sobek.Runtime,
 	disruptor disruptors.ServiceDisruptor,
+	teardown *Teardown,
 ) (*sobek.Object, error) {
+	handles := &faultHandles{}
 	d := &jsServiceDisruptor{
 		jsDisruptor: jsDisruptor{
 			ctx:       ctx,
@@ -246,24 +662,101 @@ func buildJsServiceDisruptor(
 			ctx:                   ctx,
 			rt:                    rt,
 			ProtocolFaultInjector: disruptor,
+			handles:               handles,
 		},
 		jsPodFaultInjector: jsPodFaultInjector{
 			ctx:              ctx,
 			rt:               rt,
 			PodFaultInjector: disruptor,
 		},
+		jsFaultCleaner: jsFaultCleaner{
+			ctx:          ctx,
+			rt:           rt,
+			FaultCleaner: disruptor,
+			handles:      handles,
+		},
 	}
 
+	teardown.add(cleanupOnTeardown(ctx, handles, disruptor))
+
+	return buildObject(rt, d)
+}
+
+// cleanupOnTeardown returns a teardown action that stops the faults started by a disruptor and then stops the faults
+// still running in its targets, such as those injected in the foreground
+func cleanupOnTeardown(ctx context.Context, handles *faultHandles, cleaner disruptors.FaultCleaner) func() {
+	return func() {
+		handles.stopAll()
+
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), cleanupTimeout)
+		defer cancel()
+
+		_ = cleaner.Cleanup(ctx)
+	}
+}
+
+type jsNodeDisruptor struct {
+	jsDisruptor
+	jsNetworkFaultInjector
+	jsResourceFaultInjector
+	jsFaultCleaner
+}
+
+// buildJsNodeDisruptor builds a goja object that implements the NodeDisruptor API
+func buildJsNodeDisruptor(
+	ctx context.Context,
+	rt *sobek.Runtime,
+	disruptor disruptors.NodeDisruptor,
+	teardown *Teardown,
+) (*sobek.Object, error) {
+	handles := &faultHandles{}
+	d := &jsNodeDisruptor{
+		jsDisruptor: jsDisruptor{
+			ctx:       ctx,
+			rt:        rt,
+			Disruptor: disruptor,
+		},
+		jsNetworkFaultInjector: jsNetworkFaultInjector{
+			ctx:                  ctx,
+			rt:                   rt,
+			NetworkFaultInjector: disruptor,
+			handles:              handles,
+		},
+		jsResourceFaultInjector: jsResourceFaultInjector{
+			ctx:                   ctx,
+			rt:                    rt,
+			ResourceFaultInjector: disruptor,
+		},
+		jsFaultCleaner: jsFaultCleaner{
+			ctx:          ctx,
+			rt:           rt,
+			FaultCleaner: disruptor,
+			handles:      handles,
+		},
+	}
+
+	// the agent pods created in the nodes are deleted once the faults are stopped
+	teardown.add(func() {
+		handles.stopAll()
+
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), cleanupTimeout)
+		defer cancel()
+
+		_ = disruptor.DeleteAgentPods(ctx)
+	})
+
 	return buildObject(rt, d)
 }
 
 // NewPodDisruptor creates an instance of a PodDisruptor
 // The context passed to this constructor is expected to control the lifecycle of the PodDisruptor
+// The faults running in its targets are stopped when the teardown runs
 func NewPodDisruptor(
 	ctx context.Context,
 	rt *sobek.Runtime,
 	c sobek.ConstructorCall,
 	k8s kubernetes.Kubernetes,
+	teardown *Teardown,
 ) (*sobek.Object, error) {
 	if c.Argument(0).Equals(sobek.Null()) {
 		return nil, fmt.Errorf("PodDisruptor constructor expects a non null PodSelector argument")
@@ -289,7 +782,7 @@ func NewPodDisruptor(
 		return nil, fmt.Errorf("error creating PodDisruptor: %w", err)
 	}
 
-	obj, err := buildJsPodDisruptor(ctx, rt, disruptor)
+	obj, err := buildJsPodDisruptor(ctx, rt, disruptor, teardown)
 	if err != nil {
 		return nil, fmt.Errorf("error creating PodDisruptor: %w", err)
 	}
@@ -299,11 +792,13 @@ func NewPodDisruptor(
 
 // NewServiceDisruptor creates an instance of a ServiceDisruptor and returns it as a goja object
 // The context passed to this constructor is expected to control the lifecycle of the ServiceDisruptor
+// The faults running in its targets are stopped when the teardown runs
 func NewServiceDisruptor(
 	ctx context.Context,
 	rt *sobek.Runtime,
 	c sobek.ConstructorCall,
 	k8s kubernetes.Kubernetes,
+	teardown *Teardown,
 ) (*sobek.Object, error) {
 	if len(c.Arguments) < 2 {
 		return nil, fmt.Errorf("ServiceDisruptor constructor requires service and namespace parameters")
@@ -335,10 +830,52 @@ func NewServiceDisruptor(
 		return nil, fmt.Errorf("error creating ServiceDisruptor: %w", err)
 	}
 
-	obj, err := buildJsServiceDisruptor(ctx, rt, disruptor)
+	obj, err := buildJsServiceDisruptor(ctx, rt, disruptor, teardown)
 	if err != nil {
 		return nil, fmt.Errorf("error creating ServiceDisruptor: %w", err)
 	}
 
 	return obj, nil
 }
+
+// NewNodeDisruptor creates an instance of a NodeDisruptor and returns it as a goja object
+// The context passed to this constructor is expected to control the lifecycle of the NodeDisruptor
+// The faults it starts in the background are stopped when the teardown runs
+func NewNodeDisruptor(
+	ctx context.Context,
+	rt *sobek.Runtime,
+	c sobek.ConstructorCall,
+	k8s kubernetes.Kubernetes,
+	teardown *Teardown,
+) (*sobek.Object, error) {
+	if c.Argument(0).Equals(sobek.Null()) {
+		return nil, fmt.Errorf("NodeDisruptor constructor expects a non null NodeSelector argument")
+	}
+
+	selector := disruptors.NodeSelectorSpec{}
+	err := convertValue(rt, c.Argument(0), &selector)
+	if err != nil {
+		return nil, fmt.Errorf("invalid NodeSelector: %w", err)
+	}
+
+	options := disruptors.NodeDisruptorOptions{}
+	// options argument is optional
+	if len(c.Arguments) > 1 {
+		err = convertValue(rt, c.Argument(1), &options)
+		if err != nil {
+			return nil, fmt.Errorf("invalid NodeDisruptorOptions: %w", err)
+		}
+	}
+
+	disruptor, err := disruptors.NewNodeDisruptor(ctx, k8s, selector, options)
+	if err != nil {
+		return nil, fmt.Errorf("error creating NodeDisruptor: %w", err)
+	}
+
+	obj, err := buildJsNodeDisruptor(ctx, rt, disruptor, teardown)
+	if err != nil {
+		return nil, fmt.Errorf("error creating NodeDisruptor: %w", err)
+	}
+
+	return obj, nil
+}
@@ -3,13 +3,17 @@ package api
 import (
 	"context"
 	"fmt"
+	"slices"
+	"strings"
 	"testing"
 
 	"github.com/grafana/sobek"
 	"github.com/grafana/xk6-disruptor/pkg/kubernetes"
+	"github.com/grafana/xk6-disruptor/pkg/kubernetes/helpers"
 	"github.com/grafana/xk6-disruptor/pkg/testutils/kubernetes/builders"
 	"go.k6.io/k6/js/common"
 	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/client-go/kubernetes/fake"
@@ -82,9 +86,13 @@ func testSetup() (*testEnv, error) {
 			Name:  "xk6-agent",
 			Image: "fake.registry.local/xk6-agent",
 		},
+		TargetContainerName: "main",
 	}
 
 	pod.Spec.EphemeralContainers = append(pod.Spec.EphemeralContainers, agentContainer)
+	pod.Status.ContainerStatuses = []corev1.ContainerStatus{
+		{Name: "main", ContainerID: "containerd://main"},
+	}
 
 	_, err = k8s.Client().CoreV1().Pods(ns.Name).Create(context.TODO(), &pod, metav1.CreateOptions{})
 	if err != nil {
@@ -102,6 +110,30 @@ func testSetup() (*testEnv, error) {
 		return nil, fmt.Errorf("creating namespace: %w", err)
 	}
 
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "some-tls", Namespace: ns.Name},
+		Type:       corev1.SecretTypeTLS,
+		Data: map[string][]byte{
+			corev1.TLSCertKey:       []byte("certificate"),
+			corev1.TLSPrivateKeyKey: []byte("key"),
+		},
+	}
+
+	_, err = k8s.Client().CoreV1().Secrets(ns.Name).Create(context.TODO(), secret, metav1.CreateOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("creating secret: %w", err)
+	}
+
+	node := builders.NewNodeBuilder("some-node").
+		WithLabel("pool", "pool").
+		WithCondition(corev1.NodeReady, corev1.ConditionTrue).
+		Build()
+
+	_, err = k8s.Client().CoreV1().Nodes().Create(context.TODO(), &node, metav1.CreateOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("creating node: %w", err)
+	}
+
 	return &testEnv{
 		rt:     rt,
 		client: client,
@@ -201,7 +233,7 @@ func Test_PodDisruptorConstructor(t *testing.T) {
 			}
 
 			err = env.registerConstructor("PodDisruptor", func(e *testEnv, c sobek.ConstructorCall) (*sobek.Object, error) {
-				return NewPodDisruptor(t.Context(), e.rt, c, e.k8s)
+				return NewPodDisruptor(t.Context(), e.rt, c, e.k8s, &Teardown{})
 			})
 			if err != nil {
 				t.Errorf("error in test setup %v", err)
@@ -274,6 +306,233 @@ func Test_JsPodDisruptor(t *testing.T) {
 			`,
 			expectError: false,
 		},
+		{
+			description: "inject HTTP Fault in egress upstream",
+			script: `
+			const fault = {
+				errorRate: 1.0,
+				errorCode: 503,
+				upstream: "payments:8080"
+			}
+
+			d.injectHTTPFaults(fault, "1s")
+			`,
+			expectError: false,
+		},
+		{
+			description: "inject HTTP Fault with rules",
+			script: `
+			const fault = {
+				port: 80,
+				rules: [
+					{
+						match: {
+							pathPrefix: "/api/",
+							methods: ["POST", "PUT"],
+							headers: { "X-Canary": "" },
+							query: { debug: "true" }
+						},
+						errorRate: 1.0,
+						errorCode: 503
+					},
+					{
+						match: { pathRegex: "^/users/[0-9]+$" },
+						averageDelay: "100ms"
+					}
+				]
+			}
+
+			d.injectHTTPFaults(fault, "1s")
+			`,
+			expectError: false,
+		},
+		{
+			description: "inject HTTP Fault with schedule",
+			script: `
+			const fault = {
+				port: 80,
+				averageDelay: "500ms",
+				errorRate: 0.2,
+				errorCode: 503,
+				schedule: { kind: "sine", from: 0.5, to: 1.5, period: "1m" }
+			}
+
+			d.injectHTTPFaults(fault, "1s")
+			`,
+			expectError: false,
+		},
+		{
+			description: "start HTTP Fault and wait",
+			script: `
+			const fault = {
+				errorRate: 1.0,
+				errorCode: 500
+			}
+
+			const handle = d.startHTTPFaults(fault, "1s")
+			handle.wait()
+			if (handle.status() !== "completed") {
+				throw new Error("unexpected status " + handle.status())
+			}
+			`,
+			expectError: false,
+		},
+		{
+			description: "start HTTP Fault without duration",
+			script: `
+			const fault = {
+				errorRate: 1.0,
+				errorCode: 500
+			}
+
+			d.startHTTPFaults(fault)
+			`,
+			expectError: true,
+		},
+		{
+			description: "start and stop Grpc Fault",
+			script: `
+			const fault = {
+				errorRate: 1.0,
+				statusCode: 14
+			}
+
+			const handle = d.startGrpcFaults(fault, "1s")
+			handle.stop()
+			const status = handle.status()
+			if (status !== "stopped" && status !== "completed") {
+				throw new Error("unexpected status " + status)
+			}
+			handle.wait()
+			`,
+			expectError: false,
+		},
+		{
+			description: "start HTTP Fault and stop all",
+			script: `
+			const fault = {
+				errorRate: 1.0,
+				errorCode: 500
+			}
+
+			const handle = d.startHTTPFaults(fault, "1s")
+			d.stopAll()
+			if (handle.status() === "running") {
+				throw new Error("expected the injection to end")
+			}
+			`,
+			expectError: false,
+		},
+		{
+			description: "cleanup",
+			script: `
+			d.cleanup()
+			`,
+			expectError: false,
+		},
+		{
+			description: "inject HTTP Fault with response fault",
+			script: `
+			const fault = {
+				port: 80,
+				setHeaders: { "X-Version": "2" },
+				removeHeaders: ["ETag"],
+				responseStatus: 202,
+				truncateBody: 0.5,
+				corruptBody: 0.1,
+				rules: [
+					{
+						match: { pathPrefix: "/api/" },
+						malformedJson: true
+					}
+				]
+			}
+
+			d.injectHTTPFaults(fault, "1s")
+			`,
+			expectError: false,
+		},
+		{
+			description: "inject HTTP Fault with throttling",
+			script: `
+			const fault = {
+				port: 80,
+				bodyRate: 1024,
+				firstByteDelay: "500ms",
+				stallAfter: 4096,
+				stallDuration: "10s",
+				rules: [
+					{
+						match: { pathPrefix: "/download/" },
+						bodyRate: 128
+					}
+				]
+			}
+
+			d.injectHTTPFaults(fault, "1s")
+			`,
+			expectError: false,
+		},
+		{
+			description: "inject HTTP Fault with stream fault",
+			script: `
+			const fault = {
+				port: 80,
+				frameDelay: "100ms",
+				dropAfterFrames: 10,
+				dropAfter: "5s",
+				closeCode: 1001,
+				rules: [
+					{
+						match: { pathPrefix: "/events" },
+						dropAfterFrames: 2
+					}
+				]
+			}
+
+			d.injectHTTPFaults(fault, "1s")
+			`,
+			expectError: false,
+		},
+		{
+			description: "inject HTTP Fault with TLS secret",
+			script: `
+			const fault = {
+				errorRate: 1.0,
+				errorCode: 500,
+				port: 80
+			}
+
+			d.injectHTTPFaults(fault, "1s", { tls: { secret: "some-tls" } })
+			`,
+			expectError: false,
+		},
+		{
+			description: "inject HTTP Fault with TLS CA secret",
+			script: `
+			const fault = {
+				errorRate: 1.0,
+				errorCode: 500,
+				port: 80
+			}
+
+			d.injectHTTPFaults(fault, "1s", { tls: { caSecret: "namespace/some-tls", hosts: ["app.example.com"] } })
+			`,
+			expectError: false,
+		},
+		{
+			description: "inject HTTP Fault with unknown TLS secret",
+			script: `
+			const fault = {
+				errorRate: 1.0,
+				errorCode: 500,
+				port: 80
+			}
+
+			d.injectHTTPFaults(fault, "1s", { tls: { secret: "other-tls" } })
+			`,
+			expectError: true,
+		},
 		{
 			description: "inject HTTP Fault without options",
 			script: `
@@ -358,6 +617,51 @@ func Test_JsPodDisruptor(t *testing.T) {
 			`,
 			expectError: false,
 		},
+		{
+			description: "inject Grpc Fault with rules",
+			script: `
+			const fault = {
+				port: 80,
+				rules: [
+					{
+						match: {
+							methods: ["/pkg.Service/*"],
+							excludeMethods: ["/pkg.Service/Health"],
+							metadata: { "x-canary": "" }
+						},
+						errorRate: 1.0,
+						statusCode: 14
+					}
+				]
+			}
+
+			d.injectGrpcFaults(fault, "1s")
+			`,
+			expectError: false,
+		},
+		{
+			description: "inject Grpc stream Fault",
+			script: `
+			const fault = {
+				port: 80,
+				statusCode: 14,
+				messageDelay: "50ms",
+				messageDropRate: 0.1,
+				abortAfterMessages: 10,
+				abortAfter: "5s",
+				rules: [
+					{
+						match: { methods: ["/pkg.Service/Watch"] },
+						statusCode: 4,
+						abortAfter: "1s"
+					}
+				]
+			}
+
+			d.injectGrpcFaults(fault, "1s")
+			`,
+			expectError: false,
+		},
 		{
 			description: "inject Grpc Fault without options",
 			script: `
@@ -430,91 +734,523 @@ func Test_JsPodDisruptor(t *testing.T) {
 			expectError: true,
 		},
 		{
-			description: "Terminate Pods (integer count)",
+			description: "inject TCP Fault",
 			script: `
 			const fault = {
-				count: 1
+				port: 80,
+				connectDelay: "100ms",
+				chunkDelay: "10ms",
+				bandwidth: 1024,
+				halfOpenRate: 0.1,
+				resetAfterBytes: 4096
 			}
 
-			d.terminatePods(fault)
+			const faultOpts = {
+				proxyPort: 4000,
+			}
+
+			d.injectTCPFaults(fault, "1s", faultOpts)
 			`,
 			expectError: false,
 		},
 		{
-			description: "Terminate Pods (percentage count)",
+			description: "inject TCP Fault without duration",
 			script: `
 			const fault = {
-				count: '100%'
+				port: 80,
+				connectDelay: "100ms"
 			}
 
-			d.terminatePods(fault)
+			d.injectTCPFaults(fault)
 			`,
-			expectError: false,
+			expectError: true,
 		},
 		{
-			description: "Terminate Pods (invalid percentage count)",
+			description: "inject TCP Fault with malformed fault (misspelled field)",
 			script: `
 			const fault = {
-				count: '100'
+				port: 80,
+				resetAfter: 4096,       // this is should be 'resetAfterBytes'
 			}
 
-			d.terminatePods(fault)
+			d.injectTCPFaults(fault, "1s")
 			`,
 			expectError: true,
 		},
 		{
-			description: "Terminate Pods (missing argument)",
+			description: "inject Redis Fault",
 			script: `
-			d.terminatePods()
+			const fault = {
+				port: 80,
+				averageDelay: "100ms",
+				errorRate: 0.1,
+				error: "LOADING Redis is loading the dataset in memory",
+				exclude: "PING",
+				rules: [
+					{
+						match: { commands: ["GET"], keys: ["user:*"] },
+						errorRate: 1.0,
+						error: "READONLY You can't write against a read only replica."
+					}
+				]
+			}
+
+			const faultOpts = {
+				proxyPort: 4000,
+			}
+
+			d.injectRedisFaults(fault, "1s", faultOpts)
 			`,
-			expectError: true,
+			expectError: false,
 		},
 		{
-			description: "Terminate Pods (empty argument)",
+			description: "inject Redis Fault without duration",
 			script: `
-			d.terminatePods({})
+			const fault = {
+				port: 6379,
+				averageDelay: "100ms"
+			}
+
+			d.injectRedisFaults(fault)
 			`,
 			expectError: true,
 		},
-	}
-
-	for _, tc := range testCases {
-		t.Run(tc.description, func(t *testing.T) {
-			t.Parallel()
-
-			env, err := testSetup()
-			if err != nil {
-				t.Errorf("error in test setup %v", err)
-				return
+		{
+			description: "inject Redis Fault with malformed fault (misspelled field)",
+			script: `
+			const fault = {
+				port: 6379,
+				errorRate: 1.0,
+				errorMessage: "ERR failed",       // this is should be 'error'
 			}
 
-			err = env.registerConstructor("PodDisruptor", func(e *testEnv, c sobek.ConstructorCall) (*sobek.Object, error) {
-				return NewPodDisruptor(t.Context(), e.rt, c, e.k8s)
-			})
-			if err != nil {
-				t.Errorf("error in test setup %v", err)
-				return
+			d.injectRedisFaults(fault, "1s")
+			`,
+			expectError: true,
+		},
+		{
+			description: "inject Postgres Fault",
+			script: `
+			const fault = {
+				port: 80,
+				errorRate: 0.1,
+				errorCode: "53300",
+				rules: [
+					{
+						match: { query: "^UPDATE orders", inTransaction: true },
+						errorRate: 1.0,
+						errorCode: "40001",
+						errorMessage: "could not serialize access due to concurrent update"
+					},
+					{
+						match: { query: "^COMMIT" },
+						terminateRate: 0.5
+					}
+				]
 			}
 
-			_, err = env.rt.RunString(setupPodDisruptor)
-			if err != nil {
-				t.Errorf("error in test setup %v", err)
-				return
+			const faultOpts = {
+				proxyPort: 4000,
 			}
 
-			_, err = env.rt.RunString(tc.script)
-
-			if !tc.expectError && err != nil {
-				t.Errorf("failed %v", err)
-				return
+			d.injectPostgresFaults(fault, "1s", faultOpts)
+			`,
+			expectError: false,
+		},
+		{
+			description: "inject Postgres Fault without duration",
+			script: `
+			const fault = {
+				port: 80,
+				terminateRate: 0.1
 			}
 
-			if tc.expectError && err == nil {
-				t.Errorf("should had failed")
-				return
+			d.injectPostgresFaults(fault)
+			`,
+			expectError: true,
+		},
+		{
+			description: "inject Postgres Fault with malformed fault (misspelled field)",
+			script: `
+			const fault = {
+				port: 80,
+				errorRate: 1.0,
+				sqlState: "40001",       // this is should be 'errorCode'
 			}
-		})
-	}
+
+			d.injectPostgresFaults(fault, "1s")
+			`,
+			expectError: true,
+		},
+		{
+			description: "inject Kafka Fault",
+			script: `
+			const fault = {
+				port: 80,
+				averageDelay: "10ms",
+				rules: [
+					{
+						match: { apis: ["Produce", "Fetch"], topics: ["orders"] },
+						errorRate: 0.5,
+						error: "NOT_LEADER_OR_FOLLOWER"
+					},
+					{
+						match: { apis: ["JoinGroup", "SyncGroup"] },
+						averageDelay: "5s"
+					}
+				]
+			}
+
+			const faultOpts = {
+				proxyPort: 4000,
+			}
+
+			d.injectKafkaFaults(fault, "1s", faultOpts)
+			`,
+			expectError: false,
+		},
+		{
+			description: "inject Kafka Fault without duration",
+			script: `
+			const fault = {
+				port: 80,
+				averageDelay: "10ms"
+			}
+
+			d.injectKafkaFaults(fault)
+			`,
+			expectError: true,
+		},
+		{
+			description: "inject Kafka Fault with malformed fault (misspelled field)",
+			script: `
+			const fault = {
+				port: 80,
+				errorRate: 1.0,
+				errorCode: "NOT_LEADER_OR_FOLLOWER",       // this is should be 'error'
+			}
+
+			d.injectKafkaFaults(fault, "1s")
+			`,
+			expectError: true,
+		},
+		{
+			description: "Terminate Pods (integer count)",
+			script: `
+			const fault = {
+				count: 1
+			}
+
+			d.terminatePods(fault)
+			`,
+			expectError: false,
+		},
+		{
+			description: "Terminate Pods (percentage count)",
+			script: `
+			const fault = {
+				count: '100%'
+			}
+
+			d.terminatePods(fault)
+			`,
+			expectError: false,
+		},
+		{
+			description: "Terminate Pods (invalid percentage count)",
+			script: `
+			const fault = {
+				count: '100'
+			}
+
+			d.terminatePods(fault)
+			`,
+			expectError: true,
+		},
+		{
+			description: "Terminate Pods (missing argument)",
+			script: `
+			d.terminatePods()
+			`,
+			expectError: true,
+		},
+		{
+			description: "Terminate Pods (empty argument)",
+			script: `
+			d.terminatePods({})
+			`,
+			expectError: true,
+		},
+		{
+			description: "inject Stress Fault",
+			script: `
+			const fault = {
+				load: 80,
+				cpus: 1
+			}
+
+			d.injectStressFaults(fault, "1s")
+			`,
+			expectError: false,
+		},
+		{
+			description: "inject memory Stress Fault",
+			script: `
+			const fault = {
+				memory: "256Mi",
+				memoryRampUp: "10s"
+			}
+
+			d.injectStressFaults(fault, "1s")
+			`,
+			expectError: false,
+		},
+		{
+			description: "inject Network Fault with network conditions",
+			script: `
+			const fault = {
+				port: 80,
+				delay: "100ms",
+				jitter: "10ms",
+				lossRate: 0.1,
+				bandwidth: "1mbit"
+			}
+
+			d.injectNetworkFaults(fault, "1s")
+			`,
+			expectError: false,
+		},
+		{
+			description: "inject Network Fault with schedule",
+			script: `
+			const fault = {
+				port: 80,
+				delay: "100ms",
+				schedule: {
+					kind: "steps",
+					steps: [
+						{ at: "0s", level: 0.5 },
+						{ at: "30s", level: 1.0 }
+					]
+				}
+			}
+
+			d.injectNetworkFaults(fault, "1s")
+			`,
+			expectError: false,
+		},
+		{
+			description: "inject egress Network Fault",
+			script: `
+			const fault = {
+				port: 80,
+				destinations: ["10.0.0.0/24", "db.example.com"],
+				service: "some-service"
+			}
+
+			d.injectNetworkFaults(fault, "1s")
+			`,
+			expectError: false,
+		},
+		{
+			description: "start several Network Faults",
+			script: `
+			const drop = d.startNetworkFaults({ port: 80 }, "1s")
+			const delay = d.startNetworkFaults({ port: 8080, delay: "100ms" }, "1s")
+			drop.wait()
+			delay.wait()
+			`,
+			expectError: false,
+		},
+		{
+			description: "start egress Network Fault to unknown service",
+			script: `
+			const handle = d.startNetworkFaults({ service: "namespace/unknown" }, "1s")
+			let failed = false
+			try {
+				handle.wait()
+			} catch (e) {
+				failed = true
+			}
+			if (!failed || handle.status() !== "failed") {
+				throw new Error("expected the injection to fail, status " + handle.status())
+			}
+			`,
+			expectError: false,
+		},
+		{
+			description: "inject egress Network Fault to unknown service",
+			script: `
+			const fault = {
+				service: "namespace/unknown"
+			}
+
+			d.injectNetworkFaults(fault, "1s")
+			`,
+			expectError: true,
+		},
+		{
+			description: "inject Disk Fault",
+			script: `
+			const fault = {
+				path: "/tmp",
+				workers: 2,
+				blockSize: "4Ki",
+				fileSize: "16Mi"
+			}
+
+			d.injectDiskFaults(fault, "1s")
+			`,
+			expectError: false,
+		},
+		{
+			description: "inject Disk Fault without duration",
+			script: `
+			const fault = {
+				fill: 90
+			}
+
+			d.injectDiskFaults(fault)
+			`,
+			expectError: true,
+		},
+		{
+			description: "inject DNS Fault",
+			script: `
+			const fault = {
+				errorRate: 1.0,
+				error: "NXDOMAIN",
+				addresses: ["10.0.0.1"],
+				rules: [
+					{
+						match: { domains: ["*.example.com"], types: ["A"] },
+						averageDelay: "100ms"
+					}
+				]
+			}
+
+			d.injectDNSFaults(fault, "1s", { proxyPort: 5353 })
+			`,
+			expectError: false,
+		},
+		{
+			description: "inject DNS Fault without duration",
+			script: `
+			const fault = {
+				errorRate: 1.0,
+				error: "SERVFAIL"
+			}
+
+			d.injectDNSFaults(fault)
+			`,
+			expectError: true,
+		},
+		{
+			description: "inject Stress Fault without duration",
+			script: `
+			const fault = {
+				load: 80
+			}
+
+			d.injectStressFaults(fault)
+			`,
+			expectError: true,
+		},
+		{
+			description: "inject Stress Fault with malformed fault (misspelled field)",
+			script: `
+			const fault = {
+				cpu: 1
+			}
+
+			d.injectStressFaults(fault, "1s")
+			`,
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			env, err := testSetup()
+			if err != nil {
+				t.Errorf("error in test setup %v", err)
+				return
+			}
+
+			err = env.registerConstructor("PodDisruptor", func(e *testEnv, c sobek.ConstructorCall) (*sobek.Object, error) {
+				return NewPodDisruptor(t.Context(), e.rt, c, e.k8s, &Teardown{})
+			})
+			if err != nil {
+				t.Errorf("error in test setup %v", err)
+				return
+			}
+
+			_, err = env.rt.RunString(setupPodDisruptor)
+			if err != nil {
+				t.Errorf("error in test setup %v", err)
+				return
+			}
+
+			_, err = env.rt.RunString(tc.script)
+
+			if !tc.expectError && err != nil {
+				t.Errorf("failed %v", err)
+				return
+			}
+
+			if tc.expectError && err == nil {
+				t.Errorf("should had failed")
+				return
+			}
+		})
+	}
+}
+
+func Test_PodDisruptorTeardown(t *testing.T) {
+	t.Parallel()
+
+	env, err := testSetup()
+	if err != nil {
+		t.Fatalf("error in test setup %v", err)
+	}
+
+	teardown := &Teardown{}
+	err = env.registerConstructor("PodDisruptor", func(e *testEnv, c sobek.ConstructorCall) (*sobek.Object, error) {
+		return NewPodDisruptor(t.Context(), e.rt, c, e.k8s, teardown)
+	})
+	if err != nil {
+		t.Fatalf("error in test setup %v", err)
+	}
+
+	_, err = env.rt.RunString(setupPodDisruptor + `
+	const handle = d.startHTTPFaults({ port: 80, errorRate: 0.1, errorCode: 500 }, "1h")
+	`)
+	if err != nil {
+		t.Fatalf("error in test setup %v", err)
+	}
+
+	// the end of the test stops the faults started by the disruptor
+	teardown.Run()
+
+	status, err := env.rt.RunString(`handle.status()`)
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+
+	if status.String() == faultRunning {
+		t.Fatalf("expected fault to be stopped")
+	}
+
+	// the faults still running in the targets are stopped too
+	executor := env.k8s.(*kubernetes.FakeKubernetes).GetFakeProcessExecutor() //nolint:forcetypeassert
+	cleaned := slices.ContainsFunc(executor.GetHistory(), func(cmd helpers.Command) bool {
+		return cmd.Pod == "some-pod" && strings.Join(cmd.Command, " ") == "xk6-disruptor-agent cleanup"
+	})
+	if !cleaned {
+		t.Fatalf("expected cleanup of pod some-pod")
+	}
 }
 
 func Test_ServiceDisruptorConstructor(t *testing.T) {
@@ -542,6 +1278,15 @@ func Test_ServiceDisruptorConstructor(t *testing.T) {
 			`,
 			expectError: false,
 		},
+		{
+			description: "cleanup",
+			script: `
+			const d = new ServiceDisruptor("some-service", "namespace")
+			d.cleanup()
+			d.stopAll()
+			`,
+			expectError: false,
+		},
 		{
 			description: "invalid constructor without namespace",
 			script: `
@@ -592,7 +1337,7 @@ func Test_ServiceDisruptorConstructor(t *testing.T) {
 			}
 
 			err = env.registerConstructor("ServiceDisruptor", func(e *testEnv, c sobek.ConstructorCall) (*sobek.Object, error) {
-				return NewServiceDisruptor(t.Context(), e.rt, c, e.k8s)
+				return NewServiceDisruptor(t.Context(), e.rt, c, e.k8s, &Teardown{})
 			})
 			if err != nil {
 				t.Errorf("error in test setup %v", err)
@@ -620,3 +1365,275 @@ func Test_ServiceDisruptorConstructor(t *testing.T) {
 		})
 	}
 }
+
+func Test_NodeDisruptorConstructor(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		description string
+		script      string
+		expectError bool
+	}{
+		{
+			description: "valid constructor",
+			script: `
+			const selector = {
+				select: {
+					labels: {
+						pool: "pool"
+					},
+					conditions: {
+						Ready: "True"
+					}
+				},
+				exclude: {
+					taints: ["node-role.kubernetes.io/control-plane"]
+				}
+			}
+			const opts = {
+				injectTimeout: "10s",
+				namespace: "namespace"
+			}
+			new NodeDisruptor(selector, opts)
+			`,
+			expectError: false,
+		},
+		{
+			description: "valid constructor without options",
+			script: `
+			const selector = {
+				select: {
+					labels: {
+						pool: "pool"
+					}
+				}
+			}
+			new NodeDisruptor(selector)
+			`,
+			expectError: false,
+		},
+		{
+			description: "invalid constructor without selector",
+			script: `
+			new NodeDisruptor()
+			`,
+			expectError: true,
+		},
+		{
+			description: "invalid constructor with empty selector",
+			script: `
+			new NodeDisruptor({})
+			`,
+			expectError: true,
+		},
+		{
+			description: "invalid constructor with malformed selector",
+			script: `
+			const selector = {
+				labels: {
+					pool: "pool"
+				}
+			}
+			new NodeDisruptor(selector)
+			`,
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			env, err := testSetup()
+			if err != nil {
+				t.Errorf("error in test setup %v", err)
+				return
+			}
+
+			err = env.registerConstructor("NodeDisruptor", func(e *testEnv, c sobek.ConstructorCall) (*sobek.Object, error) {
+				return NewNodeDisruptor(t.Context(), e.rt, c, e.k8s, &Teardown{})
+			})
+			if err != nil {
+				t.Errorf("error in test setup %v", err)
+				return
+			}
+
+			_, err = env.rt.RunString(tc.script)
+
+			if !tc.expectError && err != nil {
+				t.Errorf("failed %v", err)
+				return
+			}
+
+			if tc.expectError && err == nil {
+				t.Errorf("should had failed")
+				return
+			}
+		})
+	}
+}
+
+func Test_NodeDisruptorTeardown(t *testing.T) {
+	t.Parallel()
+
+	env, err := testSetup()
+	if err != nil {
+		t.Fatalf("error in test setup %v", err)
+	}
+
+	teardown := &Teardown{}
+	err = env.registerConstructor("NodeDisruptor", func(e *testEnv, c sobek.ConstructorCall) (*sobek.Object, error) {
+		return NewNodeDisruptor(t.Context(), e.rt, c, e.k8s, teardown)
+	})
+	if err != nil {
+		t.Fatalf("error in test setup %v", err)
+	}
+
+	_, err = env.rt.RunString(setupNodeDisruptor + `
+	d.injectNetworkFaults({ port: 80 }, "1s")
+	`)
+	if err != nil {
+		t.Fatalf("failed %v", err)
+	}
+
+	pods := env.k8s.Client().CoreV1().Pods(metav1.NamespaceDefault)
+	if _, err = pods.Get(t.Context(), "xk6-agent-some-node", metav1.GetOptions{}); err != nil {
+		t.Fatalf("agent pod not created: %v", err)
+	}
+
+	// the end of the test deletes the agent pods created by the disruptor
+	teardown.Run()
+
+	if _, err = pods.Get(t.Context(), "xk6-agent-some-node", metav1.GetOptions{}); !k8serrors.IsNotFound(err) {
+		t.Fatalf("expected agent pod to be deleted, got %v", err)
+	}
+}
+
+const setupNodeDisruptor = `
+const selector = {
+	select: {
+		labels: {
+			pool: "pool"
+		}
+	}
+}
+
+// force no waiting for the agent pod as the mock will not update its status
+const opts = {
+	injectTimeout: "-1s"
+}
+
+const d = new NodeDisruptor(selector, opts)
+`
+
+func Test_JsNodeDisruptor(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		description string
+		script      string
+		expectError bool
+	}{
+		{
+			description: "get targets",
+			script: `
+			d.targets()
+			`,
+			expectError: false,
+		},
+		{
+			description: "inject Network Fault",
+			script: `
+			const fault = {
+				port: 80,
+				protocol: "tcp"
+			}
+
+			d.injectNetworkFaults(fault, "1s")
+			`,
+			expectError: false,
+		},
+		{
+			description: "inject Stress Fault",
+			script: `
+			const fault = {
+				load: 80,
+				cpus: 2,
+				slice: "100ms"
+			}
+
+			d.injectStressFaults(fault, "1s")
+			`,
+			expectError: false,
+		},
+		{
+			description: "cleanup",
+			script: `
+			d.cleanup()
+			d.stopAll()
+			`,
+			expectError: false,
+		},
+		{
+			description: "inject Stress Fault without duration",
+			script: `
+			const fault = {
+				load: 80,
+				cpus: 2
+			}
+
+			d.injectStressFaults(fault)
+			`,
+			expectError: true,
+		},
+		{
+			description: "inject Stress Fault with malformed fault (misspelled field)",
+			script: `
+			const fault = {
+				cpuLoad: 80
+			}
+
+			d.injectStressFaults(fault, "1s")
+			`,
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			t.Parallel()
+
+			env, err := testSetup()
+			if err != nil {
+				t.Errorf("error in test setup %v", err)
+				return
+			}
+
+			err = env.registerConstructor("NodeDisruptor", func(e *testEnv, c sobek.ConstructorCall) (*sobek.Object, error) {
+				return NewNodeDisruptor(t.Context(), e.rt, c, e.k8s, &Teardown{})
+			})
+			if err != nil {
+				t.Errorf("error in test setup %v", err)
+				return
+			}
+
+			_, err = env.rt.RunString(setupNodeDisruptor)
+			if err != nil {
+				t.Errorf("error in test setup %v", err)
+				return
+			}
+
+			_, err = env.rt.RunString(tc.script)
+
+			if !tc.expectError && err != nil {
+				t.Errorf("failed %v", err)
+				return
+			}
+
+			if tc.expectError && err == nil {
+				t.Errorf("should had failed")
+				return
+			}
+		})
+	}
+}
@@ -130,7 +130,7 @@ func convertStruct(value interface{}, target interface{}) error {
 	}
 
 	for field, fieldValue := range fieldMap {
-		sf := targetValue.FieldByName(toGoCase(field))
+		sf := structField(targetValue, field)
 		if !sf.IsValid() {
 			return fmt.Errorf("unknown field %s in struct %s", field, targetValue.Type().Name())
 		}
@@ -144,6 +144,20 @@ func convertStruct(value interface{}, target interface{}) error {
 	return nil
 }
 
+// structField returns the field of the struct that maps to the given name. Fields with a `js` tag that matches the
+// name take precedence. Otherwise, the name is transformed to Go case (e.g. 'fieldName' maps to 'FieldName').
+// This allows fields such as 'CPUs' to be mapped from a JS field 'cpus' using the tag `js:"cpus"`.
+// The tags of the fields promoted from embedded structs are also considered.
+func structField(structValue reflect.Value, name string) reflect.Value {
+	for _, field := range reflect.VisibleFields(structValue.Type()) {
+		if field.Tag.Get("js") == name {
+			return structValue.FieldByIndex(field.Index)
+		}
+	}
+
+	return structValue.FieldByName(toGoCase(name))
+}
+
 func convertDuration(value interface{}, target interface{}) error {
 	targetValue := reflect.ValueOf(target).Elem()
 
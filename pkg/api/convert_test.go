@@ -24,6 +24,14 @@ func Test_Conversions(t *testing.T) {
 		Struct      StructField
 		Map         map[string]string
 		Array       []string
+		CPUs        int64 `js:"cpus"`
+	}
+	type EmbeddedField struct {
+		GPUs int64 `js:"gpus"`
+	}
+	type EmbeddingFields struct {
+		EmbeddedField
+		String string
 	}
 
 	testCases := []struct {
@@ -192,6 +200,7 @@ func Test_Conversions(t *testing.T) {
 					"key": "value",
 				},
 				"array": []interface{}{"string"},
+				"cpus":  int64(2),
 			},
 			target: &TypedFields{},
 			expected: TypedFields{
@@ -209,9 +218,32 @@ func Test_Conversions(t *testing.T) {
 				Map: map[string]string{
 					"key": "value",
 				},
+				CPUs: 2,
+			},
+			expectError: false,
+		},
+		{
+			description: "Struct field conversion (embedded struct)",
+			value: map[string]interface{}{
+				"string": "string",
+				"gpus":   int64(2),
+			},
+			target: &EmbeddingFields{},
+			expected: EmbeddingFields{
+				EmbeddedField: EmbeddedField{GPUs: 2},
+				String:        "string",
 			},
 			expectError: false,
 		},
+		{
+			description: "Struct field conversion (unknown field)",
+			value: map[string]interface{}{
+				"unknown": "string",
+			},
+			target:      &TypedFields{},
+			expected:    nil,
+			expectError: true,
+		},
 	}
 
 	for _, tc := range testCases {
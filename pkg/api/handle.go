@@ -0,0 +1,187 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/grafana/sobek"
+	"go.k6.io/k6/js/common"
+)
+
+// status of a fault injected in the background
+const (
+	// faultRunning is the status of a fault whose injection has not ended
+	faultRunning = "running"
+	// faultCompleted is the status of a fault injected for its whole duration
+	faultCompleted = "completed"
+	// faultStopped is the status of a fault whose injection was stopped before the end of its duration
+	faultStopped = "stopped"
+	// faultFailed is the status of a fault whose injection returned an error
+	faultFailed = "failed"
+)
+
+// jsFaultHandle implements the JS interface for controlling a fault injected in the background
+type jsFaultHandle struct {
+	rt     *sobek.Runtime
+	cancel context.CancelFunc
+	// done is closed when the injection ends
+	done    chan struct{}
+	mtx     sync.Mutex
+	stopped bool
+	err     error
+}
+
+// faultHandles keeps the handles of the faults started in the background by a disruptor
+type faultHandles struct {
+	mtx     sync.Mutex
+	handles []*jsFaultHandle
+}
+
+// add adds a handle, and forgets the handles of the injections that already ended
+func (f *faultHandles) add(h *jsFaultHandle) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+
+	f.handles = slices.DeleteFunc(f.handles, (*jsFaultHandle).ended)
+	f.handles = append(f.handles, h)
+}
+
+// stopAll stops all the injections and waits until they return
+func (f *faultHandles) stopAll() {
+	f.mtx.Lock()
+	handles := slices.Clone(f.handles)
+	f.mtx.Unlock()
+
+	for _, h := range handles {
+		h.Stop()
+	}
+}
+
+// Teardown keeps the actions for stopping the faults started by the disruptors of a VU. It is intended to be run when
+// the test ends, so the faults do not outlive it.
+type Teardown struct {
+	mtx     sync.Mutex
+	actions []func()
+}
+
+// add adds an action to the teardown
+func (t *Teardown) add(action func()) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	t.actions = append(t.actions, action)
+}
+
+// Run runs the actions of the teardown, and waits until they return
+func (t *Teardown) Run() {
+	t.mtx.Lock()
+	actions := slices.Clone(t.actions)
+	t.mtx.Unlock()
+
+	for _, action := range actions {
+		action()
+	}
+}
+
+// startFault runs the injection of a fault in the background and returns a goja object with the handle for
+// controlling it. The handle is added to the handles of the disruptor. The injection is canceled if the context is
+// done.
+func startFault(
+	ctx context.Context,
+	rt *sobek.Runtime,
+	handles *faultHandles,
+	inject func(context.Context) error,
+) *sobek.Object {
+	ctx, cancel := context.WithCancel(ctx)
+	h := &jsFaultHandle{
+		rt:     rt,
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+
+	go func() {
+		defer close(h.done)
+		defer cancel()
+
+		err := inject(ctx)
+
+		h.mtx.Lock()
+		h.err = err
+		h.mtx.Unlock()
+	}()
+
+	handles.add(h)
+
+	obj, err := buildObject(rt, h)
+	if err != nil {
+		cancel()
+		common.Throw(rt, fmt.Errorf("error creating fault handle: %w", err))
+	}
+
+	return obj
+}
+
+// Stop ends the injection of the fault and waits until the injection returns. Has no effect if the injection
+// already ended.
+func (h *jsFaultHandle) Stop() {
+	h.mtx.Lock()
+	h.stopped = h.stopped || !h.ended()
+	h.mtx.Unlock()
+
+	h.cancel()
+	<-h.done
+}
+
+// Wait blocks until the injection of the fault ends. Throws an exception if the injection failed.
+func (h *jsFaultHandle) Wait() {
+	<-h.done
+
+	if err := h.failure(); err != nil {
+		common.Throw(h.rt, fmt.Errorf("error injecting fault: %w", err))
+	}
+}
+
+// Status returns the status of the injection: "running", "completed", "stopped" or "failed"
+func (h *jsFaultHandle) Status() string {
+	if !h.ended() {
+		return faultRunning
+	}
+
+	if h.failure() != nil {
+		return faultFailed
+	}
+
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+
+	if h.stopped {
+		return faultStopped
+	}
+
+	return faultCompleted
+}
+
+// ended returns if the injection ended
+func (h *jsFaultHandle) ended() bool {
+	select {
+	case <-h.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// failure returns the error returned by the injection, if any. The cancellation caused by Stop is not a failure.
+func (h *jsFaultHandle) failure() error {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+
+	if h.stopped && errors.Is(h.err, context.Canceled) {
+		return nil
+	}
+
+	return h.err
+}
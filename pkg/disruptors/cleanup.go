@@ -0,0 +1,69 @@
+package disruptors
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/grafana/xk6-disruptor/pkg/kubernetes/helpers"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// agentPodDeleteTimeout is the maximum time for waiting the agent pod of a node to be deleted
+const agentPodDeleteTimeout = 30 * time.Second
+
+// FaultCleaner defines the methods for stopping the faults running in the targets of a disruptor
+type FaultCleaner interface {
+	// Cleanup stops any fault running in the disruptor's targets and removes the resources used for injecting it.
+	// The targets without the agent are ignored.
+	Cleanup(ctx context.Context) error
+}
+
+// PodCleanupVisitor defines a Visitor that runs the cleanup command in the agent of its target pod. The pods without
+// the agent are skipped, so the agent is not injected only for cleaning them up.
+type PodCleanupVisitor struct {
+	helper helpers.PodHelper
+}
+
+// Visit executes the cleanup command in the agent of the target Pod
+func (c PodCleanupVisitor) Visit(ctx context.Context, pod corev1.Pod) error {
+	if !hasAgent(pod) {
+		return nil
+	}
+
+	return execAgentCommands(ctx, c.helper, pod.Name, VisitCommands{Exec: buildCleanupCmd()})
+}
+
+// hasAgent returns if the agent container was injected in the pod
+func hasAgent(pod corev1.Pod) bool {
+	return slices.ContainsFunc(pod.Spec.EphemeralContainers, func(c corev1.EphemeralContainer) bool {
+		return c.Name == agentContainerName
+	})
+}
+
+// NodeCleanupVisitor defines a Visitor that stops the faults running in the agent pod of its target node, and deletes
+// the agent pod. The nodes without an agent pod are skipped.
+type NodeCleanupVisitor struct {
+	helper helpers.PodHelper
+}
+
+// Visit stops the faults running in the agent pod of the target Node and deletes the pod
+func (c NodeCleanupVisitor) Visit(ctx context.Context, node corev1.Node) error {
+	return deleteNodeAgentPod(ctx, c.helper, NodeAgentPodName(node.Name))
+}
+
+// deleteNodeAgentPod deletes an agent pod created in a node. The commands running in the pod are not signaled when the
+// pod is deleted, so they are stopped first with the cleanup command. A pod that does not exist is ignored.
+func deleteNodeAgentPod(ctx context.Context, helper helpers.PodHelper, pod string) error {
+	// errors are ignored, as the pod may not exist
+	_, _, _ = helper.Exec(ctx, pod, agentContainerName, buildCleanupCmd(), []byte{})
+
+	err := helper.Terminate(ctx, pod, agentPodDeleteTimeout)
+	if err != nil && !k8serrors.IsNotFound(err) {
+		return fmt.Errorf("deleting agent pod %q: %w", pod, err)
+	}
+
+	return nil
+}
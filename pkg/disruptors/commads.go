@@ -1,7 +1,13 @@
 package disruptors
 
 import (
+	"encoding/json"
 	"fmt"
+	"maps"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/grafana/xk6-disruptor/pkg/types/intstr"
@@ -20,12 +26,18 @@ func buildGrpcFaultCmd(
 		"xk6-disruptor-agent",
 		"grpc",
 		"-d", utils.DurationSeconds(duration),
-		"-t", fmt.Sprint(fault.Port),
 	}
 
-	// TODO: make port mandatory
-	if fault.Port != intstr.NullValue {
-		cmd = append(cmd, "-t", fault.Port.Str())
+	target := []string{"--upstream-host", targetAddress}
+	if fault.Upstream != "" {
+		target = []string{"--egress", fault.Upstream}
+	} else {
+		cmd = append(cmd, "-t", fmt.Sprint(fault.Port))
+
+		// TODO: make port mandatory
+		if fault.Port != intstr.NullValue {
+			cmd = append(cmd, "-t", fault.Port.Str())
+		}
 	}
 
 	if fault.AverageDelay > 0 {
@@ -51,15 +63,25 @@ func buildGrpcFaultCmd(
 		}
 	}
 
+	cmd = append(cmd, buildGrpcStreamFaultArgs(fault)...)
+
 	if len(fault.Exclude) > 0 {
 		cmd = append(cmd, "-x", fault.Exclude)
 	}
 
+	for _, rule := range fault.Rules {
+		// a rule only has strings, numbers, slices and maps of strings, so encoding it cannot fail
+		encoded, _ := json.Marshal(rule) //nolint:errchkjson
+		cmd = append(cmd, "--rule", string(encoded))
+	}
+
+	cmd = append(cmd, buildScheduleArgs(fault.Schedule)...)
+
 	if options.ProxyPort != 0 {
 		cmd = append(cmd, "-p", fmt.Sprint(options.ProxyPort))
 	}
 
-	cmd = append(cmd, "--upstream-host", targetAddress)
+	cmd = append(cmd, target...)
 
 	return cmd
 }
@@ -76,8 +98,10 @@ func buildHTTPFaultCmd(
 		"-d", utils.DurationSeconds(duration),
 	}
 
-	// TODO: make port mandatory
-	if fault.Port != intstr.NullValue {
+	target := []string{"--upstream-host", targetAddress}
+	if fault.Upstream != "" {
+		target = []string{"--egress", fault.Upstream}
+	} else if fault.Port != intstr.NullValue { // TODO: make port mandatory
 		cmd = append(cmd, "-t", fault.Port.Str())
 	}
 
@@ -108,112 +132,1024 @@ func buildHTTPFaultCmd(
 		cmd = append(cmd, "-x", fault.Exclude)
 	}
 
+	cmd = append(cmd, buildHTTPResponseFaultArgs(fault.HTTPResponseFault)...)
+	cmd = append(cmd, buildHTTPThrottlingFaultArgs(fault.HTTPThrottlingFault)...)
+	cmd = append(cmd, buildHTTPStreamFaultArgs(fault.HTTPStreamFault)...)
+
+	for _, rule := range fault.Rules {
+		// a rule only has strings, numbers, booleans, slices and maps of strings, so encoding it cannot fail
+		encoded, _ := json.Marshal(rule) //nolint:errchkjson
+		cmd = append(cmd, "--rule", string(encoded))
+	}
+
+	cmd = append(cmd, buildScheduleArgs(fault.Schedule)...)
+
 	if options.ProxyPort != 0 {
 		cmd = append(cmd, "-p", fmt.Sprint(options.ProxyPort))
 	}
 
-	cmd = append(cmd, "--upstream-host", targetAddress)
+	if options.TLS.enabled() {
+		cmd = append(cmd, "--tls")
+		if options.TLS.CASecret != "" {
+			cmd = append(cmd, "--tls-generate", "--tls-host", strings.Join(options.TLS.Hosts, ","))
+		}
+	}
+
+	cmd = append(cmd, target...)
 
 	return cmd
 }
 
-func buildNetworkFaultCmd(fault NetworkFault, duration time.Duration) []string {
+// buildHTTPResponseFaultArgs returns the arguments of the http command for the response fault
+func buildHTTPResponseFaultArgs(fault HTTPResponseFault) []string {
+	args := []string{}
+
+	// sort the headers for a deterministic command
+	for _, name := range slices.Sorted(maps.Keys(fault.SetHeaders)) {
+		args = append(args, "--set-header", name+"="+fault.SetHeaders[name])
+	}
+
+	if len(fault.RemoveHeaders) > 0 {
+		args = append(args, "--remove-header", strings.Join(fault.RemoveHeaders, ","))
+	}
+
+	if fault.ResponseStatus > 0 {
+		args = append(args, "--response-status", fmt.Sprint(fault.ResponseStatus))
+	}
+
+	if fault.TruncateBody > 0 {
+		args = append(args, "--truncate-body", fmt.Sprint(fault.TruncateBody))
+	}
+
+	if fault.CorruptBody > 0 {
+		args = append(args, "--corrupt-body", fmt.Sprint(fault.CorruptBody))
+	}
+
+	if fault.MalformedJSON {
+		args = append(args, "--malformed-json")
+	}
+
+	return args
+}
+
+// buildHTTPThrottlingFaultArgs returns the arguments of the http command for the throttling fault
+func buildHTTPThrottlingFaultArgs(fault HTTPThrottlingFault) []string {
+	args := []string{}
+
+	if fault.BodyRate > 0 {
+		args = append(args, "--body-rate", fmt.Sprint(fault.BodyRate))
+	}
+
+	if fault.FirstByteDelay > 0 {
+		args = append(args, "--first-byte-delay", utils.DurationMillSeconds(fault.FirstByteDelay))
+	}
+
+	if fault.StallAfter > 0 {
+		args = append(args, "--stall-after", fmt.Sprint(fault.StallAfter))
+	}
+
+	if fault.StallDuration > 0 {
+		args = append(args, "--stall-duration", utils.DurationMillSeconds(fault.StallDuration))
+	}
+
+	return args
+}
+
+// buildHTTPStreamFaultArgs returns the arguments of the http command for the stream fault
+func buildHTTPStreamFaultArgs(fault HTTPStreamFault) []string {
+	args := []string{}
+
+	if fault.FrameDelay > 0 {
+		args = append(args, "--frame-delay", utils.DurationMillSeconds(fault.FrameDelay))
+	}
+
+	if fault.DropAfterFrames > 0 {
+		args = append(args, "--drop-after-frames", fmt.Sprint(fault.DropAfterFrames))
+	}
+
+	if fault.DropAfter > 0 {
+		args = append(args, "--drop-after", utils.DurationMillSeconds(fault.DropAfter))
+	}
+
+	if fault.CloseCode > 0 {
+		args = append(args, "--close-code", fmt.Sprint(fault.CloseCode))
+	}
+
+	return args
+}
+
+// buildGrpcStreamFaultArgs returns the arguments of the grpc command for the stream fault. Aborting streams requires
+// the status code, which is only passed when the error rate is set, so it is also passed here if needed.
+func buildGrpcStreamFaultArgs(fault GrpcFault) []string {
+	args := []string{}
+
+	if fault.MessageDelay > 0 {
+		args = append(args, "--message-delay", utils.DurationMillSeconds(fault.MessageDelay))
+	}
+
+	if fault.MessageDropRate > 0 {
+		args = append(args, "--message-drop-rate", fmt.Sprint(fault.MessageDropRate))
+	}
+
+	if fault.AbortAfterMessages > 0 {
+		args = append(args, "--abort-after-messages", fmt.Sprint(fault.AbortAfterMessages))
+	}
+
+	if fault.AbortAfter > 0 {
+		args = append(args, "--abort-after", utils.DurationMillSeconds(fault.AbortAfter))
+	}
+
+	aborts := fault.AbortAfterMessages > 0 || fault.AbortAfter > 0
+	if aborts && fault.ErrorRate == 0 {
+		args = append(args, "-s", fmt.Sprint(fault.StatusCode))
+		if fault.StatusMessage != "" {
+			args = append(args, "-m", fault.StatusMessage)
+		}
+	}
+
+	return args
+}
+
+func buildTCPFaultCmd(
+	targetAddress string,
+	fault TCPFault,
+	duration time.Duration,
+	options TCPDisruptionOptions,
+) []string {
 	cmd := []string{
 		"xk6-disruptor-agent",
-		"network-drop",
+		"tcp",
 		"-d", utils.DurationSeconds(duration),
 	}
 
-	if fault.Port != 0 {
-		cmd = append(cmd, "-p", fmt.Sprint(fault.Port))
+	target := []string{"--upstream-host", targetAddress}
+	if fault.Upstream != "" {
+		target = []string{"--egress", fault.Upstream}
+	} else {
+		cmd = append(cmd, "-t", fault.Port.Str())
 	}
 
-	if fault.Protocol != "" {
-		cmd = append(cmd, "-P", fault.Protocol)
+	if fault.ConnectDelay > 0 {
+		cmd = append(cmd, "--connect-delay", utils.DurationMillSeconds(fault.ConnectDelay))
+	}
+
+	if fault.ChunkDelay > 0 {
+		cmd = append(cmd, "--chunk-delay", utils.DurationMillSeconds(fault.ChunkDelay))
+	}
+
+	if fault.Bandwidth > 0 {
+		cmd = append(cmd, "--bandwidth", fmt.Sprint(fault.Bandwidth))
 	}
 
+	if fault.HalfOpenRate > 0 {
+		cmd = append(cmd, "--half-open-rate", fmt.Sprint(fault.HalfOpenRate))
+	}
+
+	if fault.ResetAfterBytes > 0 {
+		cmd = append(cmd, "--reset-after-bytes", fmt.Sprint(fault.ResetAfterBytes))
+	}
+
+	if options.ProxyPort != 0 {
+		cmd = append(cmd, "-p", fmt.Sprint(options.ProxyPort))
+	}
+
+	cmd = append(cmd, target...)
+
 	return cmd
 }
 
-func buildCleanupCmd() []string {
-	return []string{"xk6-disruptor-agent", "cleanup"}
-}
+func buildRedisFaultCmd(
+	targetAddress string,
+	fault RedisFault,
+	duration time.Duration,
+	options RedisDisruptionOptions,
+) []string {
+	cmd := []string{
+		"xk6-disruptor-agent",
+		"redis",
+		"-d", utils.DurationSeconds(duration),
+	}
 
-// PodHTTPFaultCommand implements the PodVisitCommands interface for injecting
-// HttpFaults in a Pod
-type PodHTTPFaultCommand struct {
-	fault    HTTPFault
-	duration time.Duration
-	options  HTTPDisruptionOptions
-}
+	target := []string{"--upstream-host", targetAddress}
+	if fault.Upstream != "" {
+		target = []string{"--egress", fault.Upstream}
+	} else {
+		cmd = append(cmd, "-t", fault.Port.Str())
+	}
 
-// Commands return the command for injecting a HttpFault in a Pod
-func (c PodHTTPFaultCommand) Commands(pod corev1.Pod) (VisitCommands, error) {
-	if utils.HasHostNetwork(pod) {
-		return VisitCommands{}, fmt.Errorf("fault cannot be safely injected because pod %q uses hostNetwork", pod.Name)
+	if fault.AverageDelay > 0 {
+		cmd = append(
+			cmd,
+			"-a",
+			utils.DurationMillSeconds(fault.AverageDelay),
+			"-v",
+			utils.DurationMillSeconds(fault.DelayVariation),
+		)
 	}
 
-	// find the container port for fault injection
-	port, err := utils.FindPort(c.fault.Port, pod)
-	if err != nil {
-		return VisitCommands{}, err
+	if fault.ErrorRate > 0 {
+		cmd = append(cmd, "-r", fmt.Sprint(fault.ErrorRate))
+		if fault.Error != "" {
+			cmd = append(cmd, "--error", fault.Error)
+		}
 	}
-	podFault := c.fault
-	podFault.Port = port
 
-	targetAddress, err := utils.PodIP(pod)
-	if err != nil {
-		return VisitCommands{}, err
+	if len(fault.Exclude) > 0 {
+		cmd = append(cmd, "-x", fault.Exclude)
 	}
 
-	return VisitCommands{
-		Exec:    buildHTTPFaultCmd(targetAddress, podFault, c.duration, c.options),
-		Cleanup: buildCleanupCmd(),
-	}, nil
-}
+	for _, rule := range fault.Rules {
+		// a rule only has strings, numbers and slices of strings, so encoding it cannot fail
+		encoded, _ := json.Marshal(rule) //nolint:errchkjson
+		cmd = append(cmd, "--rule", string(encoded))
+	}
 
-// PodGrpcFaultCommand implements the PodVisitCommands interface for injecting GrpcFaults in a Pod
-type PodGrpcFaultCommand struct {
-	fault    GrpcFault
-	duration time.Duration
-	options  GrpcDisruptionOptions
+	if options.ProxyPort != 0 {
+		cmd = append(cmd, "-p", fmt.Sprint(options.ProxyPort))
+	}
+
+	cmd = append(cmd, target...)
+
+	return cmd
 }
 
-// Commands return the command for injecting a GrpcFault in a Pod
-func (c PodGrpcFaultCommand) Commands(pod corev1.Pod) (VisitCommands, error) {
-	if utils.HasHostNetwork(pod) {
-		return VisitCommands{}, fmt.Errorf("fault cannot be safely injected because pod %q uses hostNetwork", pod.Name)
+func buildPostgresFaultCmd(
+	targetAddress string,
+	fault PostgresFault,
+	duration time.Duration,
+	options PostgresDisruptionOptions,
+) []string {
+	cmd := []string{
+		"xk6-disruptor-agent",
+		"postgres",
+		"-d", utils.DurationSeconds(duration),
 	}
 
-	// find the container port for fault injection
-	port, err := utils.FindPort(c.fault.Port, pod)
-	if err != nil {
-		return VisitCommands{}, err
+	target := []string{"--upstream-host", targetAddress}
+	if fault.Upstream != "" {
+		target = []string{"--egress", fault.Upstream}
+	} else {
+		cmd = append(cmd, "-t", fault.Port.Str())
 	}
-	podFault := c.fault
-	podFault.Port = port
 
-	targetAddress, err := utils.PodIP(pod)
-	if err != nil {
-		return VisitCommands{}, err
+	if fault.AverageDelay > 0 {
+		cmd = append(
+			cmd,
+			"-a",
+			utils.DurationMillSeconds(fault.AverageDelay),
+			"-v",
+			utils.DurationMillSeconds(fault.DelayVariation),
+		)
 	}
 
-	return VisitCommands{
-		Exec:    buildGrpcFaultCmd(targetAddress, c.fault, c.duration, c.options),
-		Cleanup: buildCleanupCmd(),
-	}, nil
+	if fault.ErrorRate > 0 {
+		cmd = append(cmd, "-r", fmt.Sprint(fault.ErrorRate), "--error-code", fault.ErrorCode)
+		if fault.ErrorMessage != "" {
+			cmd = append(cmd, "--error-message", fault.ErrorMessage)
+		}
+	}
+
+	if fault.TerminateRate > 0 {
+		cmd = append(cmd, "--terminate-rate", fmt.Sprint(fault.TerminateRate))
+	}
+
+	for _, rule := range fault.Rules {
+		// a rule only has strings, numbers and booleans, so encoding it cannot fail
+		encoded, _ := json.Marshal(rule) //nolint:errchkjson
+		cmd = append(cmd, "--rule", string(encoded))
+	}
+
+	if options.ProxyPort != 0 {
+		cmd = append(cmd, "-p", fmt.Sprint(options.ProxyPort))
+	}
+
+	cmd = append(cmd, target...)
+
+	return cmd
 }
 
-// PodNetworkFaultCommand implements the PodVisitCommands interface for injecting NetworkFaults in a Pod
-type PodNetworkFaultCommand struct {
-	fault    NetworkFault
-	duration time.Duration
+func buildKafkaFaultCmd(
+	targetAddress string,
+	fault KafkaFault,
+	duration time.Duration,
+	options KafkaDisruptionOptions,
+) []string {
+	cmd := []string{
+		"xk6-disruptor-agent",
+		"kafka",
+		"-d", utils.DurationSeconds(duration),
+	}
+
+	target := []string{"--upstream-host", targetAddress}
+	if fault.Upstream != "" {
+		target = []string{"--egress", fault.Upstream}
+	} else {
+		cmd = append(cmd, "-t", fault.Port.Str())
+	}
+
+	if fault.AverageDelay > 0 {
+		cmd = append(
+			cmd,
+			"-a",
+			utils.DurationMillSeconds(fault.AverageDelay),
+			"-v",
+			utils.DurationMillSeconds(fault.DelayVariation),
+		)
+	}
+
+	if fault.ErrorRate > 0 {
+		cmd = append(cmd, "-r", fmt.Sprint(fault.ErrorRate), "--error", fault.Error)
+	}
+
+	for _, rule := range fault.Rules {
+		// a rule only has strings, numbers and slices of strings, so encoding it cannot fail
+		encoded, _ := json.Marshal(rule) //nolint:errchkjson
+		cmd = append(cmd, "--rule", string(encoded))
+	}
+
+	if options.ProxyPort != 0 {
+		cmd = append(cmd, "-p", fmt.Sprint(options.ProxyPort))
+	}
+
+	cmd = append(cmd, target...)
+
+	return cmd
 }
 
-// Commands return the command for injecting a NetworkFault in a Pod
-func (c PodNetworkFaultCommand) Commands(_ corev1.Pod) (VisitCommands, error) {
-	return VisitCommands{
-		Exec:    buildNetworkFaultCmd(c.fault, c.duration),
+func buildDNSFaultCmd(fault DNSFault, duration time.Duration, options DNSDisruptionOptions) []string {
+	cmd := []string{
+		"xk6-disruptor-agent",
+		"dns",
+		"-d", utils.DurationSeconds(duration),
+	}
+
+	if fault.AverageDelay > 0 {
+		cmd = append(
+			cmd,
+			"-a",
+			utils.DurationMillSeconds(fault.AverageDelay),
+			"-v",
+			utils.DurationMillSeconds(fault.DelayVariation),
+		)
+	}
+
+	if fault.ErrorRate > 0 {
+		cmd = append(cmd, "-r", fmt.Sprint(fault.ErrorRate), "--error", fault.Error)
+	}
+
+	for _, address := range fault.Addresses {
+		cmd = append(cmd, "--address", address)
+	}
+
+	for _, rule := range fault.Rules {
+		// a rule only has strings, numbers and slices of strings, so encoding it cannot fail
+		encoded, _ := json.Marshal(rule) //nolint:errchkjson
+		cmd = append(cmd, "--rule", string(encoded))
+	}
+
+	if fault.Upstream != "" {
+		cmd = append(cmd, "--upstream", fault.Upstream)
+	}
+
+	if options.ProxyPort != 0 {
+		cmd = append(cmd, "-p", fmt.Sprint(options.ProxyPort))
+	}
+
+	return cmd
+}
+
+func buildNetworkFaultCmd(fault NetworkFault, duration time.Duration) []string {
+	if fault.emulatesConditions() {
+		return buildNetemCmd(fault, duration)
+	}
+
+	cmd := []string{
+		"xk6-disruptor-agent",
+		"network-drop",
+		"-d", utils.DurationSeconds(duration),
+	}
+
+	if fault.Port != 0 {
+		cmd = append(cmd, "-p", fmt.Sprint(fault.Port))
+	}
+
+	if fault.Protocol != "" {
+		cmd = append(cmd, "-P", fault.Protocol)
+	}
+
+	for _, destination := range fault.Destinations {
+		cmd = append(cmd, "--egress", destination)
+	}
+
+	cmd = append(cmd, buildScheduleArgs(fault.Schedule)...)
+
+	return cmd
+}
+
+func buildNetemCmd(fault NetworkFault, duration time.Duration) []string {
+	cmd := []string{
+		"xk6-disruptor-agent",
+		"netem",
+		"-d", utils.DurationSeconds(duration),
+	}
+
+	if fault.Port != 0 {
+		cmd = append(cmd, "-p", fmt.Sprint(fault.Port))
+	}
+
+	if fault.Protocol != "" {
+		cmd = append(cmd, "-P", fault.Protocol)
+	}
+
+	if fault.Interface != "" {
+		cmd = append(cmd, "-i", fault.Interface)
+	}
+
+	for _, destination := range fault.Destinations {
+		cmd = append(cmd, "--egress", destination)
+	}
+
+	if fault.Delay > 0 {
+		cmd = append(cmd, "--delay", utils.DurationMillSeconds(fault.Delay))
+	}
+
+	if fault.Jitter > 0 {
+		cmd = append(cmd, "--jitter", utils.DurationMillSeconds(fault.Jitter))
+	}
+
+	if fault.LossRate > 0 {
+		cmd = append(cmd, "--loss", fmt.Sprint(fault.LossRate))
+	}
+
+	if fault.Bandwidth != "" {
+		cmd = append(cmd, "--bandwidth", fault.Bandwidth)
+	}
+
+	if fault.ReorderRate > 0 {
+		cmd = append(cmd, "--reorder", fmt.Sprint(fault.ReorderRate))
+	}
+
+	if fault.DuplicateRate > 0 {
+		cmd = append(cmd, "--duplicate", fmt.Sprint(fault.DuplicateRate))
+	}
+
+	if fault.CorruptRate > 0 {
+		cmd = append(cmd, "--corrupt", fmt.Sprint(fault.CorruptRate))
+	}
+
+	cmd = append(cmd, buildScheduleArgs(fault.Schedule)...)
+
+	return cmd
+}
+
+// buildScheduleArgs returns the arguments of the agent commands for the schedule of a fault
+func buildScheduleArgs(schedule FaultSchedule) []string {
+	if schedule.Kind == "" {
+		return nil
+	}
+
+	// a schedule only has strings, numbers and durations, so encoding it cannot fail
+	encoded, _ := json.Marshal(schedule) //nolint:errchkjson
+
+	return []string{"--schedule", string(encoded)}
+}
+
+func buildStressFaultCmd(fault StressFault, container string, duration time.Duration) []string {
+	cmd := []string{
+		"xk6-disruptor-agent",
+		"stress",
+		"-d", utils.DurationSeconds(duration),
+	}
+
+	if fault.Load > 0 {
+		cmd = append(cmd, "-l", fmt.Sprint(fault.Load))
+	}
+
+	// the agent stresses one CPU by default, so the number of CPUs must be explicit when only memory is stressed
+	if fault.CPUs > 0 || fault.Memory != "" {
+		cmd = append(cmd, "-c", fmt.Sprint(fault.CPUs))
+	}
+
+	if fault.Slice > 0 {
+		cmd = append(cmd, "-s", utils.DurationMillSeconds(fault.Slice))
+	}
+
+	if fault.Memory != "" {
+		cmd = append(cmd, "-m", fault.Memory)
+	}
+
+	if fault.MemoryRampUp > 0 {
+		cmd = append(cmd, "--memory-ramp", utils.DurationMillSeconds(fault.MemoryRampUp))
+	}
+
+	if container != "" {
+		cmd = append(cmd, "--target-container", container)
+	}
+
+	return cmd
+}
+
+func buildDiskFaultCmd(fault DiskFault, path string, sizeLimit int64, duration time.Duration) []string {
+	cmd := []string{
+		"xk6-disruptor-agent",
+		"disk",
+		"-d", utils.DurationSeconds(duration),
+	}
+
+	if path != "" {
+		cmd = append(cmd, "-p", path)
+	}
+
+	if fault.Fill > 0 {
+		cmd = append(cmd, "--fill", fmt.Sprint(fault.Fill))
+		if sizeLimit > 0 {
+			cmd = append(cmd, "--size-limit", fmt.Sprint(sizeLimit))
+		}
+		return cmd
+	}
+
+	if fault.Workers > 0 {
+		cmd = append(cmd, "-w", fmt.Sprint(fault.Workers))
+	}
+
+	if fault.BlockSize != "" {
+		cmd = append(cmd, "--block-size", fault.BlockSize)
+	}
+
+	if fault.FileSize != "" {
+		cmd = append(cmd, "--file-size", fault.FileSize)
+	}
+
+	return cmd
+}
+
+func buildCleanupCmd() []string {
+	return []string{"xk6-disruptor-agent", "cleanup"}
+}
+
+// PodHTTPFaultCommand implements the PodVisitCommands interface for injecting
+// HttpFaults in a Pod
+type PodHTTPFaultCommand struct {
+	fault    HTTPFault
+	duration time.Duration
+	options  HTTPDisruptionOptions
+	// PEM encoded TLS credentials sent to the agent, if TLS is enabled in the options
+	credentials []byte
+}
+
+// Commands return the command for injecting a HttpFault in a Pod
+func (c PodHTTPFaultCommand) Commands(pod corev1.Pod) (VisitCommands, error) {
+	if utils.HasHostNetwork(pod) {
+		return VisitCommands{}, fmt.Errorf("fault cannot be safely injected because pod %q uses hostNetwork", pod.Name)
+	}
+
+	// outbound requests are sent to the upstream, not to the pod
+	if c.fault.Upstream != "" {
+		options := c.options
+		options.TLS = options.TLS.withHosts(upstreamHost(c.fault.Upstream))
+
+		return VisitCommands{
+			Exec:    buildHTTPFaultCmd("", c.fault, c.duration, options),
+			Cleanup: buildCleanupCmd(),
+			Stdin:   c.credentials,
+		}, nil
+	}
+
+	// find the container port for fault injection
+	port, err := utils.FindPort(c.fault.Port, pod)
+	if err != nil {
+		return VisitCommands{}, err
+	}
+	podFault := c.fault
+	podFault.Port = port
+
+	targetAddress, err := utils.PodIP(pod)
+	if err != nil {
+		return VisitCommands{}, err
+	}
+
+	options := c.options
+	options.TLS = options.TLS.withHosts(targetAddress)
+
+	return VisitCommands{
+		Exec:    buildHTTPFaultCmd(targetAddress, podFault, c.duration, options),
+		Cleanup: buildCleanupCmd(),
+		Stdin:   c.credentials,
+	}, nil
+}
+
+// PodGrpcFaultCommand implements the PodVisitCommands interface for injecting GrpcFaults in a Pod
+type PodGrpcFaultCommand struct {
+	fault    GrpcFault
+	duration time.Duration
+	options  GrpcDisruptionOptions
+}
+
+// Commands return the command for injecting a GrpcFault in a Pod
+func (c PodGrpcFaultCommand) Commands(pod corev1.Pod) (VisitCommands, error) {
+	if utils.HasHostNetwork(pod) {
+		return VisitCommands{}, fmt.Errorf("fault cannot be safely injected because pod %q uses hostNetwork", pod.Name)
+	}
+
+	// outbound requests are sent to the upstream, not to the pod
+	if c.fault.Upstream != "" {
+		return VisitCommands{
+			Exec:    buildGrpcFaultCmd("", c.fault, c.duration, c.options),
+			Cleanup: buildCleanupCmd(),
+		}, nil
+	}
+
+	// find the container port for fault injection
+	port, err := utils.FindPort(c.fault.Port, pod)
+	if err != nil {
+		return VisitCommands{}, err
+	}
+	podFault := c.fault
+	podFault.Port = port
+
+	targetAddress, err := utils.PodIP(pod)
+	if err != nil {
+		return VisitCommands{}, err
+	}
+
+	return VisitCommands{
+		Exec:    buildGrpcFaultCmd(targetAddress, c.fault, c.duration, c.options),
+		Cleanup: buildCleanupCmd(),
+	}, nil
+}
+
+// PodTCPFaultCommand implements the PodVisitCommands interface for injecting TCPFaults in a Pod
+type PodTCPFaultCommand struct {
+	fault    TCPFault
+	duration time.Duration
+	options  TCPDisruptionOptions
+}
+
+// Commands return the command for injecting a TCPFault in a Pod
+func (c PodTCPFaultCommand) Commands(pod corev1.Pod) (VisitCommands, error) {
+	if utils.HasHostNetwork(pod) {
+		return VisitCommands{}, fmt.Errorf("fault cannot be safely injected because pod %q uses hostNetwork", pod.Name)
+	}
+
+	// outbound connections are opened to the upstream, not to the pod
+	if c.fault.Upstream != "" {
+		return VisitCommands{
+			Exec:    buildTCPFaultCmd("", c.fault, c.duration, c.options),
+			Cleanup: buildCleanupCmd(),
+		}, nil
+	}
+
+	// find the container port for fault injection
+	port, err := utils.FindPort(c.fault.Port, pod)
+	if err != nil {
+		return VisitCommands{}, err
+	}
+	podFault := c.fault
+	podFault.Port = port
+
+	targetAddress, err := utils.PodIP(pod)
+	if err != nil {
+		return VisitCommands{}, err
+	}
+
+	return VisitCommands{
+		Exec:    buildTCPFaultCmd(targetAddress, podFault, c.duration, c.options),
+		Cleanup: buildCleanupCmd(),
+	}, nil
+}
+
+// PodRedisFaultCommand implements the PodVisitCommands interface for injecting RedisFaults in a Pod
+type PodRedisFaultCommand struct {
+	fault    RedisFault
+	duration time.Duration
+	options  RedisDisruptionOptions
+}
+
+// Commands return the command for injecting a RedisFault in a Pod
+func (c PodRedisFaultCommand) Commands(pod corev1.Pod) (VisitCommands, error) {
+	if utils.HasHostNetwork(pod) {
+		return VisitCommands{}, fmt.Errorf("fault cannot be safely injected because pod %q uses hostNetwork", pod.Name)
+	}
+
+	// outbound commands are sent to the upstream, not to the pod
+	if c.fault.Upstream != "" {
+		return VisitCommands{
+			Exec:    buildRedisFaultCmd("", c.fault, c.duration, c.options),
+			Cleanup: buildCleanupCmd(),
+		}, nil
+	}
+
+	// find the container port for fault injection
+	port, err := utils.FindPort(c.fault.Port, pod)
+	if err != nil {
+		return VisitCommands{}, err
+	}
+	podFault := c.fault
+	podFault.Port = port
+
+	targetAddress, err := utils.PodIP(pod)
+	if err != nil {
+		return VisitCommands{}, err
+	}
+
+	return VisitCommands{
+		Exec:    buildRedisFaultCmd(targetAddress, podFault, c.duration, c.options),
+		Cleanup: buildCleanupCmd(),
+	}, nil
+}
+
+// PodPostgresFaultCommand implements the PodVisitCommands interface for injecting PostgresFaults in a Pod
+type PodPostgresFaultCommand struct {
+	fault    PostgresFault
+	duration time.Duration
+	options  PostgresDisruptionOptions
+}
+
+// Commands return the command for injecting a PostgresFault in a Pod
+func (c PodPostgresFaultCommand) Commands(pod corev1.Pod) (VisitCommands, error) {
+	if utils.HasHostNetwork(pod) {
+		return VisitCommands{}, fmt.Errorf("fault cannot be safely injected because pod %q uses hostNetwork", pod.Name)
+	}
+
+	// outbound queries are sent to the upstream, not to the pod
+	if c.fault.Upstream != "" {
+		return VisitCommands{
+			Exec:    buildPostgresFaultCmd("", c.fault, c.duration, c.options),
+			Cleanup: buildCleanupCmd(),
+		}, nil
+	}
+
+	// find the container port for fault injection
+	port, err := utils.FindPort(c.fault.Port, pod)
+	if err != nil {
+		return VisitCommands{}, err
+	}
+	podFault := c.fault
+	podFault.Port = port
+
+	targetAddress, err := utils.PodIP(pod)
+	if err != nil {
+		return VisitCommands{}, err
+	}
+
+	return VisitCommands{
+		Exec:    buildPostgresFaultCmd(targetAddress, podFault, c.duration, c.options),
+		Cleanup: buildCleanupCmd(),
+	}, nil
+}
+
+// PodKafkaFaultCommand implements the PodVisitCommands interface for injecting KafkaFaults in a Pod
+type PodKafkaFaultCommand struct {
+	fault    KafkaFault
+	duration time.Duration
+	options  KafkaDisruptionOptions
+}
+
+// Commands return the command for injecting a KafkaFault in a Pod
+func (c PodKafkaFaultCommand) Commands(pod corev1.Pod) (VisitCommands, error) {
+	if utils.HasHostNetwork(pod) {
+		return VisitCommands{}, fmt.Errorf("fault cannot be safely injected because pod %q uses hostNetwork", pod.Name)
+	}
+
+	// outbound requests are sent to the upstream, not to the pod
+	if c.fault.Upstream != "" {
+		return VisitCommands{
+			Exec:    buildKafkaFaultCmd("", c.fault, c.duration, c.options),
+			Cleanup: buildCleanupCmd(),
+		}, nil
+	}
+
+	// find the container port for fault injection
+	port, err := utils.FindPort(c.fault.Port, pod)
+	if err != nil {
+		return VisitCommands{}, err
+	}
+	podFault := c.fault
+	podFault.Port = port
+
+	targetAddress, err := utils.PodIP(pod)
+	if err != nil {
+		return VisitCommands{}, err
+	}
+
+	return VisitCommands{
+		Exec:    buildKafkaFaultCmd(targetAddress, podFault, c.duration, c.options),
+		Cleanup: buildCleanupCmd(),
+	}, nil
+}
+
+// PodDNSFaultCommand implements the PodVisitCommands interface for injecting DNSFaults in a Pod
+type PodDNSFaultCommand struct {
+	fault    DNSFault
+	duration time.Duration
+	options  DNSDisruptionOptions
+}
+
+// Commands return the command for injecting a DNSFault in a Pod
+func (c PodDNSFaultCommand) Commands(pod corev1.Pod) (VisitCommands, error) {
+	// the queries of a pod that uses hostNetwork cannot be redirected without affecting the node
+	if utils.HasHostNetwork(pod) {
+		return VisitCommands{}, fmt.Errorf("fault cannot be safely injected because pod %q uses hostNetwork", pod.Name)
+	}
+
+	return VisitCommands{
+		Exec:    buildDNSFaultCmd(c.fault, c.duration, c.options),
+		Cleanup: buildCleanupCmd(),
+	}, nil
+}
+
+// PodNetworkFaultCommand implements the PodVisitCommands interface for injecting NetworkFaults in a Pod
+type PodNetworkFaultCommand struct {
+	fault    NetworkFault
+	duration time.Duration
+}
+
+// Commands return the command for injecting a NetworkFault in a Pod
+func (c PodNetworkFaultCommand) Commands(_ corev1.Pod) (VisitCommands, error) {
+	return VisitCommands{
+		Exec:    buildNetworkFaultCmd(c.fault, c.duration),
+		Cleanup: buildCleanupCmd(),
+	}, nil
+}
+
+// PodStressFaultCommand implements the PodVisitCommand interface for injecting StressFaults in a Pod
+type PodStressFaultCommand struct {
+	fault    StressFault
+	duration time.Duration
+}
+
+// Commands return the command for injecting a StressFault in a Pod
+func (c PodStressFaultCommand) Commands(pod corev1.Pod) (VisitCommands, error) {
+	// the memory is accounted to the container targeted by the agent
+	container := ""
+	if c.fault.Memory != "" {
+		target := agentTargetContainer(pod)
+		if target == "" {
+			return VisitCommands{}, fmt.Errorf(
+				"the agent was injected in pod %q without a target container. Recreate the pod to stress its memory",
+				pod.Name,
+			)
+		}
+
+		var err error
+		container, err = containerID(pod, target)
+		if err != nil {
+			return VisitCommands{}, err
+		}
+	}
+
+	return VisitCommands{
+		Exec:    buildStressFaultCmd(c.fault, container, c.duration),
+		Cleanup: buildCleanupCmd(),
+	}, nil
+}
+
+// PodDiskFaultCommand implements the PodVisitCommand interface for injecting DiskFaults in a Pod
+type PodDiskFaultCommand struct {
+	fault    DiskFault
+	duration time.Duration
+}
+
+// Commands return the command for injecting a DiskFault in a Pod
+func (c PodDiskFaultCommand) Commands(pod corev1.Pod) (VisitCommands, error) {
+	if c.fault.Volume == "" {
+		// the filesystem of the agent's container is backed by the node's disk
+		if c.fault.Fill > 0 {
+			return VisitCommands{}, fmt.Errorf("filling the disk requires a volume of pod %q", pod.Name)
+		}
+
+		return VisitCommands{
+			Exec:    buildDiskFaultCmd(c.fault, c.fault.Path, 0, c.duration),
+			Cleanup: buildCleanupCmd(),
+		}, nil
+	}
+
+	volume, found := findVolume(pod, c.fault.Volume)
+	if !found || !isWritableVolume(volume) {
+		return VisitCommands{}, fmt.Errorf("pod %q does not have a writable volume %q", pod.Name, c.fault.Volume)
+	}
+
+	if c.fault.Path != "" && !filepath.IsLocal(c.fault.Path) {
+		return VisitCommands{}, fmt.Errorf("path %q must be a relative path within volume %q", c.fault.Path, volume.Name)
+	}
+
+	if !agentMountsVolume(pod, volume.Name) {
+		return VisitCommands{}, fmt.Errorf(
+			"the agent was injected in pod %q before volume %q could be mounted in it. Recreate the pod to stress it",
+			pod.Name,
+			volume.Name,
+		)
+	}
+
+	sizeLimit := int64(0)
+	if c.fault.Fill > 0 {
+		var err error
+		sizeLimit, err = fillSizeLimit(volume, c.fault.Path)
+		if err != nil {
+			return VisitCommands{}, err
+		}
+	}
+
+	return VisitCommands{
+		Exec:    buildDiskFaultCmd(c.fault, path.Join(agentVolumePath(volume.Name), c.fault.Path), sizeLimit, c.duration),
+		Cleanup: buildCleanupCmd(),
+	}, nil
+}
+
+// findVolume returns the volume of the pod with the given name
+func findVolume(pod corev1.Pod, name string) (corev1.Volume, bool) {
+	for _, volume := range pod.Spec.Volumes {
+		if volume.Name == name {
+			return volume, true
+		}
+	}
+
+	return corev1.Volume{}, false
+}
+
+// containerID returns the ID of a container of the pod, without the prefix of the container runtime
+// (e.g. "containerd://")
+func containerID(pod corev1.Pod, name string) (string, error) {
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.Name != name || status.ContainerID == "" {
+			continue
+		}
+
+		_, id, found := strings.Cut(status.ContainerID, "://")
+		if !found {
+			return status.ContainerID, nil
+		}
+
+		return id, nil
+	}
+
+	return "", fmt.Errorf("container %q of pod %q is not running", name, pod.Name)
+}
+
+// agentMountsVolume returns if the agent container mounts the volume. The mounts of the agent are set when it is
+// injected for the first time and cannot be changed, so an agent injected by a previous version of the disruptor may
+// not mount the volume. If the agent has not been injected yet, it will mount the volume.
+func agentMountsVolume(pod corev1.Pod, name string) bool {
+	for _, container := range pod.Spec.EphemeralContainers {
+		if container.Name != agentContainerName {
+			continue
+		}
+
+		return slices.ContainsFunc(container.VolumeMounts, func(mount corev1.VolumeMount) bool {
+			return mount.Name == name && mount.MountPath == agentVolumePath(name)
+		})
+	}
+
+	return true
+}
+
+// fillSizeLimit returns the size limit for filling a volume, if it shares the filesystem of the node. Filling the
+// filesystem of such a volume would fill the node's disk, so they can only be filled up to their size limit.
+func fillSizeLimit(volume corev1.Volume, path string) (int64, error) {
+	switch {
+	case volume.HostPath != nil:
+		return 0, fmt.Errorf("hostPath volume %q cannot be filled, as it is backed by the node's disk", volume.Name)
+	case volume.EmptyDir != nil:
+		if volume.EmptyDir.SizeLimit == nil || volume.EmptyDir.SizeLimit.Value() <= 0 {
+			return 0, fmt.Errorf("emptyDir volume %q cannot be filled without a size limit,"+
+				" as it is backed by the node's disk", volume.Name)
+		}
+
+		// the usage is computed from the files in the path, so it must include the whole volume
+		if path != "" {
+			return 0, fmt.Errorf("emptyDir volume %q can only be filled from its root path", volume.Name)
+		}
+
+		return volume.EmptyDir.SizeLimit.Value(), nil
+	default:
+		return 0, nil
+	}
+}
+
+// NodeNetworkFaultCommand implements the NodeVisitCommand interface for injecting NetworkFaults in a Node
+type NodeNetworkFaultCommand struct {
+	fault    NetworkFault
+	duration time.Duration
+}
+
+// Commands return the command for injecting a NetworkFault in a Node
+func (c NodeNetworkFaultCommand) Commands(_ corev1.Node) (VisitCommands, error) {
+	return VisitCommands{
+		Exec:    buildNetworkFaultCmd(c.fault, c.duration),
+		Cleanup: buildCleanupCmd(),
+	}, nil
+}
+
+// NodeStressFaultCommand implements the NodeVisitCommand interface for injecting StressFaults in a Node
+type NodeStressFaultCommand struct {
+	fault    StressFault
+	duration time.Duration
+}
+
+// Commands return the command for injecting a StressFault in a Node
+func (c NodeStressFaultCommand) Commands(_ corev1.Node) (VisitCommands, error) {
+	return VisitCommands{
+		Exec:    buildStressFaultCmd(c.fault, "", c.duration),
 		Cleanup: buildCleanupCmd(),
 	}, nil
 }
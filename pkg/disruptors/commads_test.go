@@ -10,6 +10,7 @@ import (
 	"github.com/grafana/xk6-disruptor/pkg/types/intstr"
 
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 )
 
 func buildPodWithPort(name string, portName string, port int32) corev1.Pod {
@@ -53,6 +54,186 @@ func Test_PodHTTPFaultCommandGenerator(t *testing.T) {
 			expectError: false,
 			cmdError:    nil,
 		},
+		{
+			title:  "Test egress upstream",
+			target: buildPodWithPort("my-app-pod", "http", 80),
+			fault: HTTPFault{
+				ErrorRate: 0.1,
+				ErrorCode: 503,
+				Upstream:  "payments.default.svc:8080",
+			},
+			opts:        HTTPDisruptionOptions{},
+			duration:    60 * time.Second,
+			expectedCmd: "xk6-disruptor-agent http -d 60s -r 0.1 -e 503 --egress payments.default.svc:8080",
+			expectError: false,
+			cmdError:    nil,
+		},
+		{
+			title:  "Test rules",
+			target: buildPodWithPort("my-app-pod", "http", 80),
+			fault: HTTPFault{
+				Port: intstr.FromInt32(80),
+				Rules: []HTTPRule{
+					{
+						Match:     HTTPMatch{PathPrefix: "/api/", Methods: []string{"POST"}},
+						ErrorRate: 1.0,
+						ErrorCode: 503,
+					},
+					{
+						Match:        HTTPMatch{Headers: map[string]string{"X-Canary": ""}},
+						AverageDelay: 100 * time.Millisecond,
+					},
+				},
+			},
+			opts:     HTTPDisruptionOptions{},
+			duration: 60 * time.Second,
+			expectedCmd: "xk6-disruptor-agent http -d 60s -t 80" +
+				` --rule {"match":{"pathPrefix":"/api/","methods":["POST"]},"errorRate":1,"errorCode":503}` +
+				` --rule {"match":{"headers":{"X-Canary":""}},"averageDelay":100000000}` +
+				" --upstream-host 192.0.2.6",
+			expectError: false,
+			cmdError:    nil,
+		},
+		{
+			title:  "Test schedule",
+			target: buildPodWithPort("my-app-pod", "http", 80),
+			fault: HTTPFault{
+				Port:         intstr.FromInt32(80),
+				AverageDelay: 500 * time.Millisecond,
+				Schedule:     FaultSchedule{Kind: "ramp", From: 0.1, To: 1, Duration: time.Minute},
+			},
+			opts:     HTTPDisruptionOptions{},
+			duration: 60 * time.Second,
+			expectedCmd: "xk6-disruptor-agent http -d 60s -t 80 -a 500ms -v 0ms" +
+				` --schedule {"kind":"ramp","from":0.1,"to":1,"duration":60000000000}` +
+				" --upstream-host 192.0.2.6",
+			expectError: false,
+			cmdError:    nil,
+		},
+		{
+			title:  "Test response fault",
+			target: buildPodWithPort("my-app-pod", "http", 80),
+			fault: HTTPFault{
+				Port: intstr.FromInt32(80),
+				HTTPResponseFault: HTTPResponseFault{
+					SetHeaders:     map[string]string{"X-Version": "2", "Cache-Control": "no-cache"},
+					RemoveHeaders:  []string{"ETag", "Vary"},
+					ResponseStatus: 202,
+					TruncateBody:   0.5,
+					CorruptBody:    0.1,
+					MalformedJSON:  true,
+				},
+				Rules: []HTTPRule{
+					{
+						Match:             HTTPMatch{PathPrefix: "/api/"},
+						HTTPResponseFault: HTTPResponseFault{MalformedJSON: true},
+					},
+				},
+			},
+			opts:     HTTPDisruptionOptions{},
+			duration: 60 * time.Second,
+			expectedCmd: "xk6-disruptor-agent http -d 60s -t 80" +
+				" --set-header Cache-Control=no-cache --set-header X-Version=2 --remove-header ETag,Vary" +
+				" --response-status 202 --truncate-body 0.5 --corrupt-body 0.1 --malformed-json" +
+				` --rule {"match":{"pathPrefix":"/api/"},"malformedJson":true}` +
+				" --upstream-host 192.0.2.6",
+			expectError: false,
+			cmdError:    nil,
+		},
+		{
+			title:  "Test throttling fault",
+			target: buildPodWithPort("my-app-pod", "http", 80),
+			fault: HTTPFault{
+				Port:         intstr.FromInt32(80),
+				AverageDelay: 100 * time.Millisecond,
+				HTTPThrottlingFault: HTTPThrottlingFault{
+					BodyRate:       1024,
+					FirstByteDelay: 500 * time.Millisecond,
+					StallAfter:     4096,
+					StallDuration:  10 * time.Second,
+				},
+			},
+			opts:     HTTPDisruptionOptions{},
+			duration: 60 * time.Second,
+			expectedCmd: "xk6-disruptor-agent http -d 60s -t 80 -a 100ms -v 0ms" +
+				" --body-rate 1024 --first-byte-delay 500ms --stall-after 4096 --stall-duration 10000ms" +
+				" --upstream-host 192.0.2.6",
+			expectError: false,
+			cmdError:    nil,
+		},
+		{
+			title:  "Test stream fault",
+			target: buildPodWithPort("my-app-pod", "http", 80),
+			fault: HTTPFault{
+				Port: intstr.FromInt32(80),
+				HTTPStreamFault: HTTPStreamFault{
+					FrameDelay:      100 * time.Millisecond,
+					DropAfterFrames: 10,
+					DropAfter:       5 * time.Second,
+					CloseCode:       1001,
+				},
+				Rules: []HTTPRule{
+					{
+						Match:           HTTPMatch{PathPrefix: "/events"},
+						HTTPStreamFault: HTTPStreamFault{DropAfterFrames: 2},
+					},
+				},
+			},
+			opts:     HTTPDisruptionOptions{},
+			duration: 60 * time.Second,
+			expectedCmd: "xk6-disruptor-agent http -d 60s -t 80" +
+				" --frame-delay 100ms --drop-after-frames 10 --drop-after 5000ms --close-code 1001" +
+				` --rule {"match":{"pathPrefix":"/events"},"dropAfterFrames":2}` +
+				" --upstream-host 192.0.2.6",
+			expectError: false,
+			cmdError:    nil,
+		},
+		{
+			title:  "Test TLS secret",
+			target: buildPodWithPort("my-app-pod", "https", 443),
+			fault: HTTPFault{
+				ErrorRate: 0.1,
+				ErrorCode: 500,
+				Port:      intstr.FromInt32(443),
+			},
+			opts:        HTTPDisruptionOptions{TLS: HTTPTLSOptions{Secret: "app-tls"}},
+			duration:    60 * time.Second,
+			expectedCmd: "xk6-disruptor-agent http -d 60s -t 443 -r 0.1 -e 500 --tls --upstream-host 192.0.2.6",
+			expectError: false,
+			cmdError:    nil,
+		},
+		{
+			title:  "Test TLS CA secret",
+			target: buildPodWithPort("my-app-pod", "https", 443),
+			fault: HTTPFault{
+				ErrorRate: 0.1,
+				ErrorCode: 500,
+				Port:      intstr.FromInt32(443),
+			},
+			opts: HTTPDisruptionOptions{
+				TLS: HTTPTLSOptions{CASecret: "ca", Hosts: []string{"app.example.com"}},
+			},
+			duration: 60 * time.Second,
+			expectedCmd: "xk6-disruptor-agent http -d 60s -t 443 -r 0.1 -e 500" +
+				" --tls --tls-generate --tls-host app.example.com,192.0.2.6 --upstream-host 192.0.2.6",
+			expectError: false,
+			cmdError:    nil,
+		},
+		{
+			title:  "Test TLS CA secret in egress upstream",
+			target: buildPodWithPort("my-app-pod", "http", 80),
+			fault: HTTPFault{
+				ErrorRate: 0.1,
+				ErrorCode: 500,
+				Upstream:  "payments.default.svc:443",
+			},
+			opts:     HTTPDisruptionOptions{TLS: HTTPTLSOptions{CASecret: "ca"}},
+			duration: 60 * time.Second,
+			expectedCmd: "xk6-disruptor-agent http -d 60s -r 0.1 -e 500" +
+				" --tls --tls-generate --tls-host payments.default.svc --egress payments.default.svc:443",
+			expectError: false,
+			cmdError:    nil,
+		},
 		{
 			title:  "Test error 500 with error body",
 			target: buildPodWithPort("my-app-pod", "http", 80),
@@ -204,6 +385,64 @@ func Test_PodGrpcPFaultCommandGenerator(t *testing.T) {
 			expectError: false,
 			cmdError:    nil,
 		},
+		{
+			title:  "Test egress upstream",
+			target: buildPodWithPort("my-app-pod", "http", 80),
+			fault: GrpcFault{
+				ErrorRate:  0.1,
+				StatusCode: 14,
+				Upstream:   "inventory:9090",
+			},
+			opts:        GrpcDisruptionOptions{},
+			duration:    60 * time.Second,
+			expectedCmd: "xk6-disruptor-agent grpc -d 60s -r 0.1 -s 14 --egress inventory:9090",
+			expectError: false,
+			cmdError:    nil,
+		},
+		{
+			title:  "Test rules",
+			target: buildPodWithPort("my-app-pod", "grpc", 3000),
+			fault: GrpcFault{
+				Port: intstr.FromInt32(3000),
+				Rules: []GrpcRule{
+					{
+						Match: GrpcMatch{
+							Methods:        []string{"/pkg.Service/*"},
+							ExcludeMethods: []string{"/pkg.Service/Health"},
+						},
+						ErrorRate:  1.0,
+						StatusCode: 14,
+					},
+				},
+			},
+			opts:     GrpcDisruptionOptions{},
+			duration: 60 * time.Second,
+			expectedCmd: "xk6-disruptor-agent grpc -d 60s -t 3000" +
+				` --rule {"match":{"methods":["/pkg.Service/*"],"excludeMethods":["/pkg.Service/Health"]},` +
+				`"errorRate":1,"statusCode":14}` +
+				" --upstream-host 192.0.2.6",
+			expectError: false,
+			cmdError:    nil,
+		},
+		{
+			title:  "Test stream fault",
+			target: buildPodWithPort("my-app-pod", "grpc", 3000),
+			fault: GrpcFault{
+				Port:       intstr.FromInt32(3000),
+				StatusCode: 14,
+				GrpcStreamFault: GrpcStreamFault{
+					MessageDelay:       50 * time.Millisecond,
+					MessageDropRate:    0.1,
+					AbortAfterMessages: 10,
+				},
+			},
+			opts:     GrpcDisruptionOptions{},
+			duration: 60 * time.Second,
+			expectedCmd: "xk6-disruptor-agent grpc -d 60s -t 3000 --message-delay 50ms --message-drop-rate 0.1" +
+				" --abort-after-messages 10 -s 14 --upstream-host 192.0.2.6",
+			expectError: false,
+			cmdError:    nil,
+		},
 		{
 			title:  "Test error with status message",
 			target: buildPodWithPort("my-app-pod", "grpc", 3000),
@@ -283,3 +522,1027 @@ func Test_PodGrpcPFaultCommandGenerator(t *testing.T) {
 		})
 	}
 }
+
+func Test_PodTCPFaultCommandGenerator(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		title       string
+		target      corev1.Pod
+		fault       TCPFault
+		opts        TCPDisruptionOptions
+		duration    time.Duration
+		expectedCmd string
+		expectError bool
+	}{
+		{
+			title:  "Test connection faults",
+			target: buildPodWithPort("my-app-pod", "redis", 6379),
+			fault: TCPFault{
+				Port:            intstr.FromInt32(6379),
+				ConnectDelay:    100 * time.Millisecond,
+				ChunkDelay:      10 * time.Millisecond,
+				Bandwidth:       1024,
+				HalfOpenRate:    0.1,
+				ResetAfterBytes: 4096,
+			},
+			opts:     TCPDisruptionOptions{},
+			duration: 60 * time.Second,
+			expectedCmd: "xk6-disruptor-agent tcp -d 60s -t 6379 --connect-delay 100ms --chunk-delay 10ms" +
+				" --bandwidth 1024 --half-open-rate 0.1 --reset-after-bytes 4096 --upstream-host 192.0.2.6",
+			expectError: false,
+		},
+		{
+			title:  "Test named port and proxy port",
+			target: buildPodWithPort("my-app-pod", "redis", 6379),
+			fault: TCPFault{
+				Port:         intstr.FromString("redis"),
+				ConnectDelay: time.Second,
+			},
+			opts:        TCPDisruptionOptions{ProxyPort: 9000},
+			duration:    60 * time.Second,
+			expectedCmd: "xk6-disruptor-agent tcp -d 60s -t 6379 --connect-delay 1000ms -p 9000 --upstream-host 192.0.2.6",
+			expectError: false,
+		},
+		{
+			title:  "Test egress upstream",
+			target: buildPodWithPort("my-app-pod", "http", 80),
+			fault: TCPFault{
+				Upstream:        "redis:6379",
+				ResetAfterBytes: 100,
+			},
+			opts:        TCPDisruptionOptions{},
+			duration:    60 * time.Second,
+			expectedCmd: "xk6-disruptor-agent tcp -d 60s --reset-after-bytes 100 --egress redis:6379",
+			expectError: false,
+		},
+		{
+			title:  "Test unknown port",
+			target: buildPodWithPort("my-app-pod", "http", 80),
+			fault: TCPFault{
+				Port:         intstr.FromInt32(6379),
+				ConnectDelay: time.Second,
+			},
+			opts:        TCPDisruptionOptions{},
+			duration:    60 * time.Second,
+			expectedCmd: "",
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			t.Parallel()
+
+			cmd := PodTCPFaultCommand{
+				fault:    tc.fault,
+				duration: tc.duration,
+				options:  tc.opts,
+			}
+
+			cmds, err := cmd.Commands(tc.target)
+
+			if tc.expectError && err == nil {
+				t.Errorf("should had failed")
+				return
+			}
+
+			if !tc.expectError && err != nil {
+				t.Errorf("unexpected error : %v", err)
+				return
+			}
+
+			if tc.expectError {
+				return
+			}
+
+			if !command.AssertCmdEquals(strings.Join(cmds.Exec, " "), tc.expectedCmd) {
+				t.Errorf("expected command: %s got: %s", tc.expectedCmd, cmds.Exec)
+			}
+		})
+	}
+}
+
+func Test_PodRedisFaultCommandGenerator(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		title       string
+		target      corev1.Pod
+		fault       RedisFault
+		opts        RedisDisruptionOptions
+		duration    time.Duration
+		expectedCmd string
+		expectError bool
+	}{
+		{
+			title:  "Test error and delay",
+			target: buildPodWithPort("my-app-pod", "redis", 6379),
+			fault: RedisFault{
+				Port:           intstr.FromInt32(6379),
+				AverageDelay:   100 * time.Millisecond,
+				DelayVariation: 10 * time.Millisecond,
+				ErrorRate:      0.1,
+				Error:          "LOADING loading",
+				Exclude:        "PING,INFO",
+			},
+			opts:     RedisDisruptionOptions{},
+			duration: 60 * time.Second,
+			expectedCmd: "xk6-disruptor-agent redis -d 60s -t 6379 -a 100ms -v 10ms -r 0.1 --error LOADING loading" +
+				" -x PING,INFO --upstream-host 192.0.2.6",
+			expectError: false,
+		},
+		{
+			title:  "Test rule and proxy port",
+			target: buildPodWithPort("my-app-pod", "redis", 6379),
+			fault: RedisFault{
+				Port: intstr.FromString("redis"),
+				Rules: []RedisRule{
+					{
+						Match:     RedisMatch{Commands: []string{"GET"}, Keys: []string{"user:*"}},
+						ErrorRate: 1.0,
+					},
+				},
+			},
+			opts:     RedisDisruptionOptions{ProxyPort: 9000},
+			duration: 60 * time.Second,
+			expectedCmd: `xk6-disruptor-agent redis -d 60s -t 6379` +
+				` --rule {"match":{"commands":["GET"],"keys":["user:*"]},"errorRate":1} -p 9000 --upstream-host 192.0.2.6`,
+			expectError: false,
+		},
+		{
+			title:  "Test egress upstream",
+			target: buildPodWithPort("my-app-pod", "http", 80),
+			fault: RedisFault{
+				Upstream:  "redis:6379",
+				ErrorRate: 1.0,
+			},
+			opts:        RedisDisruptionOptions{},
+			duration:    60 * time.Second,
+			expectedCmd: "xk6-disruptor-agent redis -d 60s -r 1 --egress redis:6379",
+			expectError: false,
+		},
+		{
+			title:  "Test unknown port",
+			target: buildPodWithPort("my-app-pod", "http", 80),
+			fault: RedisFault{
+				Port:      intstr.FromInt32(6379),
+				ErrorRate: 1.0,
+			},
+			opts:        RedisDisruptionOptions{},
+			duration:    60 * time.Second,
+			expectedCmd: "",
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			t.Parallel()
+
+			cmd := PodRedisFaultCommand{
+				fault:    tc.fault,
+				duration: tc.duration,
+				options:  tc.opts,
+			}
+
+			cmds, err := cmd.Commands(tc.target)
+
+			if tc.expectError && err == nil {
+				t.Errorf("should had failed")
+				return
+			}
+
+			if !tc.expectError && err != nil {
+				t.Errorf("unexpected error : %v", err)
+				return
+			}
+
+			if tc.expectError {
+				return
+			}
+
+			if !command.AssertCmdEquals(strings.Join(cmds.Exec, " "), tc.expectedCmd) {
+				t.Errorf("expected command: %s got: %s", tc.expectedCmd, cmds.Exec)
+			}
+		})
+	}
+}
+
+func Test_PodPostgresFaultCommandGenerator(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		title       string
+		target      corev1.Pod
+		fault       PostgresFault
+		opts        PostgresDisruptionOptions
+		duration    time.Duration
+		expectedCmd string
+		expectError bool
+	}{
+		{
+			title:  "Test error, delay and termination",
+			target: buildPodWithPort("my-app-pod", "postgres", 5432),
+			fault: PostgresFault{
+				Port:           intstr.FromInt32(5432),
+				AverageDelay:   100 * time.Millisecond,
+				DelayVariation: 10 * time.Millisecond,
+				ErrorRate:      0.1,
+				ErrorCode:      "40001",
+				ErrorMessage:   "serialization_failure",
+				TerminateRate:  0.05,
+			},
+			opts:     PostgresDisruptionOptions{},
+			duration: 60 * time.Second,
+			expectedCmd: "xk6-disruptor-agent postgres -d 60s -t 5432 -a 100ms -v 10ms -r 0.1 --error-code 40001" +
+				" --error-message serialization_failure --terminate-rate 0.05 --upstream-host 192.0.2.6",
+			expectError: false,
+		},
+		{
+			title:  "Test rule and proxy port",
+			target: buildPodWithPort("my-app-pod", "postgres", 5432),
+			fault: PostgresFault{
+				Port: intstr.FromString("postgres"),
+				Rules: []PostgresRule{
+					{
+						Match:         PostgresMatch{Query: "^UPDATE", InTransaction: true},
+						TerminateRate: 1.0,
+					},
+				},
+			},
+			opts:     PostgresDisruptionOptions{ProxyPort: 9000},
+			duration: 60 * time.Second,
+			expectedCmd: `xk6-disruptor-agent postgres -d 60s -t 5432` +
+				` --rule {"match":{"query":"^UPDATE","inTransaction":true},"terminateRate":1} -p 9000` +
+				` --upstream-host 192.0.2.6`,
+			expectError: false,
+		},
+		{
+			title:  "Test egress upstream",
+			target: buildPodWithPort("my-app-pod", "http", 80),
+			fault: PostgresFault{
+				Upstream:  "postgres:5432",
+				ErrorRate: 1.0,
+				ErrorCode: "53300",
+			},
+			opts:        PostgresDisruptionOptions{},
+			duration:    60 * time.Second,
+			expectedCmd: "xk6-disruptor-agent postgres -d 60s -r 1 --error-code 53300 --egress postgres:5432",
+			expectError: false,
+		},
+		{
+			title:  "Test unknown port",
+			target: buildPodWithPort("my-app-pod", "http", 80),
+			fault: PostgresFault{
+				Port:          intstr.FromInt32(5432),
+				TerminateRate: 1.0,
+			},
+			opts:        PostgresDisruptionOptions{},
+			duration:    60 * time.Second,
+			expectedCmd: "",
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			t.Parallel()
+
+			cmd := PodPostgresFaultCommand{
+				fault:    tc.fault,
+				duration: tc.duration,
+				options:  tc.opts,
+			}
+
+			cmds, err := cmd.Commands(tc.target)
+
+			if tc.expectError && err == nil {
+				t.Errorf("should had failed")
+				return
+			}
+
+			if !tc.expectError && err != nil {
+				t.Errorf("unexpected error : %v", err)
+				return
+			}
+
+			if tc.expectError {
+				return
+			}
+
+			if !command.AssertCmdEquals(strings.Join(cmds.Exec, " "), tc.expectedCmd) {
+				t.Errorf("expected command: %s got: %s", tc.expectedCmd, cmds.Exec)
+			}
+		})
+	}
+}
+
+func Test_PodKafkaFaultCommandGenerator(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		title       string
+		target      corev1.Pod
+		fault       KafkaFault
+		opts        KafkaDisruptionOptions
+		duration    time.Duration
+		expectedCmd string
+		expectError bool
+	}{
+		{
+			title:  "Test error and delay",
+			target: buildPodWithPort("my-app-pod", "kafka", 9092),
+			fault: KafkaFault{
+				Port:           intstr.FromInt32(9092),
+				AverageDelay:   100 * time.Millisecond,
+				DelayVariation: 10 * time.Millisecond,
+				ErrorRate:      0.1,
+				Error:          "NOT_LEADER_OR_FOLLOWER",
+			},
+			opts:     KafkaDisruptionOptions{},
+			duration: 60 * time.Second,
+			expectedCmd: "xk6-disruptor-agent kafka -d 60s -t 9092 -a 100ms -v 10ms -r 0.1 --error NOT_LEADER_OR_FOLLOWER" +
+				" --upstream-host 192.0.2.6",
+			expectError: false,
+		},
+		{
+			title:  "Test rule and proxy port",
+			target: buildPodWithPort("my-app-pod", "kafka", 9092),
+			fault: KafkaFault{
+				Port: intstr.FromString("kafka"),
+				Rules: []KafkaRule{
+					{
+						Match:     KafkaMatch{APIs: []string{"Produce"}, Topics: []string{"orders"}},
+						ErrorRate: 1.0,
+						Error:     "REQUEST_TIMED_OUT",
+					},
+				},
+			},
+			opts:     KafkaDisruptionOptions{ProxyPort: 9000},
+			duration: 60 * time.Second,
+			expectedCmd: `xk6-disruptor-agent kafka -d 60s -t 9092` +
+				` --rule {"match":{"apis":["Produce"],"topics":["orders"]},"errorRate":1,"error":"REQUEST_TIMED_OUT"}` +
+				` -p 9000 --upstream-host 192.0.2.6`,
+			expectError: false,
+		},
+		{
+			title:  "Test egress upstream",
+			target: buildPodWithPort("my-app-pod", "http", 80),
+			fault: KafkaFault{
+				Upstream:     "kafka:9092",
+				AverageDelay: time.Second,
+			},
+			opts:        KafkaDisruptionOptions{},
+			duration:    60 * time.Second,
+			expectedCmd: "xk6-disruptor-agent kafka -d 60s -a 1000ms -v 0ms --egress kafka:9092",
+			expectError: false,
+		},
+		{
+			title:  "Test unknown port",
+			target: buildPodWithPort("my-app-pod", "http", 80),
+			fault: KafkaFault{
+				Port:         intstr.FromInt32(9092),
+				AverageDelay: time.Second,
+			},
+			opts:        KafkaDisruptionOptions{},
+			duration:    60 * time.Second,
+			expectedCmd: "",
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			t.Parallel()
+
+			cmd := PodKafkaFaultCommand{
+				fault:    tc.fault,
+				duration: tc.duration,
+				options:  tc.opts,
+			}
+
+			cmds, err := cmd.Commands(tc.target)
+
+			if tc.expectError && err == nil {
+				t.Errorf("should had failed")
+				return
+			}
+
+			if !tc.expectError && err != nil {
+				t.Errorf("unexpected error : %v", err)
+				return
+			}
+
+			if tc.expectError {
+				return
+			}
+
+			if !command.AssertCmdEquals(strings.Join(cmds.Exec, " "), tc.expectedCmd) {
+				t.Errorf("expected command: %s got: %s", tc.expectedCmd, cmds.Exec)
+			}
+		})
+	}
+}
+
+func Test_PodDNSFaultCommandGenerator(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		title       string
+		target      corev1.Pod
+		fault       DNSFault
+		opts        DNSDisruptionOptions
+		duration    time.Duration
+		expectedCmd string
+		expectError bool
+	}{
+		{
+			title:  "Test error and delay",
+			target: buildPodWithPort("my-app-pod", "http", 80),
+			fault: DNSFault{
+				AverageDelay:   100 * time.Millisecond,
+				DelayVariation: 10 * time.Millisecond,
+				ErrorRate:      0.1,
+				Error:          "SERVFAIL",
+			},
+			opts:        DNSDisruptionOptions{},
+			duration:    60 * time.Second,
+			expectedCmd: "xk6-disruptor-agent dns -d 60s -a 100ms -v 10ms -r 0.1 --error SERVFAIL",
+			expectError: false,
+		},
+		{
+			title:  "Test spoofed addresses, rule, upstream and proxy port",
+			target: buildPodWithPort("my-app-pod", "http", 80),
+			fault: DNSFault{
+				Upstream:  "10.96.0.10:53",
+				Addresses: []string{"10.0.0.1", "fd00::1"},
+				Rules: []DNSRule{
+					{
+						Match:     DNSMatch{Domains: []string{"*.example.com"}, Types: []string{"A"}},
+						ErrorRate: 1.0,
+						Error:     "NXDOMAIN",
+					},
+				},
+			},
+			opts:     DNSDisruptionOptions{ProxyPort: 5353},
+			duration: 60 * time.Second,
+			expectedCmd: `xk6-disruptor-agent dns -d 60s --address 10.0.0.1 --address fd00::1` +
+				` --rule {"match":{"domains":["*.example.com"],"types":["A"]},"errorRate":1,"error":"NXDOMAIN"}` +
+				` --upstream 10.96.0.10:53 -p 5353`,
+			expectError: false,
+		},
+		{
+			title: "Pod with hostNetwork",
+			target: builders.NewPodBuilder("hostnet").
+				WithNamespace("test-ns").
+				WithHostNetwork(true).
+				WithIP("192.0.2.6").
+				Build(),
+			fault: DNSFault{
+				ErrorRate: 1.0,
+				Error:     "NXDOMAIN",
+			},
+			opts:        DNSDisruptionOptions{},
+			duration:    60 * time.Second,
+			expectedCmd: "",
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			t.Parallel()
+
+			cmd := PodDNSFaultCommand{
+				fault:    tc.fault,
+				duration: tc.duration,
+				options:  tc.opts,
+			}
+
+			cmds, err := cmd.Commands(tc.target)
+
+			if tc.expectError && err == nil {
+				t.Errorf("should had failed")
+				return
+			}
+
+			if !tc.expectError && err != nil {
+				t.Errorf("unexpected error : %v", err)
+				return
+			}
+
+			if tc.expectError {
+				return
+			}
+
+			if !command.AssertCmdEquals(strings.Join(cmds.Exec, " "), tc.expectedCmd) {
+				t.Errorf("expected command: %s got: %s", tc.expectedCmd, cmds.Exec)
+			}
+		})
+	}
+}
+
+func Test_PodStressFaultCommandGenerator(t *testing.T) {
+	t.Parallel()
+
+	pod := buildPodWithPort("my-app-pod", "http", 80)
+
+	running := *pod.DeepCopy()
+	running.Status.ContainerStatuses = []corev1.ContainerStatus{
+		{Name: "my-app-pod", ContainerID: "containerd://a1b2c3"},
+	}
+
+	// agent injected without a target container
+	untargeted := *running.DeepCopy()
+	untargeted.Spec.EphemeralContainers = []corev1.EphemeralContainer{
+		{EphemeralContainerCommon: corev1.EphemeralContainerCommon{Name: agentContainerName}},
+	}
+
+	testCases := []struct {
+		title       string
+		fault       StressFault
+		pod         corev1.Pod
+		expectedCmd string
+		expectError bool
+	}{
+		{
+			title: "Test load and cpus",
+			fault: StressFault{
+				Load: 80,
+				CPUs: 1,
+			},
+			pod:         pod,
+			expectedCmd: "xk6-disruptor-agent stress -d 60s -l 80 -c 1",
+		},
+		{
+			title: "Test memory percentage",
+			fault: StressFault{
+				Memory: "50%",
+			},
+			pod:         running,
+			expectedCmd: "xk6-disruptor-agent stress -d 60s -c 0 -m 50% --target-container a1b2c3",
+		},
+		{
+			title: "Test memory of container not running",
+			fault: StressFault{
+				Memory: "256Mi",
+			},
+			pod:         pod,
+			expectError: true,
+		},
+		{
+			title: "Test memory with agent without target container",
+			fault: StressFault{
+				Memory: "256Mi",
+			},
+			pod:         untargeted,
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			t.Parallel()
+
+			cmd := PodStressFaultCommand{
+				fault:    tc.fault,
+				duration: 60 * time.Second,
+			}
+
+			cmds, err := cmd.Commands(tc.pod)
+			if tc.expectError && err == nil {
+				t.Fatalf("should had failed")
+			}
+
+			if !tc.expectError && err != nil {
+				t.Fatalf("unexpected error : %v", err)
+			}
+
+			if tc.expectError {
+				return
+			}
+
+			if !command.AssertCmdEquals(strings.Join(cmds.Exec, " "), tc.expectedCmd) {
+				t.Errorf("expected command: %s got: %s", tc.expectedCmd, cmds.Exec)
+			}
+
+			expectedCleanup := "xk6-disruptor-agent cleanup"
+			if !command.AssertCmdEquals(strings.Join(cmds.Cleanup, " "), expectedCleanup) {
+				t.Errorf("expected cleanup command: %s got: %s", expectedCleanup, cmds.Cleanup)
+			}
+		})
+	}
+}
+
+func Test_NodeStressFaultCommandGenerator(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		title       string
+		fault       StressFault
+		duration    time.Duration
+		expectedCmd string
+	}{
+		{
+			title:       "Test defaults",
+			fault:       StressFault{},
+			duration:    60 * time.Second,
+			expectedCmd: "xk6-disruptor-agent stress -d 60s",
+		},
+		{
+			title: "Test load and cpus",
+			fault: StressFault{
+				Load: 80,
+				CPUs: 2,
+			},
+			duration:    60 * time.Second,
+			expectedCmd: "xk6-disruptor-agent stress -d 60s -l 80 -c 2",
+		},
+		{
+			title: "Test slice",
+			fault: StressFault{
+				Load:  50,
+				Slice: 200 * time.Millisecond,
+			},
+			duration:    60 * time.Second,
+			expectedCmd: "xk6-disruptor-agent stress -d 60s -l 50 -s 200ms",
+		},
+		{
+			title: "Test memory",
+			fault: StressFault{
+				Memory:       "256Mi",
+				MemoryRampUp: 10 * time.Second,
+			},
+			duration:    60 * time.Second,
+			expectedCmd: "xk6-disruptor-agent stress -d 60s -c 0 -m 256Mi --memory-ramp 10000ms",
+		},
+		{
+			title: "Test memory and cpu",
+			fault: StressFault{
+				Load:   80,
+				CPUs:   1,
+				Memory: "50%",
+			},
+			duration:    60 * time.Second,
+			expectedCmd: "xk6-disruptor-agent stress -d 60s -l 80 -c 1 -m 50%",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			t.Parallel()
+
+			cmd := NodeStressFaultCommand{
+				fault:    tc.fault,
+				duration: tc.duration,
+			}
+
+			cmds, err := cmd.Commands(builders.NewNodeBuilder("node1").Build())
+			if err != nil {
+				t.Errorf("unexpected error : %v", err)
+				return
+			}
+
+			if !command.AssertCmdEquals(strings.Join(cmds.Exec, " "), tc.expectedCmd) {
+				t.Errorf("expected command: %s got: %s", tc.expectedCmd, cmds.Exec)
+			}
+		})
+	}
+}
+
+func Test_PodDiskFaultCommandGenerator(t *testing.T) {
+	t.Parallel()
+
+	sizeLimit := resource.MustParse("1Gi")
+	pod := builders.NewPodBuilder("my-app-pod").
+		WithNamespace("test-ns").
+		WithVolume(corev1.Volume{
+			Name:         "data",
+			VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}},
+		}).
+		WithVolume(corev1.Volume{
+			Name:         "cache",
+			VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{SizeLimit: &sizeLimit}},
+		}).
+		WithVolume(corev1.Volume{
+			Name: "storage",
+			VolumeSource: corev1.VolumeSource{
+				PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: "storage"},
+			},
+		}).
+		WithVolume(corev1.Volume{
+			Name:         "host",
+			VolumeSource: corev1.VolumeSource{HostPath: &corev1.HostPathVolumeSource{Path: "/var/log"}},
+		}).
+		WithVolume(corev1.Volume{
+			Name:         "config",
+			VolumeSource: corev1.VolumeSource{ConfigMap: &corev1.ConfigMapVolumeSource{}},
+		}).
+		Build()
+
+	// the agent was injected without mounting the volumes of the pod
+	injected := *pod.DeepCopy()
+	injected.Spec.EphemeralContainers = []corev1.EphemeralContainer{
+		{EphemeralContainerCommon: corev1.EphemeralContainerCommon{Name: agentContainerName}},
+	}
+
+	// the agent was injected mounting the volumes of the pod
+	mounted := *pod.DeepCopy()
+	mounted.Spec.EphemeralContainers = []corev1.EphemeralContainer{
+		{
+			EphemeralContainerCommon: corev1.EphemeralContainerCommon{
+				Name:         agentContainerName,
+				VolumeMounts: agentVolumeMounts(pod),
+			},
+		},
+	}
+
+	testCases := []struct {
+		title       string
+		pod         *corev1.Pod
+		fault       DiskFault
+		duration    time.Duration
+		expectedCmd string
+		expectError bool
+	}{
+		{
+			title:       "Test defaults",
+			fault:       DiskFault{},
+			duration:    60 * time.Second,
+			expectedCmd: "xk6-disruptor-agent disk -d 60s",
+		},
+		{
+			title: "Test I/O load in volume",
+			fault: DiskFault{
+				Volume:    "data",
+				Path:      "cache",
+				Workers:   2,
+				BlockSize: "4Ki",
+				FileSize:  "16Mi",
+			},
+			duration: 60 * time.Second,
+			expectedCmd: "xk6-disruptor-agent disk -d 60s -p /xk6-disruptor/volumes/data/cache -w 2" +
+				" --block-size 4Ki --file-size 16Mi",
+		},
+		{
+			title: "Test I/O load in volume mounted by the agent",
+			pod:   &mounted,
+			fault: DiskFault{
+				Volume: "data",
+			},
+			duration:    60 * time.Second,
+			expectedCmd: "xk6-disruptor-agent disk -d 60s -p /xk6-disruptor/volumes/data",
+		},
+		{
+			title: "Test volume not mounted by the agent",
+			pod:   &injected,
+			fault: DiskFault{
+				Volume: "data",
+			},
+			duration:    60 * time.Second,
+			expectError: true,
+		},
+		{
+			title: "Test path outside volume",
+			fault: DiskFault{
+				Volume: "data",
+				Path:   "../storage",
+			},
+			duration:    60 * time.Second,
+			expectError: true,
+		},
+		{
+			title: "Test absolute path in volume",
+			fault: DiskFault{
+				Volume: "data",
+				Path:   "/etc",
+			},
+			duration:    60 * time.Second,
+			expectError: true,
+		},
+		{
+			title: "Test fill volume",
+			fault: DiskFault{
+				Volume:  "storage",
+				Fill:    90,
+				Workers: 2,
+			},
+			duration:    60 * time.Second,
+			expectedCmd: "xk6-disruptor-agent disk -d 60s -p /xk6-disruptor/volumes/storage --fill 90",
+		},
+		{
+			title: "Test fill emptyDir volume with size limit",
+			fault: DiskFault{
+				Volume: "cache",
+				Fill:   90,
+			},
+			duration:    60 * time.Second,
+			expectedCmd: "xk6-disruptor-agent disk -d 60s -p /xk6-disruptor/volumes/cache --fill 90 --size-limit 1073741824",
+		},
+		{
+			title: "Test fill path in emptyDir volume",
+			fault: DiskFault{
+				Volume: "cache",
+				Path:   "tmp",
+				Fill:   90,
+			},
+			duration:    60 * time.Second,
+			expectError: true,
+		},
+		{
+			title: "Test fill emptyDir volume without size limit",
+			fault: DiskFault{
+				Volume: "data",
+				Fill:   90,
+			},
+			duration:    60 * time.Second,
+			expectError: true,
+		},
+		{
+			title: "Test fill hostPath volume",
+			fault: DiskFault{
+				Volume: "host",
+				Fill:   90,
+			},
+			duration:    60 * time.Second,
+			expectError: true,
+		},
+		{
+			title: "Test fill without volume",
+			fault: DiskFault{
+				Fill: 90,
+			},
+			duration:    60 * time.Second,
+			expectError: true,
+		},
+		{
+			title: "Test path without volume",
+			fault: DiskFault{
+				Path: "/var/tmp",
+			},
+			duration:    60 * time.Second,
+			expectedCmd: "xk6-disruptor-agent disk -d 60s -p /var/tmp",
+		},
+		{
+			title: "Test volume not found",
+			fault: DiskFault{
+				Volume: "logs",
+			},
+			duration:    60 * time.Second,
+			expectError: true,
+		},
+		{
+			title: "Test read-only volume",
+			fault: DiskFault{
+				Volume: "config",
+			},
+			duration:    60 * time.Second,
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			t.Parallel()
+
+			cmd := PodDiskFaultCommand{
+				fault:    tc.fault,
+				duration: tc.duration,
+			}
+
+			target := pod
+			if tc.pod != nil {
+				target = *tc.pod
+			}
+
+			cmds, err := cmd.Commands(target)
+			if tc.expectError && err == nil {
+				t.Errorf("should had failed")
+				return
+			}
+
+			if !tc.expectError && err != nil {
+				t.Errorf("unexpected error : %v", err)
+				return
+			}
+
+			if tc.expectError {
+				return
+			}
+
+			if !command.AssertCmdEquals(strings.Join(cmds.Exec, " "), tc.expectedCmd) {
+				t.Errorf("expected command: %s got: %s", tc.expectedCmd, cmds.Exec)
+			}
+		})
+	}
+}
+
+func Test_PodNetworkFaultCommandGenerator(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		title       string
+		fault       NetworkFault
+		duration    time.Duration
+		expectedCmd string
+	}{
+		{
+			title: "Test drop",
+			fault: NetworkFault{
+				Port:     80,
+				Protocol: "tcp",
+			},
+			duration:    60 * time.Second,
+			expectedCmd: "xk6-disruptor-agent network-drop -d 60s -p 80 -P tcp",
+		},
+		{
+			title: "Test delay and jitter",
+			fault: NetworkFault{
+				Port:   80,
+				Delay:  100 * time.Millisecond,
+				Jitter: 10 * time.Millisecond,
+			},
+			duration:    60 * time.Second,
+			expectedCmd: "xk6-disruptor-agent netem -d 60s -p 80 --delay 100ms --jitter 10ms",
+		},
+		{
+			title: "Test all conditions",
+			fault: NetworkFault{
+				Protocol:      "udp",
+				Interface:     "eth1",
+				Delay:         50 * time.Millisecond,
+				LossRate:      0.1,
+				Bandwidth:     "1mbit",
+				ReorderRate:   0.25,
+				DuplicateRate: 0.01,
+				CorruptRate:   0.001,
+			},
+			duration: 60 * time.Second,
+			expectedCmd: "xk6-disruptor-agent netem -d 60s -P udp -i eth1 --delay 50ms --loss 0.1 --bandwidth 1mbit" +
+				" --reorder 0.25 --duplicate 0.01 --corrupt 0.001",
+		},
+		{
+			title: "Test egress drop",
+			fault: NetworkFault{
+				Port:         5432,
+				Destinations: []string{"10.0.0.0/24", "db.example.com"},
+			},
+			duration:    60 * time.Second,
+			expectedCmd: "xk6-disruptor-agent network-drop -d 60s -p 5432 --egress 10.0.0.0/24 --egress db.example.com",
+		},
+		{
+			title: "Test flapping drop",
+			fault: NetworkFault{
+				Port:     80,
+				Schedule: FaultSchedule{Kind: "flap", On: 10 * time.Second, Off: 20 * time.Second},
+			},
+			duration: 60 * time.Second,
+			expectedCmd: "xk6-disruptor-agent network-drop -d 60s -p 80" +
+				` --schedule {"kind":"flap","on":10000000000,"off":20000000000}`,
+		},
+		{
+			title: "Test delay in steps",
+			fault: NetworkFault{
+				Delay: 100 * time.Millisecond,
+				Schedule: FaultSchedule{
+					Kind:  "steps",
+					Steps: []FaultScheduleStep{{At: 0, Level: 0.5}, {At: 30 * time.Second, Level: 2}},
+				},
+			},
+			duration: 60 * time.Second,
+			expectedCmd: "xk6-disruptor-agent netem -d 60s --delay 100ms" +
+				` --schedule {"kind":"steps","steps":[{"at":0,"level":0.5},{"at":30000000000,"level":2}]}`,
+		},
+		{
+			title: "Test egress delay",
+			fault: NetworkFault{
+				Delay:        100 * time.Millisecond,
+				Destinations: []string{"10.0.0.1"},
+			},
+			duration:    60 * time.Second,
+			expectedCmd: "xk6-disruptor-agent netem -d 60s --egress 10.0.0.1 --delay 100ms",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			t.Parallel()
+
+			cmd := PodNetworkFaultCommand{
+				fault:    tc.fault,
+				duration: tc.duration,
+			}
+
+			cmds, err := cmd.Commands(buildPodWithPort("my-app-pod", "http", 80))
+			if err != nil {
+				t.Errorf("unexpected error : %v", err)
+				return
+			}
+
+			if !command.AssertCmdEquals(strings.Join(cmds.Exec, " "), tc.expectedCmd) {
+				t.Errorf("expected command: %s got: %s", tc.expectedCmd, cmds.Exec)
+			}
+		})
+	}
+}
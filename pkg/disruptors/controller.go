@@ -4,6 +4,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"hash/fnv"
+	"path"
+	"strings"
 	"time"
 
 	"github.com/grafana/xk6-disruptor/pkg/internal/version"
@@ -11,24 +14,32 @@ import (
 	"github.com/grafana/xk6-disruptor/pkg/kubernetes/helpers"
 
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
 )
 
-// PodController uses a PodVisitor to perform a certain action (Visit) on a list of pods.
-// The PodVisitor is responsible for executing the action in one target pod, while the PorController
-// is responsible for coordinating the action of the PodVisitor on multiple target pods
-type PodController struct {
-	targets []corev1.Pod
+// agentContainerName is the name of the container that runs the disruptor agent in the targets
+const agentContainerName = "xk6-agent"
+
+// agentVolumesPath is the path where the volumes of the target pod are mounted in the agent container
+const agentVolumesPath = "/xk6-disruptor/volumes"
+
+// Controller uses a Visitor to perform a certain action (Visit) on a list of targets of type T (e.g. Pods or Nodes).
+// The Visitor is responsible for executing the action in one target, while the Controller
+// is responsible for coordinating the action of the Visitor on multiple targets
+type Controller[T any] struct {
+	targets []T
 }
 
-// NewPodController creates a new controller for a collection of pods
-func NewPodController(targets []corev1.Pod) *PodController {
-	return &PodController{
+// NewController creates a new controller for a collection of targets
+func NewController[T any](targets []T) *Controller[T] {
+	return &Controller[T]{
 		targets: targets,
 	}
 }
 
 // Visit allows executing a different command on each target returned by a visiting function
-func (c *PodController) Visit(ctx context.Context, visitor PodVisitor) error {
+func (c *Controller[T]) Visit(ctx context.Context, visitor Visitor[T]) error {
 	// if there are no targets, nothing to do
 	if len(c.targets) == 0 {
 		return nil
@@ -41,10 +52,10 @@ func (c *PodController) Visit(ctx context.Context, visitor PodVisitor) error {
 	// make space to prevent blocking go routines
 	doneCh := make(chan error, len(c.targets))
 
-	for _, pod := range c.targets {
-		go func(pod corev1.Pod) {
-			doneCh <- visitor.Visit(visitCtx, pod)
-		}(pod)
+	for _, target := range c.targets {
+		go func(target T) {
+			doneCh <- visitor.Visit(visitCtx, target)
+		}(target)
 	}
 
 	pending := len(c.targets)
@@ -64,24 +75,41 @@ func (c *PodController) Visit(ctx context.Context, visitor PodVisitor) error {
 	}
 }
 
-// VisitCommands contains the commands to be executed when visiting a pod
-type VisitCommands struct {
-	Exec    []string
-	Cleanup []string
+// Visitor is the interface implemented by objects that perform actions on a target of type T
+type Visitor[T any] interface {
+	Visit(context.Context, T) error
 }
 
-// PodVisitor is the interface implemented by objects that perform actions on a Pod
-type PodVisitor interface {
-	Visit(context.Context, corev1.Pod) error
+// VisitorFunc defines a function that implements the Visitor interface
+// This allows using anonymous functions as visitors
+type VisitorFunc[T any] func(context.Context, T) error
+
+// Visit implements Visitor interface's Visit function
+func (f VisitorFunc[T]) Visit(ctx context.Context, target T) error {
+	return f(ctx, target)
 }
 
+// PodController uses a PodVisitor to perform a certain action (Visit) on a list of pods.
+type PodController = Controller[corev1.Pod]
+
+// NewPodController creates a new controller for a collection of pods
+func NewPodController(targets []corev1.Pod) *PodController {
+	return NewController(targets)
+}
+
+// PodVisitor is the interface implemented by objects that perform actions on a Pod
+type PodVisitor = Visitor[corev1.Pod]
+
 // PodVisitorFunc defines a function that implements the PodVisitor interface
-// This allows using anonymous functions as pod visitor:
-type PodVisitorFunc func(context.Context, corev1.Pod) error
+// This allows using anonymous functions as pod visitor
+type PodVisitorFunc = VisitorFunc[corev1.Pod]
 
-// Visit implements PodVisitor interface's Visit function
-func (f PodVisitorFunc) Visit(ctx context.Context, pod corev1.Pod) error {
-	return f(ctx, pod)
+// VisitCommands contains the commands to be executed when visiting a target
+type VisitCommands struct {
+	Exec    []string
+	Cleanup []string
+	// Stdin is sent to the standard input of the Exec command
+	Stdin []byte
 }
 
 // PodAgentVisitor implements PodVisitor, performing actions in a Pod by means of running a PodVisitCommand on the pod.
@@ -112,6 +140,54 @@ func NewPodAgentVisitor(
 	}
 }
 
+// agentTargetContainer returns the container whose process namespace is shared with the agent. The target of the
+// agent is set when it is injected for the first time and cannot be changed, so an agent injected by a previous version
+// of the disruptor may have no target. If the agent has not been injected yet, it will target the first container.
+func agentTargetContainer(pod corev1.Pod) string {
+	for _, container := range pod.Spec.EphemeralContainers {
+		if container.Name == agentContainerName {
+			return container.TargetContainerName
+		}
+	}
+
+	if len(pod.Spec.Containers) == 0 {
+		return ""
+	}
+
+	return pod.Spec.Containers[0].Name
+}
+
+// agentVolumeMounts returns the mounts for the volumes of the pod the agent can stress. Volumes that project
+// data from the API (e.g. ConfigMaps or Secrets) are not mounted.
+func agentVolumeMounts(pod corev1.Pod) []corev1.VolumeMount {
+	mounts := []corev1.VolumeMount{}
+	for _, volume := range pod.Spec.Volumes {
+		if !isWritableVolume(volume) {
+			continue
+		}
+
+		mounts = append(mounts, corev1.VolumeMount{
+			Name:      volume.Name,
+			MountPath: agentVolumePath(volume.Name),
+		})
+	}
+
+	return mounts
+}
+
+// isWritableVolume returns if the volume can be written by the agent
+func isWritableVolume(volume corev1.Volume) bool {
+	return volume.ConfigMap == nil &&
+		volume.Secret == nil &&
+		volume.Projected == nil &&
+		volume.DownwardAPI == nil
+}
+
+// agentVolumePath returns the path of a pod's volume in the agent container
+func agentVolumePath(volume string) string {
+	return path.Join(agentVolumesPath, volume)
+}
+
 // injectDisruptorAgent injects the Disruptor agent in the target pods
 func (c *PodAgentVisitor) injectDisruptorAgent(ctx context.Context, pod corev1.Pod) error {
 	var (
@@ -122,7 +198,7 @@ func (c *PodAgentVisitor) injectDisruptorAgent(ctx context.Context, pod corev1.P
 
 	agentContainer := corev1.EphemeralContainer{
 		EphemeralContainerCommon: corev1.EphemeralContainerCommon{
-			Name:            "xk6-agent",
+			Name:            agentContainerName,
 			Image:           version.AgentImage(),
 			ImagePullPolicy: corev1.PullIfNotPresent,
 			SecurityContext: &corev1.SecurityContext{
@@ -133,9 +209,12 @@ func (c *PodAgentVisitor) injectDisruptorAgent(ctx context.Context, pod corev1.P
 				RunAsGroup:   &rootGroup,
 				RunAsNonRoot: &runAsNonRoot,
 			},
-			TTY:   true,
-			Stdin: true,
+			VolumeMounts: agentVolumeMounts(pod),
+			TTY:          true,
+			Stdin:        true,
 		},
+		// sharing the process namespace of the target container allows the agent to find its cgroup
+		TargetContainerName: agentTargetContainer(pod),
 	}
 
 	return c.helper.AttachEphemeralContainer(
@@ -162,18 +241,29 @@ func (c *PodAgentVisitor) Visit(ctx context.Context, pod corev1.Pod) error {
 		return fmt.Errorf("unable to get command for pod %q: %w", pod.Name, err)
 	}
 
-	_, stderr, err := c.helper.Exec(ctx, pod.Name, "xk6-agent", commands.Exec, []byte{})
+	return execAgentCommands(ctx, c.helper, pod.Name, commands)
+}
+
+// execAgentCommands executes the commands in the agent container of the given pod.
+// If the execution fails, the cleanup command (if any) is executed.
+func execAgentCommands(ctx context.Context, helper helpers.PodHelper, pod string, commands VisitCommands) error {
+	stdin := commands.Stdin
+	if stdin == nil {
+		stdin = []byte{}
+	}
+
+	_, stderr, err := helper.Exec(ctx, pod, agentContainerName, commands.Exec, stdin)
 
 	if err != nil && commands.Cleanup != nil {
 		// we ignore errors because we are reporting the reason of the exec failure
 		// we use a fresh context because the context used in exec may have been cancelled or expired
 		//nolint:contextcheck
-		_, _, _ = c.helper.Exec(context.TODO(), pod.Name, "xk6-agent", commands.Cleanup, []byte{})
+		_, _, _ = helper.Exec(context.TODO(), pod, agentContainerName, commands.Cleanup, []byte{})
 	}
 
 	// if the context is cancelled, don't report error (we assume the caller is reporting this error)
 	if err != nil && !errors.Is(err, context.Canceled) {
-		return fmt.Errorf("failed command execution for pod %q: %w \n%s", pod.Name, err, string(stderr))
+		return fmt.Errorf("failed command execution for pod %q: %w \n%s", pod, err, string(stderr))
 	}
 
 	return nil
@@ -185,9 +275,134 @@ type PodAgentVisitorOptions struct {
 	Timeout time.Duration
 }
 
-// PodVisitCommand is a command that can be run on a given pod.
-// Implementations build the VisitCommands according to properties of the pod where it is going to run
-type PodVisitCommand interface {
+// VisitCommand is a command that can be run on a given target.
+// Implementations build the VisitCommands according to properties of the target where it is going to run
+type VisitCommand[T any] interface {
 	// Commands defines the command to be executed, and optionally a cleanup command
-	Commands(corev1.Pod) (VisitCommands, error)
+	Commands(T) (VisitCommands, error)
+}
+
+// PodVisitCommand is a command that can be run on a given pod.
+type PodVisitCommand = VisitCommand[corev1.Pod]
+
+// NodeVisitCommand is a command that can be run on a given node.
+type NodeVisitCommand = VisitCommand[corev1.Node]
+
+// NodeAgentVisitor implements Visitor for Nodes, performing actions in a Node by means of running a NodeVisitCommand
+// in an agent pod scheduled in the node. The agent pod is privileged and shares the node's network and PID namespaces.
+type NodeAgentVisitor struct {
+	helper  helpers.PodHelper
+	options NodeAgentVisitorOptions
+	command NodeVisitCommand
+}
+
+// NodeAgentVisitorOptions defines the options for the NodeAgentVisitor
+type NodeAgentVisitorOptions struct {
+	// Defines the timeout for injecting the agent
+	Timeout time.Duration
+}
+
+// NewNodeAgentVisitor creates a new node visitor. The helper defines the namespace where agent pods are created.
+func NewNodeAgentVisitor(
+	helper helpers.PodHelper,
+	options NodeAgentVisitorOptions,
+	command NodeVisitCommand,
+) *NodeAgentVisitor {
+	// FIXME: handling timeout < 0  is required only to allow tests to skip waiting for the agent injection
+	if options.Timeout == 0 {
+		options.Timeout = 30 * time.Second
+	}
+	if options.Timeout < 0 {
+		options.Timeout = 0
+	}
+
+	return &NodeAgentVisitor{
+		helper:  helper,
+		options: options,
+		command: command,
+	}
+}
+
+// NodeAgentPodName returns the name of the agent pod for the given node
+func NodeAgentPodName(node string) string {
+	name := agentContainerName + "-" + node
+	if len(name) <= validation.DNS1123SubdomainMaxLength {
+		return name
+	}
+
+	// pod names are limited to 253 characters. Long names are truncated and suffixed with a hash of the node name, so
+	// they are still unique. The truncated name cannot end with '-' or '.'.
+	hash := fnv.New32a()
+	_, _ = hash.Write([]byte(node))
+	suffix := fmt.Sprintf("-%08x", hash.Sum32())
+
+	return strings.TrimRight(name[:validation.DNS1123SubdomainMaxLength-len(suffix)], "-.") + suffix
+}
+
+// agentPod returns the spec of the pod that runs the agent in the given node
+func (c *NodeAgentVisitor) agentPod(node corev1.Node) corev1.Pod {
+	var (
+		rootUser   = int64(0)
+		rootGroup  = int64(0)
+		privileged = true
+	)
+
+	return corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: NodeAgentPodName(node.Name),
+			Labels: map[string]string{
+				"app.kubernetes.io/name":      "xk6-disruptor-agent",
+				"app.kubernetes.io/component": "node-agent",
+			},
+		},
+		Spec: corev1.PodSpec{
+			// bypass the scheduler to ensure the pod runs in the target node
+			NodeName:      node.Name,
+			HostNetwork:   true,
+			HostPID:       true,
+			RestartPolicy: corev1.RestartPolicyNever,
+			// tolerate any taint, as the target node may be tainted
+			Tolerations: []corev1.Toleration{
+				{Operator: corev1.TolerationOpExists},
+			},
+			Containers: []corev1.Container{
+				{
+					Name:            agentContainerName,
+					Image:           version.AgentImage(),
+					ImagePullPolicy: corev1.PullIfNotPresent,
+					SecurityContext: &corev1.SecurityContext{
+						Privileged: &privileged,
+						RunAsUser:  &rootUser,
+						RunAsGroup: &rootGroup,
+					},
+					TTY:   true,
+					Stdin: true,
+				},
+			},
+		},
+	}
+}
+
+// Visit executes the command returned by the NodeVisitCommand in the agent pod of the node
+func (c *NodeAgentVisitor) Visit(ctx context.Context, node corev1.Node) error {
+	pod := c.agentPod(node)
+	err := c.helper.Create(
+		ctx,
+		pod,
+		helpers.CreateOptions{
+			Timeout:        c.options.Timeout,
+			IgnoreIfExists: true,
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("injecting agent in the node %q: %w", node.Name, err)
+	}
+
+	// get the command to execute in the target
+	commands, err := c.command.Commands(node)
+	if err != nil {
+		return fmt.Errorf("unable to get command for node %q: %w", node.Name, err)
+	}
+
+	return execAgentCommands(ctx, c.helper, pod.Name, commands)
 }
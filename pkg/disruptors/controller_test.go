@@ -9,6 +9,8 @@ import (
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/client-go/kubernetes/fake"
 
 	"github.com/google/go-cmp/cmp"
@@ -20,12 +22,14 @@ type fakeCommand struct {
 	err     error
 	exec    []string
 	cleanup []string
+	stdin   []byte
 }
 
 func (f fakeCommand) Commands(_ corev1.Pod) (VisitCommands, error) {
 	return VisitCommands{
 		Exec:    f.exec,
 		Cleanup: f.cleanup,
+		Stdin:   f.stdin,
 	}, f.err
 }
 
@@ -68,6 +72,27 @@ func Test_PodAgentVisitor(t *testing.T) {
 				{Pod: "pod1", Container: "xk6-agent", Namespace: "test-ns", Command: []string{"command"}, Stdin: []byte{}},
 			},
 		},
+		{
+			title:     "execution with stdin",
+			namespace: "test-ns",
+			pod: builders.NewPodBuilder("pod1").
+				WithNamespace("test-ns").
+				WithIP("192.0.2.6").
+				Build(),
+			visitCmds: fakeCommand{
+				exec:    []string{"command"},
+				cleanup: []string{"cleanup"},
+				stdin:   []byte("input"),
+			},
+			err: nil,
+			options: PodAgentVisitorOptions{
+				Timeout: -1,
+			},
+			expectError: false,
+			expected: []helpers.Command{
+				{Pod: "pod1", Container: "xk6-agent", Namespace: "test-ns", Command: []string{"command"}, Stdin: []byte("input")},
+			},
+		},
 		{
 			title:     "failed execution",
 			namespace: "test-ns",
@@ -145,6 +170,287 @@ func Test_PodAgentVisitor(t *testing.T) {
 	}
 }
 
+func Test_PodCleanupVisitor(t *testing.T) {
+	t.Parallel()
+
+	withAgent := builders.NewPodBuilder("pod1").
+		WithNamespace("test-ns").
+		WithIP("192.0.2.6").
+		Build()
+	withAgent.Spec.EphemeralContainers = []corev1.EphemeralContainer{
+		{EphemeralContainerCommon: corev1.EphemeralContainerCommon{Name: "xk6-agent"}},
+	}
+
+	testCases := []struct {
+		title    string
+		pod      corev1.Pod
+		expected []helpers.Command
+	}{
+		{
+			title: "pod with agent",
+			pod:   withAgent,
+			expected: []helpers.Command{
+				{
+					Pod:       "pod1",
+					Container: "xk6-agent",
+					Namespace: "test-ns",
+					Command:   []string{"xk6-disruptor-agent", "cleanup"},
+					Stdin:     []byte{},
+				},
+			},
+		},
+		{
+			title: "pod without agent",
+			pod: builders.NewPodBuilder("pod2").
+				WithNamespace("test-ns").
+				WithIP("192.0.2.7").
+				Build(),
+			expected: nil,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			t.Parallel()
+
+			client := fake.NewSimpleClientset(&tc.pod)
+			executor := helpers.NewFakePodCommandExecutor()
+			helper := helpers.NewPodHelper(client, executor, "test-ns")
+			visitor := PodCleanupVisitor{helper: helper}
+
+			if err := visitor.Visit(t.Context(), tc.pod); err != nil {
+				t.Fatalf("failed unexpectedly: %v", err)
+			}
+
+			if diff := cmp.Diff(tc.expected, executor.GetHistory()); diff != "" {
+				t.Errorf("Expected command did not match returned:\n%s", diff)
+			}
+		})
+	}
+}
+
+type fakeNodeCommand struct {
+	exec    []string
+	cleanup []string
+}
+
+func (f fakeNodeCommand) Commands(_ corev1.Node) (VisitCommands, error) {
+	return VisitCommands{
+		Exec:    f.exec,
+		Cleanup: f.cleanup,
+	}, nil
+}
+
+func Test_NodeAgentVisitor(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		title       string
+		node        corev1.Node
+		err         error
+		expectError bool
+		expected    []helpers.Command
+	}{
+		{
+			title:       "successful execution",
+			node:        builders.NewNodeBuilder("node1").Build(),
+			err:         nil,
+			expectError: false,
+			expected: []helpers.Command{
+				{
+					Pod:       "xk6-agent-node1",
+					Container: "xk6-agent",
+					Namespace: "test-ns",
+					Command:   []string{"command"},
+					Stdin:     []byte{},
+				},
+			},
+		},
+		{
+			title:       "failed execution",
+			node:        builders.NewNodeBuilder("node1").Build(),
+			err:         fmt.Errorf("fake error"),
+			expectError: true,
+			expected: []helpers.Command{
+				{
+					Pod:       "xk6-agent-node1",
+					Container: "xk6-agent",
+					Namespace: "test-ns",
+					Command:   []string{"command"},
+					Stdin:     []byte{},
+				},
+				{
+					Pod:       "xk6-agent-node1",
+					Container: "xk6-agent",
+					Namespace: "test-ns",
+					Command:   []string{"cleanup"},
+					Stdin:     []byte{},
+				},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			t.Parallel()
+
+			client := fake.NewSimpleClientset(&tc.node)
+			executor := helpers.NewFakePodCommandExecutor()
+			helper := helpers.NewPodHelper(client, executor, "test-ns")
+			visitor := NewNodeAgentVisitor(
+				helper,
+				NodeAgentVisitorOptions{Timeout: -1},
+				fakeNodeCommand{
+					exec:    []string{"command"},
+					cleanup: []string{"cleanup"},
+				},
+			)
+
+			executor.SetResult(nil, nil, tc.err)
+			err := visitor.Visit(t.Context(), tc.node)
+			if tc.expectError && err == nil {
+				t.Fatalf("should had failed")
+			}
+
+			if !tc.expectError && err != nil {
+				t.Fatalf("failed unexpectedly: %v", err)
+			}
+
+			pod, err := client.CoreV1().Pods("test-ns").Get(t.Context(), "xk6-agent-node1", metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("agent pod not created: %v", err)
+			}
+
+			if pod.Spec.NodeName != tc.node.Name {
+				t.Fatalf("agent pod scheduled in %q expected %q", pod.Spec.NodeName, tc.node.Name)
+			}
+
+			if diff := cmp.Diff(tc.expected, executor.GetHistory()); diff != "" {
+				t.Errorf("Expected command did not match returned:\n%s", diff)
+			}
+		})
+	}
+}
+
+func Test_NodeAgentPodName(t *testing.T) {
+	t.Parallel()
+
+	// the name is truncated after "-", which is not valid at the end of a pod name
+	long := strings.Repeat("a", 233) + "-b.example.com"
+	similar := strings.Repeat("a", 233) + "-c.example.com"
+
+	testCases := []struct {
+		title string
+		node  string
+		// expectedPrefix is the expected name, without the hash added to truncated names
+		expectedPrefix string
+		truncated      bool
+	}{
+		{
+			title:          "short name",
+			node:           "node1",
+			expectedPrefix: "xk6-agent-node1",
+		},
+		{
+			title:          "name of maximum length",
+			node:           strings.Repeat("a", 243),
+			expectedPrefix: "xk6-agent-" + strings.Repeat("a", 243),
+		},
+		{
+			title:          "long name",
+			node:           long,
+			expectedPrefix: "xk6-agent-" + strings.Repeat("a", 233),
+			truncated:      true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			t.Parallel()
+
+			name := NodeAgentPodName(tc.node)
+			prefix := name
+			if tc.truncated {
+				// the hash is an hexadecimal number of 8 digits preceded by '-'
+				prefix = name[:len(name)-9]
+			}
+
+			if prefix != tc.expectedPrefix {
+				t.Fatalf("expected %q got %q", tc.expectedPrefix, name)
+			}
+
+			if errs := validation.IsDNS1123Subdomain(name); len(errs) > 0 {
+				t.Fatalf("invalid pod name %q: %v", name, errs)
+			}
+		})
+	}
+
+	if NodeAgentPodName(long) == NodeAgentPodName(similar) {
+		t.Fatalf("expected names of different nodes to be different")
+	}
+}
+
+func Test_NodeCleanupVisitor(t *testing.T) {
+	t.Parallel()
+
+	node := builders.NewNodeBuilder("node1").Build()
+	agentPod := corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "xk6-agent-node1", Namespace: "test-ns"}}
+
+	testCases := []struct {
+		title string
+		pods  []corev1.Pod
+	}{
+		{
+			title: "agent pod exists",
+			pods:  []corev1.Pod{agentPod},
+		},
+		{
+			title: "agent pod does not exist",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			t.Parallel()
+
+			client := fake.NewSimpleClientset()
+			for _, pod := range tc.pods {
+				if _, err := client.CoreV1().Pods(pod.Namespace).Create(t.Context(), &pod, metav1.CreateOptions{}); err != nil {
+					t.Fatalf("creating pod: %v", err)
+				}
+			}
+
+			executor := helpers.NewFakePodCommandExecutor()
+			visitor := NodeCleanupVisitor{helper: helpers.NewPodHelper(client, executor, "test-ns")}
+			if err := visitor.Visit(t.Context(), node); err != nil {
+				t.Fatalf("failed unexpectedly: %v", err)
+			}
+
+			expected := []helpers.Command{
+				{
+					Pod:       "xk6-agent-node1",
+					Container: "xk6-agent",
+					Namespace: "test-ns",
+					Command:   []string{"xk6-disruptor-agent", "cleanup"},
+					Stdin:     []byte{},
+				},
+			}
+			if diff := cmp.Diff(expected, executor.GetHistory()); diff != "" {
+				t.Fatalf("Expected command did not match returned:\n%s", diff)
+			}
+
+			pods, err := client.CoreV1().Pods("test-ns").List(t.Context(), metav1.ListOptions{})
+			if err != nil {
+				t.Fatalf("listing pods: %v", err)
+			}
+
+			if len(pods.Items) != 0 {
+				t.Fatalf("expected agent pod to be deleted")
+			}
+		})
+	}
+}
+
 var errFailed = errors.New("failed")
 
 func Test_PodController(t *testing.T) {
@@ -0,0 +1,64 @@
+package disruptors
+
+import (
+	"context"
+	"time"
+)
+
+// DNSFaultInjector defines the methods for injecting faults in the DNS queries sent by a target
+type DNSFaultInjector interface {
+	// InjectDNSFaults injects faults in the DNS queries sent by the disruptor's targets for the specified duration
+	InjectDNSFaults(ctx context.Context, fault DNSFault, duration time.Duration, options DNSDisruptionOptions) error
+}
+
+// DNSDisruptionOptions defines options for the injection of DNS faults in a target pod
+type DNSDisruptionOptions struct {
+	// Port used by the agent for listening
+	ProxyPort uint `js:"proxyPort"`
+}
+
+// DNSFault specifies a fault to be injected in the DNS queries sent by a target. The queries sent to any DNS server
+// are redirected to the agent, which forwards them to the Upstream.
+type DNSFault struct {
+	// Upstream DNS server (host:port) the queries are forwarded to. Defaults to the first nameserver configured in the
+	// target.
+	Upstream string `js:"upstream"`
+	// Average delay introduced to queries
+	AverageDelay time.Duration `js:"averageDelay"`
+	// Variation in the delay (with respect of the average delay)
+	DelayVariation time.Duration `js:"delayVariation"`
+	// Fraction (in the range 0.0 to 1.0) of queries that will return an error
+	ErrorRate float32 `js:"errorRate"`
+	// Error returned to the queries selected in the error rate: "NXDOMAIN" or "SERVFAIL"
+	Error string `js:"error"`
+	// Addresses returned in the answers to the A and AAAA queries instead of the upstream's answers
+	Addresses []string `js:"addresses"`
+	// Rules select queries and define the disruption applied to them. The first rule that matches a query is
+	// applied. The queries that do not match any rule are disrupted with the settings above.
+	Rules []DNSRule `js:"rules"`
+}
+
+// DNSMatch selects DNS queries by their domain and record type. A query matches if it satisfies all the criteria
+// specified.
+type DNSMatch struct {
+	// Domains given as patterns that can use "*" as a wildcard (e.g. "*.example.com"). Any of them matches
+	Domains []string `js:"domains" json:"domains,omitempty"`
+	// Types of records given by their name (e.g. "A", "SRV") or their numeric value. Any of them matches
+	Types []string `js:"types" json:"types,omitempty"`
+}
+
+// DNSRule defines the fault injected in the DNS queries that match it
+type DNSRule struct {
+	// Criteria for selecting the queries
+	Match DNSMatch `js:"match" json:"match"`
+	// Average delay introduced to queries
+	AverageDelay time.Duration `js:"averageDelay" json:"averageDelay,omitempty"`
+	// Variation in the delay (with respect of the average delay)
+	DelayVariation time.Duration `js:"delayVariation" json:"delayVariation,omitempty"`
+	// Fraction (in the range 0.0 to 1.0) of queries that will return an error
+	ErrorRate float32 `js:"errorRate" json:"errorRate,omitempty"`
+	// Error returned to the queries selected in the error rate
+	Error string `js:"error" json:"error,omitempty"`
+	// Addresses returned in the answers to the A and AAAA queries
+	Addresses []string `js:"addresses" json:"addresses,omitempty"`
+}
@@ -2,7 +2,13 @@ package disruptors
 
 import (
 	"context"
+	"fmt"
+	"net/netip"
+	"slices"
+	"strings"
 	"time"
+
+	"github.com/grafana/xk6-disruptor/pkg/kubernetes"
 )
 
 // NetworkFaultInjector defines the interface for injecting network faults
@@ -10,10 +16,84 @@ type NetworkFaultInjector interface {
 	InjectNetworkFaults(ctx context.Context, fault NetworkFault, duration time.Duration) error
 }
 
-// NetworkFault specifies a network fault to be injected
+// NetworkFault specifies a network fault to be injected.
+// If none of the network conditions (delay, loss rate, bandwidth, etc.) is specified, all the matching traffic is
+// dropped. Otherwise, the conditions are emulated in the traffic sent from the matching port.
+// If Destinations or a Service are specified, the outbound traffic sent to them (and to the port, if specified) is
+// disrupted instead.
+// Rates are expressed as a fraction in the range [0.0, 1.0].
 type NetworkFault struct {
 	// Port to target for network disruption (0 means all ports)
 	Port uint `js:"port"`
 	// Protocol to target for network disruption (tcp, udp, icmp, or empty for all)
 	Protocol string `js:"protocol"`
+	// Interface to disrupt when emulating network conditions. Defaults to eth0.
+	Interface string `js:"interface"`
+	// Delay added to each packet
+	Delay time.Duration `js:"delay"`
+	// Jitter is the variation of the delay
+	Jitter time.Duration `js:"jitter"`
+	// LossRate is the fraction of packets dropped
+	LossRate float64 `js:"lossRate"`
+	// Bandwidth limits the rate of the traffic (e.g. "1mbit")
+	Bandwidth string `js:"bandwidth"`
+	// ReorderRate is the fraction of packets sent immediately, while the rest are delayed. Requires a delay.
+	ReorderRate float64 `js:"reorderRate"`
+	// DuplicateRate is the fraction of packets duplicated
+	DuplicateRate float64 `js:"duplicateRate"`
+	// CorruptRate is the fraction of packets with a random bit error
+	CorruptRate float64 `js:"corruptRate"`
+	// Destinations of the outbound traffic to disrupt, as IP addresses, CIDRs or hostnames. Hostnames are resolved
+	// when the fault is injected. If neither Destinations nor Service are specified, the inbound traffic is disrupted.
+	Destinations []string `js:"destinations"`
+	// Service is a Kubernetes service, as "name" or "namespace/name". The traffic sent by the target to the addresses
+	// of the service (its cluster IPs and the IPs of its pods) is disrupted. Only the IPv4 addresses are disrupted.
+	// If the namespace is not specified, the namespace of the disruptor is used.
+	Service string `js:"service"`
+	// Schedule varies the delays and rates of the network conditions during the injection. If no network condition
+	// is specified, it varies the fraction of the matching traffic that is dropped.
+	Schedule FaultSchedule `js:"schedule"`
+}
+
+// emulatesConditions returns if the fault specifies any network condition to emulate
+func (f NetworkFault) emulatesConditions() bool {
+	return f.Delay > 0 || f.Jitter > 0 || f.LossRate > 0 || f.Bandwidth != "" ||
+		f.ReorderRate > 0 || f.DuplicateRate > 0 || f.CorruptRate > 0
+}
+
+// resolveService returns the fault with the IPv4 addresses of its Service added to the Destinations. The IPv6 addresses
+// are skipped, as destinations are only supported for IPv4.
+func resolveService(
+	ctx context.Context,
+	k8s kubernetes.Kubernetes,
+	namespace string,
+	fault NetworkFault,
+) (NetworkFault, error) {
+	if fault.Service == "" {
+		return fault, nil
+	}
+
+	name := fault.Service
+	if ns, svc, found := strings.Cut(fault.Service, "/"); found {
+		namespace, name = ns, svc
+	}
+
+	addresses, err := k8s.ServiceHelper(namespace).GetAddresses(ctx, name)
+	if err != nil {
+		return NetworkFault{}, err
+	}
+
+	addresses = slices.DeleteFunc(addresses, func(address string) bool {
+		addr, err := netip.ParseAddr(address)
+		return err == nil && !addr.Is4()
+	})
+
+	if len(addresses) == 0 {
+		return NetworkFault{}, fmt.Errorf("service %q has no IPv4 addresses", fault.Service)
+	}
+
+	fault.Destinations = append(slices.Clone(fault.Destinations), addresses...)
+	fault.Service = ""
+
+	return fault, nil
 }
@@ -0,0 +1,76 @@
+package disruptors
+
+import (
+	"slices"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/grafana/xk6-disruptor/pkg/kubernetes"
+)
+
+func Test_ResolveService(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		title       string
+		clusterIPs  []string
+		expected    []string
+		expectError bool
+	}{
+		{
+			title:      "IPv4 service",
+			clusterIPs: []string{"10.0.0.1"},
+			expected:   []string{"192.168.0.1", "10.0.0.1"},
+		},
+		{
+			title:      "dual-stack service",
+			clusterIPs: []string{"10.0.0.1", "fd00::1"},
+			expected:   []string{"192.168.0.1", "10.0.0.1"},
+		},
+		{
+			title:       "IPv6 service",
+			clusterIPs:  []string{"fd00::1"},
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			t.Parallel()
+
+			service := &corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-svc", Namespace: "test-ns"},
+				Spec: corev1.ServiceSpec{
+					Selector:   map[string]string{"app": "test"},
+					ClusterIPs: tc.clusterIPs,
+				},
+			}
+			client := fake.NewSimpleClientset(service)
+			k, _ := kubernetes.NewFakeKubernetes(client)
+
+			fault := NetworkFault{Destinations: []string{"192.168.0.1"}, Service: "test-ns/test-svc"}
+			resolved, err := resolveService(t.Context(), k, "default", fault)
+			if tc.expectError {
+				if err == nil {
+					t.Fatalf("should had failed")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("failed: %v", err)
+			}
+
+			if !slices.Equal(resolved.Destinations, tc.expected) {
+				t.Fatalf("expected destinations %v but %v received", tc.expected, resolved.Destinations)
+			}
+
+			if resolved.Service != "" {
+				t.Fatalf("expected the service to be resolved")
+			}
+		})
+	}
+}
@@ -0,0 +1,184 @@
+package disruptors
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/grafana/xk6-disruptor/pkg/kubernetes"
+	"github.com/grafana/xk6-disruptor/pkg/kubernetes/helpers"
+	"github.com/grafana/xk6-disruptor/pkg/utils"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// NodeDisruptor defines the types of faults that can be injected in a Node
+type NodeDisruptor interface {
+	Disruptor
+	NetworkFaultInjector
+	ResourceFaultInjector
+	FaultCleaner
+	// DeleteAgentPods deletes the agent pods created in the nodes for injecting the faults of the disruptor
+	DeleteAgentPods(ctx context.Context) error
+}
+
+// NodeDisruptorOptions defines options that controls the NodeDisruptor's behavior
+type NodeDisruptorOptions struct {
+	// timeout when waiting agent to be injected in seconds. A zero value forces default.
+	// A Negative value forces no waiting.
+	InjectTimeout time.Duration `js:"injectTimeout"`
+	// Namespace where the pods that run the agent in the nodes are created. Defaults to "default".
+	Namespace string `js:"namespace"`
+}
+
+// NodeSelectorSpec defines the criteria for selecting a node for disruption
+type NodeSelectorSpec struct {
+	// Select Nodes that match these NodeAttributes
+	Select NodeAttributes
+	// Exclude Nodes that match these NodeAttributes
+	Exclude NodeAttributes
+}
+
+// NodeAttributes defines the attributes a Node must match for being selected/excluded
+type NodeAttributes struct {
+	// Labels the node must have
+	Labels map[string]string
+	// Keys of the taints the node must have
+	Taints []string
+	// Conditions the node must satisfy, as a map of condition types (e.g. Ready) to status (True, False, Unknown)
+	Conditions map[string]string
+}
+
+// nodeDisruptor is an instance of a NodeDisruptor that uses a Controller to interact with target nodes
+type nodeDisruptor struct {
+	k8s      kubernetes.Kubernetes
+	helper   helpers.PodHelper
+	selector *NodeSelector
+	options  NodeDisruptorOptions
+	mtx      sync.Mutex
+	// agentPods are the names of the agent pods used for injecting faults
+	agentPods []string
+}
+
+// NewNodeDisruptor creates a new instance of a NodeDisruptor that acts on the nodes
+// that match the given NodeSelectorSpec
+func NewNodeDisruptor(
+	_ context.Context,
+	k8s kubernetes.Kubernetes,
+	spec NodeSelectorSpec,
+	options NodeDisruptorOptions,
+) (NodeDisruptor, error) {
+	selector, err := NewNodeSelector(spec, k8s.NodeHelper())
+	if err != nil {
+		return nil, err
+	}
+
+	if options.Namespace == "" {
+		options.Namespace = metav1.NamespaceDefault
+	}
+
+	return &nodeDisruptor{
+		k8s:      k8s,
+		helper:   k8s.PodHelper(options.Namespace),
+		selector: selector,
+		options:  options,
+	}, nil
+}
+
+func (d *nodeDisruptor) Targets(ctx context.Context) ([]string, error) {
+	targets, err := d.selector.Targets(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return utils.NodeNames(targets), nil
+}
+
+// Cleanup stops the faults running in the disruptor's targets and deletes their agent pods
+func (d *nodeDisruptor) Cleanup(ctx context.Context) error {
+	targets, err := d.selector.Targets(ctx)
+	if err != nil {
+		return err
+	}
+
+	controller := NewController(targets)
+
+	return controller.Visit(ctx, NodeCleanupVisitor{helper: d.helper})
+}
+
+// DeleteAgentPods deletes the agent pods used by the disruptor for injecting faults. If an error occurs,
+// DeleteAgentPods continues to try and delete the remaining pods.
+func (d *nodeDisruptor) DeleteAgentPods(ctx context.Context) error {
+	d.mtx.Lock()
+	pods := d.agentPods
+	d.agentPods = nil
+	d.mtx.Unlock()
+
+	var errs []error
+	for _, pod := range pods {
+		errs = append(errs, deleteNodeAgentPod(ctx, d.helper, pod))
+	}
+
+	return errors.Join(errs...)
+}
+
+// InjectNetworkFaults injects network faults in the target nodes
+func (d *nodeDisruptor) InjectNetworkFaults(
+	ctx context.Context,
+	fault NetworkFault,
+	duration time.Duration,
+) error {
+	fault, err := resolveService(ctx, d.k8s, d.options.Namespace, fault)
+	if err != nil {
+		return err
+	}
+
+	command := NodeNetworkFaultCommand{
+		fault:    fault,
+		duration: duration,
+	}
+
+	return d.visit(ctx, command)
+}
+
+// InjectStressFaults stresses the resources of the target nodes
+func (d *nodeDisruptor) InjectStressFaults(
+	ctx context.Context,
+	fault StressFault,
+	duration time.Duration,
+) error {
+	command := NodeStressFaultCommand{
+		fault:    fault,
+		duration: duration,
+	}
+
+	return d.visit(ctx, command)
+}
+
+// visit executes the given command in all the target nodes
+func (d *nodeDisruptor) visit(ctx context.Context, command NodeVisitCommand) error {
+	visitor := NewNodeAgentVisitor(
+		d.helper,
+		NodeAgentVisitorOptions{Timeout: d.options.InjectTimeout},
+		command,
+	)
+
+	targets, err := d.selector.Targets(ctx)
+	if err != nil {
+		return err
+	}
+
+	d.mtx.Lock()
+	for _, node := range targets {
+		if pod := NodeAgentPodName(node.Name); !slices.Contains(d.agentPods, pod) {
+			d.agentPods = append(d.agentPods, pod)
+		}
+	}
+	d.mtx.Unlock()
+
+	controller := NewController(targets)
+
+	return controller.Visit(ctx, visitor)
+}
@@ -20,6 +20,10 @@ type PodDisruptor interface {
 	ProtocolFaultInjector
 	PodFaultInjector
 	NetworkFaultInjector
+	ResourceFaultInjector
+	DiskFaultInjector
+	DNSFaultInjector
+	FaultCleaner
 }
 
 // PodDisruptorOptions defines options that controls the PodDisruptor's behavior
@@ -31,9 +35,11 @@ type PodDisruptorOptions struct {
 
 // podDisruptor is an instance of a PodDisruptor that uses a PodController to interact with target pods
 type podDisruptor struct {
-	helper   helpers.PodHelper
-	selector *PodSelector
-	options  PodDisruptorOptions
+	k8s       kubernetes.Kubernetes
+	namespace string
+	helper    helpers.PodHelper
+	selector  *PodSelector
+	options   PodDisruptorOptions
 }
 
 // PodSelectorSpec defines the criteria for selecting a pod for disruption
@@ -69,9 +75,11 @@ func NewPodDisruptor(
 	}
 
 	return &podDisruptor{
-		helper:   helper,
-		options:  options,
-		selector: selector,
+		k8s:       k8s,
+		namespace: namespace,
+		helper:    helper,
+		options:   options,
+		selector:  selector,
 	}, nil
 }
 
@@ -84,6 +92,18 @@ func (d *podDisruptor) Targets(ctx context.Context) ([]string, error) {
 	return utils.PodNames(targets), nil
 }
 
+// Cleanup stops the faults running in the disruptor's targets
+func (d *podDisruptor) Cleanup(ctx context.Context) error {
+	targets, err := d.selector.Targets(ctx)
+	if err != nil {
+		return err
+	}
+
+	controller := NewPodController(targets)
+
+	return controller.Visit(ctx, PodCleanupVisitor{helper: d.helper})
+}
+
 // InjectHTTPFaults injects faults in the http requests sent to the disruptor's targets
 func (d *podDisruptor) InjectHTTPFaults(
 	ctx context.Context,
@@ -97,10 +117,16 @@ func (d *podDisruptor) InjectHTTPFaults(
 		fault.Port = DefaultTargetPort
 	}
 
+	credentials, err := loadTLSCredentials(ctx, d.k8s, d.namespace, options.TLS)
+	if err != nil {
+		return err
+	}
+
 	command := PodHTTPFaultCommand{
-		fault:    fault,
-		duration: duration,
-		options:  options,
+		fault:       fault,
+		duration:    duration,
+		options:     options,
+		credentials: credentials,
 	}
 
 	visitor := NewPodAgentVisitor(
@@ -148,6 +174,46 @@ func (d *podDisruptor) InjectGrpcFaults(
 	return controller.Visit(ctx, visitor)
 }
 
+// InjectTCPFaults injects faults in the TCP connections opened to the disruptor's targets
+func (d *podDisruptor) InjectTCPFaults(
+	ctx context.Context,
+	fault TCPFault,
+	duration time.Duration,
+	options TCPDisruptionOptions,
+) error {
+	return d.visit(ctx, PodTCPFaultCommand{fault: fault, duration: duration, options: options})
+}
+
+// InjectRedisFaults injects faults in the commands sent to the disruptor's targets
+func (d *podDisruptor) InjectRedisFaults(
+	ctx context.Context,
+	fault RedisFault,
+	duration time.Duration,
+	options RedisDisruptionOptions,
+) error {
+	return d.visit(ctx, PodRedisFaultCommand{fault: fault, duration: duration, options: options})
+}
+
+// InjectPostgresFaults injects faults in the queries sent to the disruptor's targets
+func (d *podDisruptor) InjectPostgresFaults(
+	ctx context.Context,
+	fault PostgresFault,
+	duration time.Duration,
+	options PostgresDisruptionOptions,
+) error {
+	return d.visit(ctx, PodPostgresFaultCommand{fault: fault, duration: duration, options: options})
+}
+
+// InjectKafkaFaults injects faults in the requests sent to the disruptor's targets
+func (d *podDisruptor) InjectKafkaFaults(
+	ctx context.Context,
+	fault KafkaFault,
+	duration time.Duration,
+	options KafkaDisruptionOptions,
+) error {
+	return d.visit(ctx, PodKafkaFaultCommand{fault: fault, duration: duration, options: options})
+}
+
 // TerminatePods terminates a subset of the target pods of the disruptor
 func (d *podDisruptor) TerminatePods(
 	ctx context.Context,
@@ -170,12 +236,35 @@ func (d *podDisruptor) TerminatePods(
 	return utils.PodNames(targets), controller.Visit(ctx, visitor)
 }
 
+// visit injects the agent in the disruptor's targets and executes the command in them
+func (d *podDisruptor) visit(ctx context.Context, command PodVisitCommand) error {
+	visitor := NewPodAgentVisitor(
+		d.helper,
+		PodAgentVisitorOptions{Timeout: d.options.InjectTimeout},
+		command,
+	)
+
+	targets, err := d.selector.Targets(ctx)
+	if err != nil {
+		return err
+	}
+
+	controller := NewPodController(targets)
+
+	return controller.Visit(ctx, visitor)
+}
+
 // InjectNetworkFaults injects network faults in the target pods
 func (d *podDisruptor) InjectNetworkFaults(
 	ctx context.Context,
 	fault NetworkFault,
 	duration time.Duration,
 ) error {
+	fault, err := resolveService(ctx, d.k8s, d.namespace, fault)
+	if err != nil {
+		return err
+	}
+
 	command := PodNetworkFaultCommand{
 		fault:    fault,
 		duration: duration,
@@ -196,3 +285,31 @@ func (d *podDisruptor) InjectNetworkFaults(
 
 	return controller.Visit(ctx, visitor)
 }
+
+// InjectStressFaults stresses the resources of the target pods
+func (d *podDisruptor) InjectStressFaults(
+	ctx context.Context,
+	fault StressFault,
+	duration time.Duration,
+) error {
+	return d.visit(ctx, PodStressFaultCommand{fault: fault, duration: duration})
+}
+
+// InjectDiskFaults stresses the disk of the target pods
+func (d *podDisruptor) InjectDiskFaults(
+	ctx context.Context,
+	fault DiskFault,
+	duration time.Duration,
+) error {
+	return d.visit(ctx, PodDiskFaultCommand{fault: fault, duration: duration})
+}
+
+// InjectDNSFaults injects faults in the DNS queries sent by the target pods
+func (d *podDisruptor) InjectDNSFaults(
+	ctx context.Context,
+	fault DNSFault,
+	duration time.Duration,
+	options DNSDisruptionOptions,
+) error {
+	return d.visit(ctx, PodDNSFaultCommand{fault: fault, duration: duration, options: options})
+}
@@ -15,12 +15,54 @@ type ProtocolFaultInjector interface {
 	// InjectGrpcFault injects faults in the grpc requests sent to the disruptor's targets
 	// for the specified duration
 	InjectGrpcFaults(ctx context.Context, fault GrpcFault, duration time.Duration, options GrpcDisruptionOptions) error
+	// InjectTCPFaults injects faults in the TCP connections opened to the disruptor's targets
+	// for the specified duration
+	InjectTCPFaults(ctx context.Context, fault TCPFault, duration time.Duration, options TCPDisruptionOptions) error
+	// InjectRedisFaults injects faults in the commands sent to the disruptor's targets
+	// for the specified duration
+	InjectRedisFaults(
+		ctx context.Context,
+		fault RedisFault,
+		duration time.Duration,
+		options RedisDisruptionOptions,
+	) error
+	// InjectPostgresFaults injects faults in the queries sent to the disruptor's targets
+	// for the specified duration
+	InjectPostgresFaults(
+		ctx context.Context,
+		fault PostgresFault,
+		duration time.Duration,
+		options PostgresDisruptionOptions,
+	) error
+	// InjectKafkaFaults injects faults in the requests sent to the disruptor's targets
+	// for the specified duration
+	InjectKafkaFaults(
+		ctx context.Context,
+		fault KafkaFault,
+		duration time.Duration,
+		options KafkaDisruptionOptions,
+	) error
 }
 
 // HTTPDisruptionOptions defines options for the injection of HTTP faults in a target pod
 type HTTPDisruptionOptions struct {
 	// Port used by the agent for listening
 	ProxyPort uint `js:"proxyPort"`
+	// TLS termination of the requests by the agent
+	TLS HTTPTLSOptions `js:"tls"`
+}
+
+// HTTPTLSOptions defines how the agent terminates TLS for targets that serve HTTPS. The agent serves a certificate
+// taken from a Secret, or generated from a CA taken from a Secret, and re-encrypts the requests to the target.
+// Secrets must contain the certificate and key in the 'tls.crt' and 'tls.key' entries (e.g. kubernetes.io/tls Secrets)
+// and are given as "name" or "namespace/name". If the namespace is omitted, the namespace of the targets is used.
+type HTTPTLSOptions struct {
+	// Secret with the certificate and key served by the agent
+	Secret string `js:"secret"`
+	// Secret with the CA certificate and key used for generating the certificate served by the agent
+	CASecret string `js:"caSecret"`
+	// Additional hosts (names or IPs) of the generated certificate. The IP of the target is always included.
+	Hosts []string `js:"hosts"`
 }
 
 // GrpcDisruptionOptions defines options for the injection of grpc faults in a target pod
@@ -29,6 +71,30 @@ type GrpcDisruptionOptions struct {
 	ProxyPort uint `js:"proxyPort"`
 }
 
+// TCPDisruptionOptions defines options for the injection of TCP faults in a target pod
+type TCPDisruptionOptions struct {
+	// Port used by the agent for listening
+	ProxyPort uint `js:"proxyPort"`
+}
+
+// RedisDisruptionOptions defines options for the injection of Redis faults in a target pod
+type RedisDisruptionOptions struct {
+	// Port used by the agent for listening
+	ProxyPort uint `js:"proxyPort"`
+}
+
+// PostgresDisruptionOptions defines options for the injection of PostgreSQL faults in a target pod
+type PostgresDisruptionOptions struct {
+	// Port used by the agent for listening
+	ProxyPort uint `js:"proxyPort"`
+}
+
+// KafkaDisruptionOptions defines options for the injection of Kafka faults in a target pod
+type KafkaDisruptionOptions struct {
+	// Port used by the agent for listening
+	ProxyPort uint `js:"proxyPort"`
+}
+
 // HTTPFault specifies a fault to be injected in http requests
 type HTTPFault struct {
 	// port the disruptions will be applied to
@@ -45,6 +111,101 @@ type HTTPFault struct {
 	ErrorBody string `js:"errorBody"`
 	// Comma-separated list of url paths to be excluded from disruptions
 	Exclude string
+	// Upstream (host:port) the target sends requests to. If specified, the requests sent by the target to the
+	// upstream are disrupted instead of the requests sent to the target, and Port is ignored.
+	Upstream string `js:"upstream"`
+	// Modifications of the responses
+	HTTPResponseFault
+	// Throttling of the body of the responses
+	HTTPThrottlingFault
+	// Disruption of WebSocket connections and Server-Sent Events streams
+	HTTPStreamFault
+	// Rules select requests and define the disruption applied to them. The first rule that matches a request is
+	// applied. The requests that do not match any rule are disrupted with the settings above.
+	Rules []HTTPRule `js:"rules"`
+	// Schedule varies the delays and error rates of the fault during the injection
+	Schedule FaultSchedule `js:"schedule"`
+}
+
+// HTTPResponseFault specifies modifications to the responses returned to the disrupted requests
+type HTTPResponseFault struct {
+	// Headers added to the responses, replacing any existing value
+	SetHeaders map[string]string `js:"setHeaders" json:"setHeaders,omitempty"`
+	// Headers removed from the responses
+	RemoveHeaders []string `js:"removeHeaders" json:"removeHeaders,omitempty"`
+	// Status code that replaces the status code of the responses, keeping their body
+	ResponseStatus uint `js:"responseStatus" json:"responseStatus,omitempty"`
+	// Fraction (in the range 0.0 to 1.0) of the body removed from its end
+	TruncateBody float32 `js:"truncateBody" json:"truncateBody,omitempty"`
+	// Fraction (in the range 0.0 to 1.0) of the bytes of the body replaced by random bytes
+	CorruptBody float32 `js:"corruptBody" json:"corruptBody,omitempty"`
+	// Replace the body of the responses with a malformed JSON payload
+	MalformedJSON bool `js:"malformedJson" json:"malformedJson,omitempty"`
+}
+
+// HTTPThrottlingFault specifies how the body of the responses is sent to the client. The headers are sent after the
+// delay of the fault, and then the body is sent following the throttling.
+type HTTPThrottlingFault struct {
+	// Maximum rate, in bytes per second, at which the body is sent
+	BodyRate uint `js:"bodyRate" json:"bodyRate,omitempty"`
+	// Delay between sending the headers and the first byte of the body
+	FirstByteDelay time.Duration `js:"firstByteDelay" json:"firstByteDelay,omitempty"`
+	// Number of bytes of the body sent before the body stalls
+	StallAfter uint `js:"stallAfter" json:"stallAfter,omitempty"`
+	// Time the body stalls after sending the number of bytes specified in StallAfter
+	StallDuration time.Duration `js:"stallDuration" json:"stallDuration,omitempty"`
+}
+
+// HTTPStreamFault specifies a fault to be injected in WebSocket connections and Server-Sent Events streams. It cannot
+// be combined with the modifications of the body of an HTTPResponseFault.
+type HTTPStreamFault struct {
+	// Delay introduced to each WebSocket frame, in either direction, and to each event of a stream
+	FrameDelay time.Duration `js:"frameDelay" json:"frameDelay,omitempty"`
+	// Number of frames or events sent to the client after which the connection is dropped
+	DropAfterFrames uint `js:"dropAfterFrames" json:"dropAfterFrames,omitempty"`
+	// Maximum time after which the connection is dropped. The connection is dropped at a random time up to this
+	// duration
+	DropAfter time.Duration `js:"dropAfter" json:"dropAfter,omitempty"`
+	// Close code sent to the client when a WebSocket connection is dropped. If 0, no close frame is sent
+	CloseCode uint16 `js:"closeCode" json:"closeCode,omitempty"`
+}
+
+// HTTPMatch selects http requests by their attributes. A request matches if it satisfies all the criteria specified.
+type HTTPMatch struct {
+	// Prefix of the request path
+	PathPrefix string `js:"pathPrefix" json:"pathPrefix,omitempty"`
+	// Glob pattern for the request path (e.g. "/users/*/orders"). '*' does not match '/'
+	PathGlob string `js:"pathGlob" json:"pathGlob,omitempty"`
+	// Regular expression for the request path
+	PathRegex string `js:"pathRegex" json:"pathRegex,omitempty"`
+	// Request methods. Any of them matches
+	Methods []string `js:"methods" json:"methods,omitempty"`
+	// Request headers. All of them must be present. An empty value matches any value of the header
+	Headers map[string]string `js:"headers" json:"headers,omitempty"`
+	// Query parameters. All of them must be present. An empty value matches any value of the parameter
+	Query map[string]string `js:"query" json:"query,omitempty"`
+}
+
+// HTTPRule defines the fault injected in the http requests that match it
+type HTTPRule struct {
+	// Criteria for selecting the requests
+	Match HTTPMatch `js:"match" json:"match"`
+	// Average delay introduced to requests
+	AverageDelay time.Duration `js:"averageDelay" json:"averageDelay,omitempty"`
+	// Variation in the delay (with respect of the average delay)
+	DelayVariation time.Duration `js:"delayVariation" json:"delayVariation,omitempty"`
+	// Fraction (in the range 0.0 to 1.0) of requests that will return an error
+	ErrorRate float32 `js:"errorRate" json:"errorRate,omitempty"`
+	// Error code to be returned by requests selected in the error rate
+	ErrorCode uint `js:"errorCode" json:"errorCode,omitempty"`
+	// Body to be returned when an error is injected
+	ErrorBody string `js:"errorBody" json:"errorBody,omitempty"`
+	// Modifications of the responses
+	HTTPResponseFault
+	// Throttling of the body of the responses
+	HTTPThrottlingFault
+	// Disruption of WebSocket connections and Server-Sent Events streams
+	HTTPStreamFault
 }
 
 // GrpcFault specifies a fault to be injected in grpc requests
@@ -63,4 +224,221 @@ type GrpcFault struct {
 	StatusMessage string `js:"statusMessage"`
 	// List of grpc services to be excluded from disruptions
 	Exclude string `js:"exclude"`
+	// Upstream (host:port) the target sends requests to. If specified, the requests sent by the target to the
+	// upstream are disrupted instead of the requests sent to the target, and Port is ignored.
+	Upstream string `js:"upstream"`
+	// Disruption of the individual messages of the streams
+	GrpcStreamFault
+	// Rules select requests and define the disruption applied to them. The first rule that matches a request is
+	// applied. The requests that do not match any rule are disrupted with the settings above.
+	Rules []GrpcRule `js:"rules"`
+	// Schedule varies the delays and error rates of the fault during the injection
+	Schedule FaultSchedule `js:"schedule"`
+}
+
+// GrpcStreamFault specifies a fault to be injected in the individual messages of grpc streams.
+// Streams are aborted with the status code and message of the fault.
+type GrpcStreamFault struct {
+	// Delay introduced to each message, in either direction
+	MessageDelay time.Duration `js:"messageDelay" json:"messageDelay,omitempty"`
+	// Fraction (in the range 0.0 to 1.0) of messages that are dropped, in either direction
+	MessageDropRate float32 `js:"messageDropRate" json:"messageDropRate,omitempty"`
+	// Number of messages sent to the client after which the stream is aborted
+	AbortAfterMessages uint `js:"abortAfterMessages" json:"abortAfterMessages,omitempty"`
+	// Maximum time after which the stream is aborted. The stream is aborted at a random time up to this duration
+	AbortAfter time.Duration `js:"abortAfter" json:"abortAfter,omitempty"`
+}
+
+// GrpcMatch selects grpc requests by their method and metadata. A request matches if it satisfies all the criteria
+// specified. Methods are patterns for the full method name (e.g. "/pkg.Service/Method"), where '*' matches any
+// sequence of characters except '/' (e.g. "/pkg.Service/*").
+type GrpcMatch struct {
+	// Methods to include. Any of them matches
+	Methods []string `js:"methods" json:"methods,omitempty"`
+	// Methods to exclude. Excluded requests can match a later rule, but are not disrupted by the default fault
+	ExcludeMethods []string `js:"excludeMethods" json:"excludeMethods,omitempty"`
+	// Request metadata. All the keys must be present. An empty value matches any value of the key
+	Metadata map[string]string `js:"metadata" json:"metadata,omitempty"`
+}
+
+// GrpcRule defines the fault injected in the grpc requests that match it
+type GrpcRule struct {
+	// Criteria for selecting the requests
+	Match GrpcMatch `js:"match" json:"match"`
+	// Average delay introduced to requests
+	AverageDelay time.Duration `js:"averageDelay" json:"averageDelay,omitempty"`
+	// Variation in the delay (with respect of the average delay)
+	DelayVariation time.Duration `js:"delayVariation" json:"delayVariation,omitempty"`
+	// Fraction (in the range 0.0 to 1.0) of requests that will return an error
+	ErrorRate float32 `js:"errorRate" json:"errorRate,omitempty"`
+	// Status code to be returned by requests selected to return an error
+	StatusCode int32 `js:"statusCode" json:"statusCode,omitempty"`
+	// Status message to be returned in requests selected to return an error
+	StatusMessage string `js:"statusMessage" json:"statusMessage,omitempty"`
+	// Disruption of the individual messages of the streams
+	GrpcStreamFault
+}
+
+// TCPFault specifies a fault to be injected in TCP connections. The data of the connections is not decoded, so the
+// fault can be injected in any protocol that runs over TCP.
+type TCPFault struct {
+	// port the disruptions will be applied to
+	Port intstr.IntOrString
+	// Upstream (host:port) the target opens connections to. If specified, the connections opened by the target to
+	// the upstream are disrupted instead of the connections opened to the target, and Port is ignored.
+	Upstream string `js:"upstream"`
+	// Delay introduced when a connection is accepted, before connecting to the target
+	ConnectDelay time.Duration `js:"connectDelay"`
+	// Delay introduced to each chunk of data forwarded, in either direction
+	ChunkDelay time.Duration `js:"chunkDelay"`
+	// Maximum rate, in bytes per second, at which data is forwarded in each direction
+	Bandwidth uint `js:"bandwidth"`
+	// Fraction (in the range 0.0 to 1.0) of connections that are accepted but never forwarded to the target
+	HalfOpenRate float32 `js:"halfOpenRate"`
+	// Number of bytes forwarded, adding both directions, after which the connection is reset
+	ResetAfterBytes uint `js:"resetAfterBytes"`
+}
+
+// RedisFault specifies a fault to be injected in the commands sent to a Redis server. In a transaction, only EXEC is
+// disrupted, and returning an error to EXEC discards the transaction.
+type RedisFault struct {
+	// port the disruptions will be applied to
+	Port intstr.IntOrString
+	// Upstream (host:port) the target sends commands to. If specified, the commands sent by the target to the
+	// upstream are disrupted instead of the commands sent to the target, and Port is ignored.
+	Upstream string `js:"upstream"`
+	// Average delay introduced to commands
+	AverageDelay time.Duration `js:"averageDelay"`
+	// Variation in the delay (with respect of the average delay)
+	DelayVariation time.Duration `js:"delayVariation"`
+	// Fraction (in the range 0.0 to 1.0) of commands that will return an error
+	ErrorRate float32 `js:"errorRate"`
+	// Error returned by the commands selected to return an error, starting with the error code
+	// (e.g. "LOADING Redis is loading the dataset in memory"). Defaults to "ERR injected error"
+	Error string `js:"error"`
+	// Comma-separated list of commands to be excluded from disruptions
+	Exclude string `js:"exclude"`
+	// Rules select commands and define the disruption applied to them. The first rule that matches a command is
+	// applied. The commands that do not match any rule are disrupted with the settings above.
+	Rules []RedisRule `js:"rules"`
+}
+
+// RedisMatch selects Redis commands by their name and key. A command matches if it satisfies all the criteria
+// specified. Keys are glob-style patterns as in the KEYS command (e.g. "user:*"), and the key of a command is its
+// first argument.
+type RedisMatch struct {
+	// Command names. Any of them matches
+	Commands []string `js:"commands" json:"commands,omitempty"`
+	// Key patterns. Any of them matches
+	Keys []string `js:"keys" json:"keys,omitempty"`
+}
+
+// RedisRule defines the fault injected in the Redis commands that match it
+type RedisRule struct {
+	// Criteria for selecting the commands
+	Match RedisMatch `js:"match" json:"match"`
+	// Average delay introduced to commands
+	AverageDelay time.Duration `js:"averageDelay" json:"averageDelay,omitempty"`
+	// Variation in the delay (with respect of the average delay)
+	DelayVariation time.Duration `js:"delayVariation" json:"delayVariation,omitempty"`
+	// Fraction (in the range 0.0 to 1.0) of commands that will return an error
+	ErrorRate float32 `js:"errorRate" json:"errorRate,omitempty"`
+	// Error returned by the commands selected to return an error, starting with the error code
+	Error string `js:"error" json:"error,omitempty"`
+}
+
+// PostgresFault specifies a fault to be injected in the queries sent to a PostgreSQL server
+type PostgresFault struct {
+	// port the disruptions will be applied to
+	Port intstr.IntOrString
+	// Upstream (host:port) the target sends queries to. If specified, the queries sent by the target to the
+	// upstream are disrupted instead of the queries sent to the target, and Port is ignored.
+	Upstream string `js:"upstream"`
+	// Average delay introduced to queries
+	AverageDelay time.Duration `js:"averageDelay"`
+	// Variation in the delay (with respect of the average delay)
+	DelayVariation time.Duration `js:"delayVariation"`
+	// Fraction (in the range 0.0 to 1.0) of queries that will return an error
+	ErrorRate float32 `js:"errorRate"`
+	// SQLSTATE code of the error returned by the queries selected in the error rate (e.g. "40001")
+	ErrorCode string `js:"errorCode"`
+	// Message of the error returned by the queries selected in the error rate
+	ErrorMessage string `js:"errorMessage"`
+	// Fraction (in the range 0.0 to 1.0) of queries that terminate the connection to the server, aborting the
+	// transaction in progress
+	TerminateRate float32 `js:"terminateRate"`
+	// Rules select queries and define the disruption applied to them. The first rule that matches a query is
+	// applied. The queries that do not match any rule are disrupted with the settings above.
+	Rules []PostgresRule `js:"rules"`
+}
+
+// PostgresMatch selects PostgreSQL queries by their text and the state of the transaction they are executed in.
+// A query matches if it satisfies all the criteria specified.
+type PostgresMatch struct {
+	// Regular expression for the text of the query (e.g. "(?i)^\\s*UPDATE orders")
+	Query string `js:"query" json:"query,omitempty"`
+	// Select only the queries executed inside a transaction block
+	InTransaction bool `js:"inTransaction" json:"inTransaction,omitempty"`
+}
+
+// PostgresRule defines the fault injected in the PostgreSQL queries that match it
+type PostgresRule struct {
+	// Criteria for selecting the queries
+	Match PostgresMatch `js:"match" json:"match"`
+	// Average delay introduced to queries
+	AverageDelay time.Duration `js:"averageDelay" json:"averageDelay,omitempty"`
+	// Variation in the delay (with respect of the average delay)
+	DelayVariation time.Duration `js:"delayVariation" json:"delayVariation,omitempty"`
+	// Fraction (in the range 0.0 to 1.0) of queries that will return an error
+	ErrorRate float32 `js:"errorRate" json:"errorRate,omitempty"`
+	// SQLSTATE code of the error returned by the queries selected in the error rate
+	ErrorCode string `js:"errorCode" json:"errorCode,omitempty"`
+	// Message of the error returned by the queries selected in the error rate
+	ErrorMessage string `js:"errorMessage" json:"errorMessage,omitempty"`
+	// Fraction (in the range 0.0 to 1.0) of queries that terminate the connection to the server
+	TerminateRate float32 `js:"terminateRate" json:"terminateRate,omitempty"`
+}
+
+// KafkaFault specifies a fault to be injected in the requests sent to a Kafka broker
+type KafkaFault struct {
+	// port the disruptions will be applied to
+	Port intstr.IntOrString
+	// Upstream (host:port) the target sends requests to. If specified, the requests sent by the target to the
+	// upstream are disrupted instead of the requests sent to the target, and Port is ignored.
+	Upstream string `js:"upstream"`
+	// Average delay introduced to requests
+	AverageDelay time.Duration `js:"averageDelay"`
+	// Variation in the delay (with respect of the average delay)
+	DelayVariation time.Duration `js:"delayVariation"`
+	// Fraction (in the range 0.0 to 1.0) of Produce and Fetch requests that will return an error
+	ErrorRate float32 `js:"errorRate"`
+	// Error returned for all the partitions of the requests selected in the error rate, given by its name
+	// (e.g. "NOT_LEADER_OR_FOLLOWER") or its numeric code
+	Error string `js:"error"`
+	// Rules select requests and define the disruption applied to them. The first rule that matches a request is
+	// applied. The requests that do not match any rule are disrupted with the settings above.
+	Rules []KafkaRule `js:"rules"`
+}
+
+// KafkaMatch selects Kafka requests by their API and topics. A request matches if it satisfies all the criteria
+// specified.
+type KafkaMatch struct {
+	// APIs given by their name (e.g. "Produce", "JoinGroup") or their numeric key. Any of them matches
+	APIs []string `js:"apis" json:"apis,omitempty"`
+	// Topics included in Produce and Fetch requests. Any of them matches
+	Topics []string `js:"topics" json:"topics,omitempty"`
+}
+
+// KafkaRule defines the fault injected in the Kafka requests that match it
+type KafkaRule struct {
+	// Criteria for selecting the requests
+	Match KafkaMatch `js:"match" json:"match"`
+	// Average delay introduced to requests
+	AverageDelay time.Duration `js:"averageDelay" json:"averageDelay,omitempty"`
+	// Variation in the delay (with respect of the average delay)
+	DelayVariation time.Duration `js:"delayVariation" json:"delayVariation,omitempty"`
+	// Fraction (in the range 0.0 to 1.0) of Produce and Fetch requests that will return an error
+	ErrorRate float32 `js:"errorRate" json:"errorRate,omitempty"`
+	// Error returned for all the partitions of the requests selected in the error rate
+	Error string `js:"error" json:"error,omitempty"`
 }
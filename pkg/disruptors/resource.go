@@ -0,0 +1,54 @@
+package disruptors
+
+import (
+	"context"
+	"time"
+)
+
+// ResourceFaultInjector defines the methods for injecting faults that stress the resources of a target
+type ResourceFaultInjector interface {
+	// InjectStressFaults stresses the resources of the disruptor's targets for the specified duration
+	InjectStressFaults(ctx context.Context, fault StressFault, duration time.Duration) error
+}
+
+// StressFault specifies a fault that stresses the resources of a target
+type StressFault struct {
+	// Load is the CPU load to be generated in each CPU as a percentage (0-100)
+	Load int `js:"load"`
+	// CPUs is the number of CPUs to stress. If zero, one CPU is stressed unless a Memory stress is specified.
+	CPUs int `js:"cpus"`
+	// Slice is the interval of CPU stress. Each slice is divided between busy and idle time to achieve the load.
+	Slice time.Duration `js:"slice"`
+	// Memory is the amount of memory to allocate, either as a quantity (e.g. "256Mi") or as a percentage of the
+	// target's memory limit (e.g. "50%"). In pods, the memory is accounted to the first container, which can be
+	// OOM-killed if the memory exceeds its limit. In nodes, the memory is accounted to the agent and a percentage
+	// cannot be used, as there is no target container.
+	Memory string `js:"memory"`
+	// MemoryRampUp is the time for reaching the amount of memory. If zero, the memory is allocated at once.
+	MemoryRampUp time.Duration `js:"memoryRampUp"`
+}
+
+// DiskFaultInjector defines the methods for injecting faults that stress the disk of a target
+type DiskFaultInjector interface {
+	// InjectDiskFaults stresses the disk of the disruptor's targets for the specified duration
+	InjectDiskFaults(ctx context.Context, fault DiskFault, duration time.Duration) error
+}
+
+// DiskFault specifies a fault that stresses the disk of a target, either generating I/O load or
+// filling a volume up to a target usage
+type DiskFault struct {
+	// Volume is the name of the target's volume to stress. If empty, the filesystem of the agent is stressed.
+	Volume string `js:"volume"`
+	// Path is the directory to stress, relative to the volume. It cannot leave the volume.
+	Path string `js:"path"`
+	// Fill is the usage of the volume to reach as a percentage. If set, the volume is filled
+	// instead of generating I/O load. Volumes backed by the node's disk cannot be filled, except emptyDir volumes
+	// with a size limit, which are filled from their root up to a percentage of their limit.
+	Fill int `js:"fill"`
+	// Workers is the number of concurrent workers generating I/O load
+	Workers int `js:"workers"`
+	// BlockSize is the size of each read and write (e.g. "1Mi")
+	BlockSize string `js:"blockSize"`
+	// FileSize is the size of the file written and read by each worker (e.g. "64Mi")
+	FileSize string `js:"fileSize"`
+}
@@ -0,0 +1,33 @@
+package disruptors
+
+import "time"
+
+// FaultSchedule defines how the intensity of a fault changes during its injection. At any time, the schedule has a
+// level that multiplies the delays and rates of the fault. While the level is 0.0, the fault is not injected.
+// If Kind is not specified, the fault is injected with level 1.0 during the whole injection.
+type FaultSchedule struct {
+	// Kind of schedule: "constant", "ramp", "steps", "flap" or "sine"
+	Kind string `js:"kind" json:"kind,omitempty"`
+	// From is the initial level of a ramp, and the minimum level of a sine
+	From float64 `js:"from" json:"from,omitempty"`
+	// To is the final level of a ramp, and the maximum level of a sine
+	To float64 `js:"to" json:"to,omitempty"`
+	// Duration of a ramp. After it, the level is kept at To
+	Duration time.Duration `js:"duration" json:"duration,omitempty"`
+	// Steps of a step function, sorted by time. The level is 0.0 before the first step
+	Steps []FaultScheduleStep `js:"steps" json:"steps,omitempty"`
+	// On is the duration of the periods of a flap with level 1.0
+	On time.Duration `js:"on" json:"on,omitempty"`
+	// Off is the duration of the periods of a flap with level 0.0
+	Off time.Duration `js:"off" json:"off,omitempty"`
+	// Period of a sine, which starts at its minimum level
+	Period time.Duration `js:"period" json:"period,omitempty"`
+}
+
+// FaultScheduleStep is a change of level at a time since the start of the injection
+type FaultScheduleStep struct {
+	// At is the time since the start of the injection
+	At time.Duration `js:"at" json:"at"`
+	// Level from At until the next step
+	Level float64 `js:"level" json:"level"`
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"sort"
 	"strings"
 
 	"github.com/grafana/xk6-disruptor/pkg/kubernetes/helpers"
@@ -20,6 +21,10 @@ var ErrSelectorNoPods = errors.New("no pods found matching selector")
 // ErrServiceNoTargets is returned by NewServiceDisruptor when passed a service without any pod matching its selector.
 var ErrServiceNoTargets = errors.New("service does not have any backing pods")
 
+// ErrSelectorNoNodes is returned by NewNodeDisruptor when the selector passed to it does not match any node in the
+// cluster.
+var ErrSelectorNoNodes = errors.New("no nodes found matching selector")
+
 // PodSelector returns the target of a PodSelectorSpec
 type PodSelector struct {
 	helper helpers.PodHelper
@@ -139,3 +144,139 @@ func (s *ServicePodSelector) Targets(ctx context.Context) ([]corev1.Pod, error)
 
 	return targets, nil
 }
+
+// NodeSelector returns the targets of a NodeSelectorSpec
+type NodeSelector struct {
+	helper helpers.NodeHelper
+	spec   NodeSelectorSpec
+}
+
+// NewNodeSelector creates a new NodeSelector
+func NewNodeSelector(spec NodeSelectorSpec, helper helpers.NodeHelper) (*NodeSelector, error) {
+	// validate selector. Selecting all nodes in the cluster must be explicit.
+	emptySelect := reflect.DeepEqual(spec.Select, NodeAttributes{})
+	emptyExclude := reflect.DeepEqual(spec.Exclude, NodeAttributes{})
+	if emptySelect && emptyExclude {
+		return nil, fmt.Errorf("select and exclude attributes in node selector cannot both be empty")
+	}
+
+	return &NodeSelector{
+		spec:   spec,
+		helper: helper,
+	}, nil
+}
+
+// Targets returns the list of target nodes
+func (s *NodeSelector) Targets(ctx context.Context) ([]corev1.Node, error) {
+	// labels are filtered by the API. Taints and conditions are filtered here.
+	filter := helpers.NodeFilter{
+		Select:  s.spec.Select.Labels,
+		Exclude: s.spec.Exclude.Labels,
+	}
+
+	nodes, err := s.helper.List(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	targets := []corev1.Node{}
+	for _, node := range nodes {
+		if !s.spec.Select.matchesAll(node) || s.spec.Exclude.matchesAny(node) {
+			continue
+		}
+		targets = append(targets, node)
+	}
+
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("finding nodes matching '%s': %w", s.spec, ErrSelectorNoNodes)
+	}
+
+	return targets, nil
+}
+
+// matchesAll returns true if the node has all the taints and satisfies all the conditions in the attributes
+func (a NodeAttributes) matchesAll(node corev1.Node) bool {
+	for _, taint := range a.Taints {
+		if !hasTaint(node, taint) {
+			return false
+		}
+	}
+
+	for condition, status := range a.Conditions {
+		if !hasCondition(node, condition, status) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// matchesAny returns true if the node has any of the taints or satisfies any of the conditions in the attributes
+func (a NodeAttributes) matchesAny(node corev1.Node) bool {
+	for _, taint := range a.Taints {
+		if hasTaint(node, taint) {
+			return true
+		}
+	}
+
+	for condition, status := range a.Conditions {
+		if hasCondition(node, condition, status) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func hasTaint(node corev1.Node, key string) bool {
+	for _, taint := range node.Spec.Taints {
+		if taint.Key == key {
+			return true
+		}
+	}
+
+	return false
+}
+
+func hasCondition(node corev1.Node, condition string, status string) bool {
+	for _, c := range node.Status.Conditions {
+		if strings.EqualFold(string(c.Type), condition) {
+			return strings.EqualFold(string(c.Status), status)
+		}
+	}
+
+	return false
+}
+
+// String returns a human-readable explanation of the nodes matched by a NodeSelector.
+func (n NodeSelectorSpec) String() string {
+	str := "nodes "
+	str += n.Select.group("including")
+	str += n.Exclude.group("excluding")
+
+	return strings.TrimSuffix(str, ", ")
+}
+
+// group returns the attributes as a string, giving that group a name. The returned string has the form of:
+// `groupName(foo=bar, taint=key, condition=Ready:True), `, including the trailing space and comma.
+// Empty attributes produce an empty string.
+func (a NodeAttributes) group(groupName string) string {
+	items := []string{}
+	for k, v := range a.Labels {
+		items = append(items, fmt.Sprintf("%s=%s", k, v))
+	}
+	for _, taint := range a.Taints {
+		items = append(items, fmt.Sprintf("taint=%s", taint))
+	}
+	for condition, status := range a.Conditions {
+		items = append(items, fmt.Sprintf("condition=%s:%s", condition, status))
+	}
+
+	if len(items) == 0 {
+		return ""
+	}
+
+	sort.Strings(items)
+
+	return groupName + "(" + strings.Join(items, ", ") + "), "
+}
@@ -322,3 +322,222 @@ func Test_ServicePodSelectorTargets(t *testing.T) {
 		})
 	}
 }
+
+func Test_NewNodeSelector(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		title       string
+		spec        NodeSelectorSpec
+		expectError bool
+	}{
+		{
+			title: "valid specs",
+			spec: NodeSelectorSpec{
+				Select: NodeAttributes{Labels: map[string]string{
+					"pool": "test",
+				}},
+			},
+			expectError: false,
+		},
+		{
+			title: "only exclusions",
+			spec: NodeSelectorSpec{
+				Exclude: NodeAttributes{Taints: []string{"node-role.kubernetes.io/control-plane"}},
+			},
+			expectError: false,
+		},
+		{
+			title:       "empty specs",
+			spec:        NodeSelectorSpec{},
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			t.Parallel()
+
+			client := fake.NewSimpleClientset()
+			k, _ := kubernetes.NewFakeKubernetes(client)
+
+			_, err := NewNodeSelector(tc.spec, k.NodeHelper())
+
+			if !tc.expectError && err != nil {
+				t.Fatalf("unexpected error creating node selector: %v", err)
+			}
+
+			if tc.expectError && err == nil {
+				t.Fatalf("should had failed creating node selector")
+			}
+		})
+	}
+}
+
+func Test_NodeSelectorString(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name     string
+		selector NodeSelectorSpec
+		expected string
+	}{
+		{
+			name: "Only inclusions",
+			selector: NodeSelectorSpec{
+				Select: NodeAttributes{Labels: map[string]string{"pool": "test"}},
+			},
+			expected: `nodes including(pool=test)`,
+		},
+		{
+			name: "Only exclusions",
+			selector: NodeSelectorSpec{
+				Exclude: NodeAttributes{Taints: []string{"dedicated"}},
+			},
+			expected: `nodes excluding(taint=dedicated)`,
+		},
+		{
+			name: "Both inclusions and exclusions",
+			selector: NodeSelectorSpec{
+				Select: NodeAttributes{
+					Labels:     map[string]string{"pool": "test"},
+					Conditions: map[string]string{"Ready": "True"},
+				},
+				Exclude: NodeAttributes{Taints: []string{"dedicated"}},
+			},
+			expected: `nodes including(condition=Ready:True, pool=test), excluding(taint=dedicated)`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			output := tc.selector.String()
+			if tc.expected != output {
+				t.Fatalf("expected string does not match output string:\n%s\n%s", tc.expected, output)
+			}
+		})
+	}
+}
+
+func Test_NodeSelectorTargets(t *testing.T) {
+	t.Parallel()
+
+	nodes := []corev1.Node{
+		builders.NewNodeBuilder("node-1").
+			WithLabel("pool", "test").
+			WithCondition(corev1.NodeReady, corev1.ConditionTrue).
+			Build(),
+		builders.NewNodeBuilder("node-2").
+			WithLabel("pool", "test").
+			WithTaint("dedicated", "db", corev1.TaintEffectNoSchedule).
+			WithCondition(corev1.NodeReady, corev1.ConditionTrue).
+			Build(),
+		builders.NewNodeBuilder("node-3").
+			WithLabel("pool", "test").
+			WithCondition(corev1.NodeReady, corev1.ConditionFalse).
+			Build(),
+		builders.NewNodeBuilder("node-4").
+			WithLabel("pool", "other").
+			WithCondition(corev1.NodeReady, corev1.ConditionTrue).
+			Build(),
+	}
+
+	testCases := []struct {
+		title       string
+		spec        NodeSelectorSpec
+		expectError bool
+		expected    []string
+	}{
+		{
+			title: "matching labels",
+			spec: NodeSelectorSpec{
+				Select: NodeAttributes{Labels: map[string]string{"pool": "test"}},
+			},
+			expected: []string{"node-1", "node-2", "node-3"},
+		},
+		{
+			title: "excluding labels",
+			spec: NodeSelectorSpec{
+				Exclude: NodeAttributes{Labels: map[string]string{"pool": "test"}},
+			},
+			expected: []string{"node-4"},
+		},
+		{
+			title: "matching taints",
+			spec: NodeSelectorSpec{
+				Select: NodeAttributes{Taints: []string{"dedicated"}},
+			},
+			expected: []string{"node-2"},
+		},
+		{
+			title: "excluding taints",
+			spec: NodeSelectorSpec{
+				Select:  NodeAttributes{Labels: map[string]string{"pool": "test"}},
+				Exclude: NodeAttributes{Taints: []string{"dedicated"}},
+			},
+			expected: []string{"node-1", "node-3"},
+		},
+		{
+			title: "matching conditions",
+			spec: NodeSelectorSpec{
+				Select: NodeAttributes{
+					Labels:     map[string]string{"pool": "test"},
+					Conditions: map[string]string{"ready": "true"},
+				},
+			},
+			expected: []string{"node-1", "node-2"},
+		},
+		{
+			title: "excluding conditions",
+			spec: NodeSelectorSpec{
+				Exclude: NodeAttributes{Conditions: map[string]string{"Ready": "True"}},
+			},
+			expected: []string{"node-3"},
+		},
+		{
+			title: "no matching nodes",
+			spec: NodeSelectorSpec{
+				Select: NodeAttributes{Labels: map[string]string{"pool": "none"}},
+			},
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			t.Parallel()
+
+			var objs []runtime.Object
+			for n := range nodes {
+				objs = append(objs, &nodes[n])
+			}
+
+			client := fake.NewSimpleClientset(objs...)
+			k, _ := kubernetes.NewFakeKubernetes(client)
+
+			s, err := NewNodeSelector(tc.spec, k.NodeHelper())
+			if err != nil {
+				t.Fatalf("failed%v", err)
+			}
+
+			targets, err := s.Targets(t.Context())
+			if tc.expectError && err != nil {
+				return
+			}
+
+			if !tc.expectError && err != nil {
+				t.Fatalf("failed%v", err)
+			}
+
+			if tc.expectError && err == nil {
+				t.Fatalf("should had failed")
+			}
+
+			targetNames := utils.NodeNames(targets)
+			sort.Strings(targetNames)
+			if diff := cmp.Diff(targetNames, tc.expected); diff != "" {
+				t.Fatalf("expected targets dot not match returned\n%s", diff)
+			}
+		})
+	}
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/grafana/xk6-disruptor/pkg/kubernetes"
 	"github.com/grafana/xk6-disruptor/pkg/kubernetes/helpers"
+	"github.com/grafana/xk6-disruptor/pkg/types/intstr"
 	"github.com/grafana/xk6-disruptor/pkg/utils"
 
 	corev1 "k8s.io/api/core/v1"
@@ -18,6 +19,7 @@ type ServiceDisruptor interface {
 	Disruptor
 	ProtocolFaultInjector
 	PodFaultInjector
+	FaultCleaner
 }
 
 // ServiceDisruptorOptions defines options that controls the behavior of the ServiceDisruptor
@@ -30,6 +32,7 @@ type ServiceDisruptorOptions struct {
 // serviceDisruptor is an instance of a ServiceDisruptor
 type serviceDisruptor struct {
 	service  corev1.Service
+	k8s      kubernetes.Kubernetes
 	helper   helpers.PodHelper
 	selector *ServicePodSelector
 	options  ServiceDisruptorOptions
@@ -63,6 +66,7 @@ func NewServiceDisruptor(
 
 	return &serviceDisruptor{
 		service:  *svc,
+		k8s:      k8s,
 		helper:   k8s.PodHelper(namespace),
 		selector: selector,
 		options:  options,
@@ -75,18 +79,31 @@ func (d *serviceDisruptor) InjectHTTPFaults(
 	duration time.Duration,
 	options HTTPDisruptionOptions,
 ) error {
-	// Map service port to a target pod port
-	port, err := utils.GetTargetPort(d.service, fault.Port)
+	// Map service port to a target pod port, unless the outbound requests to an upstream are disrupted
+	podFault := fault
+	if fault.Upstream == "" {
+		port, err := utils.GetTargetPort(d.service, fault.Port)
+		if err != nil {
+			return err
+		}
+		podFault.Port = port
+	}
+
+	credentials, err := loadTLSCredentials(ctx, d.k8s, d.service.Namespace, options.TLS)
 	if err != nil {
 		return err
 	}
-	podFault := fault
-	podFault.Port = port
+
+	// clients may address the targets by any of the names of the service
+	if fault.Upstream == "" {
+		options.TLS = options.TLS.withHosts(serviceHosts(d.service)...)
+	}
 
 	command := PodHTTPFaultCommand{
-		fault:    podFault,
-		duration: duration,
-		options:  options,
+		fault:       podFault,
+		duration:    duration,
+		options:     options,
+		credentials: credentials,
 	}
 
 	visitor := NewPodAgentVisitor(
@@ -105,19 +122,31 @@ func (d *serviceDisruptor) InjectHTTPFaults(
 	return controller.Visit(ctx, visitor)
 }
 
+// serviceHosts returns the DNS names of the service
+func serviceHosts(service corev1.Service) []string {
+	return []string{
+		service.Name,
+		service.Name + "." + service.Namespace,
+		service.Name + "." + service.Namespace + ".svc",
+		service.Name + "." + service.Namespace + ".svc.cluster.local",
+	}
+}
+
 func (d *serviceDisruptor) InjectGrpcFaults(
 	ctx context.Context,
 	fault GrpcFault,
 	duration time.Duration,
 	options GrpcDisruptionOptions,
 ) error {
-	// Map service port to a target pod port
-	port, err := utils.GetTargetPort(d.service, fault.Port)
-	if err != nil {
-		return err
-	}
+	// Map service port to a target pod port, unless the outbound requests to an upstream are disrupted
 	podFault := fault
-	podFault.Port = port
+	if fault.Upstream == "" {
+		port, err := utils.GetTargetPort(d.service, fault.Port)
+		if err != nil {
+			return err
+		}
+		podFault.Port = port
+	}
 
 	command := PodGrpcFaultCommand{
 		fault:    fault,
@@ -141,6 +170,94 @@ func (d *serviceDisruptor) InjectGrpcFaults(
 	return controller.Visit(ctx, visitor)
 }
 
+func (d *serviceDisruptor) InjectTCPFaults(
+	ctx context.Context,
+	fault TCPFault,
+	duration time.Duration,
+	options TCPDisruptionOptions,
+) error {
+	port, err := d.targetPort(fault.Port, fault.Upstream)
+	if err != nil {
+		return err
+	}
+	fault.Port = port
+
+	return d.visit(ctx, PodTCPFaultCommand{fault: fault, duration: duration, options: options})
+}
+
+func (d *serviceDisruptor) InjectRedisFaults(
+	ctx context.Context,
+	fault RedisFault,
+	duration time.Duration,
+	options RedisDisruptionOptions,
+) error {
+	port, err := d.targetPort(fault.Port, fault.Upstream)
+	if err != nil {
+		return err
+	}
+	fault.Port = port
+
+	return d.visit(ctx, PodRedisFaultCommand{fault: fault, duration: duration, options: options})
+}
+
+func (d *serviceDisruptor) InjectPostgresFaults(
+	ctx context.Context,
+	fault PostgresFault,
+	duration time.Duration,
+	options PostgresDisruptionOptions,
+) error {
+	port, err := d.targetPort(fault.Port, fault.Upstream)
+	if err != nil {
+		return err
+	}
+	fault.Port = port
+
+	return d.visit(ctx, PodPostgresFaultCommand{fault: fault, duration: duration, options: options})
+}
+
+func (d *serviceDisruptor) InjectKafkaFaults(
+	ctx context.Context,
+	fault KafkaFault,
+	duration time.Duration,
+	options KafkaDisruptionOptions,
+) error {
+	port, err := d.targetPort(fault.Port, fault.Upstream)
+	if err != nil {
+		return err
+	}
+	fault.Port = port
+
+	return d.visit(ctx, PodKafkaFaultCommand{fault: fault, duration: duration, options: options})
+}
+
+// targetPort maps a port of the service to the port of the target pods. The port is not mapped if an upstream is
+// given, as the outbound traffic of the targets is disrupted instead.
+func (d *serviceDisruptor) targetPort(port intstr.IntOrString, upstream string) (intstr.IntOrString, error) {
+	if upstream != "" {
+		return port, nil
+	}
+
+	return utils.GetTargetPort(d.service, port)
+}
+
+// visit injects the agent in the disruptor's targets and executes the command in them
+func (d *serviceDisruptor) visit(ctx context.Context, command PodVisitCommand) error {
+	visitor := NewPodAgentVisitor(
+		d.helper,
+		PodAgentVisitorOptions{Timeout: d.options.InjectTimeout},
+		command,
+	)
+
+	targets, err := d.selector.Targets(ctx)
+	if err != nil {
+		return err
+	}
+
+	controller := NewPodController(targets)
+
+	return controller.Visit(ctx, visitor)
+}
+
 func (d *serviceDisruptor) Targets(ctx context.Context) ([]string, error) {
 	targets, err := d.selector.Targets(ctx)
 	if err != nil {
@@ -150,6 +267,18 @@ func (d *serviceDisruptor) Targets(ctx context.Context) ([]string, error) {
 	return utils.PodNames(targets), nil
 }
 
+// Cleanup stops the faults running in the disruptor's targets
+func (d *serviceDisruptor) Cleanup(ctx context.Context) error {
+	targets, err := d.selector.Targets(ctx)
+	if err != nil {
+		return err
+	}
+
+	controller := NewPodController(targets)
+
+	return controller.Visit(ctx, PodCleanupVisitor{helper: d.helper})
+}
+
 // TerminatePods terminates a subset of the target pods of the disruptor
 func (d *serviceDisruptor) TerminatePods(
 	ctx context.Context,
@@ -0,0 +1,75 @@
+package disruptors
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+
+	"github.com/grafana/xk6-disruptor/pkg/kubernetes"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// enabled returns if the options define the termination of TLS
+func (o HTTPTLSOptions) enabled() bool {
+	return o.Secret != "" || o.CASecret != ""
+}
+
+// withHosts returns the options with the hosts added to the hosts of the generated certificate
+func (o HTTPTLSOptions) withHosts(hosts ...string) HTTPTLSOptions {
+	o.Hosts = slices.Concat(o.Hosts, hosts)
+	return o
+}
+
+// loadTLSCredentials returns the PEM encoded certificate and key of the Secret referenced by the options. The
+// credentials are sent to the agent through the standard input of its command.
+func loadTLSCredentials(
+	ctx context.Context,
+	k8s kubernetes.Kubernetes,
+	namespace string,
+	options HTTPTLSOptions,
+) ([]byte, error) {
+	if options.Secret != "" && options.CASecret != "" {
+		return nil, fmt.Errorf("TLS secret and CA secret cannot be both specified")
+	}
+
+	secret := options.Secret
+	if secret == "" {
+		secret = options.CASecret
+	}
+
+	if secret == "" {
+		return nil, nil
+	}
+
+	name := secret
+	if ns, s, found := strings.Cut(secret, "/"); found {
+		namespace, name = ns, s
+	}
+
+	s, err := k8s.Client().CoreV1().Secrets(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("getting TLS secret %q: %w", secret, err)
+	}
+
+	cert := s.Data[corev1.TLSCertKey]
+	key := s.Data[corev1.TLSPrivateKeyKey]
+	if len(cert) == 0 || len(key) == 0 {
+		return nil, fmt.Errorf("TLS secret %q must contain %q and %q", secret, corev1.TLSCertKey, corev1.TLSPrivateKeyKey)
+	}
+
+	return slices.Concat(cert, []byte("\n"), key), nil
+}
+
+// upstreamHost returns the host of an upstream given as host:port
+func upstreamHost(upstream string) string {
+	host, _, err := net.SplitHostPort(upstream)
+	if err != nil {
+		return upstream
+	}
+
+	return host
+}
@@ -1,16 +1,40 @@
-// Package iptables implements objects that manipulate netfilter rules by calling the iptables binary.
+// Package iptables implements objects that manipulate netfilter rules by calling the iptables binaries.
+//
+// The rules are not added to the built-in chains. Instead, they are added to chains of the agent, prefixed with
+// ChainPrefix, that the built-in chains jump to. This allows identifying and removing all the rules added by the agent,
+// even if the process that added them is gone, and prevents conflicts with the rules added by other components, such
+// as the CNI or a service mesh.
+//
+// A RuleSet adds its rules atomically with iptables-restore to chains that only it uses, and removes them by flushing
+// and deleting these chains.
 package iptables
 
 import (
+	"errors"
 	"fmt"
+	"math/rand"
+	"slices"
 	"strings"
 
 	"github.com/grafana/xk6-disruptor/pkg/runtime"
 )
 
-// Iptables adds and removes iptables rules by executing the `iptables` binary.
+// ChainPrefix is the prefix of the chains created by the agent
+const ChainPrefix = "XK6-DISRUPTOR-"
+
+// tables are the netfilter tables where the agent adds rules
+//
+//nolint:gochecknoglobals
+var tables = []string{"filter", "nat"}
+
+// agentChain returns the name of the chain of the agent that a built-in chain jumps to
+func agentChain(chain string) string {
+	return ChainPrefix + chain
+}
+
+// Iptables adds and removes iptables rules by executing the `iptables` and `iptables-restore` binaries.
 type Iptables struct {
-	// Executor is the runtime.Executor used to run the iptables binary.
+	// Executor is the runtime.Executor used to run the iptables binaries.
 	executor runtime.Executor
 }
 
@@ -21,9 +45,15 @@ func New(executor runtime.Executor) Iptables {
 	}
 }
 
-// Add appends a rule into the corresponding table and chain.
+// Add appends a rule into the chain of the agent for the rule's chain. The chain of the agent, and the jump to it from
+// the rule's chain, are created if they do not exist.
 func (i Iptables) Add(r Rule) error {
-	err := i.exec(r.add())
+	err := i.ensureChain(r.Table, r.Chain)
+	if err != nil {
+		return err
+	}
+
+	err = i.exec(r.add())
 	if err != nil {
 		return err
 	}
@@ -41,6 +71,81 @@ func (i Iptables) Remove(r Rule) error {
 	return nil
 }
 
+// ensureChain creates the chain of the agent for a built-in chain, and the jump to it, if they do not exist
+func (i Iptables) ensureChain(table string, chain string) error {
+	jump := fmt.Sprintf("%s -j %s", chain, agentChain(chain))
+
+	// checking the jump fails if either the jump or the chain of the agent do not exist
+	if i.exec(fmt.Sprintf("-t %s -C %s", table, jump)) == nil {
+		return nil
+	}
+
+	// the chain may exist without the jump
+	_ = i.exec(fmt.Sprintf("-t %s -N %s", table, agentChain(chain)))
+
+	return i.exec(fmt.Sprintf("-t %s -A %s", table, jump))
+}
+
+// RemoveAll removes the chains of the agent, with all their rules, and the jumps to them. It is intended for removing
+// the rules left behind by a process that could not remove them, for example because it was killed.
+// If an error occurs, RemoveAll continues to try and remove the remaining chains.
+func (i Iptables) RemoveAll() error {
+	var errs []error
+
+	for _, table := range tables {
+		out, err := i.executor.Exec("iptables", "-t", table, "-S")
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%w: %q", err, out))
+			continue
+		}
+
+		chains := []string{}
+		for _, line := range strings.Split(string(out), "\n") {
+			fields := strings.Fields(line)
+			switch {
+			case len(fields) == 2 && fields[0] == "-N" && strings.HasPrefix(fields[1], ChainPrefix):
+				chains = append(chains, fields[1])
+			case isJump(fields):
+				// jumps must be removed before the chains they jump to
+				err = i.exec(fmt.Sprintf("-t %s -D %s", table, strings.Join(fields[1:], " ")))
+				if err != nil {
+					errs = append(errs, err)
+				}
+			}
+		}
+
+		for _, chain := range chains {
+			err = i.exec(fmt.Sprintf("-t %s -F %s", table, chain))
+			if err == nil {
+				err = i.exec(fmt.Sprintf("-t %s -X %s", table, chain))
+			}
+			if err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// isJump returns if the fields of a rule, as listed by iptables -S, are a jump from a built-in chain to a chain of the
+// agent
+func isJump(fields []string) bool {
+	return len(fields) == 4 && fields[0] == "-A" && !strings.HasPrefix(fields[1], ChainPrefix) &&
+		fields[2] == "-j" && strings.HasPrefix(fields[3], ChainPrefix)
+}
+
+// restore applies changes in the format of iptables-save. The changes to each table are applied atomically.
+// The tables and chains not included in the changes are not modified.
+func (i Iptables) restore(input string) error {
+	out, err := i.executor.ExecWithInput([]byte(input), "iptables-restore", "--noflush")
+	if err != nil {
+		return fmt.Errorf("%w: %q", err, out)
+	}
+
+	return nil
+}
+
 func (i Iptables) exec(args string) error {
 	out, err := i.executor.Exec("iptables", strings.Split(args, " ")...)
 	if err != nil {
@@ -50,51 +155,156 @@ func (i Iptables) exec(args string) error {
 	return nil
 }
 
-// RuleSet is a stateful object that allows adding rules and keeping track of them to remove them later.
+// RuleSet is a stateful object that adds rules and keeps track of them to remove them later.
+// The rules of a RuleSet are added to chains that only this RuleSet uses, so several RuleSets can be used at the
+// same time without interfering with each other.
 type RuleSet struct {
 	iptables Iptables
-	rules    []Rule
+	// id identifies the chains of the RuleSet
+	id    string
+	rules []Rule
+	// installed are the hooks whose chains and jumps have been created
+	installed []hook
+}
+
+// hook is a built-in chain that can jump to a chain of a RuleSet
+type hook struct {
+	table string
+	chain string
+	// abbr is the abbreviation of the built-in chain used in the name of the chain of the RuleSet, which must be
+	// at most 28 characters long
+	abbr string
+}
+
+// hooks are the built-in chains where a RuleSet can add rules
+//
+//nolint:gochecknoglobals
+var hooks = []hook{
+	{table: "filter", chain: "INPUT", abbr: "IN"},
+	{table: "filter", chain: "OUTPUT", abbr: "OUT"},
+	{table: "nat", chain: "PREROUTING", abbr: "PRE"},
+	{table: "nat", chain: "OUTPUT", abbr: "OUT"},
+}
+
+// findHook returns the hook for the given table and chain
+func findHook(table string, chain string) (hook, bool) {
+	for _, h := range hooks {
+		if h.table == table && h.chain == chain {
+			return h, true
+		}
+	}
+
+	return hook{}, false
 }
 
 // NewRuleSet builds a RuleSet that uses the provided Iptables instance to add and remove rules.
 func NewRuleSet(iptables Iptables) *RuleSet {
+	return newRuleSet(iptables, fmt.Sprintf("%08x", rand.Uint32())) //nolint:gosec // not used for security
+}
+
+func newRuleSet(iptables Iptables, id string) *RuleSet {
 	return &RuleSet{
 		iptables: iptables,
+		id:       id,
 	}
 }
 
-// Add adds a rule. Added rule will be remembered and removed later together with other rules when Remove is called.
-func (i *RuleSet) Add(r Rule) error {
-	err := i.iptables.Add(r)
-	if err != nil {
-		return err
+// chain returns the name of the chain of the RuleSet the hook jumps to
+func (i *RuleSet) chain(h hook) string {
+	return fmt.Sprintf("%s%s-%s", ChainPrefix, i.id, h.abbr)
+}
+
+// Add adds rules. Either all the rules are added or none of them is. Added rules will be remembered and removed
+// later together with other rules when Remove is called.
+func (i *RuleSet) Add(rules ...Rule) error {
+	return i.Replace(append(slices.Clone(i.rules), rules...)...)
+}
+
+// Replace atomically replaces the rules previously added with the given rules. The chains of the RuleSet, and the
+// jumps to them from the built-in chains, are created the first time a rule is added to a built-in chain.
+func (i *RuleSet) Replace(rules ...Rule) error {
+	used := slices.Clone(i.installed)
+	for _, r := range rules {
+		h, found := findHook(r.Table, r.Chain)
+		if !found {
+			return fmt.Errorf("rules cannot be added to chain %s in table %s", r.Chain, r.Table)
+		}
+
+		if !slices.Contains(used, h) {
+			used = append(used, h)
+		}
 	}
 
-	i.rules = append(i.rules, r)
+	input := &strings.Builder{}
+	for _, table := range tables {
+		tableHooks := slices.DeleteFunc(slices.Clone(used), func(h hook) bool { return h.table != table })
+		if len(tableHooks) == 0 {
+			continue
+		}
+
+		fmt.Fprintf(input, "*%s\n", table)
+		// declaring an existing chain flushes it
+		for _, h := range tableHooks {
+			fmt.Fprintf(input, ":%s - [0:0]\n", i.chain(h))
+		}
+		for _, h := range tableHooks {
+			if !slices.Contains(i.installed, h) {
+				fmt.Fprintf(input, "-A %s -j %s\n", h.chain, i.chain(h))
+			}
+		}
+		for _, r := range rules {
+			if r.Table == table {
+				h, _ := findHook(r.Table, r.Chain)
+				fmt.Fprintf(input, "-A %s %s\n", i.chain(h), r.Args)
+			}
+		}
+		fmt.Fprintln(input, "COMMIT")
+	}
+
+	if input.Len() > 0 {
+		if err := i.iptables.restore(input.String()); err != nil {
+			return err
+		}
+	}
+
+	i.rules = slices.Clone(rules)
+	i.installed = used
 
 	return nil
 }
 
-// Remove removes all added rules. If an error occurs, Remove continues to try and remove remaining rules.
+// Remove removes all added rules by removing the jumps to the chains of the RuleSet, and flushing and deleting the
+// chains. The chains of all the tables are removed atomically.
 func (i *RuleSet) Remove() error {
-	var errors []error
+	input := &strings.Builder{}
+	for _, table := range tables {
+		tableHooks := slices.DeleteFunc(slices.Clone(i.installed), func(h hook) bool { return h.table != table })
+		if len(tableHooks) == 0 {
+			continue
+		}
 
-	var remaining []Rule
-	for _, rule := range i.rules {
-		err := i.iptables.Remove(rule)
-		if err != nil {
-			errors = append(errors, err)
-			remaining = append(remaining, rule)
+		fmt.Fprintf(input, "*%s\n", table)
+		for _, h := range tableHooks {
+			fmt.Fprintf(input, "-D %s -j %s\n", h.chain, i.chain(h))
+		}
+		for _, h := range tableHooks {
+			fmt.Fprintf(input, "-F %s\n", i.chain(h))
 		}
+		for _, h := range tableHooks {
+			fmt.Fprintf(input, "-X %s\n", i.chain(h))
+		}
+		fmt.Fprintln(input, "COMMIT")
 	}
 
-	i.rules = remaining
-
-	// TODO: Return all errors with errors.Join when we move to go 1.21.
-	if len(errors) > 0 {
-		return errors[0]
+	if input.Len() > 0 {
+		if err := i.iptables.restore(input.String()); err != nil {
+			return err
+		}
 	}
 
+	i.rules = nil
+	i.installed = nil
+
 	return nil
 }
 
@@ -102,7 +312,8 @@ func (i *RuleSet) Remove() error {
 type Rule struct {
 	// Table is the netfilter table to which this rule belongs. It is usually "filter".
 	Table string
-	// Chain is the netfilter chain to which this rule belongs. Usual values are "INPUT", "OUTPUT".
+	// Chain is the built-in netfilter chain whose traffic this rule applies to. Usual values are "INPUT", "OUTPUT".
+	// The rule is added to the chain of the agent that this chain jumps to.
 	Chain string
 	// Args is the rest of the netfilter rule.
 	// Arguments must be space-separated. Using shell-style quotes or backslashes to group more than one space-separated
@@ -111,9 +322,9 @@ type Rule struct {
 }
 
 func (r Rule) add() string {
-	return fmt.Sprintf("-t %s -A %s %s", r.Table, r.Chain, r.Args)
+	return fmt.Sprintf("-t %s -A %s %s", r.Table, agentChain(r.Chain), r.Args)
 }
 
 func (r Rule) remove() string {
-	return fmt.Sprintf("-t %s -D %s %s", r.Table, r.Chain, r.Args)
+	return fmt.Sprintf("-t %s -D %s %s", r.Table, agentChain(r.Chain), r.Args)
 }
@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
 	"github.com/grafana/xk6-disruptor/pkg/runtime"
 )
 
@@ -30,7 +31,8 @@ func Test_Iptables(t *testing.T) {
 				})
 			},
 			expectedCommands: []string{
-				"iptables -t some -A ECHO foo -t bar -w xx",
+				"iptables -t some -C ECHO -j XK6-DISRUPTOR-ECHO",
+				"iptables -t some -A XK6-DISRUPTOR-ECHO foo -t bar -w xx",
 			},
 		},
 		{
@@ -43,7 +45,7 @@ func Test_Iptables(t *testing.T) {
 				})
 			},
 			expectedCommands: []string{
-				"iptables -t some -D ECHO foo -t bar -w xx",
+				"iptables -t some -D XK6-DISRUPTOR-ECHO foo -t bar -w xx",
 			},
 		},
 		{
@@ -57,7 +59,7 @@ func Test_Iptables(t *testing.T) {
 			},
 			execError: anError,
 			expectedCommands: []string{
-				"iptables -t some -D ECHO foo -t bar -w xx",
+				"iptables -t some -D XK6-DISRUPTOR-ECHO foo -t bar -w xx",
 			},
 			expectedError: anError,
 		},
@@ -84,31 +86,39 @@ func Test_RulesetAddsRemovesRules(t *testing.T) {
 	t.Parallel()
 
 	exec := runtime.NewFakeExecutor(nil, nil)
-	ruleset := NewRuleSet(New(exec))
+	ruleset := newRuleSet(New(exec), "test")
 
 	// Add two rules
-	err := ruleset.Add(Rule{
-		Table: "table1", Chain: "CHAIN1", Args: "--foo foo --bar bar",
-	})
+	err := ruleset.Add(Rule{Table: "filter", Chain: "INPUT", Args: "--foo foo --bar bar"})
 	if err != nil {
 		t.Fatalf("error adding rule: %v", err)
 	}
 
-	err = ruleset.Add(Rule{
-		Table: "table2", Chain: "CHAIN2", Args: "--boo boo --baz baz",
-	})
+	err = ruleset.Add(Rule{Table: "nat", Chain: "OUTPUT", Args: "--boo boo --baz baz"})
 	if err != nil {
 		t.Fatalf("error adding rule: %v", err)
 	}
 
-	// Check we have run the expected commands.
-	expectedAddCmds := []string{
-		"iptables -t table1 -A CHAIN1 --foo foo --bar bar",
-		"iptables -t table2 -A CHAIN2 --boo boo --baz baz",
+	// Check the chains are created once, and rules are added again with the new ones
+	expectedAddInputs := []string{
+		"*filter\n" +
+			":XK6-DISRUPTOR-test-IN - [0:0]\n" +
+			"-A INPUT -j XK6-DISRUPTOR-test-IN\n" +
+			"-A XK6-DISRUPTOR-test-IN --foo foo --bar bar\n" +
+			"COMMIT\n",
+		"*filter\n" +
+			":XK6-DISRUPTOR-test-IN - [0:0]\n" +
+			"-A XK6-DISRUPTOR-test-IN --foo foo --bar bar\n" +
+			"COMMIT\n" +
+			"*nat\n" +
+			":XK6-DISRUPTOR-test-OUT - [0:0]\n" +
+			"-A OUTPUT -j XK6-DISRUPTOR-test-OUT\n" +
+			"-A XK6-DISRUPTOR-test-OUT --boo boo --baz baz\n" +
+			"COMMIT\n",
 	}
 
-	if diff := cmp.Diff(exec.CmdHistory(), expectedAddCmds); diff != "" {
-		t.Fatalf("Executed commands to add rules do not match expected:\n%s", diff)
+	if diff := cmp.Diff(expectedAddInputs, exec.InputHistory()); diff != "" {
+		t.Fatalf("Changes to add rules do not match expected:\n%s", diff)
 	}
 
 	exec.Reset()
@@ -119,48 +129,289 @@ func Test_RulesetAddsRemovesRules(t *testing.T) {
 		t.Fatalf("error removing rules: %v", err)
 	}
 
-	// Check we have run the expected commands.
-	expectedRemoveCmds := []string{
-		"iptables -t table1 -D CHAIN1 --foo foo --bar bar",
-		"iptables -t table2 -D CHAIN2 --boo boo --baz baz",
+	// Check only the chains of the ruleset are removed
+	expectedRemoveInputs := []string{
+		"*filter\n" +
+			"-D INPUT -j XK6-DISRUPTOR-test-IN\n" +
+			"-F XK6-DISRUPTOR-test-IN\n" +
+			"-X XK6-DISRUPTOR-test-IN\n" +
+			"COMMIT\n" +
+			"*nat\n" +
+			"-D OUTPUT -j XK6-DISRUPTOR-test-OUT\n" +
+			"-F XK6-DISRUPTOR-test-OUT\n" +
+			"-X XK6-DISRUPTOR-test-OUT\n" +
+			"COMMIT\n",
 	}
 
-	if diff := cmp.Diff(exec.CmdHistory(), expectedRemoveCmds); diff != "" {
-		t.Fatalf("Executed commands to remove rules do not match expected:\n%s", diff)
+	if diff := cmp.Diff(expectedRemoveInputs, exec.InputHistory()); diff != "" {
+		t.Fatalf("Changes to remove rules do not match expected:\n%s", diff)
 	}
 
 	exec.Reset()
 
-	// After removing the rules, add a new one.
-	err = ruleset.Add(Rule{
-		Table: "table3", Chain: "CHAIN3", Args: "--zoo zoo --zap zap",
-	})
+	// Removing again does nothing
+	err = ruleset.Remove()
 	if err != nil {
-		t.Fatalf("error adding rule: %v", err)
+		t.Fatalf("error removing rules: %v", err)
 	}
 
-	// Check we run the expected add command.
-	expectedAddCmds = []string{
-		"iptables -t table3 -A CHAIN3 --zoo zoo --zap zap",
+	if len(exec.CmdHistory()) != 0 {
+		t.Fatalf("unexpected commands: %v", exec.CmdHistory())
 	}
-	if diff := cmp.Diff(exec.CmdHistory(), expectedAddCmds); diff != "" {
-		t.Fatalf("Executed commands to add rules do not match expected:\n%s", diff)
+}
+
+func Test_RulesetReplace(t *testing.T) {
+	t.Parallel()
+
+	anError := errors.New("an error occurred")
+
+	for _, tc := range []struct {
+		name          string
+		rules         []Rule
+		execError     error
+		expectedInput []string
+		expectError   bool
+	}{
+		{
+			name:  "Replaces rules",
+			rules: []Rule{{Table: "filter", Chain: "INPUT", Args: "-j DROP"}},
+			expectedInput: []string{
+				"*filter\n" +
+					":XK6-DISRUPTOR-test-IN - [0:0]\n" +
+					"-A XK6-DISRUPTOR-test-IN -j DROP\n" +
+					"COMMIT\n",
+			},
+		},
+		{
+			name: "Flushes chains without rules",
+			expectedInput: []string{
+				"*filter\n" +
+					":XK6-DISRUPTOR-test-IN - [0:0]\n" +
+					"COMMIT\n",
+			},
+		},
+		{
+			name:        "Rejects rules for unsupported chain",
+			rules:       []Rule{{Table: "filter", Chain: "FORWARD", Args: "-j DROP"}},
+			expectError: true,
+		},
+		{
+			name:      "Keeps rules on error",
+			rules:     []Rule{{Table: "filter", Chain: "INPUT", Args: "-j DROP"}},
+			execError: anError,
+			expectedInput: []string{
+				"*filter\n" +
+					":XK6-DISRUPTOR-test-IN - [0:0]\n" +
+					"-A XK6-DISRUPTOR-test-IN -j DROP\n" +
+					"COMMIT\n",
+			},
+			expectError: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var execError error
+			exec := runtime.NewCallbackExecutor(func(_ string, _ ...string) ([]byte, error) {
+				return nil, execError
+			})
+			ruleset := newRuleSet(New(exec), "test")
+
+			initial := Rule{Table: "filter", Chain: "INPUT", Args: "-j ACCEPT"}
+			if err := ruleset.Add(initial); err != nil {
+				t.Fatalf("error adding rule: %v", err)
+			}
+
+			exec.Reset()
+			execError = tc.execError
+
+			err := ruleset.Replace(tc.rules...)
+			if tc.expectError && err == nil {
+				t.Fatalf("expected error but none returned")
+			}
+
+			if !tc.expectError && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if diff := cmp.Diff(tc.expectedInput, exec.InputHistory(), cmpopts.EquateEmpty()); diff != "" {
+				t.Fatalf("Changes do not match expected:\n%s", diff)
+			}
+
+			expectedRules := tc.rules
+			if tc.expectError {
+				expectedRules = []Rule{initial}
+			}
+
+			if diff := cmp.Diff(expectedRules, ruleset.rules, cmpopts.EquateEmpty()); diff != "" {
+				t.Fatalf("Rules do not match expected:\n%s", diff)
+			}
+		})
 	}
+}
 
-	exec.Reset()
+func Test_RulesetsUseTheirOwnChains(t *testing.T) {
+	t.Parallel()
 
-	// Remove the rule.
-	err = ruleset.Remove()
-	if err != nil {
+	exec := runtime.NewFakeExecutor(nil, nil)
+	first := newRuleSet(New(exec), "first")
+	second := newRuleSet(New(exec), "second")
+
+	rule := Rule{Table: "filter", Chain: "INPUT", Args: "-j DROP"}
+	for _, ruleset := range []*RuleSet{first, second} {
+		if err := ruleset.Add(rule); err != nil {
+			t.Fatalf("error adding rule: %v", err)
+		}
+	}
+
+	if err := first.Remove(); err != nil {
 		t.Fatalf("error removing rules: %v", err)
 	}
 
-	// Check we have run the expected command.
-	expectedRemoveCmds = []string{
-		"iptables -t table3 -D CHAIN3 --zoo zoo --zap zap",
+	expected := []string{
+		"*filter\n" +
+			":XK6-DISRUPTOR-first-IN - [0:0]\n" +
+			"-A INPUT -j XK6-DISRUPTOR-first-IN\n" +
+			"-A XK6-DISRUPTOR-first-IN -j DROP\n" +
+			"COMMIT\n",
+		"*filter\n" +
+			":XK6-DISRUPTOR-second-IN - [0:0]\n" +
+			"-A INPUT -j XK6-DISRUPTOR-second-IN\n" +
+			"-A XK6-DISRUPTOR-second-IN -j DROP\n" +
+			"COMMIT\n",
+		"*filter\n" +
+			"-D INPUT -j XK6-DISRUPTOR-first-IN\n" +
+			"-F XK6-DISRUPTOR-first-IN\n" +
+			"-X XK6-DISRUPTOR-first-IN\n" +
+			"COMMIT\n",
+	}
+	if diff := cmp.Diff(expected, exec.InputHistory()); diff != "" {
+		t.Fatalf("Changes do not match expected:\n%s", diff)
+	}
+}
+
+func Test_RulesetChainNameLength(t *testing.T) {
+	t.Parallel()
+
+	ruleset := NewRuleSet(New(runtime.NewFakeExecutor(nil, nil)))
+	for _, h := range hooks {
+		// iptables rejects chain names longer than 28 characters
+		if chain := ruleset.chain(h); len(chain) > 28 {
+			t.Fatalf("chain name %q is too long", chain)
+		}
+	}
+}
+
+func Test_AddCreatesChain(t *testing.T) {
+	t.Parallel()
+
+	anError := errors.New("an error occurred")
+
+	// checking the jump fails, as the chain does not exist
+	exec := runtime.NewCallbackExecutor(func(_ string, args ...string) ([]byte, error) {
+		if args[2] == "-C" {
+			return nil, anError
+		}
+
+		return nil, nil
+	})
+
+	err := New(exec).Add(Rule{Table: "nat", Chain: "OUTPUT", Args: "-p tcp -j REDIRECT --to-port 8080"})
+	if err != nil {
+		t.Fatalf("error adding rule: %v", err)
+	}
+
+	expected := []string{
+		"iptables -t nat -C OUTPUT -j XK6-DISRUPTOR-OUTPUT",
+		"iptables -t nat -N XK6-DISRUPTOR-OUTPUT",
+		"iptables -t nat -A OUTPUT -j XK6-DISRUPTOR-OUTPUT",
+		"iptables -t nat -A XK6-DISRUPTOR-OUTPUT -p tcp -j REDIRECT --to-port 8080",
 	}
+	if diff := cmp.Diff(expected, exec.CmdHistory()); diff != "" {
+		t.Fatalf("Ran commands do not match expected:\n%s", diff)
+	}
+}
+
+func Test_RemoveAll(t *testing.T) {
+	t.Parallel()
+
+	anError := errors.New("an error occurred")
 
-	if diff := cmp.Diff(exec.CmdHistory(), expectedRemoveCmds); diff != "" {
-		t.Fatalf("Executed commands to remove rules do not match expected:\n%s", diff)
+	for _, tc := range []struct {
+		name             string
+		rules            map[string]string
+		listError        error
+		expectedCommands []string
+		expectError      bool
+	}{
+		{
+			name: "Removes chains of the agent",
+			rules: map[string]string{
+				"filter": "-P INPUT ACCEPT\n" +
+					"-N XK6-DISRUPTOR-INPUT\n" +
+					"-A INPUT -p tcp -m tcp --dport 22 -j ACCEPT\n" +
+					"-A INPUT -j XK6-DISRUPTOR-INPUT\n" +
+					"-A XK6-DISRUPTOR-INPUT -p tcp -m tcp --dport 80 -j DROP\n",
+				"nat": "-P OUTPUT ACCEPT\n" +
+					"-N OTHER\n" +
+					"-N XK6-DISRUPTOR-OUTPUT\n" +
+					"-A OUTPUT -j OTHER\n" +
+					"-A OUTPUT -j XK6-DISRUPTOR-OUTPUT\n",
+			},
+			expectedCommands: []string{
+				"iptables -t filter -S",
+				"iptables -t filter -D INPUT -j XK6-DISRUPTOR-INPUT",
+				"iptables -t filter -F XK6-DISRUPTOR-INPUT",
+				"iptables -t filter -X XK6-DISRUPTOR-INPUT",
+				"iptables -t nat -S",
+				"iptables -t nat -D OUTPUT -j XK6-DISRUPTOR-OUTPUT",
+				"iptables -t nat -F XK6-DISRUPTOR-OUTPUT",
+				"iptables -t nat -X XK6-DISRUPTOR-OUTPUT",
+			},
+		},
+		{
+			name: "No chains of the agent",
+			rules: map[string]string{
+				"filter": "-P INPUT ACCEPT\n-N OTHER\n-A INPUT -j OTHER\n",
+			},
+			expectedCommands: []string{
+				"iptables -t filter -S",
+				"iptables -t nat -S",
+			},
+		},
+		{
+			name:      "Continues after error",
+			listError: anError,
+			expectedCommands: []string{
+				"iptables -t filter -S",
+				"iptables -t nat -S",
+			},
+			expectError: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			exec := runtime.NewCallbackExecutor(func(_ string, args ...string) ([]byte, error) {
+				if args[len(args)-1] != "-S" {
+					return nil, nil
+				}
+
+				return []byte(tc.rules[args[1]]), tc.listError
+			})
+
+			err := New(exec).RemoveAll()
+			if tc.expectError && err == nil {
+				t.Fatalf("expected error but none returned")
+			}
+
+			if !tc.expectError && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if diff := cmp.Diff(tc.expectedCommands, exec.CmdHistory()); diff != "" {
+				t.Fatalf("Ran commands do not match expected:\n%s", diff)
+			}
+		})
 	}
 }
@@ -42,6 +42,11 @@ func (f *FakeKubernetes) ServiceHelper(namespace string) helpers.ServiceHelper {
 	)
 }
 
+// NodeHelper returns a NodeHelper
+func (f *FakeKubernetes) NodeHelper() helpers.NodeHelper {
+	return helpers.NewNodeHelper(f.client)
+}
+
 // Client return a kubernetes client
 func (f *FakeKubernetes) Client() kubernetes.Interface {
 	return f.client
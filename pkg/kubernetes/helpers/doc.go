@@ -1,3 +1,3 @@
 // Package helpers implement helper functions for managing Kubernetes resources
-// such as services and pods
+// such as services, pods and nodes
 package helpers
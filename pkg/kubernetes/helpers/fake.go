@@ -55,6 +55,9 @@ func (f *FakePodCommandExecutor) SetResult(stdout []byte, stderr []byte, err err
 
 // GetHistory returns the history of commands executed by the FakePodCommandExecutor
 func (f *FakePodCommandExecutor) GetHistory() []Command {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
 	return f.history
 }
 
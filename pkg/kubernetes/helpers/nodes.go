@@ -0,0 +1,55 @@
+package helpers
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// NodeHelper defines helper methods for handling Nodes
+type NodeHelper interface {
+	// List returns a list of nodes that match the given NodeFilter
+	List(ctx context.Context, filter NodeFilter) ([]corev1.Node, error)
+}
+
+// nodeHelper struct holds the data required by the helpers
+type nodeHelper struct {
+	client kubernetes.Interface
+}
+
+// NewNodeHelper returns a NodeHelper
+func NewNodeHelper(client kubernetes.Interface) NodeHelper {
+	return &nodeHelper{
+		client: client,
+	}
+}
+
+// NodeFilter defines the criteria for selecting a node for disruption
+type NodeFilter struct {
+	// Select Nodes that match these labels
+	Select map[string]string
+	// Exclude Nodes that match these labels
+	Exclude map[string]string
+}
+
+func (h *nodeHelper) List(ctx context.Context, filter NodeFilter) ([]corev1.Node, error) {
+	labelSelector, err := buildLabelSelector(filter.Select, filter.Exclude)
+	if err != nil {
+		return nil, err
+	}
+
+	listOptions := metav1.ListOptions{
+		LabelSelector: labelSelector.String(),
+	}
+	nodes, err := h.client.CoreV1().Nodes().List(
+		ctx,
+		listOptions,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return nodes.Items, nil
+}
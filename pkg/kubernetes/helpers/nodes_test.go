@@ -0,0 +1,128 @@
+package helpers
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/grafana/xk6-disruptor/pkg/testutils/assertions"
+	"github.com/grafana/xk6-disruptor/pkg/testutils/kubernetes/builders"
+)
+
+func Test_ListNodes(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		title         string
+		nodes         []corev1.Node
+		filter        NodeFilter
+		expectError   bool
+		expectedNodes []string
+	}{
+		{
+			title: "No matching node",
+			nodes: []corev1.Node{
+				builders.NewNodeBuilder("node-without-labels").
+					Build(),
+			},
+			filter: NodeFilter{
+				Select: map[string]string{
+					"pool": "test",
+				},
+			},
+			expectError:   false,
+			expectedNodes: []string{},
+		},
+		{
+			title: "multiple matching nodes",
+			nodes: []corev1.Node{
+				builders.NewNodeBuilder("node-1").
+					WithLabel("pool", "test").
+					Build(),
+				builders.NewNodeBuilder("node-2").
+					WithLabel("pool", "test").
+					Build(),
+				builders.NewNodeBuilder("node-3").
+					WithLabel("pool", "other").
+					Build(),
+			},
+			filter: NodeFilter{
+				Select: map[string]string{
+					"pool": "test",
+				},
+			},
+			expectError:   false,
+			expectedNodes: []string{"node-1", "node-2"},
+		},
+		{
+			title: "excluded nodes",
+			nodes: []corev1.Node{
+				builders.NewNodeBuilder("node-1").
+					WithLabel("pool", "test").
+					Build(),
+				builders.NewNodeBuilder("node-2").
+					WithLabel("pool", "other").
+					Build(),
+			},
+			filter: NodeFilter{
+				Exclude: map[string]string{
+					"pool": "test",
+				},
+			},
+			expectError:   false,
+			expectedNodes: []string{"node-2"},
+		},
+		{
+			title: "empty filter",
+			nodes: []corev1.Node{
+				builders.NewNodeBuilder("node-1").
+					Build(),
+				builders.NewNodeBuilder("node-2").
+					Build(),
+			},
+			filter:        NodeFilter{},
+			expectError:   false,
+			expectedNodes: []string{"node-1", "node-2"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			t.Parallel()
+
+			nodes := []runtime.Object{}
+			for n := range tc.nodes {
+				nodes = append(nodes, &tc.nodes[n])
+			}
+			client := fake.NewSimpleClientset(nodes...)
+
+			helper := NewNodeHelper(client)
+			nodeList, err := helper.List(t.Context(), tc.filter)
+
+			if tc.expectError && err == nil {
+				t.Errorf("should had failed")
+				return
+			}
+
+			if !tc.expectError && err != nil {
+				t.Errorf("failed: %v", err)
+				return
+			}
+
+			if tc.expectError && err != nil {
+				return
+			}
+
+			names := []string{}
+			for _, n := range nodeList {
+				names = append(names, n.Name)
+			}
+			if !assertions.CompareStringArrays(names, tc.expectedNodes) {
+				t.Errorf("result does not match expected value. Expected: %s\nActual: %s\n", tc.expectedNodes, names)
+				return
+			}
+		})
+	}
+}
@@ -40,6 +40,8 @@ type PodHelper interface {
 	List(ctx context.Context, filter PodFilter) ([]corev1.Pod, error)
 	// Terminate terminates the execution of a running Pod
 	Terminate(ctx context.Context, name string, timeout time.Duration) error
+	// Create creates a Pod and optionally waits for it to be running
+	Create(ctx context.Context, pod corev1.Pod, options CreateOptions) error
 }
 
 // helpers struct holds the data required by the helpers
@@ -75,6 +77,15 @@ type AttachOptions struct {
 	IgnoreIfExists bool
 }
 
+// CreateOptions defines options for creating a pod
+type CreateOptions struct {
+	// timeout for waiting until the pod is running. A zero value forces no waiting.
+	Timeout time.Duration
+	// IgnoreIfExists causes Create to return successfully if the pod already exists when set to true.
+	// If set to false, it will exit with an error if the pod already exists.
+	IgnoreIfExists bool
+}
+
 // podConditionChecker defines a function that checks if a pod satisfies a condition
 type podConditionChecker func(*corev1.Pod) (bool, error)
 
@@ -260,10 +271,11 @@ func checkEphemeralContainerIsRunning(pod *corev1.Pod) (bool, error) {
 	return false, nil
 }
 
-// buildLabelSelector builds a label selector to be used in the k8s api, from a PodSelector
-func buildLabelSelector(f PodFilter) (labels.Selector, error) {
+// buildLabelSelector builds a label selector to be used in the k8s api, from a set of labels to select
+// and a set of labels to exclude
+func buildLabelSelector(selectLabels map[string]string, excludeLabels map[string]string) (labels.Selector, error) {
 	labelsSelector := labels.NewSelector()
-	for label, value := range f.Select {
+	for label, value := range selectLabels {
 		req, err := labels.NewRequirement(label, selection.Equals, []string{value})
 		if err != nil {
 			return nil, err
@@ -271,7 +283,7 @@ func buildLabelSelector(f PodFilter) (labels.Selector, error) {
 		labelsSelector = labelsSelector.Add(*req)
 	}
 
-	for label, value := range f.Exclude {
+	for label, value := range excludeLabels {
 		req, err := labels.NewRequirement(label, selection.NotEquals, []string{value})
 		if err != nil {
 			return nil, err
@@ -283,7 +295,7 @@ func buildLabelSelector(f PodFilter) (labels.Selector, error) {
 }
 
 func (h *podHelper) List(ctx context.Context, filter PodFilter) ([]corev1.Pod, error) {
-	labelSelector, err := buildLabelSelector(filter)
+	labelSelector, err := buildLabelSelector(filter.Select, filter.Exclude)
 	if err != nil {
 		return nil, err
 	}
@@ -354,3 +366,25 @@ func (h *podHelper) Terminate(ctx context.Context, pod string, timeout time.Dura
 
 	return h.WaitPodDeleted(ctx, pod, timeout)
 }
+
+// Create creates a Pod in the namespace of the helper
+func (h *podHelper) Create(ctx context.Context, pod corev1.Pod, options CreateOptions) error {
+	_, err := h.client.CoreV1().Pods(h.namespace).Create(ctx, &pod, metav1.CreateOptions{})
+	if err != nil && !(k8serrors.IsAlreadyExists(err) && options.IgnoreIfExists) {
+		return fmt.Errorf("creating pod %q in %q: %w", pod.Name, h.namespace, err)
+	}
+
+	if options.Timeout == 0 {
+		return nil
+	}
+
+	running, err := h.WaitPodRunning(ctx, pod.Name, options.Timeout)
+	if err != nil {
+		return fmt.Errorf("waiting for pod %q to start: %w", pod.Name, err)
+	}
+	if !running {
+		return fmt.Errorf("pod %q has not started after %fs", pod.Name, options.Timeout.Seconds())
+	}
+
+	return nil
+}
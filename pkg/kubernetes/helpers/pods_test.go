@@ -544,3 +544,102 @@ func Test_WaitPodDeleted(t *testing.T) {
 		})
 	}
 }
+
+func Test_CreatePod(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		title       string
+		existing    []corev1.Pod
+		pod         corev1.Pod
+		options     CreateOptions
+		expectError bool
+	}{
+		{
+			title: "create pod without waiting",
+			pod: builders.NewPodBuilder("pod-1").
+				WithNamespace("test-ns").
+				Build(),
+			options:     CreateOptions{},
+			expectError: false,
+		},
+		{
+			title: "pod already exists",
+			existing: []corev1.Pod{
+				builders.NewPodBuilder("pod-1").
+					WithNamespace("test-ns").
+					Build(),
+			},
+			pod: builders.NewPodBuilder("pod-1").
+				WithNamespace("test-ns").
+				Build(),
+			options:     CreateOptions{},
+			expectError: true,
+		},
+		{
+			title: "ignore existing pod",
+			existing: []corev1.Pod{
+				builders.NewPodBuilder("pod-1").
+					WithNamespace("test-ns").
+					Build(),
+			},
+			pod: builders.NewPodBuilder("pod-1").
+				WithNamespace("test-ns").
+				Build(),
+			options:     CreateOptions{IgnoreIfExists: true},
+			expectError: false,
+		},
+		{
+			title: "wait for running pod",
+			pod: builders.NewPodBuilder("pod-1").
+				WithNamespace("test-ns").
+				WithPhase(corev1.PodRunning).
+				Build(),
+			options:     CreateOptions{Timeout: time.Second},
+			expectError: false,
+		},
+		{
+			title: "timeout waiting for pod",
+			pod: builders.NewPodBuilder("pod-1").
+				WithNamespace("test-ns").
+				WithPhase(corev1.PodPending).
+				Build(),
+			options:     CreateOptions{Timeout: time.Second},
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			t.Parallel()
+
+			pods := []runtime.Object{}
+			for p := range tc.existing {
+				pods = append(pods, &tc.existing[p])
+			}
+			client := fake.NewSimpleClientset(pods...)
+
+			helper := NewPodHelper(client, nil, "test-ns")
+			err := helper.Create(t.Context(), tc.pod, tc.options)
+
+			if tc.expectError && err == nil {
+				t.Errorf("should had failed")
+				return
+			}
+
+			if !tc.expectError && err != nil {
+				t.Errorf("failed: %v", err)
+				return
+			}
+
+			if tc.expectError {
+				return
+			}
+
+			_, err = client.CoreV1().Pods("test-ns").Get(t.Context(), tc.pod.Name, metav1.GetOptions{})
+			if err != nil {
+				t.Errorf("pod was not created: %v", err)
+			}
+		})
+	}
+}
@@ -21,6 +21,8 @@ type ServiceHelper interface {
 	WaitIngressReady(ctx context.Context, ingress string, timeout time.Duration) error
 	// GetTargets returns the list of pods that match the service selector criteria
 	GetTargets(ctx context.Context, service string) ([]corev1.Pod, error)
+	// GetAddresses returns the cluster IPs of the service and the IPs of the pods that match its selector
+	GetAddresses(ctx context.Context, service string) ([]string, error)
 }
 
 // helpers struct holds the data required by the helpers
@@ -89,3 +91,30 @@ func (h *serviceHelper) GetTargets(ctx context.Context, name string) ([]corev1.P
 
 	return pods.Items, err
 }
+
+func (h *serviceHelper) GetAddresses(ctx context.Context, name string) ([]string, error) {
+	service, err := h.client.CoreV1().Services(h.namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve service %s: %w", name, err)
+	}
+
+	addresses := []string{}
+	for _, ip := range service.Spec.ClusterIPs {
+		if ip != "" && ip != corev1.ClusterIPNone {
+			addresses = append(addresses, ip)
+		}
+	}
+
+	pods, err := h.GetTargets(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, pod := range pods {
+		if pod.Status.PodIP != "" {
+			addresses = append(addresses, pod.Status.PodIP)
+		}
+	}
+
+	return addresses, nil
+}
@@ -9,6 +9,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	networking "k8s.io/api/networking/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/client-go/kubernetes/fake"
 )
@@ -350,3 +351,91 @@ func Test_Targets(t *testing.T) {
 		})
 	}
 }
+
+func Test_GetAddresses(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		title       string
+		service     *corev1.Service
+		pods        []corev1.Pod
+		expectError bool
+		expected    []string
+	}{
+		{
+			title: "cluster IP and pods",
+			service: builders.NewServiceBuilder("test-svc").
+				WithNamespace("test-ns").
+				WithSelectorLabel("app", "test").
+				WithClusterIP("10.96.0.10").
+				BuildAsPtr(),
+			pods: []corev1.Pod{
+				builders.NewPodBuilder("pod-1").
+					WithNamespace("test-ns").
+					WithLabel("app", "test").
+					WithIP("192.0.2.1").
+					Build(),
+				builders.NewPodBuilder("pod-2").
+					WithNamespace("test-ns").
+					WithLabel("app", "other").
+					WithIP("192.0.2.2").
+					Build(),
+			},
+			expected: []string{"10.96.0.10", "192.0.2.1"},
+		},
+		{
+			title: "headless service",
+			service: builders.NewServiceBuilder("test-svc").
+				WithNamespace("test-ns").
+				WithSelectorLabel("app", "test").
+				WithClusterIP(corev1.ClusterIPNone).
+				BuildAsPtr(),
+			pods: []corev1.Pod{
+				builders.NewPodBuilder("pod-1").
+					WithNamespace("test-ns").
+					WithLabel("app", "test").
+					WithIP("192.0.2.1").
+					Build(),
+			},
+			expected: []string{"192.0.2.1"},
+		},
+		{
+			title:       "service does not exist",
+			service:     nil,
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			t.Parallel()
+
+			objs := []runtime.Object{}
+			if tc.service != nil {
+				objs = append(objs, tc.service)
+			}
+			for p := range tc.pods {
+				objs = append(objs, &tc.pods[p])
+			}
+			client := fake.NewSimpleClientset(objs...)
+
+			helper := NewServiceHelper(client, "test-ns")
+			addresses, err := helper.GetAddresses(t.Context(), "test-svc")
+			if tc.expectError && err == nil {
+				t.Fatalf("should had failed")
+			}
+
+			if !tc.expectError && err != nil {
+				t.Fatalf("failed: %v", err)
+			}
+
+			if tc.expectError {
+				return
+			}
+
+			if !assertions.CompareStringArrays(addresses, tc.expected) {
+				t.Fatalf("expected %v got %v", tc.expected, addresses)
+			}
+		})
+	}
+}
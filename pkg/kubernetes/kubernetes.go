@@ -23,6 +23,8 @@ type Kubernetes interface {
 	ServiceHelper(namespace string) helpers.ServiceHelper
 	// PodHelper returns a helpers.PodHelper scoped for the given namespace
 	PodHelper(namespace string) helpers.PodHelper
+	// NodeHelper returns a helpers.NodeHelper
+	NodeHelper() helpers.NodeHelper
 }
 
 // k8s Holds the reference to the helpers for interacting with kubernetes
@@ -125,6 +127,11 @@ func (k *k8s) PodHelper(namespace string) helpers.PodHelper {
 	)
 }
 
+// NodeHelper returns a NodeHelper
+func (k *k8s) NodeHelper() helpers.NodeHelper {
+	return helpers.NewNodeHelper(k.Interface)
+}
+
 func (k *k8s) Client() kubernetes.Interface {
 	return k.Interface
 }
@@ -1,6 +1,7 @@
 package runtime
 
 import (
+	"bytes"
 	"os/exec"
 )
 
@@ -9,6 +10,9 @@ type Executor interface {
 	// Exec executes a process and waits for its completion, returning
 	// the combined stdout and stdout
 	Exec(cmd string, args ...string) ([]byte, error)
+	// ExecWithInput executes a process passing the input to its stdin and waits for its completion, returning
+	// the combined stdout and stderr
+	ExecWithInput(input []byte, cmd string, args ...string) ([]byte, error)
 }
 
 // An instance of an executor that uses the os/exec package for
@@ -24,3 +28,10 @@ func DefaultExecutor() Executor {
 func (e *executor) Exec(cmd string, args ...string) ([]byte, error) {
 	return exec.Command(cmd, args...).CombinedOutput()
 }
+
+// ExecWithInput executes a process with the given stdin and returns the combined stdout and stderr
+func (e *executor) ExecWithInput(input []byte, cmd string, args ...string) ([]byte, error) {
+	command := exec.Command(cmd, args...)
+	command.Stdin = bytes.NewReader(input)
+	return command.CombinedOutput()
+}
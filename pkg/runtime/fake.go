@@ -17,6 +17,7 @@ import (
 type FakeExecutor struct {
 	invocations int
 	commands    []string
+	inputs      []string
 	err         error
 	output      []byte
 }
@@ -41,6 +42,12 @@ func (p *FakeExecutor) Exec(cmd string, args ...string) ([]byte, error) {
 	return p.output, p.err
 }
 
+// ExecWithInput mocks the executing of the process and keeps the input for inspection
+func (p *FakeExecutor) ExecWithInput(input []byte, cmd string, args ...string) ([]byte, error) {
+	p.inputs = append(p.inputs, string(input))
+	return p.Exec(cmd, args...)
+}
+
 // Invoked indicates if the Exec command was invoked at least once
 func (p *FakeExecutor) Invoked() bool {
 	return p.invocations > 0
@@ -60,6 +67,11 @@ func (p *FakeExecutor) CmdHistory() []string {
 	return p.commands
 }
 
+// InputHistory returns the history of the inputs passed to ExecWithInput
+func (p *FakeExecutor) InputHistory() []string {
+	return p.inputs
+}
+
 // Invocations returns the number of invocations to the Exec function
 func (p *FakeExecutor) Invocations() int {
 	return p.invocations
@@ -69,6 +81,7 @@ func (p *FakeExecutor) Invocations() int {
 func (p *FakeExecutor) Reset() {
 	p.invocations = 0
 	p.commands = []string{}
+	p.inputs = []string{}
 }
 
 // ExecCallback defines a function that can receive the forward of an Exec invocation
@@ -90,6 +103,12 @@ func (c *CallbackExecutor) Exec(cmd string, args ...string) ([]byte, error) {
 	return c.callback(cmd, args...)
 }
 
+// ExecWithInput keeps the input for inspection and forwards invocation to the callback
+func (c *CallbackExecutor) ExecWithInput(input []byte, cmd string, args ...string) ([]byte, error) {
+	c.FakeExecutor.inputs = append(c.FakeExecutor.inputs, string(input))
+	return c.Exec(cmd, args...)
+}
+
 // NewCallbackExecutor returns an instance of a CallbackExecutor
 func NewCallbackExecutor(callback ExecCallback) *CallbackExecutor {
 	return &CallbackExecutor{
@@ -0,0 +1,151 @@
+// Package tc implements objects that manipulate traffic control queueing disciplines by calling the tc binary.
+package tc
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/grafana/xk6-disruptor/pkg/runtime"
+)
+
+// TC adds, changes and removes queueing disciplines and filters by executing the `tc` binary.
+type TC struct {
+	// Executor is the runtime.Executor used to run the tc binary.
+	executor runtime.Executor
+}
+
+// New returns a new TC ready to use.
+func New(executor runtime.Executor) TC {
+	return TC{
+		executor: executor,
+	}
+}
+
+// AddQdisc adds a queueing discipline to a device.
+func (t TC) AddQdisc(q Qdisc) error {
+	return t.exec(q.add())
+}
+
+// ChangeQdisc changes the parameters of a queueing discipline already attached to a device.
+func (t TC) ChangeQdisc(q Qdisc) error {
+	return t.exec(q.change())
+}
+
+// RemoveQdisc removes a queueing discipline from a device. Any child queueing discipline or filter attached to it is
+// also removed.
+func (t TC) RemoveQdisc(q Qdisc) error {
+	return t.exec(q.remove())
+}
+
+// RootQdiscs returns the root queueing disciplines attached to all the devices. The Args of the queueing disciplines
+// are not returned.
+func (t TC) RootQdiscs() ([]Qdisc, error) {
+	out, err := t.executor.Exec("tc", "qdisc", "show")
+	if err != nil {
+		return nil, fmt.Errorf("%w: %q", err, out)
+	}
+
+	qdiscs := []Qdisc{}
+	for _, line := range strings.Split(string(out), "\n") {
+		// e.g. "qdisc prio 1: dev eth0 root refcnt 2 bands 4 priomap ..."
+		fields := strings.Fields(line)
+		if len(fields) < 6 || fields[0] != "qdisc" || fields[3] != "dev" || fields[5] != "root" {
+			continue
+		}
+
+		qdiscs = append(qdiscs, Qdisc{
+			Device: fields[4],
+			Parent: "root",
+			Handle: fields[2],
+			Kind:   fields[1],
+		})
+	}
+
+	return qdiscs, nil
+}
+
+// AddFilter adds a filter to a device.
+func (t TC) AddFilter(f Filter) error {
+	return t.exec(f.add())
+}
+
+func (t TC) exec(args string) error {
+	out, err := t.executor.Exec("tc", strings.Split(args, " ")...)
+	if err != nil {
+		return fmt.Errorf("%w: %q", err, out)
+	}
+
+	return nil
+}
+
+// Qdisc is a queueing discipline attached to a device.
+type Qdisc struct {
+	// Device is the network device the queueing discipline is attached to.
+	Device string
+	// Parent is either "root" or the class id of the parent (e.g. "1:1").
+	Parent string
+	// Handle identifies the queueing discipline (e.g. "1:"). Optional.
+	Handle string
+	// Kind is the type of queueing discipline (e.g. "netem").
+	Kind string
+	// Args are the parameters of the queueing discipline.
+	// Arguments must be space-separated. Using shell-style quotes or backslashes to group more than one space-separated
+	// word as one argument is not allowed.
+	Args string
+}
+
+func (q Qdisc) parent() string {
+	parent := "root"
+	if q.Parent != "" && q.Parent != "root" {
+		parent = "parent " + q.Parent
+	}
+
+	if q.Handle != "" {
+		parent += " handle " + q.Handle
+	}
+
+	return parent
+}
+
+func (q Qdisc) add() string {
+	return q.command("add")
+}
+
+func (q Qdisc) change() string {
+	return q.command("change")
+}
+
+func (q Qdisc) command(action string) string {
+	cmd := fmt.Sprintf("qdisc %s dev %s %s %s", action, q.Device, q.parent(), q.Kind)
+	if q.Args != "" {
+		cmd += " " + q.Args
+	}
+
+	return cmd
+}
+
+func (q Qdisc) remove() string {
+	return fmt.Sprintf("qdisc del dev %s %s", q.Device, q.parent())
+}
+
+// Filter classifies the packets of a device into the classes of a queueing discipline.
+type Filter struct {
+	// Device is the network device the filter is attached to.
+	Device string
+	// Parent is the handle of the queueing discipline the filter is attached to (e.g. "1:").
+	Parent string
+	// Protocol is the protocol of the packets to filter (e.g. "ip").
+	Protocol string
+	// Priority of the filter.
+	Priority uint
+	// Args are the classifier and its parameters (e.g. "u32 match ip sport 80 0xffff flowid 1:4").
+	// Arguments must be space-separated.
+	Args string
+}
+
+func (f Filter) add() string {
+	return fmt.Sprintf(
+		"filter add dev %s parent %s protocol %s prio %d %s",
+		f.Device, f.Parent, f.Protocol, f.Priority, f.Args,
+	)
+}
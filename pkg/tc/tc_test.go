@@ -0,0 +1,145 @@
+package tc
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/grafana/xk6-disruptor/pkg/runtime"
+)
+
+func Test_TC(t *testing.T) {
+	t.Parallel()
+
+	anError := errors.New("an error occurred")
+
+	for _, tc := range []struct {
+		name             string
+		testFunc         func(TC) error
+		execError        error
+		expectedCommands []string
+		expectedError    error
+	}{
+		{
+			name: "Adds root qdisc",
+			testFunc: func(t TC) error {
+				return t.AddQdisc(Qdisc{
+					Device: "eth0",
+					Parent: "root",
+					Kind:   "netem",
+					Args:   "delay 100ms 10ms",
+				})
+			},
+			expectedCommands: []string{
+				"tc qdisc add dev eth0 root netem delay 100ms 10ms",
+			},
+		},
+		{
+			name: "Adds child qdisc",
+			testFunc: func(t TC) error {
+				return t.AddQdisc(Qdisc{
+					Device: "eth0",
+					Parent: "1:4",
+					Handle: "40:",
+					Kind:   "netem",
+					Args:   "loss 10%",
+				})
+			},
+			expectedCommands: []string{
+				"tc qdisc add dev eth0 parent 1:4 handle 40: netem loss 10%",
+			},
+		},
+		{
+			name: "Changes child qdisc",
+			testFunc: func(t TC) error {
+				return t.ChangeQdisc(Qdisc{
+					Device: "eth0",
+					Parent: "1:4",
+					Handle: "40:",
+					Kind:   "netem",
+					Args:   "loss 5%",
+				})
+			},
+			expectedCommands: []string{
+				"tc qdisc change dev eth0 parent 1:4 handle 40: netem loss 5%",
+			},
+		},
+		{
+			name: "Removes qdisc",
+			testFunc: func(t TC) error {
+				return t.RemoveQdisc(Qdisc{
+					Device: "eth0",
+					Handle: "1:",
+					Kind:   "prio",
+				})
+			},
+			expectedCommands: []string{
+				"tc qdisc del dev eth0 root handle 1:",
+			},
+		},
+		{
+			name: "Adds filter",
+			testFunc: func(t TC) error {
+				return t.AddFilter(Filter{
+					Device:   "eth0",
+					Parent:   "1:",
+					Protocol: "ip",
+					Priority: 1,
+					Args:     "u32 match ip sport 80 0xffff flowid 1:4",
+				})
+			},
+			expectedCommands: []string{
+				"tc filter add dev eth0 parent 1: protocol ip prio 1 u32 match ip sport 80 0xffff flowid 1:4",
+			},
+		},
+		{
+			name: "Propagates error",
+			testFunc: func(t TC) error {
+				return t.RemoveQdisc(Qdisc{
+					Device: "eth0",
+				})
+			},
+			execError: anError,
+			expectedCommands: []string{
+				"tc qdisc del dev eth0 root",
+			},
+			expectedError: anError,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			fakeExec := runtime.NewFakeExecutor(nil, tc.execError)
+			err := tc.testFunc(New(fakeExec))
+			if !errors.Is(err, tc.expectedError) {
+				t.Fatalf("Expected error to be %v, got %v", tc.expectedError, err)
+			}
+
+			commands := fakeExec.CmdHistory()
+			if diff := cmp.Diff(commands, tc.expectedCommands); diff != "" {
+				t.Fatalf("Ran commands do not match expected:\n%s", diff)
+			}
+		})
+	}
+}
+
+func Test_RootQdiscs(t *testing.T) {
+	t.Parallel()
+
+	output := "qdisc noqueue 0: dev lo root refcnt 2\n" +
+		"qdisc prio 1: dev eth0 root refcnt 2 bands 4 priomap 1 2 2 2 1 2 0 0 1 1 1 1 1 1 1 1\n" +
+		"qdisc netem 40: dev eth0 parent 1:4 limit 1000 delay 100ms\n"
+
+	qdiscs, err := New(runtime.NewFakeExecutor([]byte(output), nil)).RootQdiscs()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []Qdisc{
+		{Device: "lo", Parent: "root", Handle: "0:", Kind: "noqueue"},
+		{Device: "eth0", Parent: "root", Handle: "1:", Kind: "prio"},
+	}
+	if diff := cmp.Diff(expected, qdiscs); diff != "" {
+		t.Fatalf("Root qdiscs do not match expected:\n%s", diff)
+	}
+}
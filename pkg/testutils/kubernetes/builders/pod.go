@@ -28,6 +28,8 @@ type PodBuilder interface {
 	WithHostNetwork(hostNetwork bool) PodBuilder
 	// WithContainer add a container to the pod
 	WithContainer(c corev1.Container) PodBuilder
+	// WithVolume add a volume to the pod
+	WithVolume(v corev1.Volume) PodBuilder
 }
 
 // podBuilder defines the attributes for building a pod
@@ -40,6 +42,7 @@ type podBuilder struct {
 	ip          string
 	hostNetwork bool
 	containers  []corev1.Container
+	volumes     []corev1.Volume
 }
 
 // NewPodBuilder creates a new instance of PodBuilder with the given pod name
@@ -97,6 +100,11 @@ func (b *podBuilder) WithContainer(c corev1.Container) PodBuilder {
 	return b
 }
 
+func (b *podBuilder) WithVolume(v corev1.Volume) PodBuilder {
+	b.volumes = append(b.volumes, v)
+	return b
+}
+
 func (b *podBuilder) Build() corev1.Pod {
 	pod := corev1.Pod{
 		TypeMeta: metav1.TypeMeta{
@@ -112,6 +120,7 @@ func (b *podBuilder) Build() corev1.Pod {
 		Spec: corev1.PodSpec{
 			Containers:          b.containers,
 			HostNetwork:         b.hostNetwork,
+			Volumes:             b.volumes,
 			EphemeralContainers: nil,
 		},
 		Status: corev1.PodStatus{
@@ -29,6 +29,8 @@ type ServiceBuilder interface {
 	WithServiceType(t corev1.ServiceType) ServiceBuilder
 	// WithAnnotation adds an annotation to the service
 	WithAnnotation(key string, value string) ServiceBuilder
+	// WithClusterIP sets the cluster IP of the service
+	WithClusterIP(ip string) ServiceBuilder
 }
 
 // serviceBuilder defines the attributes for building a service
@@ -40,6 +42,7 @@ type serviceBuilder struct {
 	selector    map[string]string
 	annotations map[string]string
 	labels      map[string]string
+	clusterIP   string
 }
 
 // NewServiceBuilder creates a new instance of ServiceBuilder with the given pod name
@@ -90,7 +93,17 @@ func (s *serviceBuilder) WithAnnotation(key string, value string) ServiceBuilder
 	return s
 }
 
+func (s *serviceBuilder) WithClusterIP(ip string) ServiceBuilder {
+	s.clusterIP = ip
+	return s
+}
+
 func (s *serviceBuilder) Build() corev1.Service {
+	var clusterIPs []string
+	if s.clusterIP != "" {
+		clusterIPs = []string{s.clusterIP}
+	}
+
 	return corev1.Service{
 		TypeMeta: metav1.TypeMeta{
 			APIVersion: "v1",
@@ -102,9 +115,11 @@ func (s *serviceBuilder) Build() corev1.Service {
 			Annotations: s.annotations,
 		},
 		Spec: corev1.ServiceSpec{
-			Selector: s.selector,
-			Type:     s.serviceType,
-			Ports:    s.ports,
+			Selector:   s.selector,
+			Type:       s.serviceType,
+			Ports:      s.ports,
+			ClusterIP:  s.clusterIP,
+			ClusterIPs: clusterIPs,
 		},
 	}
 }
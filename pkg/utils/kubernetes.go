@@ -79,6 +79,16 @@ func PodNames(pods []corev1.Pod) []string {
 	return names
 }
 
+// NodeNames return the name of the nodes in a list
+func NodeNames(nodes []corev1.Node) []string {
+	names := make([]string, 0, len(nodes))
+	for _, node := range nodes {
+		names = append(names, node.Name)
+	}
+
+	return names
+}
+
 // Sample a subset of the given list of Pods. The count is defined as a int or a string representing a percentage.
 // If the count is a percentage and there are no enough elements in the pod list, the number is rounded up.
 // If the list is not empty, at least one element is returned